@@ -3,12 +3,16 @@ package main
 import (
 	"CardFlow/internal/config"
 	database "CardFlow/internal/database"
+	"CardFlow/internal/middleware"
+	"CardFlow/internal/repositories"
 	"CardFlow/internal/routes"
+	"CardFlow/internal/services"
 	"context"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -24,6 +28,8 @@ func main() {
 	// 2. Initialize Fiber app
 	app := fiber.New(fiber.Config{
 		AppName: "CardFlow Service",
+		// room for a KYC document at utils.MaxKycDocumentBytes plus form overhead
+		BodyLimit: 12 * 1024 * 1024,
 	})
 
 	// 3. Logger middleware
@@ -37,8 +43,12 @@ func main() {
 		})
 	})
 
-	// 5. Gateway auth middleware
+	// 5. Gateway auth middleware. The partner API authenticates with
+	// partner API keys instead, see middleware.PartnerProtected.
 	app.Use(func(c *fiber.Ctx) error {
+		if strings.HasPrefix(c.Path(), "/api/v1/partner/") {
+			return c.Next()
+		}
 		auth := c.Get("G-Auth")
 		if auth == "" || auth != config.GatewaySecret {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
@@ -50,9 +60,25 @@ func main() {
 	})
 
 	// 6. Route registration (dependency injection)
-	routes.Routes(app, db)
+	userRepo := repositories.NewUserRepository(db)
+	screening, err := services.NewScreeningService(repositories.NewScreeningRepository(db), userRepo, repositories.NewKycRepository(db), repositories.NewAuditRepository(db))
+	if err != nil {
+		log.Fatalf("failed to load sanctions watchlists: %v", err)
+	}
+	sessions, err := services.NewSessionService(repositories.NewSessionRepository(db), repositories.NewAuditRepository(db))
+	if err != nil {
+		log.Fatalf("failed to load the GeoIP database: %v", err)
+	}
+	middleware.UseSessions(sessions)
+	routes.Routes(app, db, screening, sessions)
+
+	// 7. Background jobs. Re-encryption after a key rotation is not one of
+	// them: it runs once, from cmd/reencrypt, not on every replica.
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go services.CronJobs(jobsCtx, services.NewCronService(userRepo, repositories.NewCardRepository(db), repositories.NewKycRepository(db), screening))
 
-	// 7. 404 handler
+	// 8. 404 handler
 	app.All("*", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
@@ -60,7 +86,7 @@ func main() {
 		})
 	})
 
-	// 8. Graceful shutdown
+	// 9. Graceful shutdown
 	go func() {
 		if err := app.Listen(":8081"); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("server stopped: %v", err)
@@ -74,6 +100,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down gracefully...")
+	stopJobs()
 
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 	defer cancel()
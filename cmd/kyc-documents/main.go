@@ -0,0 +1,42 @@
+// Command kyc-documents moves KYC document blobs out of Postgres into the
+// configured document store. It is safe to stop and rerun.
+package main
+
+import (
+	database "CardFlow/internal/database"
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/services"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 100, "rows loaded per query")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	store, err := integrations.NewDocumentStore()
+	if err != nil {
+		log.Fatalf("failed to set up kyc document store: %v", err)
+	}
+	db := database.NewGormConnection()
+	job := services.NewDocumentMigrationJob(repositories.NewKycRepository(db), store)
+	job.BatchSize = *batchSize
+
+	stats, err := job.Run(ctx)
+	log.Printf("moved %d kyc documents, %d failed", stats.Moved, stats.Failed)
+	if err != nil {
+		log.Printf("stopped early: %v, rerun to continue", err)
+		os.Exit(1)
+	}
+	if stats.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,76 @@
+// Command legacy-cvvs removes the encrypted CVVs that cards issued before
+// CVVs were derived still hold. The stored copies are no longer read, so
+// those cards switched to their derived CVV, a different code, as soon as
+// derived CVVs were deployed; this command only tells holders of live cards
+// afterwards, with an in-app notice to check the new one in the app. Run it
+// right after that deploy. It only touches cards that
+// still hold a CVV, so it can be stopped and rerun. Migration 0024 drops the
+// column and refuses to run until this has cleared every card.
+package main
+
+import (
+	database "CardFlow/internal/database"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/services"
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 500, "cards loaded per query")
+	flag.Parse()
+
+	ctx := context.Background()
+	db := database.NewGormConnection()
+	cardRepo := repositories.NewCardRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+
+	var cleared, notified, failed int
+	after := uuid.Nil
+	for {
+		cards, err := cardRepo.FindCardsWithStoredCvv(ctx, after, *batchSize)
+		if err != nil {
+			log.Fatalf("failed to load cards: %v", err)
+		}
+		if len(cards) == 0 {
+			break
+		}
+		for _, card := range cards {
+			after = card.ID
+			// a card whose notice failed keeps its stored CVV, so a rerun
+			// tries again
+			if card.Status != "terminated" && card.Status != "expired" {
+				if err := notifyCvvChanged(ctx, notificationRepo, card); err != nil {
+					log.Printf("failed to notify the holder of card %s: %v", card.ID, err)
+					failed++
+					continue
+				}
+				notified++
+			}
+			if err := cardRepo.ClearStoredCvv(ctx, card.ID); err != nil {
+				log.Fatalf("failed to update card %s: %v", card.ID, err)
+			}
+			cleared++
+		}
+	}
+	log.Printf("cleared the stored CVV of %d cards, notified %d cardholders, %d left for a rerun", cleared, notified, failed)
+}
+
+func notifyCvvChanged(ctx context.Context, repo repositories.NotificationRepository, card models.Card) error {
+	subject := "Your card's security code has changed"
+	now := time.Now()
+	return repo.Create(ctx, &models.Notification{
+		UserID:  card.UserID,
+		Type:    "card_cvv_changed",
+		Channel: services.NotificationChannelInApp,
+		Subject: &subject,
+		Body:    "The security code (CVV) of your card ending " + card.LastFour + " has changed. Reveal the card in the app to see the new one.",
+		Status:  "unread",
+		SentAt:  &now,
+	})
+}
@@ -0,0 +1,61 @@
+// Command pan-fingerprints backfills the keyed PAN fingerprint for cards
+// issued before it existed. It only touches cards without a fingerprint, so
+// it can be stopped and rerun. Cards whose PAN already belongs to another
+// card are reported and left for manual review.
+package main
+
+import (
+	database "CardFlow/internal/database"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"flag"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 500, "cards loaded per query")
+	flag.Parse()
+
+	ctx := context.Background()
+	cardRepo := repositories.NewCardRepository(database.NewGormConnection())
+
+	var updated, duplicates int
+	after := uuid.Nil
+	for {
+		cards, err := cardRepo.FindCardsWithoutFingerprint(ctx, after, *batchSize)
+		if err != nil {
+			log.Fatalf("failed to load cards: %v", err)
+		}
+		if len(cards) == 0 {
+			break
+		}
+		for _, card := range cards {
+			after = card.ID
+			pan, err := utils.DecryptWithKeyring(card.PANencrypted)
+			if err != nil {
+				log.Fatalf("failed to decrypt card %s: %v", card.ID, err)
+			}
+			fingerprint, err := utils.PanFingerprint(pan)
+			if err != nil {
+				log.Fatal(err)
+			}
+			existing, err := cardRepo.FindCardByPANFingerprint(ctx, fingerprint)
+			if err != nil {
+				log.Fatalf("failed to check card %s: %v", card.ID, err)
+			}
+			if existing.ID != uuid.Nil {
+				log.Printf("card %s has the same PAN as card %s, skipped", card.ID, existing.ID)
+				duplicates++
+				continue
+			}
+			if err := cardRepo.SetPANFingerprint(ctx, card.ID, fingerprint); err != nil {
+				log.Fatalf("failed to update card %s: %v", card.ID, err)
+			}
+			updated++
+		}
+	}
+	log.Printf("fingerprinted %d cards, %d duplicate PANs need review", updated, duplicates)
+}
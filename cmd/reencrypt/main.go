@@ -0,0 +1,42 @@
+// Command reencrypt moves card and KYC document ciphertexts onto the primary
+// encryption key after a rotation. It is safe to stop and rerun.
+package main
+
+import (
+	database "CardFlow/internal/database"
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/services"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 200, "rows loaded per query")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	store, err := integrations.NewDocumentStore()
+	if err != nil {
+		log.Fatalf("failed to set up kyc document store: %v", err)
+	}
+	db := database.NewGormConnection()
+	job := services.NewReencryptionJob(repositories.NewCardRepository(db), repositories.NewKycRepository(db), repositories.NewOrganizationRepository(db), store)
+	job.BatchSize = *batchSize
+
+	stats, err := job.Run(ctx)
+	log.Printf("re-encrypted %d cards, %d kyc documents, %d kyc profiles and %d kyb documents, %d failed", stats.Cards, stats.Documents, stats.Profiles, stats.Kyb, stats.Failed)
+	if err != nil {
+		log.Printf("stopped early: %v, rerun to continue", err)
+		os.Exit(1)
+	}
+	if stats.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -29,6 +29,7 @@ type awsConfig struct {
 	Bucket_region string
 	Access_key    string
 	Secret_key    string
+	Endpoint      string
 }
 
 func Aws() awsConfig {
@@ -37,6 +38,7 @@ func Aws() awsConfig {
 		Bucket_region: os.Getenv("S3_BUCKET_REGION"),
 		Access_key:    os.Getenv("AWS_ACCESS_KEY"),
 		Secret_key:    os.Getenv("AWS_SECRET_KEY"),
+		Endpoint:      os.Getenv("S3_ENDPOINT"), // for MinIO and other S3-compatible stores
 	}
 }
 
@@ -45,10 +47,53 @@ func Aws() awsConfig {
 
 var GatewaySecret = os.Getenv("GATEWAY_SECRET")
 var JwtSecret = os.Getenv("JWT_SECRET")
+var AdminJwtSecret = os.Getenv("ADMIN_JWT_SECRET") // signs back-office tokens, must differ from JWT_SECRET
 var AppPassword = os.Getenv("APP_PASSWORD")
 var AppEmail = os.Getenv("APP_EMAIL")
 var KorapayUrl = os.Getenv("KORA_PAY_URL")
 var KorapaySecret = os.Getenv("KORA_PAY_SECRET")
-var EncryptionKey = os.Getenv("ENCRYPTION_KEY_BASE64")
+var EncryptionKey = os.Getenv("ENCRYPTION_KEY_BASE64") // legacy key, key ID v1 in the keyring
+var EncryptionKeys = os.Getenv("ENCRYPTION_KEYS") // e.g. v2:base64key,v3:base64key
+var EncryptionPrimaryKeyID = os.Getenv("ENCRYPTION_PRIMARY_KEY_ID")
+var KmsProvider = os.Getenv("KMS_PROVIDER") // local, pkcs11, aws or gcp; empty keeps keyring keys unwrapped
+var KmsKeyID = os.Getenv("KMS_KEY_ID") // alias of the key-encryption key new data keys are wrapped with
+var KmsKeys = os.Getenv("KMS_KEYS") // alias=provider key name,...; unlisted aliases are used as the name
+var KmsLocalKeyFile = os.Getenv("KMS_LOCAL_KEY_FILE")
+var KmsKeyCacheTTL = os.Getenv("KMS_KEY_CACHE_TTL") // seconds, default 300
+var Pkcs11Module = os.Getenv("PKCS11_MODULE")
+var Pkcs11TokenLabel = os.Getenv("PKCS11_TOKEN_LABEL")
+var Pkcs11Pin = os.Getenv("PKCS11_PIN")
+var AwsRegion = os.Getenv("AWS_REGION")
+var AwsAccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+var AwsSecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+var AwsSessionToken = os.Getenv("AWS_SESSION_TOKEN")
+var GcpAccessToken = os.Getenv("GCP_ACCESS_TOKEN") // falls back to the metadata server
+var DocumentStore = os.Getenv("DOCUMENT_STORE") // s3 or filesystem (default)
+var DocumentStorePath = os.Getenv("DOCUMENT_STORE_PATH") // filesystem root, default data/kyc-documents
+var KycProvider = os.Getenv("KYC_PROVIDER") // http or fake; empty leaves KYC to manual review only
+var KycProviderUrl = os.Getenv("KYC_PROVIDER_URL")
+var KycProviderApiKey = os.Getenv("KYC_PROVIDER_API_KEY")
+var KycReverifyMonths = os.Getenv("KYC_REVERIFY_MONTHS") // how long an approval lasts at most, default 24
+var KycExpiryReminderDays = os.Getenv("KYC_EXPIRY_REMINDER_DAYS") // default 30
+var KycMinimumAge = os.Getenv("KYC_MINIMUM_AGE") // default 18
+var WatchlistFiles = os.Getenv("WATCHLIST_FILES") // e.g. ofac:/lists/sdn.xml,un:/lists/un.xml,eu:/lists/eu.xml,pep:/lists/pep.csv
+var ScreeningNameThreshold = os.Getenv("SCREENING_NAME_THRESHOLD") // 0 to 1, default 0.88
+var ScreeningDobYearTolerance = os.Getenv("SCREENING_DOB_YEAR_TOLERANCE") // default 1
+var PartnerRateLimit = os.Getenv("PARTNER_RATE_LIMIT_PER_MINUTE") // default for new partner API keys, 120
+var FraudResponseUrl = os.Getenv("FRAUD_RESPONSE_URL") // page behind the confirm or deny link in fraud alerts, ?token= is added
+var SecurityTeamEmail = os.Getenv("SECURITY_TEAM_EMAIL") // told about lost, stolen and compromised card reports
+var GeoIPDatabaseFile = os.Getenv("GEOIP_DATABASE_FILE") // CSV of IP ranges: start, end, country, region, city
+var LoginNewDeviceStepUp = os.Getenv("LOGIN_NEW_DEVICE_STEP_UP") // true asks for an emailed OTP on logins from unrecognized devices
+var PasswordResetUrl = os.Getenv("PASSWORD_RESET_URL") // page behind the link in password reset emails, ?token= is added
+var PasswordResetTTL = os.Getenv("PASSWORD_RESET_TTL_MINUTES") // default 30
 var WebhookSecret = os.Getenv("WEBHOOK_SECRET")
-var IIN = os.Getenv("IIN")
\ No newline at end of file
+var IIN = os.Getenv("IIN")
+var FxRates = os.Getenv("FX_RATES") // e.g. USD_NGN=1550,USD_EUR=0.92
+var RevealServiceSecret = os.Getenv("REVEAL_SERVICE_SECRET") // shared with the PCI-scoped card display service
+var PinEncryptionKey = os.Getenv("PIN_ENCRYPTION_KEY_BASE64")
+var PinBlockFormat = os.Getenv("PIN_BLOCK_FORMAT") // iso4 (default) or iso0
+var PanFingerprintKey = os.Getenv("PAN_FINGERPRINT_KEY_BASE64")
+var CvvKey = os.Getenv("CVV_KEY_BASE64")
+var TspUrl = os.Getenv("TSP_URL")
+var TspApiKey = os.Getenv("TSP_API_KEY")
+var TspWebhookSecret = os.Getenv("TSP_WEBHOOK_SECRET")
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/services"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AdminHandler struct {
+    service services.AdminService
+}
+
+func NewAdminHandler(service services.AdminService) *AdminHandler {
+    return &AdminHandler{service: service}
+}
+
+
+func (h *AdminHandler) Login(c *fiber.Ctx) error {
+    var req models.AdminLoginReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    if req.Email == "" || req.Password == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "email and password are required",
+        })
+    }
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.Login(ctx, req)
+    if err != nil {
+        if err.Error() == "MFA required" {
+            return c.Status(fiber.StatusOK).JSON(fiber.Map{
+                "mfa_required": true,
+                "message": "Multi-factor authentication required",
+            })
+        }
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "Login successful",
+        "data": res,
+    })
+}
+
+func (h *AdminHandler) FindCardByPAN(c *fiber.Ctx) error {
+    var req models.AdminFindCardReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil || req.PAN == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.AdminId = c.Locals("admin_id").(uuid.UUID)
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.FindCardByPAN(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "card found",
+        "data": res,
+    })
+}
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"time"
 
+	"CardFlow/internal/config"
 	"CardFlow/internal/models"
 	"CardFlow/internal/services"
+	"CardFlow/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -37,6 +41,11 @@ func (h *CardHandler) CreateCard(c *fiber.Ctx) error {
 	}
     res, err := h.service.CreateCard(ctx, req)
     if err != nil {
+        if errors.Is(err, services.ErrScreeningHold) {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "error": err.Error(),
+            })
+        }
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
             "error": err.Error(),
         })
@@ -88,7 +97,7 @@ func (h *CardHandler)FetchCardById(c *fiber.Ctx) error{
 }
 
 func (h *CardHandler)ModifyCardStatus(c *fiber.Ctx) error{
-    var req models.GetCardReq
+    var req models.ModifyCardStatusReq
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
     if err := c.BodyParser(&req); err != nil {
@@ -96,6 +105,15 @@ func (h *CardHandler)ModifyCardStatus(c *fiber.Ctx) error{
             "error": "invalid request body",
         })
     }
+    req.UserId = c.Locals("user_id").(uuid.UUID)
+    if partnerID, ok := c.Locals("partner_id").(uuid.UUID); ok {
+        req.PartnerId = &partnerID
+    }
+    if req.Payout != nil && !validPayoutDestination(*req.Payout) {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "incomplete payout details",
+		})
+    }
     Status := c.Params("status")
     if Status == "" || req.CardId == ""{
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -145,3 +163,343 @@ func (h *CardHandler)TopUpCard(c *fiber.Ctx) error{
 		"data": res,
     })
 }
+
+func (h *CardHandler)TransferBetweenCards(c *fiber.Ctx) error{
+    var req models.TransferReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.Userid = c.Locals("user_id").(uuid.UUID)
+    if req.FromCardid == "" || req.ToCardid == "" || req.Amount <= 0 || !validIdempotencyKey(req.IdempotencyKey) {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "incomplete request data",
+		})
+    }
+    res, err := h.service.TransferBetweenCards(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "transfer completed successfully",
+		"data": res,
+    })
+}
+
+func (h *CardHandler)WithdrawCardBalance(c *fiber.Ctx) error{
+    var req models.WithdrawReq
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.Userid = c.Locals("user_id").(uuid.UUID)
+    req.Cardid = c.Params("id")
+    if req.Amount <= 0 || !validIdempotencyKey(req.IdempotencyKey) || !validPayoutDestination(req.Destination) {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "incomplete request data",
+		})
+    }
+    res, err := h.service.WithdrawCardBalance(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "withdrawal initiated successfully",
+		"data": res,
+    })
+}
+
+func (h *CardHandler)ReissueCard(c *fiber.Ctx) error{
+    var req models.GetCardReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    req.UserId = c.Locals("user_id").(uuid.UUID)
+    req.CardId = c.Params("id")
+    if req.CardId == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "incomplete request data",
+		})
+    }
+    res, err := h.service.ReissueCard(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "card reissued successfully",
+		"data": res,
+    })
+}
+
+// ReportCard blocks a lost, stolen or compromised card, disputes the
+// transactions the user doesn't recognize and, if asked, reissues the card.
+func (h *CardHandler)ReportCard(c *fiber.Ctx) error{
+    var req models.ReportCardReq
+    ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.Userid = c.Locals("user_id").(uuid.UUID)
+    req.Cardid = c.Params("id")
+    if req.Cardid == "" || req.Reason == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "incomplete request data",
+		})
+    }
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    res, err := h.service.ReportCard(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    if res.Replacement != nil {
+        c.Set(fiber.HeaderCacheControl, "no-store")
+    }
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "card reported and blocked",
+		"data": res,
+    })
+}
+
+func (h *CardHandler)RevealCard(c *fiber.Ctx) error{
+    var req models.RevealCardReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.UserId = c.Locals("user_id").(uuid.UUID)
+    req.CardId = c.Params("id")
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    res, err := h.service.RevealCard(ctx, req)
+    if err != nil {
+        if errors.Is(err, services.ErrStepUpRequired) {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "error": err.Error(),
+            })
+        }
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    c.Set(fiber.HeaderCacheControl, "no-store")
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "card details revealed",
+		"data": res,
+    })
+}
+
+// RedeemRevealToken is called by the PCI-scoped card display service, not by
+// end users, so it is authenticated with that service's shared secret.
+func (h *CardHandler)RedeemRevealToken(c *fiber.Ctx) error{
+    var req models.RedeemRevealTokenReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    secret := c.Get("X-Reveal-Secret")
+    if config.RevealServiceSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(config.RevealServiceSecret)) != 1 {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "access denied",
+        })
+    }
+    if err := c.BodyParser(&req); err != nil || req.Token == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    res, err := h.service.RedeemRevealToken(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    c.Set(fiber.HeaderCacheControl, "no-store")
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "card details revealed",
+		"data": res,
+    })
+}
+
+func (h *CardHandler)SetCardPin(c *fiber.Ctx) error{
+    return h.handleCardPin(c, h.service.SetCardPin, "card PIN set successfully")
+}
+
+func (h *CardHandler)ChangeCardPin(c *fiber.Ctx) error{
+    return h.handleCardPin(c, h.service.ChangeCardPin, "card PIN changed successfully")
+}
+
+func (h *CardHandler)handleCardPin(c *fiber.Ctx, action func(context.Context, models.CardPinReq) error, message string) error{
+    var req models.CardPinReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil || req.Pin == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.UserId = c.Locals("user_id").(uuid.UUID)
+    req.CardId = c.Params("id")
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    if err := action(ctx, req); err != nil {
+        if errors.Is(err, services.ErrStepUpRequired) {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "error": err.Error(),
+            })
+        }
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": message,
+    })
+}
+
+func (h *CardHandler)ProvisionToken(c *fiber.Ctx) error{
+    var req models.ProvisionTokenReq
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil || req.Wallet == "" || req.DeviceId == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.UserId = c.Locals("user_id").(uuid.UUID)
+    req.CardId = c.Params("id")
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    res, err := h.service.ProvisionToken(ctx, req)
+    if err != nil {
+        if errors.Is(err, services.ErrStepUpRequired) {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "error": err.Error(),
+            })
+        }
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": res.Decision == services.ProvisionApproved,
+        "message": "provisioning " + res.Decision,
+		"data": res,
+    })
+}
+
+func (h *CardHandler)FetchCardTokens(c *fiber.Ctx) error{
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    req := models.GetCardReq{UserId: c.Locals("user_id").(uuid.UUID), CardId: c.Params("id")}
+    res, err := h.service.GetCardTokens(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "tokens fetched successfully",
+		"data": res,
+    })
+}
+
+func (h *CardHandler)ModifyToken(c *fiber.Ctx) error{
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+    req := models.ModifyTokenReq{
+        UserId:  c.Locals("user_id").(uuid.UUID),
+        CardId:  c.Params("id"),
+        TokenId: c.Params("tokenId"),
+        Action:  c.Params("action"),
+        Client:  models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+    }
+    res, err := h.service.ModifyToken(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "token updated successfully",
+		"data": res,
+    })
+}
+
+// HandleTokenWebhook receives token state changes from the token service
+// provider, signed with the shared TSP webhook secret.
+func (h *CardHandler)HandleTokenWebhook(c *fiber.Ctx) error{
+    var req models.TokenLifecycleWebhookReq
+    rawBody := c.Body()
+    signature := c.Get("X-TSP-Signature")
+    if signature == "" {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "missing hmac signature",
+        })
+    }
+    if err := utils.ValidateTspSignature(rawBody, signature); err != nil {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "invalid hmac signature",
+        })
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil || req.TokenReference == "" || req.Event == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    if err := h.service.TokenLifecycleWebhook(ctx, req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "event processed successfully",
+    })
+}
+
+// idempotency keys get a suffix for the second leg of a transfer, so they are
+// kept well below the 100 character column
+func validIdempotencyKey(key string) bool {
+    return key != "" && len(key) <= 64
+}
+
+func validPayoutDestination(d models.PayoutDestination) bool {
+    return d.BankCode != "" && d.AccountNumber != "" && d.AccountName != ""
+}
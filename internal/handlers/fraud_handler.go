@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/services"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type FraudHandler struct {
+    service services.FraudService
+}
+
+func NewFraudHandler(service services.FraudService) *FraudHandler {
+    return &FraudHandler{service: service}
+}
+
+func (h *FraudHandler) FetchRules(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetRules(ctx)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *FraudHandler) CreateRule(c *fiber.Ctx) error {
+    var req models.FraudRuleReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.AdminId = c.Locals("admin_id").(uuid.UUID)
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.CreateRule(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// UpdateRule changes a rule's name, params, action, mode or whether it is
+// enabled.
+func (h *FraudHandler) UpdateRule(c *fiber.Ctx) error {
+    var req models.FraudRuleReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.AdminId = c.Locals("admin_id").(uuid.UUID)
+    req.Ruleid = c.Params("id")
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.UpdateRule(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *FraudHandler) FetchRuleHits(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetRuleHits(ctx, c.Params("id"))
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// FetchCases lists fraud cases by status, open by default.
+func (h *FraudHandler) FetchCases(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetCases(ctx, c.Query("status"))
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *FraudHandler) FetchCase(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetCase(ctx, c.Params("id"))
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *FraudHandler) LinkToCase(c *fiber.Ctx) error {
+    var req models.FraudCaseLinkReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.AdminId = c.Locals("admin_id").(uuid.UUID)
+    req.Caseid = c.Params("id")
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.LinkToCase(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// CaseCardAction freezes or terminates a card linked to the case.
+func (h *FraudHandler) CaseCardAction(c *fiber.Ctx) error {
+    var req models.FraudCaseCardActionReq
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)// terminating may pay the balance out
+	defer cancel()
+    if len(c.Body()) > 0 {
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "error": "invalid request body",
+            })
+        }
+    }
+    req.AdminId = c.Locals("admin_id").(uuid.UUID)
+    req.Caseid = c.Params("id")
+    req.Cardid = c.Params("cardId")
+    req.Action = c.Params("action")
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.CaseCardAction(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *FraudHandler) ResolveCase(c *fiber.Ctx) error {
+    var req models.FraudCaseResolveReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.AdminId = c.Locals("admin_id").(uuid.UUID)
+    req.Caseid = c.Params("id")
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.ResolveCase(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// ReportFraud lets the logged-in user report activity they don't recognize
+// on one of their cards.
+func (h *FraudHandler) ReportFraud(c *fiber.Ctx) error {
+    var req models.FraudReportReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.Userid = c.Locals("user_id").(uuid.UUID)
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    if req.Cardid == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete request data",
+        })
+    }
+
+    res, err := h.service.ReportFraud(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "message": "report received, your card has been frozen",
+        "data": res,
+    })
+}
+
+// RespondToTransaction takes the customer's answer from the link in a
+// fraud alert.
+func (h *FraudHandler) RespondToTransaction(c *fiber.Ctx) error {
+    var req models.FraudResponseReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    if err := h.service.RespondToTransaction(ctx, req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "thank you, your answer has been recorded",
+    })
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"CardFlow/internal/models"
 	"CardFlow/internal/services"
+	"CardFlow/internal/utils"
 	"context"
+	"io"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -102,4 +104,274 @@ func (h *KycHandler)UploadProofOfAddress(c *fiber.Ctx) error{
         "success": true,
         "message": "Proof of Address uploaded successfully, Verification Pending",
     })
-}
\ No newline at end of file
+}
+// UploadKycFile takes a document as multipart/form-data, in a "file" field,
+// instead of a base64 data URI.
+func (h *KycHandler) UploadKycFile(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	header, err := c.FormFile("file")
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "file is required",
+        })
+    }
+	if header.Size > utils.MaxKycDocumentBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+            "error": "document too large",
+        })
+	}
+	file, err := header.Open()
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid file",
+        })
+    }
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, utils.MaxKycDocumentBytes+1))
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid file",
+        })
+    }
+
+	data := models.KycFile{
+		Userid:       c.Locals("user_id").(uuid.UUID),
+		DocumentType: c.Params("type"),
+		Data:         content,
+		DOB:          c.FormValue("dob"),
+	}
+	if err := h.service.UploadKycFile(ctx, data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "Document uploaded successfully",
+    })
+}
+
+func (h *KycHandler) CreateKycUpload(c *fiber.Ctx) error {
+    var data models.KycUploadReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+    if data.DocumentType == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete data",
+        })
+	}
+    res, err := h.service.CreateKycUpload(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *KycHandler) ConfirmKycUpload(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var data models.ConfirmKycUploadReq
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Uploadid = c.Params("id")
+    if err := h.service.ConfirmKycUpload(ctx, data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "Document uploaded successfully",
+    })
+}
+
+// FetchProviderChecks lists the identity verification provider's results for
+// a submission, for the compliance officer reviewing it.
+func (h *KycHandler) FetchProviderChecks(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data := models.AdminKycCheckReq{
+		AdminId:      c.Locals("admin_id").(uuid.UUID),
+		Submissionid: c.Params("id"),
+	}
+    res, err := h.service.GetProviderChecks(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *KycHandler) RerunProviderCheck(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	data := models.AdminKycCheckReq{
+		AdminId:      c.Locals("admin_id").(uuid.UUID),
+		Submissionid: c.Params("id"),
+		Client:       models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+	}
+    res, err := h.service.RerunProviderCheck(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// FetchKycTier returns the user's verification tier, its limits and what
+// the next tier unlocks.
+func (h *KycHandler) FetchKycTier(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetKycTier(ctx, c.Locals("user_id").(uuid.UUID))
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// ReviewSubmission approves or rejects a submission under review.
+func (h *KycHandler) ReviewSubmission(c *fiber.Ctx) error {
+    var data models.KycReviewReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.AdminId = c.Locals("admin_id").(uuid.UUID)
+	data.Submissionid = c.Params("id")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    res, err := h.service.ReviewSubmission(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// FetchKycStatus returns the user's latest submission with the status of
+// each document.
+func (h *KycHandler) FetchKycStatus(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetKycStatus(ctx, c.Locals("user_id").(uuid.UUID))
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// SaveKycProfile stores the user's date of birth, nationality, address and
+// ID document details on their open submission.
+func (h *KycHandler) SaveKycProfile(c *fiber.Ctx) error {
+	var data models.KycProfileDetailsReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+
+    res, err := h.service.SaveKycProfile(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *KycHandler) FetchKycProfile(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetKycProfile(ctx, c.Locals("user_id").(uuid.UUID))
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// FetchProfileForReview shows a reviewer a submission's profile with the
+// full ID number.
+func (h *KycHandler) FetchProfileForReview(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data := models.AdminKycCheckReq{
+		AdminId:      c.Locals("admin_id").(uuid.UUID),
+		Submissionid: c.Params("id"),
+		Client:       models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+	}
+    res, err := h.service.GetProfileForReview(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
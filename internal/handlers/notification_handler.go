@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/services"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type NotificationHandler struct {
+    service services.NotificationService
+}
+
+func NewNotificationHandler(service services.NotificationService) *NotificationHandler {
+    return &NotificationHandler{service: service}
+}
+
+func (h *NotificationHandler) FetchNotifications(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetNotifications(ctx, c.Locals("user_id").(uuid.UUID))
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    req := models.NotificationReq{
+        Userid:         c.Locals("user_id").(uuid.UUID),
+        Notificationid: c.Params("id"),
+    }
+    if err := h.service.MarkNotificationRead(ctx, req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "notification marked as read",
+    })
+}
@@ -0,0 +1,686 @@
+package handlers
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/services"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type OrganizationHandler struct {
+    service services.OrganizationService
+}
+
+func NewOrganizationHandler(service services.OrganizationService) *OrganizationHandler {
+    return &OrganizationHandler{service: service}
+}
+
+// organizationError answers with 403 when the member's role does not allow
+// the action or the card is held by screening, and 400 otherwise.
+func organizationError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrOrgForbidden) || errors.Is(err, services.ErrScreeningHold) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func (h *OrganizationHandler) orgReq(c *fiber.Ctx) models.OrganizationReq {
+	return models.OrganizationReq{
+		Userid: c.Locals("user_id").(uuid.UUID),
+		Orgid:  c.Params("id"),
+		Client: models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+	}
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	var data models.CreateOrganizationReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	if data.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete data",
+        })
+	}
+	res, err := h.service.CreateOrganization(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// FetchOrganizations lists the organizations the user is an active member of.
+func (h *OrganizationHandler) FetchOrganizations(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.GetOrganizations(ctx, c.Locals("user_id").(uuid.UUID))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) FetchOrganization(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.GetOrganization(ctx, h.orgReq(c))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) InviteMember(c *fiber.Ctx) error {
+	var data models.InviteMemberReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	if data.Email == "" || data.Role == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete data",
+        })
+	}
+	res, err := h.service.InviteMember(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "message": "invitation sent",
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) AcceptInvite(c *fiber.Ctx) error {
+	var data models.AcceptInviteReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	if data.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete data",
+        })
+	}
+	res, err := h.service.AcceptInvite(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) FetchMembers(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.GetMembers(ctx, h.orgReq(c))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) UpdateMemberRole(c *fiber.Ctx) error {
+	var data models.UpdateMemberReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	data.Memberid = c.Params("memberId")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	if data.Role == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete data",
+        })
+	}
+	res, err := h.service.UpdateMemberRole(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// RemoveMember takes a member out of the organization and suspends the
+// business cards they hold.
+func (h *OrganizationHandler) RemoveMember(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data := models.UpdateMemberReq{
+		Userid:   c.Locals("user_id").(uuid.UUID),
+		Orgid:    c.Params("id"),
+		Memberid: c.Params("memberId"),
+		Client:   models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+	}
+	if err := h.service.RemoveMember(ctx, data); err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "member removed",
+    })
+}
+
+// fundsReq reads the amount for a deposit or a card funding or return.
+func (h *OrganizationHandler) fundsReq(c *fiber.Ctx) (models.OrgFundsReq, error) {
+	var data models.OrgFundsReq
+	if err := c.BodyParser(&data); err != nil {
+		return data, errors.New("invalid request body")
+	}
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	data.Cardid = c.Params("cardId")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	if data.Amount <= 0 {
+		return data, errors.New("amount must be greater than zero")
+	}
+	return data, nil
+}
+
+func (h *OrganizationHandler) Deposit(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data, err := h.fundsReq(c)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+	res, err := h.service.Deposit(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) FundCard(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data, err := h.fundsReq(c)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+	res, err := h.service.FundCard(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// ReturnCardFunds moves money from a business card back to the
+// organization's balance.
+func (h *OrganizationHandler) ReturnCardFunds(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data, err := h.fundsReq(c)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+	res, err := h.service.ReturnCardFunds(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) FetchLedger(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.GetLedger(ctx, h.orgReq(c))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) IssueCard(c *fiber.Ctx) error {
+	var data models.CreateOrgCardReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	if data.Memberid == "" || data.CardType == "" || data.SpendingLimit <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete request data",
+        })
+	}
+	res, err := h.service.IssueCard(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "message": "card created successfully",
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) FetchCards(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.GetCards(ctx, h.orgReq(c))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) FetchTransactions(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.GetTransactions(ctx, h.orgReq(c))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// SaveKybDetails stores the registration number, country of incorporation
+// and directors of the organization.
+func (h *OrganizationHandler) SaveKybDetails(c *fiber.Ctx) error {
+	var data models.KybDetailsReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	res, err := h.service.SaveKybDetails(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// UploadKybDocument takes a company document as multipart/form-data, in a
+// "file" field.
+func (h *OrganizationHandler) UploadKybDocument(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	header, err := c.FormFile("file")
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "file is required",
+        })
+    }
+	if header.Size > utils.MaxKycDocumentBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+            "error": "document too large",
+        })
+	}
+	file, err := header.Open()
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid file",
+        })
+    }
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, utils.MaxKycDocumentBytes+1))
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid file",
+        })
+    }
+
+	data := models.KybFile{
+		Userid:       c.Locals("user_id").(uuid.UUID),
+		Orgid:        c.Params("id"),
+		DocumentType: c.Params("type"),
+		Data:         content,
+	}
+	if err := h.service.UploadKybDocument(ctx, data); err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "Document uploaded successfully",
+    })
+}
+
+func (h *OrganizationHandler) FetchKybStatus(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.GetKybStatus(ctx, h.orgReq(c))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) SubmitKyb(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.SubmitKyb(ctx, h.orgReq(c))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "KYB submitted for review",
+        "data": res,
+    })
+}
+
+// FetchKybForReview shows a reviewer an organization's KYB details and
+// documents.
+func (h *OrganizationHandler) FetchKybForReview(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data := models.AdminOrganizationReq{
+		AdminId: c.Locals("admin_id").(uuid.UUID),
+		Orgid:   c.Params("id"),
+		Client:  models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+	}
+	res, err := h.service.GetKybForReview(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// ReviewKyb approves or rejects an organization's KYB.
+func (h *OrganizationHandler) ReviewKyb(c *fiber.Ctx) error {
+    var data models.AdminKybReviewReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.AdminId = c.Locals("admin_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	res, err := h.service.ReviewKyb(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) FetchApprovalPolicies(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := h.service.GetApprovalPolicies(ctx, h.orgReq(c))
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// SaveApprovalPolicies replaces the organization's approval tiers.
+func (h *OrganizationHandler) SaveApprovalPolicies(c *fiber.Ctx) error {
+	var data models.ApprovalPoliciesReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	res, err := h.service.SaveApprovalPolicies(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) RequestApproval(c *fiber.Ctx) error {
+	var data models.ApprovalRequestReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	if data.Type == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete request data",
+        })
+	}
+	res, err := h.service.RequestApproval(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "message": "request sent for approval",
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) FetchApprovalRequests(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data := models.ApprovalListReq{
+		Userid: c.Locals("user_id").(uuid.UUID),
+		Orgid:  c.Params("id"),
+		Status: c.Query("status"),
+	}
+	res, err := h.service.GetApprovalRequests(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// decisionReq reads the optional comment of an approval decision.
+func (h *OrganizationHandler) decisionReq(c *fiber.Ctx) (models.ApprovalDecisionReq, error) {
+	var data models.ApprovalDecisionReq
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&data); err != nil {
+			return data, errors.New("invalid request body")
+		}
+	}
+	data.Userid = c.Locals("user_id").(uuid.UUID)
+	data.Orgid = c.Params("id")
+	data.Requestid = c.Params("requestId")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+	return data, nil
+}
+
+func (h *OrganizationHandler) FetchApprovalRequest(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data, _ := h.decisionReq(c)
+	res, err := h.service.GetApprovalRequest(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) ApproveRequest(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)// may issue or fund the card
+	defer cancel()
+	data, err := h.decisionReq(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+	}
+	res, err := h.service.ApproveRequest(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) RejectRequest(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data, err := h.decisionReq(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+	}
+	res, err := h.service.RejectRequest(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *OrganizationHandler) CancelRequest(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	data, _ := h.decisionReq(c)
+	res, err := h.service.CancelRequest(ctx, data)
+	if err != nil {
+		return organizationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
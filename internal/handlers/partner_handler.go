@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/services"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PartnerHandler struct {
+    service services.PartnerService
+}
+
+func NewPartnerHandler(service services.PartnerService) *PartnerHandler {
+    return &PartnerHandler{service: service}
+}
+
+func (h *PartnerHandler) CreatePartner(c *fiber.Ctx) error {
+    var req models.AdminCreatePartnerReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.AdminId = c.Locals("admin_id").(uuid.UUID)
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.CreatePartner(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *PartnerHandler) FetchPartners(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetPartners(ctx)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// CreateKey issues a partner API key. The key is only in this response.
+func (h *PartnerHandler) CreateKey(c *fiber.Ctx) error {
+    var req models.AdminCreatePartnerKeyReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    req.AdminId = c.Locals("admin_id").(uuid.UUID)
+    req.Partnerid = c.Params("id")
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+
+    res, err := h.service.CreateKey(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+        "success": true,
+        "message": "store this key now, it will not be shown again",
+        "data": res,
+    })
+}
+
+func (h *PartnerHandler) FetchKeys(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    req := models.AdminPartnerKeyReq{
+        AdminId:   c.Locals("admin_id").(uuid.UUID),
+        Partnerid: c.Params("id"),
+    }
+    res, err := h.service.GetKeys(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *PartnerHandler) RevokeKey(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    req := models.AdminPartnerKeyReq{
+        AdminId:   c.Locals("admin_id").(uuid.UUID),
+        Partnerid: c.Params("id"),
+        Keyid:     c.Params("keyId"),
+        Client:    models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+    }
+    if err := h.service.RevokeKey(ctx, req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "API key revoked",
+    })
+}
+
+// LinkPartner lets the logged-in user grant a partner access to act on
+// their behalf.
+func (h *PartnerHandler) LinkPartner(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    req := models.PartnerLinkReq{
+        Userid:    c.Locals("user_id").(uuid.UUID),
+        Partnerid: c.Params("id"),
+        Client:    models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+    }
+    res, err := h.service.LinkPartner(ctx, req)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *PartnerHandler) FetchLinkedPartners(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetLinkedPartners(ctx, c.Locals("user_id").(uuid.UUID))
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *PartnerHandler) UnlinkPartner(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    req := models.PartnerLinkReq{
+        Userid:    c.Locals("user_id").(uuid.UUID),
+        Partnerid: c.Params("id"),
+        Client:    models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+    }
+    if err := h.service.UnlinkPartner(ctx, req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "partner access removed",
+    })
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/services"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PasswordHandler struct {
+    service services.PasswordService
+}
+
+func NewPasswordHandler(service services.PasswordService) *PasswordHandler {
+    return &PasswordHandler{service: service}
+}
+
+// ForgotPassword answers the same way whether or not the email has an
+// account.
+func (h *PasswordHandler) ForgotPassword(c *fiber.Ctx) error {
+    var req models.ForgotPasswordReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil || req.Email == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "email is required",
+        })
+    }
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    if err := h.service.ForgotPassword(ctx, req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "if an account exists for this email, a reset link is on its way",
+    })
+}
+
+// ResetPassword and ChangePassword compare the new password against several
+// bcrypt hashes, hence the longer timeout.
+func (h *PasswordHandler) ResetPassword(c *fiber.Ctx) error {
+    var req models.ResetPasswordReq
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    if req.Token == "" || req.NewPassword == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete request data",
+        })
+    }
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    if err := h.service.ResetPassword(ctx, req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "password reset, please log in again",
+    })
+}
+
+func (h *PasswordHandler) ChangePassword(c *fiber.Ctx) error {
+    var req models.ChangePasswordReq
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+    if err := c.BodyParser(&req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    if req.CurrentPassword == "" || req.NewPassword == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "incomplete request data",
+        })
+    }
+    req.Userid = c.Locals("user_id").(uuid.UUID)
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    if err := h.service.ChangePassword(ctx, req); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "password changed, please log in again",
+    })
+}
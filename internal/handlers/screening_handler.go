@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/services"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ScreeningHandler struct {
+    service services.ScreeningService
+}
+
+func NewScreeningHandler(service services.ScreeningService) *ScreeningHandler {
+    return &ScreeningHandler{service: service}
+}
+
+// FetchCases lists screening cases by status, open by default.
+func (h *ScreeningHandler) FetchCases(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    res, err := h.service.GetCases(ctx, c.Query("status"))
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+// ResolveCase clears a false positive or confirms a hit.
+func (h *ScreeningHandler) ResolveCase(c *fiber.Ctx) error {
+    var data models.ResolveScreeningCaseReq
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.BodyParser(&data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+	data.AdminId = c.Locals("admin_id").(uuid.UUID)
+	data.Caseid = c.Params("id")
+	data.Action = c.Params("action")
+	data.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    res, err := h.service.ResolveCase(ctx, data)
+	if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
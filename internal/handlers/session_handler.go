@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/services"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+    service services.SessionService
+}
+
+func NewSessionHandler(service services.SessionService) *SessionHandler {
+    return &SessionHandler{service: service}
+}
+
+// FetchSessions lists the devices the user is logged in on; the one making
+// the request is marked current.
+func (h *SessionHandler) FetchSessions(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    current, _ := c.Locals("session_id").(uuid.UUID)
+    res, err := h.service.GetSessions(ctx, c.Locals("user_id").(uuid.UUID), current)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "data": res,
+    })
+}
+
+func (h *SessionHandler) RevokeSession(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    client := models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+    if err := h.service.RevokeSession(ctx, c.Locals("user_id").(uuid.UUID), c.Params("id"), client); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "session signed out",
+    })
+}
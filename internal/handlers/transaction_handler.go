@@ -51,7 +51,7 @@ func(h *TransactionHandler)HandleWebhook(c *fiber.Ctx) error{
             "error": "invalid request body",
         })
     }
-    if data.Amount <=0 || data.CardReference == "" || data.Currency == "" || data.Direction == "" || data.IdempotencyKey == "" || data.Status == "" || data.TransactionID == "" || data.Type == "" || data.Timestamp.IsZero() {
+    if data.Amount <=0 || (data.CardReference == "" && data.TokenReference == "") || data.Currency == "" || data.Direction == "" || data.IdempotencyKey == "" || data.Status == "" || data.TransactionID == "" || data.Type == "" || data.Timestamp.IsZero() {
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
             "error": "incomplete request data",
         })
@@ -92,4 +92,36 @@ func (h *TransactionHandler)GetCardTransactions(c *fiber.Ctx) error{
         "message": "data processed successfully",
 		"data": res,
     })
-}
\ No newline at end of file
+}
+
+func (h *TransactionHandler)HandlePayoutWebhook(c *fiber.Ctx) error{
+    var data models.PayoutWebhookReq
+    if err := c.BodyParser(&data); err != nil || len(data.Data) == 0 {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "invalid request body",
+        })
+    }
+    signature := c.Get("x-korapay-signature")
+    if signature == "" {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "missing hmac signature",
+        })
+    }
+    if err := utils.ValidateKorapaySignature(data.Data, signature); err != nil {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "invalid hmac signature",
+        })
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    if err := h.service.PayoutWebhook(ctx, data); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "data processed successfully",
+    })
+}
@@ -5,6 +5,7 @@ import (
 	"CardFlow/internal/services"
 	"CardFlow/internal/utils"
 	"context"
+	"errors"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -71,6 +72,7 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
             "error": "email and password are required",
         })
     }
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
 
     token, err := h.service.Login(ctx, req)
     if err != nil {
@@ -80,6 +82,12 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
                 "message": "Multi-factor authentication required",
             })
         }
+        if errors.Is(err, services.ErrDeviceVerificationRequired) {
+            return c.Status(200).JSON(fiber.Map{
+                "device_verification_required": true,
+                "message": "New device, enter the code we emailed you to continue",
+            })
+        }
         return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
             "error": err.Error(),
         })
@@ -105,6 +113,7 @@ func (h *UserHandler) MFALogin(c *fiber.Ctx) error {
             "error": "email and TOTP code are required",
         })
     }
+    req.Client = models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
 
     token, err := h.service.MFALogin(ctx, req)
     if err != nil {
@@ -209,4 +218,21 @@ func (h *UserHandler) VerifyMFA(c *fiber.Ctx) error{
         "success": true,
         "message": "multi-factor authentication enabled",
     })
-}
\ No newline at end of file
+}
+
+func (h *UserHandler) SendStepUpOtp(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+    user_id:= c.Locals("user_id").(uuid.UUID)
+    err := h.service.SendStepUpOtp(ctx, user_id)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "success": true,
+        "message": "otp sent successfully",
+    })
+}
@@ -0,0 +1,66 @@
+package integrations
+
+import (
+	"CardFlow/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrDocumentNotFound is returned by DocumentStore.Get for a missing key.
+var ErrDocumentNotFound = errors.New("document not found")
+
+// DocumentStore holds KYC document blobs outside Postgres. Callers encrypt
+// before Put, so a store only ever sees ciphertext.
+type DocumentStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DirectUploadStore is implemented by stores clients can upload to without
+// going through the API, using a short-lived presigned URL.
+type DirectUploadStore interface {
+	DocumentStore
+	PresignPut(key string, ttl time.Duration) (string, error)
+	// Size reports an object's size without downloading it.
+	Size(ctx context.Context, key string) (int64, error)
+}
+
+// NewDocumentStore builds the DocumentStore named by DOCUMENT_STORE.
+func NewDocumentStore() (DocumentStore, error) {
+	switch config.DocumentStore {
+	case "", "filesystem":
+		path := config.DocumentStorePath
+		if path == "" {
+			path = "data/kyc-documents"
+		}
+		return NewFilesystemStore(path)
+	case "s3":
+		aws := config.Aws()
+		return NewS3Store(aws.Endpoint, aws.Bucket_name, aws.Bucket_region, aws.Access_key, aws.Secret_key)
+	default:
+		return nil, fmt.Errorf("unknown DOCUMENT_STORE %q", config.DocumentStore)
+	}
+}
+
+// validObjectKey keeps keys to relative, slash separated paths so they are
+// safe as both file paths and URL paths.
+func validObjectKey(key string) bool {
+	if key == "" || len(key) > 255 || strings.HasPrefix(key, "/") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == '/') {
+			return false
+		}
+	}
+	return true
+}
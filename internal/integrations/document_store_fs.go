@@ -0,0 +1,81 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FilesystemStore keeps documents under a local directory, for single-node
+// deployments and development.
+type FilesystemStore struct {
+	root string
+}
+
+func NewFilesystemStore(root string) (*FilesystemStore, error) {
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create document store directory: %w", err)
+	}
+	return &FilesystemStore{root: root}, nil
+}
+
+// Put writes to a temporary file and renames it, so a crash never leaves a
+// partial document under the final key.
+func (f *FilesystemStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *FilesystemStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrDocumentNotFound
+	}
+	return data, err
+}
+
+func (f *FilesystemStore) Delete(ctx context.Context, key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (f *FilesystemStore) path(key string) (string, error) {
+	if !validObjectKey(key) {
+		return "", fmt.Errorf("invalid document key %q", key)
+	}
+	return filepath.Join(f.root, filepath.FromSlash(key)), nil
+}
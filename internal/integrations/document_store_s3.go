@@ -0,0 +1,130 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Store keeps documents in an S3 bucket, or any store speaking the S3 API
+// such as MinIO when an endpoint is given. Requests use path-style URLs so
+// custom endpoints need no bucket DNS.
+type S3Store struct {
+	awsCredentials
+	endpoint string
+	bucket   string
+	http     *http.Client
+}
+
+func NewS3Store(endpoint, bucket, region, accessKeyID, secretKey string) (*S3Store, error) {
+	if bucket == "" || region == "" || accessKeyID == "" || secretKey == "" {
+		return nil, errors.New("S3_BUCKET_NAME, S3_BUCKET_REGION, AWS_ACCESS_KEY and AWS_SECRET_KEY are required for the s3 document store")
+	}
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	return &S3Store{
+		awsCredentials: awsCredentials{region: region, accessKeyID: accessKeyID, secretKey: secretKey},
+		endpoint:       strings.TrimRight(endpoint, "/"),
+		bucket:         bucket,
+		http:           &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("put", resp)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrDocumentNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3Error("get", resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// S3 answers 204 whether or not the object existed
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error("delete", resp)
+	}
+	return nil
+}
+
+// PresignPut returns a URL the client can PUT the object to directly.
+func (s *S3Store) PresignPut(key string, ttl time.Duration) (string, error) {
+	if !validObjectKey(key) {
+		return "", fmt.Errorf("invalid document key %q", key)
+	}
+	u, err := url.Parse(s.endpoint + "/" + s.bucket + "/" + key)
+	if err != nil {
+		return "", err
+	}
+	return s.presign(http.MethodPut, u, "s3", ttl, time.Now().UTC()), nil
+}
+
+func (s *S3Store) Size(ctx context.Context, key string) (int64, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, ErrDocumentNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, s3Error("head", resp)
+	}
+	if resp.ContentLength < 0 {
+		return 0, errors.New("s3 head returned no content length")
+	}
+	return resp.ContentLength, nil
+}
+
+func (s *S3Store) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	if !validObjectKey(key) {
+		return nil, fmt.Errorf("invalid document key %q", key)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+"/"+s.bucket+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	s.sign(req, "s3", payloadHash, time.Now().UTC())
+	return s.http.Do(req)
+}
+
+func s3Error(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s failed with status %d: %s", op, resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,79 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible server.
+func fakeS3(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(body)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestDocumentStores(t *testing.T) {
+	server := fakeS3(t)
+	defer server.Close()
+	s3, err := NewS3Store(server.URL, "kyc", "us-east-1", "test-key", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := NewFilesystemStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for name, store := range map[string]DocumentStore{"s3": s3, "filesystem": fs} {
+		key := "kyc/submission/selfie-1"
+		if err := store.Put(ctx, key, []byte("ciphertext")); err != nil {
+			t.Fatalf("%s: put: %v", name, err)
+		}
+		data, err := store.Get(ctx, key)
+		if err != nil || string(data) != "ciphertext" {
+			t.Fatalf("%s: expected the stored object back, got %q %v", name, data, err)
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			t.Fatalf("%s: delete: %v", name, err)
+		}
+		if _, err := store.Get(ctx, key); !errors.Is(err, ErrDocumentNotFound) {
+			t.Fatalf("%s: expected a deleted object to be missing, got %v", name, err)
+		}
+		if err := store.Put(ctx, "../escape", []byte("x")); err == nil {
+			t.Fatalf("%s: expected a path traversal key to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package integrations
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// GeoLocation is where an IP address is registered. Fields are empty when
+// the address isn't in the database.
+type GeoLocation struct {
+	Country string // ISO 3166-1 alpha-2
+	Region  string
+	City    string
+}
+
+type geoRange struct {
+	start, end netip.Addr
+	loc        GeoLocation
+}
+
+// GeoIPDatabase looks up IP addresses in a local copy of a GeoIP range
+// database, so no address leaves the service.
+type GeoIPDatabase struct {
+	ranges []geoRange
+}
+
+// LoadGeoIPFile loads a GeoIP CSV from disk, see ParseGeoIPCSV.
+func LoadGeoIPFile(path string) (*GeoIPDatabase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseGeoIPCSV(f)
+}
+
+// ParseGeoIPCSV reads rows of start IP, end IP, country code and optionally
+// region and city, the layout of the DB-IP and IP2Location lite CSVs. IPv4
+// and IPv6 ranges can be mixed; a header row is skipped.
+func ParseGeoIPCSV(r io.Reader) (*GeoIPDatabase, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	db := &GeoIPDatabase{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least start, end and country", line)
+		}
+		start, err := netip.ParseAddr(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid start address %q", line, record[0])
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if err != nil || end.Is4() != start.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid end address %q", line, record[1])
+		}
+		loc := GeoLocation{Country: strings.ToUpper(strings.TrimSpace(record[2]))}
+		if len(record) > 3 {
+			loc.Region = strings.TrimSpace(record[3])
+		}
+		if len(record) > 4 {
+			loc.City = strings.TrimSpace(record[4])
+		}
+		db.ranges = append(db.ranges, geoRange{start: start.Unmap(), end: end.Unmap(), loc: loc})
+	}
+	sort.Slice(db.ranges, func(i, j int) bool { return db.ranges[i].start.Less(db.ranges[j].start) })
+	return db, nil
+}
+
+// Lookup finds the location of an address. A nil database, an invalid
+// address or one outside every range gives an empty location.
+func (db *GeoIPDatabase) Lookup(ip string) GeoLocation {
+	if db == nil {
+		return GeoLocation{}
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return GeoLocation{}
+	}
+	addr = addr.Unmap()
+	// the last range starting at or before addr is the only candidate
+	i := sort.Search(len(db.ranges), func(i int) bool { return addr.Less(db.ranges[i].start) }) - 1
+	if i < 0 || db.ranges[i].end.Less(addr) || db.ranges[i].start.Is4() != addr.Is4() {
+		return GeoLocation{}
+	}
+	return db.ranges[i].loc
+}
+
+// Size is the number of ranges loaded.
+func (db *GeoIPDatabase) Size() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.ranges)
+}
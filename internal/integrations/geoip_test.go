@@ -0,0 +1,46 @@
+package integrations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeoIPLookup(t *testing.T) {
+	data := `ip_start,ip_end,country,region,city
+102.88.0.0,102.88.255.255,ng,Lagos,Lagos
+1.0.0.0,1.0.0.255,AU
+2001:db8::,2001:db8::ffff,DE,Berlin,Berlin
+`
+	db, err := ParseGeoIPCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Size() != 3 {
+		t.Fatalf("expected 3 ranges, got %d", db.Size())
+	}
+	cases := []struct {
+		ip   string
+		want GeoLocation
+	}{
+		{"102.88.34.10", GeoLocation{Country: "NG", Region: "Lagos", City: "Lagos"}},
+		{"::ffff:102.88.0.1", GeoLocation{Country: "NG", Region: "Lagos", City: "Lagos"}},
+		{"1.0.0.255", GeoLocation{Country: "AU"}},
+		{"1.0.1.0", GeoLocation{}},
+		{"2001:db8::10", GeoLocation{Country: "DE", Region: "Berlin", City: "Berlin"}},
+		{"8.8.8.8", GeoLocation{}},
+		{"not an ip", GeoLocation{}},
+	}
+	for _, c := range cases {
+		if got := db.Lookup(c.ip); got != c.want {
+			t.Errorf("Lookup(%q) = %+v, want %+v", c.ip, got, c.want)
+		}
+	}
+
+	var none *GeoIPDatabase
+	if got := none.Lookup("102.88.34.10"); got != (GeoLocation{}) {
+		t.Errorf("nil database should find nothing, got %+v", got)
+	}
+	if _, err := ParseGeoIPCSV(strings.NewReader("1.0.0.0,1.0.0.255,AU\n1.0.1.0,garbage,AU\n")); err == nil {
+		t.Errorf("expected an error for an invalid end address")
+	}
+}
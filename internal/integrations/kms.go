@@ -0,0 +1,30 @@
+package integrations
+
+import (
+	"CardFlow/internal/config"
+	"context"
+	"fmt"
+)
+
+// KeyManager wraps and unwraps data keys with a key-encryption key that never
+// leaves the key management system.
+type KeyManager interface {
+	WrapKey(ctx context.Context, kekID string, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, kekID string, wrapped []byte) ([]byte, error)
+}
+
+// NewKeyManager builds the KeyManager named by KMS_PROVIDER.
+func NewKeyManager() (KeyManager, error) {
+	switch config.KmsProvider {
+	case "local":
+		return NewLocalKMS(config.KmsLocalKeyFile)
+	case "pkcs11":
+		return NewPkcs11KMS(config.Pkcs11Module, config.Pkcs11TokenLabel, config.Pkcs11Pin)
+	case "aws":
+		return NewAwsKMS(config.AwsRegion, config.AwsAccessKeyID, config.AwsSecretAccessKey, config.AwsSessionToken)
+	case "gcp":
+		return NewGcpKMS(config.GcpAccessToken), nil
+	default:
+		return nil, fmt.Errorf("unknown KMS_PROVIDER %q", config.KmsProvider)
+	}
+}
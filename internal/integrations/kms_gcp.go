@@ -0,0 +1,121 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// gcpKMS calls the Cloud KMS REST API. kekID is the full CryptoKey resource
+// name, projects/.../locations/.../keyRings/.../cryptoKeys/.... Without a
+// static GCP_ACCESS_TOKEN, tokens come from the instance metadata server.
+type gcpKMS struct {
+	baseURL     string
+	staticToken string
+	http        *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func NewGcpKMS(accessToken string) KeyManager {
+	return &gcpKMS{
+		baseURL:     "https://cloudkms.googleapis.com/v1/",
+		staticToken: accessToken,
+		http:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *gcpKMS) WrapKey(ctx context.Context, kekID string, dataKey []byte) ([]byte, error) {
+	var out struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := g.call(ctx, kekID+":encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Ciphertext)
+}
+
+func (g *gcpKMS) UnwrapKey(ctx context.Context, kekID string, wrapped []byte) ([]byte, error) {
+	var out struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := g.call(ctx, kekID+":decrypt", map[string]string{"ciphertext": base64.StdEncoding.EncodeToString(wrapped)}, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Plaintext)
+}
+
+func (g *gcpKMS) call(ctx context.Context, path string, in any, out any) error {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&failure)
+		return fmt.Errorf("gcp kms request failed: %s", failure.Error.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (g *gcpKMS) accessToken(ctx context.Context) (string, error) {
+	if g.staticToken != "" {
+		return g.staticToken, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Now().Before(g.tokenExpiry) {
+		return g.token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gcp metadata server unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.AccessToken == "" {
+		return "", errors.New("gcp metadata server returned no access token")
+	}
+	g.token = out.AccessToken
+	// refresh a minute early so a token never expires mid-request
+	g.tokenExpiry = time.Now().Add(time.Duration(out.ExpiresIn)*time.Second - time.Minute)
+	return g.token, nil
+}
@@ -0,0 +1,11 @@
+//go:build !pkcs11
+
+package integrations
+
+import "errors"
+
+// NewPkcs11KMS is only available in builds tagged pkcs11, which need cgo and
+// the HSM vendor's PKCS#11 module.
+func NewPkcs11KMS(module, tokenLabel, pin string) (KeyManager, error) {
+	return nil, errors.New("this build has no PKCS#11 support, rebuild with -tags pkcs11")
+}
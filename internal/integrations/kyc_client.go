@@ -1 +1,150 @@
-package integrations
\ No newline at end of file
+package integrations
+
+import (
+	"CardFlow/internal/config"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Provider decisions. They are advice for the compliance officer reviewing
+// the submission, never a final KYC outcome on their own.
+const (
+	KycDecisionApprove = "approve"
+	KycDecisionReview  = "review"
+	KycDecisionDecline = "decline"
+)
+
+type KycDocumentFile struct {
+	MimeType string
+	Data     []byte
+}
+
+type KycCheckRequest struct {
+	Reference      string // our submission ID, echoed back by the provider
+	Selfie         KycDocumentFile
+	IDDocument     KycDocumentFile
+	ProofOfAddress KycDocumentFile
+}
+
+// KycCheckResult holds the provider's scores, each from 0 to 1.
+type KycCheckResult struct {
+	ProviderReference    string
+	DocumentAuthenticity float64
+	FaceMatch            float64
+	Liveness             float64
+	Decision             string
+	Reasons              []string
+}
+
+// KycProvider is an identity verification service that checks the ID
+// document is genuine, that the selfie matches it and that the selfie is of
+// a live person.
+type KycProvider interface {
+	Name() string
+	Verify(ctx context.Context, req KycCheckRequest) (KycCheckResult, error)
+}
+
+// NewKycProvider builds the provider named by KYC_PROVIDER, or nil when
+// submissions are only reviewed by hand.
+func NewKycProvider() (KycProvider, error) {
+	switch config.KycProvider {
+	case "":
+		return nil, nil
+	case "http":
+		if config.KycProviderUrl == "" || config.KycProviderApiKey == "" {
+			return nil, errors.New("KYC_PROVIDER_URL and KYC_PROVIDER_API_KEY are required for the http KYC provider")
+		}
+		return NewHttpKycProvider(config.KycProviderUrl, config.KycProviderApiKey), nil
+	case "fake":
+		return NewFakeKycProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown KYC_PROVIDER %q", config.KycProvider)
+	}
+}
+
+type httpKycProvider struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+func NewHttpKycProvider(baseURL, apiKey string) KycProvider {
+	return &httpKycProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (p *httpKycProvider) Name() string {
+	return "http"
+}
+
+type kycProviderFile struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"`
+}
+
+type kycProviderResp struct {
+	Reference            string   `json:"reference"`
+	DocumentAuthenticity float64  `json:"document_authenticity"`
+	FaceMatch            float64  `json:"face_match"`
+	Liveness             float64  `json:"liveness"`
+	Decision             string   `json:"decision"`
+	Reasons              []string `json:"reasons"`
+	Message              string   `json:"message"`
+}
+
+func (p *httpKycProvider) Verify(ctx context.Context, req KycCheckRequest) (KycCheckResult, error) {
+	file := func(f KycDocumentFile) kycProviderFile {
+		return kycProviderFile{MimeType: f.MimeType, Data: base64.StdEncoding.EncodeToString(f.Data)}
+	}
+	body, err := json.Marshal(map[string]any{
+		"reference":        req.Reference,
+		"selfie":           file(req.Selfie),
+		"id_document":      file(req.IDDocument),
+		"proof_of_address": file(req.ProofOfAddress),
+	})
+	if err != nil {
+		return KycCheckResult{}, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/verifications", bytes.NewReader(body))
+	if err != nil {
+		return KycCheckResult{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.http.Do(httpReq)
+	if err != nil {
+		return KycCheckResult{}, err
+	}
+	defer resp.Body.Close()
+	var out kycProviderResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return KycCheckResult{}, fmt.Errorf("invalid kyc provider response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return KycCheckResult{}, fmt.Errorf("kyc provider returned status %d: %s", resp.StatusCode, out.Message)
+	}
+	switch out.Decision {
+	case KycDecisionApprove, KycDecisionReview, KycDecisionDecline:
+	default:
+		return KycCheckResult{}, fmt.Errorf("kyc provider returned unknown decision %q", out.Decision)
+	}
+	return KycCheckResult{
+		ProviderReference:    out.Reference,
+		DocumentAuthenticity: out.DocumentAuthenticity,
+		FaceMatch:            out.FaceMatch,
+		Liveness:             out.Liveness,
+		Decision:             out.Decision,
+		Reasons:              out.Reasons,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package integrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// FakeKycProvider is a deterministic stand-in for an identity verification
+// provider, for tests and local development. Scores are derived from the
+// document contents, so the same submission always gets the same result.
+type FakeKycProvider struct{}
+
+func NewFakeKycProvider() *FakeKycProvider {
+	return &FakeKycProvider{}
+}
+
+func (f *FakeKycProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakeKycProvider) Verify(ctx context.Context, req KycCheckRequest) (KycCheckResult, error) {
+	if len(req.Selfie.Data) == 0 || len(req.IDDocument.Data) == 0 {
+		return KycCheckResult{}, errors.New("selfie and id document are required")
+	}
+	idSum := sha256.Sum256(req.IDDocument.Data)
+	pairSum := sha256.Sum256(append(append([]byte{}, req.Selfie.Data...), req.IDDocument.Data...))
+	selfieSum := sha256.Sum256(req.Selfie.Data)
+
+	result := KycCheckResult{
+		ProviderReference:    "fake_" + hex.EncodeToString(pairSum[:8]),
+		DocumentAuthenticity: fakeScore(idSum),
+		FaceMatch:            fakeScore(pairSum),
+		Liveness:             fakeScore(selfieSum),
+	}
+	result.Decision, result.Reasons = KycDecisionFor(result)
+	return result, nil
+}
+
+// fakeScore maps a digest to a score between 0.5 and 1.
+func fakeScore(sum [32]byte) float64 {
+	return 0.5 + float64(int(sum[0])<<8|int(sum[1]))/65535/2
+}
+
+// Score thresholds used by KycDecisionFor.
+const (
+	KycScoreApprove = 0.85
+	KycScoreDecline = 0.6
+)
+
+// KycDecisionFor turns scores into a decision: every score must clear
+// KycScoreApprove for approve, and any score under KycScoreDecline declines.
+func KycDecisionFor(result KycCheckResult) (string, []string) {
+	scores := []struct {
+		name  string
+		value float64
+	}{
+		{"document_authenticity", result.DocumentAuthenticity},
+		{"face_match", result.FaceMatch},
+		{"liveness", result.Liveness},
+	}
+	decision := KycDecisionApprove
+	var reasons []string
+	for _, score := range scores {
+		switch {
+		case score.value < KycScoreDecline:
+			decision = KycDecisionDecline
+			reasons = append(reasons, score.name+"_failed")
+		case score.value < KycScoreApprove:
+			if decision == KycDecisionApprove {
+				decision = KycDecisionReview
+			}
+			reasons = append(reasons, score.name+"_low")
+		}
+	}
+	return decision, reasons
+}
@@ -0,0 +1,49 @@
+package integrations
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestKycDecisionFor(t *testing.T) {
+	cases := []struct {
+		result   KycCheckResult
+		decision string
+		reasons  []string
+	}{
+		{KycCheckResult{DocumentAuthenticity: 0.9, FaceMatch: 0.95, Liveness: 0.99}, KycDecisionApprove, nil},
+		{KycCheckResult{DocumentAuthenticity: 0.9, FaceMatch: 0.7, Liveness: 0.99}, KycDecisionReview, []string{"face_match_low"}},
+		{KycCheckResult{DocumentAuthenticity: 0.7, FaceMatch: 0.4, Liveness: 0.99}, KycDecisionDecline, []string{"document_authenticity_low", "face_match_failed"}},
+	}
+	for _, c := range cases {
+		decision, reasons := KycDecisionFor(c.result)
+		if decision != c.decision || !reflect.DeepEqual(reasons, c.reasons) {
+			t.Errorf("expected %s %v, got %s %v", c.decision, c.reasons, decision, reasons)
+		}
+	}
+}
+
+func TestFakeKycProviderIsDeterministic(t *testing.T) {
+	provider := NewFakeKycProvider()
+	req := KycCheckRequest{
+		Selfie:     KycDocumentFile{MimeType: "image/png", Data: []byte("selfie")},
+		IDDocument: KycDocumentFile{MimeType: "image/png", Data: []byte("passport")},
+	}
+	first, err := provider.Verify(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := provider.Verify(context.Background(), req)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("expected the same result for the same documents")
+	}
+	for _, score := range []float64{first.DocumentAuthenticity, first.FaceMatch, first.Liveness} {
+		if score < 0.5 || score > 1 {
+			t.Fatalf("expected scores between 0.5 and 1, got %v", score)
+		}
+	}
+	if _, err := provider.Verify(context.Background(), KycCheckRequest{}); err == nil {
+		t.Fatalf("expected a submission without documents to be rejected")
+	}
+}
@@ -0,0 +1,118 @@
+package integrations
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type PayoutRequest struct {
+	Reference     string
+	Amount        float64
+	Currency      string
+	Narration     string
+	CustomerName  string
+	CustomerEmail string
+	Destination   models.PayoutDestination
+}
+
+type PayoutResult struct {
+	Reference string
+	Status    string // processing, success, failed
+}
+
+// ErrPayoutRejected marks a payout the provider definitely did not make: it
+// was never sent, or the provider refused it. Any other error leaves the
+// outcome unknown until the payout webhook arrives.
+var ErrPayoutRejected = errors.New("payout rejected")
+
+// PayoutClient sends money from the platform float to a bank account.
+type PayoutClient interface {
+	Disburse(ctx context.Context, req PayoutRequest) (PayoutResult, error)
+}
+
+type korapayClient struct {
+	baseURL string
+	secret  string
+	http    *http.Client
+}
+
+func NewKorapayClient() PayoutClient {
+	return &korapayClient{
+		baseURL: config.KorapayUrl,
+		secret:  config.KorapaySecret,
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type korapayDisburseResp struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		Reference string `json:"reference"`
+		Status    string `json:"status"`
+	} `json:"data"`
+}
+
+func (k *korapayClient) Disburse(ctx context.Context, req PayoutRequest) (PayoutResult, error) {
+	if k.baseURL == "" || k.secret == "" {
+		return PayoutResult{}, fmt.Errorf("%w: payout provider is not configured", ErrPayoutRejected)
+	}
+	body, err := json.Marshal(map[string]any{
+		"reference": req.Reference,
+		"destination": map[string]any{
+			"type":      "bank_account",
+			"amount":    fmt.Sprintf("%.2f", req.Amount),
+			"currency":  req.Currency,
+			"narration": req.Narration,
+			"bank_account": map[string]string{
+				"bank":    req.Destination.BankCode,
+				"account": req.Destination.AccountNumber,
+			},
+			"customer": map[string]string{
+				"name":  req.CustomerName,
+				"email": req.CustomerEmail,
+			},
+		},
+	})
+	if err != nil {
+		return PayoutResult{}, fmt.Errorf("%w: %v", ErrPayoutRejected, err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, k.baseURL+"/merchant/api/v1/transactions/disburse", bytes.NewReader(body))
+	if err != nil {
+		return PayoutResult{}, fmt.Errorf("%w: %v", ErrPayoutRejected, err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+k.secret)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := k.http.Do(httpReq)
+	if err != nil {
+		return PayoutResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var out korapayDisburseResp
+	decodeErr := json.NewDecoder(resp.Body).Decode(&out)
+	// a 4xx means the request was refused; a timeout, a 5xx or an unreadable
+	// answer may still have been paid out
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return PayoutResult{}, fmt.Errorf("%w: %s", ErrPayoutRejected, out.Message)
+	}
+	if decodeErr != nil {
+		return PayoutResult{}, fmt.Errorf("invalid payout response: %w", decodeErr)
+	}
+	if resp.StatusCode >= 300 || !out.Status {
+		return PayoutResult{}, fmt.Errorf("payout not confirmed: %s", out.Message)
+	}
+	if out.Data.Status == "failed" {
+		return PayoutResult{}, fmt.Errorf("%w: %s", ErrPayoutRejected, out.Message)
+	}
+	return PayoutResult{Reference: out.Data.Reference, Status: out.Data.Status}, nil
+}
@@ -0,0 +1,46 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKorapayDisburseOutcomes(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		rejected bool
+		failed   bool
+	}{
+		{"accepted", 200, `{"status":true,"data":{"reference":"WDR1","status":"processing"}}`, false, false},
+		{"refused", 400, `{"status":false,"message":"invalid account"}`, true, true},
+		{"refused without a body", 422, ``, true, true},
+		{"explicitly failed", 200, `{"status":true,"message":"failed","data":{"status":"failed"}}`, true, true},
+		{"server error", 502, `{"status":false,"message":"bad gateway"}`, false, true},
+		{"unreadable success", 200, `<html>`, false, true},
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			w.Write([]byte(c.body))
+		}))
+		client := &korapayClient{baseURL: srv.URL, secret: "sk_test", http: srv.Client()}
+		_, err := client.Disburse(context.Background(), PayoutRequest{Reference: "WDR1", Amount: 10, Currency: "NGN"})
+		srv.Close()
+		if (err != nil) != c.failed {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if errors.Is(err, ErrPayoutRejected) != c.rejected {
+			t.Errorf("%s: expected rejected=%v, got %v", c.name, c.rejected, err)
+		}
+	}
+
+	unconfigured := &korapayClient{}
+	if _, err := unconfigured.Disburse(context.Background(), PayoutRequest{}); !errors.Is(err, ErrPayoutRejected) {
+		t.Fatalf("expected a payout that was never sent to count as rejected, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package integrations
+
+import (
+	"CardFlow/internal/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type TokenRequest struct {
+	PAN         string
+	ExpiryMonth string
+	ExpiryYear  string
+	Wallet      string
+	DeviceID    string
+	DeviceName  string
+}
+
+type TokenResult struct {
+	TokenReference string
+	TokenLastFour  string
+}
+
+// TokenServiceProvider is the card network's token service, which issues the
+// device tokens wallets pay with and keeps their state in step with ours.
+type TokenServiceProvider interface {
+	ProvisionToken(ctx context.Context, req TokenRequest) (TokenResult, error)
+	UpdateTokenStatus(ctx context.Context, tokenReference, status string) error
+}
+
+type tspClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+func NewTspClient() TokenServiceProvider {
+	return &tspClient{
+		baseURL: config.TspUrl,
+		apiKey:  config.TspApiKey,
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type tspTokenResp struct {
+	TokenReference string `json:"token_reference"`
+	TokenLastFour  string `json:"token_last_four"`
+	Message        string `json:"message"`
+}
+
+func (t *tspClient) ProvisionToken(ctx context.Context, req TokenRequest) (TokenResult, error) {
+	body, err := json.Marshal(map[string]string{
+		"pan":          req.PAN,
+		"expiry_month": req.ExpiryMonth,
+		"expiry_year":  req.ExpiryYear,
+		"wallet":       req.Wallet,
+		"device_id":    req.DeviceID,
+		"device_name":  req.DeviceName,
+	})
+	if err != nil {
+		return TokenResult{}, err
+	}
+	var out tspTokenResp
+	if err := t.do(ctx, http.MethodPost, "/tokens", body, &out); err != nil {
+		return TokenResult{}, err
+	}
+	if out.TokenReference == "" {
+		return TokenResult{}, errors.New("token service returned no token")
+	}
+	return TokenResult{TokenReference: out.TokenReference, TokenLastFour: out.TokenLastFour}, nil
+}
+
+func (t *tspClient) UpdateTokenStatus(ctx context.Context, tokenReference, status string) error {
+	body, err := json.Marshal(map[string]string{"status": status})
+	if err != nil {
+		return err
+	}
+	return t.do(ctx, http.MethodPut, "/tokens/"+url.PathEscape(tokenReference)+"/status", body, nil)
+}
+
+func (t *tspClient) do(ctx context.Context, method, path string, body []byte, out any) error {
+	if t.baseURL == "" || t.apiKey == "" {
+		return errors.New("token service provider is not configured")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, t.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+t.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure tspTokenResp
+		_ = json.NewDecoder(resp.Body).Decode(&failure)
+		return fmt.Errorf("token service rejected request: %s", failure.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid token service response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package integrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// TspSimulator is an in-memory token service provider for tests and local
+// development. Token references are derived from the PAN and device, so the
+// same provisioning request always yields the same token.
+type TspSimulator struct {
+	mu     sync.Mutex
+	tokens map[string]string // token reference -> status
+	// DeclinePANs makes provisioning fail for the listed PANs, the way the
+	// network declines cards it considers ineligible.
+	DeclinePANs map[string]bool
+}
+
+func NewTspSimulator() *TspSimulator {
+	return &TspSimulator{tokens: map[string]string{}, DeclinePANs: map[string]bool{}}
+}
+
+func (t *TspSimulator) ProvisionToken(ctx context.Context, req TokenRequest) (TokenResult, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.DeclinePANs[req.PAN] {
+		return TokenResult{}, errors.New("token service rejected request: card not eligible")
+	}
+	sum := sha256.Sum256([]byte(req.PAN + "|" + req.Wallet + "|" + req.DeviceID))
+	reference := "DNITHE" + hex.EncodeToString(sum[:12])
+	t.tokens[reference] = "active"
+	lastFour := fmt.Sprintf("%04d", (int(sum[12])<<8|int(sum[13]))%10000)
+	return TokenResult{TokenReference: reference, TokenLastFour: lastFour}, nil
+}
+
+func (t *TspSimulator) UpdateTokenStatus(ctx context.Context, tokenReference, status string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current, ok := t.tokens[tokenReference]
+	if !ok {
+		return errors.New("token service rejected request: unknown token")
+	}
+	if current == "deleted" {
+		return errors.New("token service rejected request: token is deleted")
+	}
+	switch status {
+	case "active", "suspended", "deleted":
+		t.tokens[tokenReference] = status
+		return nil
+	default:
+		return errors.New("token service rejected request: invalid status")
+	}
+}
+
+// Status reports what the simulated network holds for a token.
+func (t *TspSimulator) Status(tokenReference string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tokens[tokenReference]
+}
@@ -0,0 +1,404 @@
+package integrations
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// WatchlistEntry is one sanctioned or politically exposed party, in a shape
+// common to every list format we load.
+type WatchlistEntry struct {
+	Source string // ofac, un, eu or pep
+	ListID string // the list's own identifier for the entry
+	Type   string // individual or entity
+	Name   string
+	// other names the party is known by
+	Aliases []string
+	// YYYY-MM-DD, or YYYY when only the year is known
+	DatesOfBirth []string
+	Programs     []string
+}
+
+// LoadWatchlistFiles loads every list named in spec, a comma separated list
+// of source:path pairs such as "ofac:/lists/sdn.xml,un:/lists/un.xml". OFAC
+// lists may be the SDN CSV or XML, told apart by the file extension; pep
+// files are CSV with name, aliases (separated by ';'), date of birth and
+// position columns.
+func LoadWatchlistFiles(spec string) ([]WatchlistEntry, error) {
+	var entries []WatchlistEntry
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		source, path, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid watchlist entry %q, expected source:path", item)
+		}
+		loaded, err := loadWatchlistFile(source, path)
+		if err != nil {
+			return nil, fmt.Errorf("watchlist %s: %w", path, err)
+		}
+		entries = append(entries, loaded...)
+	}
+	return entries, nil
+}
+
+func loadWatchlistFile(source, path string) ([]WatchlistEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	isXML := strings.EqualFold(filepath.Ext(path), ".xml")
+	switch {
+	case source == "ofac" && isXML:
+		return ParseOfacSdnXML(f)
+	case source == "ofac":
+		return ParseOfacSdnCSV(f)
+	case source == "un":
+		return ParseUnConsolidatedXML(f)
+	case source == "eu":
+		return ParseEuConsolidatedXML(f)
+	case source == "pep":
+		return ParsePepCSV(f)
+	default:
+		return nil, fmt.Errorf("unknown watchlist source %q", source)
+	}
+}
+
+var (
+	ofacDOB   = regexp.MustCompile(`DOB ([^;]+?)(?:;|\.$|$)`)
+	ofacAlias = regexp.MustCompile(`a\.k\.a\. '([^']+)'`)
+)
+
+// ParseOfacSdnCSV reads OFAC's sdn.csv: ent_num, SDN_Name, SDN_Type,
+// Program, ... Remarks, with no header row. Aliases and dates of birth are
+// only available from the remarks column in this format.
+func ParseOfacSdnCSV(r io.Reader) ([]WatchlistEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var entries []WatchlistEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 || ofacNull(record[1]) == "" {
+			continue
+		}
+		entry := WatchlistEntry{
+			Source: "ofac",
+			ListID: strings.TrimSpace(record[0]),
+			Type:   "entity",
+			Name:   ofacNull(record[1]),
+		}
+		if strings.EqualFold(ofacNull(record[2]), "individual") {
+			entry.Type = "individual"
+		}
+		if len(record) > 3 && ofacNull(record[3]) != "" {
+			entry.Programs = strings.Fields(strings.NewReplacer("[", "", "]", "").Replace(ofacNull(record[3])))
+		}
+		if len(record) > 11 {
+			remarks := ofacNull(record[11])
+			for _, m := range ofacAlias.FindAllStringSubmatch(remarks, -1) {
+				entry.Aliases = append(entry.Aliases, m[1])
+			}
+			for _, m := range ofacDOB.FindAllStringSubmatch(remarks, -1) {
+				if dob := NormalizeListDate(m[1]); dob != "" {
+					entry.DatesOfBirth = append(entry.DatesOfBirth, dob)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ofacNull maps OFAC's "-0-" placeholder to an empty string.
+func ofacNull(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "-0-" {
+		return ""
+	}
+	return value
+}
+
+type ofacSdnXML struct {
+	Entries []struct {
+		UID       string   `xml:"uid"`
+		FirstName string   `xml:"firstName"`
+		LastName  string   `xml:"lastName"`
+		SdnType   string   `xml:"sdnType"`
+		Programs  []string `xml:"programList>program"`
+		Akas      []struct {
+			FirstName string `xml:"firstName"`
+			LastName  string `xml:"lastName"`
+		} `xml:"akaList>aka"`
+		DatesOfBirth []string `xml:"dateOfBirthList>dateOfBirthItem>dateOfBirth"`
+	} `xml:"sdnEntry"`
+}
+
+// ParseOfacSdnXML reads OFAC's sdn.xml.
+func ParseOfacSdnXML(r io.Reader) ([]WatchlistEntry, error) {
+	var list ofacSdnXML
+	if err := xml.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+	entries := make([]WatchlistEntry, 0, len(list.Entries))
+	for _, e := range list.Entries {
+		entry := WatchlistEntry{
+			Source:   "ofac",
+			ListID:   e.UID,
+			Type:     "entity",
+			Name:     joinName(e.FirstName, e.LastName),
+			Programs: e.Programs,
+		}
+		if strings.EqualFold(e.SdnType, "individual") {
+			entry.Type = "individual"
+		}
+		for _, aka := range e.Akas {
+			if name := joinName(aka.FirstName, aka.LastName); name != "" {
+				entry.Aliases = append(entry.Aliases, name)
+			}
+		}
+		for _, dob := range e.DatesOfBirth {
+			if dob = NormalizeListDate(dob); dob != "" {
+				entry.DatesOfBirth = append(entry.DatesOfBirth, dob)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+type unParty struct {
+	DataID      string   `xml:"DATAID"`
+	FirstName   string   `xml:"FIRST_NAME"`
+	SecondName  string   `xml:"SECOND_NAME"`
+	ThirdName   string   `xml:"THIRD_NAME"`
+	FourthName  string   `xml:"FOURTH_NAME"`
+	ListType    string   `xml:"UN_LIST_TYPE"`
+	Reference   string   `xml:"REFERENCE_NUMBER"`
+	Aliases     []string `xml:"INDIVIDUAL_ALIAS>ALIAS_NAME"`
+	EntityAlias []string `xml:"ENTITY_ALIAS>ALIAS_NAME"`
+	Births      []struct {
+		Date     string `xml:"DATE"`
+		Year     string `xml:"YEAR"`
+		FromYear string `xml:"FROM_YEAR"`
+	} `xml:"INDIVIDUAL_DATE_OF_BIRTH"`
+}
+
+type unConsolidatedXML struct {
+	Individuals []unParty `xml:"INDIVIDUALS>INDIVIDUAL"`
+	Entities    []unParty `xml:"ENTITIES>ENTITY"`
+}
+
+// ParseUnConsolidatedXML reads the UN Security Council consolidated list.
+func ParseUnConsolidatedXML(r io.Reader) ([]WatchlistEntry, error) {
+	var list unConsolidatedXML
+	if err := xml.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+	var entries []WatchlistEntry
+	add := func(p unParty, kind string) {
+		entry := WatchlistEntry{
+			Source: "un",
+			ListID: firstNonEmpty(p.Reference, p.DataID),
+			Type:   kind,
+			Name:   joinName(p.FirstName, p.SecondName, p.ThirdName, p.FourthName),
+		}
+		if p.ListType != "" {
+			entry.Programs = []string{p.ListType}
+		}
+		for _, alias := range append(p.Aliases, p.EntityAlias...) {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Aliases = append(entry.Aliases, alias)
+			}
+		}
+		for _, birth := range p.Births {
+			if dob := NormalizeListDate(firstNonEmpty(birth.Date, birth.Year, birth.FromYear)); dob != "" {
+				entry.DatesOfBirth = append(entry.DatesOfBirth, dob)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	for _, p := range list.Individuals {
+		add(p, "individual")
+	}
+	for _, p := range list.Entities {
+		add(p, "entity")
+	}
+	return entries, nil
+}
+
+type euConsolidatedXML struct {
+	Entities []struct {
+		LogicalID   string `xml:"logicalId,attr"`
+		SubjectType struct {
+			Code string `xml:"code,attr"`
+		} `xml:"subjectType"`
+		Names []struct {
+			WholeName string `xml:"wholeName,attr"`
+		} `xml:"nameAlias"`
+		Births []struct {
+			Birthdate string `xml:"birthdate,attr"`
+			Year      string `xml:"year,attr"`
+		} `xml:"birthdate"`
+		Regulations []struct {
+			Programme string `xml:"programme,attr"`
+		} `xml:"regulation"`
+	} `xml:"sanctionEntity"`
+}
+
+// ParseEuConsolidatedXML reads the EU consolidated financial sanctions list.
+// The first name alias is taken as the primary name.
+func ParseEuConsolidatedXML(r io.Reader) ([]WatchlistEntry, error) {
+	var list euConsolidatedXML
+	if err := xml.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+	entries := make([]WatchlistEntry, 0, len(list.Entities))
+	for _, e := range list.Entities {
+		var names []string
+		for _, n := range e.Names {
+			if name := strings.TrimSpace(n.WholeName); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		entry := WatchlistEntry{
+			Source:  "eu",
+			ListID:  e.LogicalID,
+			Type:    "entity",
+			Name:    names[0],
+			Aliases: names[1:],
+		}
+		if e.SubjectType.Code == "person" {
+			entry.Type = "individual"
+		}
+		for _, birth := range e.Births {
+			if dob := NormalizeListDate(firstNonEmpty(birth.Birthdate, birth.Year)); dob != "" {
+				entry.DatesOfBirth = append(entry.DatesOfBirth, dob)
+			}
+		}
+		for _, reg := range e.Regulations {
+			if reg.Programme != "" {
+				entry.Programs = append(entry.Programs, reg.Programme)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ParsePepCSV reads a politically exposed persons list with a header row and
+// name, aliases, date_of_birth and position columns.
+func ParsePepCSV(r io.Reader) ([]WatchlistEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("pep list has no name column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	var entries []WatchlistEntry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := WatchlistEntry{
+			Source: "pep",
+			ListID: fmt.Sprintf("line-%d", line),
+			Type:   "individual",
+			Name:   field(record, "name"),
+		}
+		if entry.Name == "" {
+			continue
+		}
+		for _, alias := range strings.Split(field(record, "aliases"), ";") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Aliases = append(entry.Aliases, alias)
+			}
+		}
+		if dob := NormalizeListDate(field(record, "date_of_birth")); dob != "" {
+			entry.DatesOfBirth = []string{dob}
+		}
+		if position := field(record, "position"); position != "" {
+			entry.Programs = []string{position}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+var listDateLayouts = []string{"2006-01-02", "02 Jan 2006", "2 Jan 2006", "02 January 2006", "2 January 2006", "01/02/2006"}
+
+var leadingYear = regexp.MustCompile(`\b(1[89]\d\d|20\d\d)\b`)
+
+// NormalizeListDate turns the date formats used by the lists into
+// YYYY-MM-DD, or YYYY when only a year is given, such as "circa 1960".
+func NormalizeListDate(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	for _, layout := range listDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	if m := leadingYear.FindString(value); m != "" {
+		return m
+	}
+	return ""
+}
+
+func joinName(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
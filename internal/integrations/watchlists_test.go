@@ -0,0 +1,50 @@
+package integrations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOfacSdnCSV(t *testing.T) {
+	data := `36,"AEROCARIBBEAN AIRLINES",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- 
+2674,"ABDULLAH, Ahmed","individual","SDGT] [IRAQ2",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,"DOB 14 Mar 1970; a.k.a. 'ABU AHMED'; nationality Iraq."
+`
+	entries, err := ParseOfacSdnCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Type != "entity" {
+		t.Fatalf("expected an entity, got %q", entries[0].Type)
+	}
+	want := WatchlistEntry{
+		Source:       "ofac",
+		ListID:       "2674",
+		Type:         "individual",
+		Name:         "ABDULLAH, Ahmed",
+		Aliases:      []string{"ABU AHMED"},
+		DatesOfBirth: []string{"1970-03-14"},
+		Programs:     []string{"SDGT", "IRAQ2"},
+	}
+	if !reflect.DeepEqual(entries[1], want) {
+		t.Fatalf("got %+v, want %+v", entries[1], want)
+	}
+}
+
+func TestParsePepCSV(t *testing.T) {
+	data := "name,aliases,date_of_birth,position\nJane Doe,J. Doe; Jane A. Doe,1965,Minister of Finance\n,,,\n"
+	entries, err := ParsePepCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Name != "Jane Doe" || len(e.Aliases) != 2 || !reflect.DeepEqual(e.DatesOfBirth, []string{"1965"}) || e.Programs[0] != "Minister of Finance" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"CardFlow/internal/utils"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// AdminProtected accepts only admin tokens from utils.GenerateAdminJWT, signed
+// with the admin secret and scoped to the admin audience, whose role is one
+// of roles, and sets admin_id and admin_role in Locals.
+func AdminProtected(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get("Authorization")
+		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": "Missing or invalid Authorization header",
+			})
+		}
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+		secret, err := utils.AdminJwtSecret()
+		if err != nil {
+			log.Println(err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"message": "Something went wrong, please try again later",
+			})
+		}
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(secret), nil
+		}, jwt.WithAudience(utils.AdminTokenAudience))
+		if err != nil || !token.Valid {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": "Invalid or expired token",
+			})
+		}
+
+		claims, _ := token.Claims.(jwt.MapClaims)
+		rawAdminID, _ := claims["admin_id"].(string)
+		role, _ := claims["role"].(string)
+		adminID, err := uuid.Parse(rawAdminID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": "Unauthorized: Please log in again",
+			})
+		}
+		allowed := false
+		for _, r := range roles {
+			if r == role {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"message": "Access denied",
+			})
+		}
+		c.Locals("admin_id", adminID)
+		c.Locals("admin_role", role)
+		return c.Next()
+	}
+}
@@ -2,15 +2,33 @@ package middleware
 
 import (
 	"CardFlow/internal/config"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// SessionValidator checks that the session a token was issued for is still
+// live, see services.SessionService.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) error
+}
+
+var sessions SessionValidator
+
+// UseSessions makes JWTProtected reject tokens of revoked sessions. Call it
+// once at startup, before the routes are served.
+func UseSessions(v SessionValidator) {
+	sessions = v
+}
 
 func JWTProtected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -45,9 +63,18 @@ func JWTProtected() fiber.Handler {
 			})
 		}
 
+		// back-office tokens are signed with their own secret, but refuse
+		// their audience here too in case the two secrets were ever shared
+		if aud, _ := token.Claims.GetAudience(); slices.Contains(aud, utils.AdminTokenAudience) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"message": "Invalid or expired token",
+			})
+		}
+
 		// Validate and set user_id in context
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			if rawUserID := claims["user_id"].(string); rawUserID != "" {
+			if rawUserID, _ := claims["user_id"].(string); rawUserID != "" {
 				user_id, err := uuid.Parse(rawUserID)
 				if err != nil { 
 					log.Println("Invalid user_id format in token claims")
@@ -57,6 +84,33 @@ func JWTProtected() fiber.Handler {
 					})
 				}
 				c.Locals("user_id", user_id)
+				// tokens from before sessions were recorded carry no
+				// session_id and simply run until they expire
+				if rawSessionID, _ := claims["session_id"].(string); rawSessionID != "" && sessions != nil {
+					session_id, err := uuid.Parse(rawSessionID)
+					if err != nil {
+						return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+							"success": false,
+							"message": "Unauthorized: Please log in again",
+						})
+					}
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					if err := sessions.ValidateSession(ctx, user_id, session_id); err != nil {
+						if errors.Is(err, utils.ErrSessionEnded) {
+							return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+								"success": false,
+								"message": err.Error(),
+							})
+						}
+						log.Printf("failed to check session %s: %v", session_id, err)
+						return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+							"success": false,
+							"message": "Something went wrong, please try again later",
+						})
+					}
+					c.Locals("session_id", session_id)
+				}
 			}else {
 				log.Println("User_id missing or invalid in token claims")
 				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PartnerAuthenticator checks a partner API key, see
+// services.PartnerService.
+type PartnerAuthenticator interface {
+	AuthenticatePartnerKey(ctx context.Context, data models.PartnerAuthReq) (models.PartnerPrincipal, error)
+}
+
+type partnerWindow struct {
+    Count     int
+    ExpiresAt time.Time
+}
+
+
+var partnerWindows = make(map[uuid.UUID]*partnerWindow)
+var partnerMu sync.Mutex
+
+
+
+// PartnerProtected authenticates server-to-server calls made with a partner
+// API key in X-API-Key on behalf of the user in X-On-Behalf-Of. The key
+// needs scope, and each key has its own per-minute rate limit. It sets
+// user_id like JWTProtected, plus partner_id and api_key_id, so the user
+// handlers can be reused.
+func PartnerProtected(auth PartnerAuthenticator, scope string) fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        key := c.Get("X-API-Key")
+        if key == "" {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Missing X-API-Key header",
+            })
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        principal, err := auth.AuthenticatePartnerKey(ctx, models.PartnerAuthReq{
+            Key:        key,
+            Scope:      scope,
+            OnBehalfOf: c.Get("X-On-Behalf-Of"),
+            Method:     c.Method(),
+            Path:       c.Path(),
+            Client:     models.ClientInfo{IPAddress: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)},
+        })
+        if err != nil {
+            status := fiber.StatusInternalServerError
+            switch {
+            case errors.Is(err, utils.ErrInvalidAPIKey):
+                status = fiber.StatusUnauthorized
+            case errors.Is(err, utils.ErrAPIKeyIPNotAllowed), errors.Is(err, utils.ErrAPIKeyScope), errors.Is(err, utils.ErrPartnerNotLinked):
+                status = fiber.StatusForbidden
+            }
+            return c.Status(status).JSON(fiber.Map{
+                "success": false,
+                "message": err.Error(),
+            })
+        }
+
+        if !allowPartnerRequest(principal.Keyid, principal.RateLimitPerMinute, time.Now()) {
+            c.Set(fiber.HeaderRetryAfter, "60")
+            return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+                "success": false,
+                "message": "Rate limit exceeded for this API key. Please try again later.",
+            })
+        }
+
+        c.Locals("user_id", principal.Userid)
+        c.Locals("partner_id", principal.Partnerid)
+        c.Locals("api_key_id", principal.Keyid)
+        return c.Next()
+    }
+}
+
+// allowPartnerRequest counts a request against the key's fixed one minute
+// window.
+func allowPartnerRequest(keyID uuid.UUID, limit int, now time.Time) bool {
+    partnerMu.Lock()
+    defer partnerMu.Unlock()
+
+    window, exists := partnerWindows[keyID]
+    if !exists || now.After(window.ExpiresAt) {
+        partnerWindows[keyID] = &partnerWindow{
+            Count:     1,
+            ExpiresAt: now.Add(1 * time.Minute),
+        }
+        return true
+    }
+    if window.Count >= limit {
+        return false
+    }
+    window.Count++
+    return true
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type revealAttempt struct {
+    Count     int
+    ExpiresAt time.Time
+}
+
+
+var revealAttempts = make(map[uuid.UUID]*revealAttempt)
+var revealMu sync.Mutex
+
+
+
+// RevealRateLimit caps card detail reveals per user, independently of the
+// login limiter. It must run after JWTProtected.
+func RevealRateLimit() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        userID, _ := c.Locals("user_id").(uuid.UUID)
+        now := time.Now()
+
+        // the lock covers the counter only, not the reveal itself
+        revealMu.Lock()
+        allowed := true
+        attempt, exists := revealAttempts[userID]
+
+        // First attempt OR expired window
+        if !exists || now.After(attempt.ExpiresAt) {
+            revealAttempts[userID] = &revealAttempt{
+                Count:     1,
+                ExpiresAt: now.Add(1 * time.Hour),
+            }
+        } else if attempt.Count >= 5 {
+            // Too many attempts
+            allowed = false
+        } else {
+            // Increment attempt count
+            attempt.Count++
+        }
+        revealMu.Unlock()
+
+        if !allowed {
+            return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+                "success": false,
+                "message": "Too many card reveal attempts. Please try again later.",
+            })
+        }
+        return c.Next()
+    }
+}
@@ -34,6 +34,8 @@ type User struct {
 	MFASecret  string `gorm:"column:mfa_secret;size:255"`
 
 	LastLoginAt *time.Time `gorm:"column:last_login_at"`
+	// last time the user was screened against the sanctions and PEP lists
+	ScreenedAt *time.Time `gorm:"column:screened_at"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -89,7 +91,41 @@ type KYCSubmission struct {
 
 	SubmittedAt time.Time  `gorm:"not null;default:current_timestamp"`
 	ReviewedAt  *time.Time
+	// set on approval to the ID document's expiry or the end of the
+	// re-verification period, whichever comes first
 	ExpiresAt   *time.Time
+	IDDocumentExpiresOn  *time.Time `gorm:"column:id_document_expires_on;type:date"`
+	ExpiryReminderSentAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// KYCProfile is the identity data a user declares with a submission. The ID
+// number is encrypted with the keyring; its last four characters are kept
+// for display. Only the date of birth is set until the user fills in the
+// rest.
+type KYCProfile struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	KYCSubmissionID uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex"`
+	KYCSubmission   KYCSubmission `gorm:"foreignKey:KYCSubmissionID"`
+
+	DateOfBirth *time.Time `gorm:"column:date_of_birth;type:date"`
+	Nationality string     `gorm:"size:2"` // ISO 3166-1 alpha-2
+
+	AddressLine1 string `gorm:"column:address_line1;size:100"`
+	AddressLine2 string `gorm:"column:address_line2;size:100"`
+	City         string `gorm:"size:100"`
+	State        string `gorm:"size:100"`
+	PostalCode   string `gorm:"size:20"`
+	Country      string `gorm:"size:2"`
+
+	IDType            string     `gorm:"column:id_type;size:30"` // passport, national_id, drivers_license, voters_card
+	IDNumberEncrypted string     `gorm:"column:id_number_encrypted;type:text"`
+	IDNumberLast4     string     `gorm:"column:id_number_last4;size:4"`
+	IDIssuingCountry  string     `gorm:"column:id_issuing_country;size:2"`
+	IDExpiresOn       *time.Time `gorm:"column:id_expires_on;type:date"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -98,17 +134,341 @@ type KYCSubmission struct {
 type KYCDocument struct {
 	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 
-	KYCSubmissionID uuid.UUID      `gorm:"type:uuid;not null;index, unique"`
+	KYCSubmissionID uuid.UUID      `gorm:"type:uuid;not null;index"`
 	KYCSubmission   KYCSubmission  `gorm:"foreignKey:KYCSubmissionID"`
 
-	DocumentType      string `gorm:"size:50;not null, unique"`
+	// a submission keeps rejected documents next to their replacements; at
+	// most one document of each type is not rejected
+	DocumentType      string `gorm:"size:50;not null"`
 	MimeType          string `gorm:"size:100;not null"`
-	EncryptedData     []byte `gorm:"type:bytea;not null"`
 	EncryptionVersion string `gorm:"size:20;not null;default:v1"`
 
+	Status          string  `gorm:"size:20;not null;default:pending"` // pending, accepted, rejected
+	RejectionReason *string `gorm:"type:text"`
+	ReviewedAt      *time.Time
+
+	// the encrypted document lives in the DocumentStore under StorageKey;
+	// Checksum is the SHA-256 of the stored ciphertext
+	StorageKey *string `gorm:"size:255;uniqueIndex"`
+	Checksum   string  `gorm:"size:64"`
+	SizeBytes  int64
+	// only documents not yet moved out by cmd/kyc-documents
+	EncryptedData []byte `gorm:"type:bytea"`
+
+	CreatedAt time.Time
+}
+
+// KYCProviderCheck is one run of a submission through the identity
+// verification provider. Scores run from 0 to 1 and are only set once the
+// check completes; the decision is advice for the reviewing officer.
+type KYCProviderCheck struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	KYCSubmissionID uuid.UUID     `gorm:"type:uuid;not null;index"`
+	KYCSubmission   KYCSubmission `gorm:"foreignKey:KYCSubmissionID"`
+
+	Provider          string `gorm:"size:50;not null"`
+	ProviderReference string `gorm:"size:255"`
+	Status            string `gorm:"size:20;not null;default:pending"` // pending, completed, failed
+
+	DocumentAuthenticityScore *float64
+	FaceMatchScore            *float64
+	LivenessScore             *float64
+	Decision                  string         `gorm:"size:20"` // approve, review, decline
+	Reasons                   datatypes.JSON `gorm:"type:jsonb"`
+	Error                     string         `gorm:"type:text"`
+
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// KYCUpload tracks a document the client uploads straight to storage with a
+// presigned URL. The object sits under StagingKey until the upload is
+// confirmed, checked and attached to the submission.
+type KYCUpload struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	DocumentType string `gorm:"size:50;not null"`
+	StagingKey   string `gorm:"size:255;uniqueIndex;not null"`
+	Status       string `gorm:"size:20;not null;default:pending"` // pending, attached, rejected
+
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+//
+// =========================
+// Sanctions screening
+// =========================
+//
+
+// ScreeningCase holds potential watchlist hits for a user. While a case is
+// open or confirmed the user cannot be issued cards; a compliance officer
+// clears false positives.
+type ScreeningCase struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	Status  string         `gorm:"size:20;not null;default:open"` // open, cleared, confirmed
+	Trigger string         `gorm:"size:30;not null"`              // kyc, card_issuance, rescreen
+	Matches datatypes.JSON `gorm:"type:jsonb;not null"`           // []ScreeningMatch
+
+	ResolvedBy     *uuid.UUID `gorm:"type:uuid"`
+	Resolver       *Admin     `gorm:"foreignKey:ResolvedBy"`
+	ResolutionNote string     `gorm:"type:text"`
+	ResolvedAt     *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+//
+// =========================
+// Organizations
+// =========================
+//
+
+// Organization is a business account. It holds a funding balance that its
+// admins move onto cards issued to team members, and is verified through
+// KYB separately from its members' personal KYC.
+type Organization struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	Name               string `gorm:"size:255;not null"`
+	RegistrationNumber string `gorm:"column:registration_number;size:50"`
+	Country            string `gorm:"size:2"` // country of incorporation
+
+	Status string `gorm:"size:20;not null;default:active"`
+
+	KybStatus          string     `gorm:"column:kyb_status;size:20;not null;default:not_started"` // not_started, under_review, verified, rejected
+	KybRejectionReason *string    `gorm:"column:kyb_rejection_reason;type:text"`
+	KybReviewedBy      *uuid.UUID `gorm:"column:kyb_reviewed_by;type:uuid"`
+	KybReviewedAt      *time.Time `gorm:"column:kyb_reviewed_at"`
+
+	Balance  float64 `gorm:"type:decimal(15,2);not null;default:0.00"`
+	Currency string  `gorm:"size:3;not null;default:USD"`
+
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrganizationMember is a user's place in an organization. Invitations are
+// made by email; UserID is filled in when the invite is accepted.
+type OrganizationMember struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	OrganizationID uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_org_member_email"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+
+	UserID *uuid.UUID `gorm:"type:uuid;index"`
+	User   *User      `gorm:"foreignKey:UserID"`
+
+	Email  string `gorm:"size:255;not null;uniqueIndex:idx_org_member_email"`
+	Role   string `gorm:"size:20;not null"`                 // owner, admin, cardholder, viewer
+	Status string `gorm:"size:20;not null;default:invited"` // invited, active, removed
+
+	// SHA-256 of the token sent in the invitation email
+	InviteTokenHash *string    `gorm:"column:invite_token_hash;size:64;uniqueIndex"`
+	InviteExpiresAt *time.Time `gorm:"column:invite_expires_at"`
+	InvitedBy       *uuid.UUID `gorm:"column:invited_by;type:uuid"`
+	JoinedAt        *time.Time `gorm:"column:joined_at"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrganizationLedger records every change to an organization's balance.
+type OrganizationLedger struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	OrganizationID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+
+	CardID  *uuid.UUID `gorm:"type:uuid"`
+	ActorID uuid.UUID  `gorm:"column:actor_id;type:uuid;not null"` // the member who moved the money
+
+	EntryType    string  `gorm:"size:30;not null"` // deposit, card_funding, card_return
+	Amount       float64 `gorm:"type:decimal(15,2);not null"`
+	BalanceAfter float64 `gorm:"type:decimal(15,2);not null"`
+	Reference    string  `gorm:"size:100;not null;uniqueIndex"`
+
+	CreatedAt time.Time
+}
+
+// OrganizationDirector is a director or beneficial owner declared for KYB.
+type OrganizationDirector struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	OrganizationID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+
+	FullName         string     `gorm:"column:full_name;size:255;not null"`
+	DateOfBirth      *time.Time `gorm:"column:date_of_birth;type:date"`
+	Nationality      string     `gorm:"size:2"`
+	OwnershipPercent float64    `gorm:"column:ownership_percent;type:decimal(5,2);not null;default:0"`
+
+	CreatedAt time.Time
+}
+
+// KYBDocument is a company registration document. Like KYC documents the
+// ciphertext lives in the document store.
+type KYBDocument struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	OrganizationID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+
+	DocumentType      string `gorm:"size:50;not null"` // certificate_of_incorporation, memorandum_of_association, register_of_directors, proof_of_address
+	MimeType          string `gorm:"size:100"`
+	EncryptionVersion string `gorm:"size:20"`
+	StorageKey        string `gorm:"column:storage_key;size:255;not null"`
+	Checksum          string `gorm:"size:64;not null"`
+	SizeBytes         int64  `gorm:"column:size_bytes;not null"`
+
+	CreatedAt time.Time
+}
+
+// ApprovalPolicy sets how many approvers an organization's card and top-up
+// requests need from a given amount upwards. The highest matching tier
+// applies; with no matching tier one approval is enough.
+type ApprovalPolicy struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	OrganizationID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+
+	RequestType       string  `gorm:"column:request_type;size:20;not null"` // card, top_up
+	MinAmount         float64 `gorm:"column:min_amount;type:decimal(15,2);not null;default:0"`
+	RequiredApprovals int     `gorm:"column:required_approvals;not null"`
+
+	CreatedAt time.Time
+}
+
+// ApprovalRequest is a member's request for a business card or a top-up of
+// their card, which runs once enough approvers have agreed.
+type ApprovalRequest struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	OrganizationID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+
+	RequesterID uuid.UUID `gorm:"column:requester_id;type:uuid;not null;index"`
+	Requester   User      `gorm:"foreignKey:RequesterID"`
+
+	Type   string     `gorm:"size:20;not null"` // card, top_up
+	CardID *uuid.UUID `gorm:"type:uuid"`        // the card to top up
+
+	// top-up amount in the organization currency, or the requested card's
+	// spending limit
+	Amount   float64 `gorm:"type:decimal(15,2);not null"`
+	Currency string  `gorm:"size:3;not null"`
+	CardType string  `gorm:"column:card_type;size:50"`
+	Note     *string `gorm:"type:text"`
+
+	Status            string `gorm:"size:20;not null;default:pending"` // pending, approved, executing, executed, failed, rejected, cancelled
+	RequiredApprovals int    `gorm:"column:required_approvals;not null"`
+	Approvals         int    `gorm:"not null;default:0"`
+
+	ResultCardID    *uuid.UUID `gorm:"column:result_card_id;type:uuid"`
+	ResultReference *string    `gorm:"column:result_reference;size:100"`
+	FailureReason   *string    `gorm:"column:failure_reason;type:text"`
+
+	DecidedAt  *time.Time
+	ExecutedAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ApprovalDecision is one approver's answer to a request.
+type ApprovalDecision struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	ApprovalRequestID uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_approval_decision_approver"`
+	ApprovalRequest   ApprovalRequest `gorm:"foreignKey:ApprovalRequestID"`
+
+	ApproverID uuid.UUID `gorm:"column:approver_id;type:uuid;not null;uniqueIndex:idx_approval_decision_approver"`
+
+	Decision string  `gorm:"size:10;not null"` // approve, reject
+	Comment  *string `gorm:"type:text"`
+
 	CreatedAt time.Time
 }
 
+//
+// =========================
+// Partners
+// =========================
+//
+
+// Partner is a platform that integrates CardFlow into its own product and
+// calls the API server-to-server with its API keys.
+type Partner struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	Name         string `gorm:"size:255;not null"`
+	ContactEmail string `gorm:"column:contact_email;size:255"`
+	Status       string `gorm:"size:20;not null;default:active"` // active, suspended
+
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null"` // admin
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PartnerAPIKey is one of a partner's keys. Only a hash of the key is kept;
+// Prefix is the part shown in the dashboard and used to find the key.
+type PartnerAPIKey struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	PartnerID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Partner   Partner   `gorm:"foreignKey:PartnerID"`
+
+	Name    string `gorm:"size:100;not null"`
+	Prefix  string `gorm:"size:8;not null;uniqueIndex"`
+	KeyHash string `gorm:"column:key_hash;size:64;not null"`
+
+	Scopes             datatypes.JSON `gorm:"type:jsonb;not null"` // []string, e.g. cards:write
+	AllowedIPs         datatypes.JSON `gorm:"column:allowed_ips;type:jsonb"` // []string of CIDR ranges, empty allows any
+	RateLimitPerMinute int            `gorm:"column:rate_limit_per_minute;not null"`
+
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	LastUsedIP *string    `gorm:"column:last_used_ip;size:45"`
+
+	RevokedAt *time.Time
+	RevokedBy *uuid.UUID `gorm:"type:uuid"`
+	CreatedBy uuid.UUID  `gorm:"type:uuid;not null"`
+
+	CreatedAt time.Time
+}
+
+// PartnerLink is a user's consent for a partner to act on their behalf.
+type PartnerLink struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	PartnerID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_partner_link_user"`
+	Partner   Partner   `gorm:"foreignKey:PartnerID"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_partner_link_user"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	Status    string `gorm:"size:20;not null;default:active"` // active, revoked
+	RevokedAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 //
 // =========================
 // Cards
@@ -121,13 +481,19 @@ type Card struct {
 	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
 	User   User      `gorm:"foreignKey:UserID"`
 
+	// set on business cards: the organization owns the money on the card and
+	// UserID is the team member holding it
+	OrganizationID *uuid.UUID    `gorm:"column:organization_id;type:uuid;index"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationID"`
+
 	CardReference string `gorm:"size:100;uniqueIndex;not null"`
-	PANencrypted  string `gorm:"column:pan_encrypted;size:255;not null"`
-	CVVencrypted  string `gorm:"column:cvv_encrypted;size:255;not null"`
+	PANencrypted  string `gorm:"column:pan_encrypted;type:text;not null"`
 	MaskedPAN     string `gorm:"column:masked_pan;size:255;not null"`
 	LastFour      string `gorm:"column:last_four;size:4;not null"`
-	PANHash       string `gorm:"column:pan_hash;size:255;not null"`
-	CVVHash       string `gorm:"column:cvv_hash;size:255;not null"`
+
+	// keyed HMAC of the PAN for lookups; see utils.PanFingerprint
+	PANFingerprint *string `gorm:"column:pan_fingerprint;size:64;uniqueIndex"`
+	// the CVV is derived from the card verification key, never stored
 
 	CardType string `gorm:"size:50"`
 	Currency string `gorm:"size:3;not null;default:USD"`
@@ -136,6 +502,12 @@ type Card struct {
 
 	SpendingLimitAmount float64 `gorm:"type:decimal(15,2)"`
 
+	// merchant-locked cards bind to a single merchant, either named at creation
+	// or taken from the first authorization the card sees
+	LockedMerchantName *string  `gorm:"column:locked_merchant_name;size:255"`
+	RecurringAmount    *float64 `gorm:"column:recurring_amount;type:decimal(15,2)"`
+	RecurringTolerance *float64 `gorm:"column:recurring_tolerance;type:decimal(5,2)"` // percent
+
 	CurrentBalance float64 `gorm:"type:decimal(15,2);not null;default:0.00"`
 	HeldBalance float64 `gorm:"type:decimal(15,2);not null;default:0.00"`
 
@@ -143,11 +515,81 @@ type Card struct {
 	ExpiryYear  string `gorm:"size:4"`
 	ExpiresAt   time.Time
 
+	// a reissued card points back at the card it replaced and vice versa
+	ReplacesCardID   *uuid.UUID `gorm:"column:replaces_card_id;type:uuid"`
+	ReplacedByCardID *uuid.UUID `gorm:"column:replaced_by_card_id;type:uuid"`
+
 	IssuedAt  time.Time `gorm:"not null;default:current_timestamp"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// CardRevealToken is a one-time token the PCI-scoped display service
+// exchanges for a card's details. Only the SHA-256 of the token is stored,
+// so every API instance can redeem it but a database read can't.
+type CardRevealToken struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	CardID uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserID uuid.UUID `gorm:"type:uuid;not null"`
+
+	TokenHash string     `gorm:"column:token_hash;size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+
+	CreatedAt time.Time
+}
+
+//
+// =========================
+// Network tokens
+// =========================
+//
+
+// NetworkToken maps a wallet token issued by the token service provider to
+// the card it stands in for. Deleted tokens are kept for the history.
+type NetworkToken struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	CardID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Card   Card      `gorm:"foreignKey:CardID"`
+
+	TokenReference string `gorm:"column:token_reference;size:100;uniqueIndex;not null"`
+	TokenLastFour  string `gorm:"column:token_last_four;size:4;not null"`
+	Wallet         string `gorm:"size:50;not null"` // apple_pay, google_pay, samsung_pay
+	DeviceID       string `gorm:"column:device_id;size:255;not null"`
+	DeviceName     string `gorm:"column:device_name;size:255"`
+
+	Status       string `gorm:"size:20;not null;default:active"` // active, suspended, deleted
+	StatusReason string `gorm:"column:status_reason;size:50"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+//
+// =========================
+// Card PINs
+// =========================
+//
+
+// CardPin keeps the encrypted ISO 9564 PIN block out of the cards table.
+type CardPin struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	CardID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	Card   Card      `gorm:"foreignKey:CardID"`
+
+	PinBlock string `gorm:"column:pin_block;size:255;not null"`
+	Format   string `gorm:"size:10;not null"` // iso0, iso4
+
+	FailedAttempts int        `gorm:"not null;default:0"`
+	LockedAt       *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 //
 // =========================
 // Transactions
@@ -182,6 +624,12 @@ type Transaction struct {
 
 	DeclineReason *string `gorm:"type:text"`
 
+	// outcome of the fraud rules on an authorization: approve, decline or
+	// flag; the rules that matched, shadow rules included, are in
+	// FraudRuleHits
+	FraudDecision *string        `gorm:"column:fraud_decision;size:20"`
+	FraudRuleHits datatypes.JSON `gorm:"column:fraud_rule_hits;type:jsonb"`
+
 	MetadataJSON datatypes.JSON `gorm:"type:jsonb"`
 
 
@@ -212,6 +660,118 @@ type BalanceLedger struct {
 	CreatedAt time.Time
 }
 
+//
+// =========================
+// Fraud Rules
+// =========================
+//
+
+// FraudRule is a rule checked on every card authorization. Active rules
+// decline or flag what they match; shadow rules only record their hits so a
+// new rule can be tried on live traffic first.
+type FraudRule struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	Name   string         `gorm:"size:100;not null"`
+	Type   string         `gorm:"size:50;not null"` // velocity, high_risk_mcc, country_mismatch, near_limit, card_testing
+	Params datatypes.JSON `gorm:"type:jsonb"`
+
+	Action  string `gorm:"size:20;not null"`                // decline, flag
+	Mode    string `gorm:"size:20;not null;default:shadow"` // active, shadow
+	Enabled bool   `gorm:"not null;default:true"`
+
+	CreatedBy uuid.UUID  `gorm:"column:created_by;type:uuid;not null"`
+	UpdatedBy *uuid.UUID `gorm:"column:updated_by;type:uuid"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// FraudCase collects suspected fraud on a user's cards for review. Cases are
+// opened by fraud rule hits, by the user reporting activity they don't
+// recognize, or by a dispute.
+type FraudCase struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	Source  string `gorm:"size:20;not null"`               // fraud_rule, user_report, dispute
+	Status  string `gorm:"size:20;not null;default:open"` // open, resolved
+	Summary string `gorm:"type:text;not null"`
+
+	Outcome        *string    `gorm:"size:30"` // confirmed_fraud, false_positive, customer_confirmed
+	ResolutionNote *string    `gorm:"column:resolution_note;type:text"`
+	ResolvedBy     *uuid.UUID `gorm:"column:resolved_by;type:uuid"` // empty when the customer's answers closed it
+	ResolvedAt     *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// FraudCaseTransaction links a transaction to a case. A transaction the
+// customer is asked about carries the hash of the one-time token in the
+// confirm or deny link, and their answer.
+type FraudCaseTransaction struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	FraudCaseID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_fraud_case_transaction"`
+	FraudCase   FraudCase `gorm:"foreignKey:FraudCaseID"`
+
+	TransactionID uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_fraud_case_transaction"`
+	Transaction   Transaction `gorm:"foreignKey:TransactionID"`
+
+	ResponseTokenHash *string    `gorm:"column:response_token_hash;size:64;uniqueIndex"`
+	ResponseExpiresAt *time.Time `gorm:"column:response_expires_at"`
+	CustomerResponse  *string    `gorm:"column:customer_response;size:20"` // confirmed, denied
+	RespondedAt       *time.Time
+
+	CreatedAt time.Time
+}
+
+// FraudCaseCard links a card to a case, with the action taken on it from
+// the case.
+type FraudCaseCard struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	FraudCaseID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_fraud_case_card"`
+	FraudCase   FraudCase `gorm:"foreignKey:FraudCaseID"`
+
+	CardID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_fraud_case_card"`
+	Card   Card      `gorm:"foreignKey:CardID"`
+
+	Action *string `gorm:"size:20"` // frozen, blocked, terminated
+
+	CreatedAt time.Time
+}
+
+// Dispute is a card transaction the cardholder doesn't recognize, raised
+// when they report the card lost, stolen or compromised. A transaction can
+// be disputed once.
+type Dispute struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	CardID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Card   Card      `gorm:"foreignKey:CardID"`
+
+	TransactionID uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex"`
+	Transaction   Transaction `gorm:"foreignKey:TransactionID"`
+
+	FraudCaseID *uuid.UUID `gorm:"column:fraud_case_id;type:uuid;index"`
+
+	Reason      string  `gorm:"size:20;not null"`               // lost, stolen, compromised
+	Status      string  `gorm:"size:20;not null;default:open"` // open, won, lost
+	Amount      float64 `gorm:"type:decimal(15,2);not null"`
+	Currency    string  `gorm:"size:3;not null"`
+	Description string  `gorm:"type:text"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 //
 // =========================
 // Audit Logs
@@ -224,6 +784,10 @@ type AuditLog struct {
 	UserID *uuid.UUID `gorm:"type:uuid;index"`
 	User   *User      `gorm:"foreignKey:UserID"`
 
+	// set instead of UserID when a back-office admin acted
+	AdminID *uuid.UUID `gorm:"column:admin_id;type:uuid;index"`
+	Admin   *Admin     `gorm:"foreignKey:AdminID"`
+
 	Action     string `gorm:"size:100;not null"`
 	EntityType string `gorm:"size:50;not null"`
 	EntityID   *uuid.UUID `gorm:"type:uuid"`
@@ -231,7 +795,7 @@ type AuditLog struct {
 	IPAddress  *string `gorm:"type:inet"`
 	UserAgent  *string `gorm:"type:text"`
 	RequestID  *string `gorm:"size:100"`
-	Metadata   datatypes.JSON `gorm:"type:jsonb"`
+	Metadata   datatypes.JSON `gorm:"column:metadata_json;type:jsonb"`
 
 	CreatedAt time.Time
 }
@@ -262,6 +826,71 @@ type Notification struct {
 	CreatedAt time.Time
 }
 
+//
+// =========================
+// Sessions
+// =========================
+//
+
+// Session is one login of a user on a device. Its ID is in the JWT, so
+// revoking the session signs that device out.
+type Session struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	// SHA-256 of the device ID the app sends, or of the user agent
+	DeviceFingerprint string `gorm:"column:device_fingerprint;size:64;not null;index"`
+	IPAddress         string `gorm:"column:ip_address;size:45"`
+	UserAgent         string `gorm:"column:user_agent;type:text"`
+
+	// from the local GeoIP database, empty when the IP isn't in it
+	Country string `gorm:"size:2"`
+	Region  string `gorm:"size:100"`
+	City    string `gorm:"size:100"`
+
+	LastSeenAt time.Time  `gorm:"column:last_seen_at;not null"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+
+	CreatedAt time.Time
+}
+
+//
+// =========================
+// Passwords
+// =========================
+//
+
+// PasswordResetToken is a forgot-password link. Only the SHA-256 of the
+// token in the emailed link is stored, and it can be used once.
+type PasswordResetToken struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	TokenHash string     `gorm:"column:token_hash;size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+
+	CreatedAt time.Time
+}
+
+// PasswordHistory keeps the hashes of a user's earlier passwords so recent
+// ones can't be reused.
+type PasswordHistory struct {
+	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	PasswordHash string `gorm:"column:password_hash;size:255;not null"`
+
+	CreatedAt time.Time
+}
+
 //
 // =========================
 // Refresh Tokens
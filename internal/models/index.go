@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,11 +19,16 @@ type CreateUserRequest struct {
 type LoginReq struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	DeviceID string `json:"device_id"` // stable ID of the app install, optional
+	Otp      string `json:"otp"`       // emailed code, when logging in from an unrecognized device
+	Client   ClientInfo
 }
 
 type MFALoginReq struct  {
 	Email    string `json:"email"`
 	TOTPCode string `json:"totp_code"`
+	DeviceID string `json:"device_id"`
+	Client   ClientInfo
 }
 
 
@@ -45,12 +51,110 @@ type KycDoc struct{
 	DocStr string `json:"doc_string"`
 }
 
+// KycFile is a document received as raw bytes, from a multipart form or a
+// confirmed direct upload.
+type KycFile struct{
+	Userid uuid.UUID
+	DocumentType string
+	Data []byte
+	DOB string // YYYY-MM-DD, optional, kept with the selfie
+}
+
+type KycUploadReq struct{
+	Userid uuid.UUID
+	DocumentType string `json:"document_type"` // selfie, id_document or proof_of_address
+}
+
+type KycUploadResp struct{
+	Uploadid uuid.UUID `json:"upload_id"`
+	Url string `json:"url"`
+	Method string `json:"method"`
+	MaxBytes int `json:"max_bytes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type KycProviderCheckResp struct{
+	Checkid uuid.UUID `json:"check_id"`
+	Provider string `json:"provider"`
+	ProviderReference string `json:"provider_reference,omitempty"`
+	Status string `json:"status"`
+	DocumentAuthenticityScore *float64 `json:"document_authenticity_score"`
+	FaceMatchScore *float64 `json:"face_match_score"`
+	LivenessScore *float64 `json:"liveness_score"`
+	Decision string `json:"decision,omitempty"`
+	Reasons json.RawMessage `json:"reasons,omitempty"`
+	Error string `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+type AdminKycCheckReq struct{
+	AdminId uuid.UUID
+	Submissionid string
+	Client ClientInfo
+}
+
+type KycReviewReq struct{
+	AdminId uuid.UUID
+	Submissionid string
+	Decision string `json:"decision"` // approve or reject
+	IDExpiryDate string `json:"id_expiry_date"` // YYYY-MM-DD, read off the ID document; defaults to the profile's when approving
+	Reason string `json:"reason"` // required to reject
+	// documents to reject, each with an optional reason of its own; a
+	// rejection without any rejects every document
+	Documents []KycDocumentDecision `json:"documents"`
+	Client ClientInfo
+}
+
+type KycDocumentDecision struct{
+	DocumentType string `json:"document_type"`
+	Reason string `json:"reason"`
+}
+
+type KycDocumentStatus struct{
+	DocumentType string `json:"document_type"`
+	Status string `json:"status"` // pending, accepted, rejected
+	RejectionReason *string `json:"rejection_reason,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+type KycStatusResp struct{
+	Submissionid *uuid.UUID `json:"submission_id,omitempty"`
+	Status string `json:"status"` // not_started when there is no submission
+	RejectionReason *string `json:"rejection_reason,omitempty"`
+	Documents []KycDocumentStatus `json:"documents"`
+	// document types still to upload, or to upload again after a rejection,
+	// and "profile" until the profile is filled in
+	Outstanding []string `json:"outstanding"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type KycReviewResp struct{
+	Submissionid uuid.UUID `json:"submission_id"`
+	Status string `json:"status"`
+	IDExpiresOn *time.Time `json:"id_expires_on,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	RejectedDocuments []string `json:"rejected_documents,omitempty"`
+}
+
+type ConfirmKycUploadReq struct{
+	Userid uuid.UUID
+	Uploadid string
+	DOB string `json:"dob"` // YYYY-MM-DD, optional, kept with the selfie
+}
+
 
 type CreateCardReq struct{
 	Userid uuid.UUID
 	CardType string `json:"card_type"`
 	Currency string `json:"currency"`
 	SpendingLimit float64 `json:"spending_limit"`
+	MerchantName string `json:"merchant_name"` // merchant-locked cards only, optional
+	RecurringAmount float64 `json:"recurring_amount"` // merchant-locked cards only, optional
+	RecurringTolerance float64 `json:"recurring_tolerance"` // percent, merchant-locked cards only, optional
 }
 
 type CreateCardResp struct{
@@ -63,7 +167,13 @@ type CreateCardResp struct{
 	Status string `json:"status"`
 	ExpiryMonth string `json:"expiry_month"`
 	ExpiryYear string `json:"expiry_year"`
+	LockedMerchant *string `json:"locked_merchant,omitempty"`
+}
 
+type ReissueCardResp struct{
+	Cardid uuid.UUID `json:"card_id"`
+	ReplacedCardid uuid.UUID `json:"replaced_card_id"`
+	CreateCardResp
 }
 
 type GetAllCardsResp struct {
@@ -71,7 +181,6 @@ type GetAllCardsResp struct {
 	CardType string `json:"card_type"`
 	MaskedPAN string `json:"masked_pan"`
 	Lastfour string `json:"last_four"`
-	Cvv string `json:"cvv"`
 	Currency string `json:"currency"`
 	Status string `json:"status"`
 	
@@ -85,8 +194,7 @@ type GetCardReq struct{
 type GetCardResp struct{
 	Cardid uuid.UUID `json:"card_id"`
 	CardType string `json:"card_type"`
-	PAN string `json:"card_number"`
-	Cvv string `json:"cvv"`
+	MaskedPAN string `json:"masked_pan"`
 	Lastfour string `json:"last_four"`
 	Currency string `json:"currency"`
 	Status string `json:"status"`
@@ -94,6 +202,131 @@ type GetCardResp struct{
 	CurrentBalance float64 `json:"current_balance"`
 	ExpiryMonth string `json:"expiry_month"`
 	ExpiryYear string `json:"expiry_year"`
+	LockedMerchant *string `json:"locked_merchant,omitempty"`
+	RecurringAmount *float64 `json:"recurring_amount,omitempty"`
+	RecurringTolerance *float64 `json:"recurring_tolerance,omitempty"`
+	NextExpectedCharge *NextExpectedCharge `json:"next_expected_charge,omitempty"`
+	ReplacesCardid *uuid.UUID `json:"replaces_card_id,omitempty"`
+	ReplacedByCardid *uuid.UUID `json:"replaced_by_card_id,omitempty"`
+}
+
+type NextExpectedCharge struct{
+	MerchantName string `json:"merchant_name"`
+	Amount float64 `json:"amount"`
+	Currency string `json:"currency"`
+	IntervalDays int `json:"interval_days"`
+	ExpectedAt time.Time `json:"expected_at"`
+}
+
+type ModifyCardStatusReq struct{
+	UserId uuid.UUID
+	CardId string
+	// a card with money left on it can only be terminated once the balance
+	// is moved to another card or paid out to a bank account
+	TransferToCardId string `json:"transfer_to_card_id"`
+	Payout *PayoutDestination `json:"payout"`
+	// set when a partner API key makes the change
+	PartnerId *uuid.UUID `json:"-"`
+}
+
+type PayoutDestination struct{
+	BankCode string `json:"bank_code"`
+	AccountNumber string `json:"account_number"`
+	AccountName string `json:"account_name"`
+}
+
+type TransferReq struct{
+	Userid uuid.UUID
+	FromCardid string `json:"from_card_id"`
+	ToCardid string `json:"to_card_id"`
+	Amount float64 `json:"amount"`
+	IdempotencyKey string `json:"idempotency_key"`
+	// set when terminating the source card sweeps its balance
+	ClosingCard bool `json:"-"`
+}
+
+type TransferResp struct{
+	Reference string `json:"reference"`
+	FromCardid string `json:"from_card_id"`
+	ToCardid string `json:"to_card_id"`
+	Amount float64 `json:"amount"`
+	Fee float64 `json:"fee"`
+	FromCurrency string `json:"from_currency"`
+	ToCurrency string `json:"to_currency"`
+	Rate float64 `json:"rate"`
+	CreditedAmount float64 `json:"credited_amount"`
+}
+
+type WithdrawReq struct{
+	Userid uuid.UUID
+	Cardid string
+	Amount float64 `json:"amount"`
+	IdempotencyKey string `json:"idempotency_key"`
+	Destination PayoutDestination `json:"destination"`
+	// set when terminating the card pays out its balance
+	ClosingCard bool `json:"-"`
+}
+
+type WithdrawResp struct{
+	Reference string `json:"reference"`
+	Cardid string `json:"card_id"`
+	Amount float64 `json:"amount"`
+	Fee float64 `json:"fee"`
+	PayoutAmount float64 `json:"payout_amount"`
+	Currency string `json:"currency"`
+	Status string `json:"status"`
+}
+
+type PayoutWebhookReq struct{
+	Event string `json:"event"`
+	Data json.RawMessage `json:"data"`
+}
+
+type PayoutWebhookData struct{
+	Reference string `json:"reference"`
+	Status string `json:"status"`
+	Amount float64 `json:"amount"`
+	Fee float64 `json:"fee"`
+}
+
+// ClientInfo identifies where a request came from, for audit records.
+type ClientInfo struct{
+	IPAddress string
+	UserAgent string
+}
+
+type RevealCardReq struct{
+	UserId uuid.UUID
+	CardId string
+	TotpCode string `json:"totp_code"`
+	Otp string `json:"otp"`
+	Mode string `json:"mode"` // direct (default) or token
+	Client ClientInfo
+}
+
+type RedeemRevealTokenReq struct{
+	Token string `json:"token"`
+	Client ClientInfo
+}
+
+type RevealCardResp struct{
+	Cardid uuid.UUID `json:"card_id"`
+	PAN string `json:"card_number,omitempty"`
+	Cvv string `json:"cvv,omitempty"`
+	ExpiryMonth string `json:"expiry_month,omitempty"`
+	ExpiryYear string `json:"expiry_year,omitempty"`
+	Token string `json:"reveal_token,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type CardPinReq struct{
+	UserId uuid.UUID
+	CardId string
+	Pin string `json:"pin"`
+	CurrentPin string `json:"current_pin"` // change PIN only
+	TotpCode string `json:"totp_code"`
+	Otp string `json:"otp"`
+	Client ClientInfo
 }
 
 type StatusReq struct{
@@ -139,6 +372,8 @@ type WebhookReq struct {
 	Network string `json:"network"`
 	Timestamp time.Time `json:"timestamp"`
 	IdempotencyKey string `json:"idempotency_key"`
+	PinVerification string `json:"pin_verification"` // verified, failed, not_performed; authorizations only
+	TokenReference string `json:"token_reference"` // set instead of card_reference for wallet payments
 }
 
 type GetCardTransactionsReq struct {
@@ -159,4 +394,662 @@ type GetCardTransactionsResp struct{
 	Source *string `json:"source"`
 	DeclineReason *string `json:"decline_reason"`
 	CreatedAt time.Time `json:"created_at"`
-}
\ No newline at end of file
+}
+type ProvisionTokenReq struct {
+	UserId     uuid.UUID
+	CardId     string
+	Wallet     string `json:"wallet"`
+	DeviceId   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+	TotpCode   string `json:"totp_code"`
+	Otp        string `json:"otp"`
+	Client     ClientInfo
+}
+
+type ProvisionTokenResp struct {
+	Decision string            `json:"decision"` // approved, declined, require_verification
+	Reason   string            `json:"reason,omitempty"`
+	Token    *NetworkTokenResp `json:"token,omitempty"`
+}
+
+type NetworkTokenResp struct {
+	Tokenid       uuid.UUID `json:"token_id"`
+	Cardid        uuid.UUID `json:"card_id"`
+	TokenLastFour string    `json:"token_last_four"`
+	Wallet        string    `json:"wallet"`
+	DeviceName    string    `json:"device_name"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ModifyTokenReq struct {
+	UserId  uuid.UUID
+	CardId  string
+	TokenId string
+	Action  string // suspend, resume, delete
+	Client  ClientInfo
+}
+
+// TokenLifecycleWebhookReq is sent by the token service provider when a
+// token changes state on the network side, e.g. removed from a wallet.
+type TokenLifecycleWebhookReq struct {
+	Event          string `json:"event"` // token.suspended, token.resumed, token.deleted
+	TokenReference string `json:"token_reference"`
+	Reason         string `json:"reason"`
+}
+
+type AdminLoginReq struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	TotpCode string `json:"totp_code"`
+	Client   ClientInfo
+}
+
+type AdminLoginResp struct {
+	Token string `json:"token"`
+	Role  string `json:"role"`
+}
+
+// AdminFindCardReq takes the PAN in the body so it never shows up in URLs or
+// access logs.
+type AdminFindCardReq struct {
+	AdminId uuid.UUID
+	PAN     string `json:"pan"`
+	Reason  string `json:"reason"`
+	Client  ClientInfo
+}
+
+type AdminCardResp struct {
+	Cardid    uuid.UUID `json:"card_id"`
+	Userid    uuid.UUID `json:"user_id"`
+	MaskedPAN string    `json:"masked_pan"`
+	CardType  string    `json:"card_type"`
+	Status    string    `json:"status"`
+	Currency  string    `json:"currency"`
+	IssuedAt  time.Time `json:"issued_at"`
+}
+
+// ScreeningMatch is one watchlist entry a user's name came close to.
+type ScreeningMatch struct{
+	Source string `json:"source"`
+	ListID string `json:"list_id"`
+	ListedName string `json:"listed_name"`
+	MatchedName string `json:"matched_name"`
+	Score float64 `json:"score"`
+	DatesOfBirth []string `json:"dates_of_birth,omitempty"`
+	Programs []string `json:"programs,omitempty"`
+}
+
+type ScreeningCaseResp struct{
+	Caseid uuid.UUID `json:"case_id"`
+	Userid uuid.UUID `json:"user_id"`
+	Status string `json:"status"`
+	Trigger string `json:"trigger"`
+	Matches json.RawMessage `json:"matches"`
+	ResolutionNote string `json:"resolution_note,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ResolveScreeningCaseReq struct{
+	AdminId uuid.UUID
+	Caseid string
+	Action string // clear or confirm
+	Note string `json:"note"`
+	Client ClientInfo
+}
+
+type KycTierInfo struct{
+	Level int `json:"level"`
+	Name string `json:"name"`
+	Requirements []string `json:"requirements"`
+	MaxCards int `json:"max_cards"`
+	MaxBalance float64 `json:"max_balance"`
+	MonthlyFundingCap float64 `json:"monthly_funding_cap"`
+	CardTypes []string `json:"card_types"`
+}
+
+type KycTierResp struct{
+	Current KycTierInfo `json:"current"`
+	Restricted bool `json:"restricted"` // verification expired; top-ups and new cards are blocked
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Next *KycTierInfo `json:"next,omitempty"` // what verifying further unlocks; nil at the top tier
+}
+
+type KycAddress struct{
+	Line1 string `json:"line1"`
+	Line2 string `json:"line2,omitempty"`
+	City string `json:"city"`
+	State string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country string `json:"country"` // ISO 3166-1 alpha-2
+}
+
+type KycIDDocument struct{
+	Type string `json:"type"` // passport, national_id, drivers_license or voters_card
+	Number string `json:"number,omitempty"` // masked except for reviewers
+	IssuingCountry string `json:"issuing_country"`
+	ExpiryDate string `json:"expiry_date"` // YYYY-MM-DD
+}
+
+type KycProfileDetailsReq struct{
+	Userid uuid.UUID
+	DateOfBirth string `json:"date_of_birth"` // YYYY-MM-DD
+	Nationality string `json:"nationality"`
+	Address KycAddress `json:"address"`
+	IDDocument KycIDDocument `json:"id_document"`
+}
+
+type KycProfileResp struct{
+	Submissionid uuid.UUID `json:"submission_id"`
+	DateOfBirth string `json:"date_of_birth,omitempty"`
+	Nationality string `json:"nationality,omitempty"`
+	Address KycAddress `json:"address"`
+	IDDocument KycIDDocument `json:"id_document"`
+	Complete bool `json:"complete"`
+}
+
+type CreateOrganizationReq struct{
+	Userid uuid.UUID
+	Name string `json:"name"`
+	Client ClientInfo
+}
+
+// OrganizationReq names an organization and the member acting on it.
+type OrganizationReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Client ClientInfo
+}
+
+type OrganizationResp struct{
+	Orgid uuid.UUID `json:"organization_id"`
+	Name string `json:"name"`
+	Status string `json:"status"`
+	KybStatus string `json:"kyb_status"`
+	Balance float64 `json:"balance"`
+	Currency string `json:"currency"`
+	Role string `json:"role"` // the caller's role
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type InviteMemberReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Email string `json:"email"`
+	Role string `json:"role"` // owner, admin, cardholder or viewer
+	Client ClientInfo
+}
+
+type AcceptInviteReq struct{
+	Userid uuid.UUID
+	Token string `json:"token"`
+}
+
+type UpdateMemberReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Memberid string
+	Role string `json:"role"`
+	Client ClientInfo
+}
+
+type OrganizationMemberResp struct{
+	Memberid uuid.UUID `json:"member_id"`
+	Userid *uuid.UUID `json:"user_id,omitempty"`
+	Email string `json:"email"`
+	Role string `json:"role"`
+	Status string `json:"status"`
+	JoinedAt *time.Time `json:"joined_at,omitempty"`
+}
+
+// OrgFundsReq moves money into the organization balance, or between it and
+// one of its cards when Cardid is set.
+type OrgFundsReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Cardid string
+	Amount float64 `json:"amount"`
+	Client ClientInfo
+}
+
+type OrgFundsResp struct{
+	Reference string `json:"reference"`
+	Balance float64 `json:"organization_balance"`
+	CardBalance *float64 `json:"card_balance,omitempty"`
+}
+
+type OrganizationLedgerResp struct{
+	Reference string `json:"reference"`
+	EntryType string `json:"entry_type"`
+	Amount float64 `json:"amount"`
+	BalanceAfter float64 `json:"balance_after"`
+	Cardid *uuid.UUID `json:"card_id,omitempty"`
+	Actorid uuid.UUID `json:"actor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateOrgCardReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Memberid string `json:"member_id"` // the team member to issue the card to
+	CardType string `json:"card_type"`
+	Currency string `json:"currency"`
+	SpendingLimit float64 `json:"spending_limit"`
+	Client ClientInfo
+}
+
+type OrgCardResp struct{
+	Cardid uuid.UUID `json:"card_id"`
+	Holderid uuid.UUID `json:"holder_id"`
+	HolderEmail string `json:"holder_email"`
+	CardType string `json:"card_type"`
+	MaskedPAN string `json:"masked_pan"`
+	Lastfour string `json:"last_four"`
+	Currency string `json:"currency"`
+	Status string `json:"status"`
+	Balance float64 `json:"balance"`
+	SpendingLimit float64 `json:"spending_limit"`
+}
+
+type KybDirector struct{
+	FullName string `json:"full_name"`
+	DateOfBirth string `json:"date_of_birth"` // YYYY-MM-DD
+	Nationality string `json:"nationality"`
+	OwnershipPercent float64 `json:"ownership_percent"`
+}
+
+type KybDetailsReq struct{
+	Userid uuid.UUID
+	Orgid string
+	RegistrationNumber string `json:"registration_number"`
+	Country string `json:"country"` // country of incorporation
+	Directors []KybDirector `json:"directors"`
+}
+
+type KybFile struct{
+	Userid uuid.UUID
+	Orgid string
+	DocumentType string
+	Data []byte
+}
+
+type KybDocumentResp struct{
+	Documentid uuid.UUID `json:"document_id"`
+	DocumentType string `json:"document_type"`
+	MimeType string `json:"mime_type"`
+	SizeBytes int64 `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type KybStatusResp struct{
+	Orgid uuid.UUID `json:"organization_id"`
+	Name string `json:"name"`
+	Status string `json:"status"`
+	RejectionReason *string `json:"rejection_reason,omitempty"`
+	RegistrationNumber string `json:"registration_number"`
+	Country string `json:"country"`
+	Directors []KybDirector `json:"directors"`
+	Documents []KybDocumentResp `json:"documents"`
+	// document types and details still missing before KYB can be submitted
+	Outstanding []string `json:"outstanding"`
+}
+
+type AdminKybReviewReq struct{
+	AdminId uuid.UUID
+	Orgid string
+	Decision string `json:"decision"` // approve or reject
+	Reason string `json:"reason"` // required to reject
+	Client ClientInfo
+}
+
+type AdminOrganizationReq struct{
+	AdminId uuid.UUID
+	Orgid string
+	Client ClientInfo
+}
+
+type AdminCreatePartnerReq struct{
+	AdminId uuid.UUID
+	Name string `json:"name"`
+	ContactEmail string `json:"contact_email"`
+	Client ClientInfo
+}
+
+type PartnerResp struct{
+	Partnerid uuid.UUID `json:"partner_id"`
+	Name string `json:"name"`
+	ContactEmail string `json:"contact_email,omitempty"`
+	Status string `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AdminCreatePartnerKeyReq struct{
+	AdminId uuid.UUID
+	Partnerid string
+	Name string `json:"name"`
+	Scopes []string `json:"scopes"` // cards:read, cards:write, transactions:read
+	AllowedIPs []string `json:"allowed_ips"` // addresses or CIDR ranges, empty allows any
+	RateLimitPerMinute int `json:"rate_limit_per_minute"` // 0 uses the default
+	ExpiresAt *time.Time `json:"expires_at"`
+	Client ClientInfo
+}
+
+type AdminPartnerKeyReq struct{
+	AdminId uuid.UUID
+	Partnerid string
+	Keyid string
+	Client ClientInfo
+}
+
+type PartnerKeyResp struct{
+	Keyid uuid.UUID `json:"key_id"`
+	Name string `json:"name"`
+	Prefix string `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	AllowedIPs []string `json:"allowed_ips"`
+	RateLimitPerMinute int `json:"rate_limit_per_minute"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PartnerKeyCreatedResp carries the key itself, which is not shown again.
+type PartnerKeyCreatedResp struct{
+	PartnerKeyResp
+	Key string `json:"key"`
+}
+
+type PartnerLinkReq struct{
+	Userid uuid.UUID
+	Partnerid string
+	Client ClientInfo
+}
+
+type PartnerLinkResp struct{
+	Partnerid uuid.UUID `json:"partner_id"`
+	Name string `json:"name"`
+	Status string `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PartnerAuthReq is a partner API call to authenticate: the key, the scope
+// the route needs and the user it is made for.
+type PartnerAuthReq struct{
+	Key string
+	Scope string
+	OnBehalfOf string
+	Method string
+	Path string
+	Client ClientInfo
+}
+
+type PartnerPrincipal struct{
+	Partnerid uuid.UUID
+	Keyid uuid.UUID
+	Userid uuid.UUID
+	RateLimitPerMinute int
+}
+
+type ApprovalPolicyTier struct{
+	RequestType string `json:"request_type"` // card or top_up
+	MinAmount float64 `json:"min_amount"` // applies from this amount upwards
+	RequiredApprovals int `json:"required_approvals"`
+}
+
+type ApprovalPoliciesReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Policies []ApprovalPolicyTier `json:"policies"`
+	Client ClientInfo
+}
+
+// ApprovalRequestReq asks the organization's approvers for a new card, held
+// by the requester, or a top-up of one of the requester's cards.
+type ApprovalRequestReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Type string `json:"type"` // card or top_up
+	Cardid string `json:"card_id"` // top_up only
+	Amount float64 `json:"amount"` // top_up only, in the organization currency
+	CardType string `json:"card_type"` // card only
+	Currency string `json:"currency"` // card only
+	SpendingLimit float64 `json:"spending_limit"` // card only
+	Note string `json:"note"`
+	Client ClientInfo
+}
+
+type ApprovalListReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Status string
+}
+
+type ApprovalDecisionReq struct{
+	Userid uuid.UUID
+	Orgid string
+	Requestid string
+	Comment string `json:"comment"`
+	Client ClientInfo
+}
+
+type ApprovalDecisionResp struct{
+	Approverid uuid.UUID `json:"approver_id"`
+	Decision string `json:"decision"`
+	Comment *string `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ApprovalRequestResp struct{
+	Requestid uuid.UUID `json:"request_id"`
+	Type string `json:"type"`
+	Requesterid uuid.UUID `json:"requester_id"`
+	RequesterEmail string `json:"requester_email,omitempty"`
+	Cardid *uuid.UUID `json:"card_id,omitempty"`
+	Amount float64 `json:"amount"`
+	Currency string `json:"currency"`
+	CardType string `json:"card_type,omitempty"`
+	Note *string `json:"note,omitempty"`
+	Status string `json:"status"`
+	RequiredApprovals int `json:"required_approvals"`
+	Approvals int `json:"approvals"`
+	ResultCardid *uuid.UUID `json:"result_card_id,omitempty"`
+	ResultReference *string `json:"result_reference,omitempty"`
+	FailureReason *string `json:"failure_reason,omitempty"`
+	Decisions []ApprovalDecisionResp `json:"decisions,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExecutedAt *time.Time `json:"executed_at,omitempty"`
+}
+
+type NotificationResp struct{
+	Notificationid uuid.UUID `json:"notification_id"`
+	Type string `json:"type"`
+	Subject *string `json:"subject,omitempty"`
+	Body string `json:"body"`
+	Read bool `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type NotificationReq struct{
+	Userid uuid.UUID
+	Notificationid string
+}
+
+// FraudRuleReq creates a fraud rule, or on update changes the fields that
+// are set.
+type FraudRuleReq struct{
+	AdminId uuid.UUID
+	Ruleid string
+	Name string `json:"name"`
+	Type string `json:"type"` // create only
+	Params json.RawMessage `json:"params"`
+	Action string `json:"action"` // decline or flag
+	Mode string `json:"mode"` // active or shadow
+	Enabled *bool `json:"enabled"`
+	Client ClientInfo
+}
+
+type FraudRuleResp struct{
+	Ruleid uuid.UUID `json:"rule_id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Params json.RawMessage `json:"params"`
+	Action string `json:"action"`
+	Mode string `json:"mode"`
+	Enabled bool `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FraudRuleHitResp is an authorization a rule matched.
+type FraudRuleHitResp struct{
+	Transactionid uuid.UUID `json:"transaction_id"`
+	Cardid uuid.UUID `json:"card_id"`
+	Amount float64 `json:"amount"`
+	Currency string `json:"currency"`
+	Status string `json:"status"`
+	FraudDecision string `json:"fraud_decision"`
+	Hits json.RawMessage `json:"hits"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FraudReportReq is a user reporting activity on their card they don't
+// recognize.
+type FraudReportReq struct{
+	Userid uuid.UUID
+	Cardid string `json:"card_id"`
+	Transactionids []string `json:"transaction_ids"`
+	Description string `json:"description"`
+	Client ClientInfo
+}
+
+// FraudResponseReq answers a confirm or deny link. The token identifies the
+// transaction, so no login is needed.
+type FraudResponseReq struct{
+	Token string `json:"token"`
+	Response string `json:"response"` // confirmed or denied
+	Client ClientInfo
+}
+
+type FraudCaseLinkReq struct{
+	AdminId uuid.UUID
+	Caseid string
+	Transactionids []string `json:"transaction_ids"`
+	Cardids []string `json:"card_ids"`
+	Client ClientInfo
+}
+
+// FraudCaseCardActionReq freezes or terminates a card from a case. A card
+// with a balance needs a transfer card or payout to be terminated, as in
+// ModifyCardStatusReq.
+type FraudCaseCardActionReq struct{
+	AdminId uuid.UUID
+	Caseid string
+	Cardid string
+	Action string // freeze or terminate
+	TransferToCardId string `json:"transfer_to_card_id"`
+	Payout *PayoutDestination `json:"payout"`
+	Client ClientInfo
+}
+
+type FraudCaseResolveReq struct{
+	AdminId uuid.UUID
+	Caseid string
+	Outcome string `json:"outcome"` // confirmed_fraud or false_positive
+	Note string `json:"note"`
+	Client ClientInfo
+}
+
+type FraudCaseTransactionResp struct{
+	Transactionid uuid.UUID `json:"transaction_id"`
+	Cardid uuid.UUID `json:"card_id"`
+	Amount float64 `json:"amount"`
+	Currency string `json:"currency"`
+	MerchantName *string `json:"merchant_name,omitempty"`
+	Status string `json:"status"`
+	FraudDecision *string `json:"fraud_decision,omitempty"`
+	CustomerResponse *string `json:"customer_response,omitempty"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+type FraudCaseCardResp struct{
+	Cardid uuid.UUID `json:"card_id"`
+	LastFour string `json:"last_four"`
+	Status string `json:"status"`
+	Action *string `json:"action,omitempty"`
+}
+
+type FraudCaseResp struct{
+	Caseid uuid.UUID `json:"case_id"`
+	Userid uuid.UUID `json:"user_id"`
+	Source string `json:"source"`
+	Status string `json:"status"`
+	Summary string `json:"summary"`
+	Outcome *string `json:"outcome,omitempty"`
+	ResolutionNote *string `json:"resolution_note,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Transactions []FraudCaseTransactionResp `json:"transactions,omitempty"`
+	Cards []FraudCaseCardResp `json:"cards,omitempty"`
+}
+
+// ReportCardReq reports a card lost, stolen or compromised. Transactionids
+// are the recent transactions on it the user doesn't recognize; Reissue
+// replaces the card in the same step.
+type ReportCardReq struct{
+	Userid uuid.UUID
+	Cardid string
+	Reason string `json:"reason"` // lost, stolen or compromised
+	Transactionids []string `json:"transaction_ids"`
+	Description string `json:"description"`
+	Reissue bool `json:"reissue"`
+	Client ClientInfo
+}
+
+type DisputeResp struct{
+	Disputeid uuid.UUID `json:"dispute_id"`
+	Transactionid uuid.UUID `json:"transaction_id"`
+	Amount float64 `json:"amount"`
+	Currency string `json:"currency"`
+	Status string `json:"status"`
+}
+
+type ReportCardResp struct{
+	Cardid uuid.UUID `json:"card_id"`
+	Status string `json:"status"`
+	Caseid *uuid.UUID `json:"case_id,omitempty"`
+	Disputes []DisputeResp `json:"disputes"`
+	Replacement *ReissueCardResp `json:"replacement,omitempty"`
+}
+
+type SessionResp struct{
+	Sessionid uuid.UUID `json:"session_id"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+	Country string `json:"country,omitempty"`
+	Region string `json:"region,omitempty"`
+	City string `json:"city,omitempty"`
+	Current bool `json:"current"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ForgotPasswordReq struct{
+	Email string `json:"email"`
+	Client ClientInfo
+}
+
+// ResetPasswordReq sets a new password with the token from a reset link.
+type ResetPasswordReq struct{
+	Token string `json:"token"`
+	NewPassword string `json:"new_password"`
+	Client ClientInfo
+}
+
+type ChangePasswordReq struct{
+	Userid uuid.UUID
+	CurrentPassword string `json:"current_password"`
+	NewPassword string `json:"new_password"`
+	Client ClientInfo
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type adminRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminRepository(db *gorm.DB) AdminRepository {
+    return &adminRepository{db: db}
+}
+
+type AdminRepository interface {
+    FindByEmail(ctx context.Context, email string) (*models.Admin, error)
+    FindByID(ctx context.Context, id uuid.UUID) (*models.Admin, error)
+    Update(ctx context.Context, admin *models.Admin) error
+    UseTotpStep(ctx context.Context, adminID uuid.UUID, step int64) (bool, error)
+}
+
+
+func (r *adminRepository) FindByEmail(ctx context.Context, email string) (*models.Admin, error) {
+    var admin models.Admin
+    err := r.db.WithContext(ctx).Where("email = ?", email).First(&admin).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return &admin, nil
+}
+
+func (r *adminRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Admin, error) {
+    var admin models.Admin
+    err := r.db.WithContext(ctx).Where("id = ?", id).First(&admin).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return &admin, nil
+}
+
+func (r *adminRepository) Update(ctx context.Context, admin *models.Admin) error {
+    return r.db.WithContext(ctx).Save(admin).Error
+}
+
+// UseTotpStep is UserRepository.UseTotpStep for admins.
+func (r *adminRepository) UseTotpStep(ctx context.Context, adminID uuid.UUID, step int64) (bool, error) {
+    res := r.db.WithContext(ctx).Model(&models.Admin{}).
+        Where("id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", adminID, step).
+        Update("totp_last_step", step)
+    return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type approvalRepository struct {
+	db *gorm.DB
+}
+
+
+func NewApprovalRepository(db *gorm.DB) ApprovalRepository{
+   return &approvalRepository{db: db}
+}
+
+type ApprovalRepository interface{
+	ReplacePolicies(ctx context.Context, orgID uuid.UUID, policies []models.ApprovalPolicy) error
+	FindPolicies(ctx context.Context, orgID uuid.UUID)([]models.ApprovalPolicy, error)
+	CreateRequest(ctx context.Context, req *models.ApprovalRequest) error
+	UpdateRequest(ctx context.Context, req *models.ApprovalRequest) error
+	FindRequest(ctx context.Context, orgID, requestID uuid.UUID)(*models.ApprovalRequest, error)
+	FindRequestForUpdate(ctx context.Context, orgID, requestID uuid.UUID)(*models.ApprovalRequest, error)
+	FindRequests(ctx context.Context, orgID uuid.UUID, requesterID *uuid.UUID, status string, limit int)([]models.ApprovalRequest, error)
+	ClaimForExecution(ctx context.Context, requestID uuid.UUID, staleBefore time.Time)(bool, error)
+	SetResultCard(ctx context.Context, requestID, cardID uuid.UUID)(bool, error)
+	CreateDecision(ctx context.Context, decision *models.ApprovalDecision) error
+	FindDecision(ctx context.Context, requestID, approverID uuid.UUID)(*models.ApprovalDecision, error)
+	FindDecisions(ctx context.Context, requestID uuid.UUID)([]models.ApprovalDecision, error)
+	RunInTransaction(ctx context.Context, fn func(approvals ApprovalRepository) error) error
+	RunWithCards(ctx context.Context, fn func(approvals ApprovalRepository, cards CardRepository) error) error
+}
+
+// RunInTransaction runs fn with a repository on one database transaction, so
+// a decision and the request it counts towards are saved together.
+func (r *approvalRepository) RunInTransaction(ctx context.Context, fn func(approvals ApprovalRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&approvalRepository{db: tx})
+	})
+}
+
+// RunWithCards runs fn with approval and card repositories sharing one
+// database transaction, so a card issued for a request and the request's
+// link to it are saved together.
+func (r *approvalRepository) RunWithCards(ctx context.Context, fn func(approvals ApprovalRepository, cards CardRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&approvalRepository{db: tx}, &cardRepository{db: tx})
+	})
+}
+
+// ReplacePolicies swaps an organization's approval tiers for new ones.
+func (r *approvalRepository) ReplacePolicies(ctx context.Context, orgID uuid.UUID, policies []models.ApprovalPolicy) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", orgID).Delete(&models.ApprovalPolicy{}).Error; err != nil {
+			return err
+		}
+		if len(policies) == 0 {
+			return nil
+		}
+		for i := range policies {
+			policies[i].OrganizationID = orgID
+		}
+		return tx.Omit("Organization").Create(&policies).Error
+	})
+}
+
+func (r *approvalRepository) FindPolicies(ctx context.Context, orgID uuid.UUID)([]models.ApprovalPolicy, error){
+	var policies []models.ApprovalPolicy
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("request_type, min_amount").Find(&policies).Error
+	return policies, err
+}
+
+func (r *approvalRepository) CreateRequest(ctx context.Context, req *models.ApprovalRequest) error {
+	return r.db.WithContext(ctx).Omit("Organization", "Requester").Create(req).Error
+}
+
+func (r *approvalRepository) UpdateRequest(ctx context.Context, req *models.ApprovalRequest) error {
+	return r.db.WithContext(ctx).Omit("Organization", "Requester").Save(req).Error
+}
+
+func (r *approvalRepository) FindRequest(ctx context.Context, orgID, requestID uuid.UUID)(*models.ApprovalRequest, error){
+	var req models.ApprovalRequest
+	err := r.db.WithContext(ctx).Preload("Requester").
+		Where("id = ? AND organization_id = ?", requestID, orgID).First(&req).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// FindRequestForUpdate loads a request with a row lock; call it inside
+// RunInTransaction.
+func (r *approvalRepository) FindRequestForUpdate(ctx context.Context, orgID, requestID uuid.UUID)(*models.ApprovalRequest, error){
+	var req models.ApprovalRequest
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND organization_id = ?", requestID, orgID).First(&req).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// FindRequests lists an organization's requests, newest first, optionally
+// only one requester's or those with a given status.
+func (r *approvalRepository) FindRequests(ctx context.Context, orgID uuid.UUID, requesterID *uuid.UUID, status string, limit int)([]models.ApprovalRequest, error){
+	var reqs []models.ApprovalRequest
+	q := r.db.WithContext(ctx).Preload("Requester").Where("organization_id = ?", orgID)
+	if requesterID != nil {
+		q = q.Where("requester_id = ?", *requesterID)
+	}
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	err := q.Order("created_at DESC").Limit(limit).Find(&reqs).Error
+	return reqs, err
+}
+
+// ClaimForExecution moves an approved (or previously failed) request to
+// executing and reports whether this caller won it, so the card action runs
+// at most once at a time. A request left executing since before staleBefore,
+// by a process that died mid-way, can be claimed again.
+func (r *approvalRepository) ClaimForExecution(ctx context.Context, requestID uuid.UUID, staleBefore time.Time)(bool, error){
+	res := r.db.WithContext(ctx).Model(&models.ApprovalRequest{}).
+		Where("id = ? AND (status IN ? OR (status = ? AND updated_at < ?))", requestID, []string{"approved", "failed"}, "executing", staleBefore).
+		Updates(map[string]interface{}{"status": "executing", "updated_at": time.Now()})
+	return res.RowsAffected == 1, res.Error
+}
+
+// SetResultCard links the card issued for a request, and reports false if
+// one already is, so a request never gets a second card.
+func (r *approvalRepository) SetResultCard(ctx context.Context, requestID, cardID uuid.UUID)(bool, error){
+	res := r.db.WithContext(ctx).Model(&models.ApprovalRequest{}).
+		Where("id = ? AND result_card_id IS NULL", requestID).
+		Update("result_card_id", cardID)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *approvalRepository) CreateDecision(ctx context.Context, decision *models.ApprovalDecision) error {
+	return r.db.WithContext(ctx).Omit("ApprovalRequest").Create(decision).Error
+}
+
+func (r *approvalRepository) FindDecision(ctx context.Context, requestID, approverID uuid.UUID)(*models.ApprovalDecision, error){
+	var decision models.ApprovalDecision
+	err := r.db.WithContext(ctx).Where("approval_request_id = ? AND approver_id = ?", requestID, approverID).First(&decision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &decision, nil
+}
+
+func (r *approvalRepository) FindDecisions(ctx context.Context, requestID uuid.UUID)([]models.ApprovalDecision, error){
+	var decisions []models.ApprovalDecision
+	err := r.db.WithContext(ctx).Where("approval_request_id = ?", requestID).Order("created_at").Find(&decisions).Error
+	return decisions, err
+}
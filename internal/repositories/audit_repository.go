@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+
+func NewAuditRepository(db *gorm.DB) AuditRepository{
+   return &auditRepository{db: db}
+}
+
+type AuditRepository interface{
+	Create(ctx context.Context, entry *models.AuditLog) error
+	FindByEntity(ctx context.Context, entityType string, entityID uuid.UUID)([]models.AuditLog, error)
+}
+
+
+func (r *auditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+    return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditRepository) FindByEntity(ctx context.Context, entityType string, entityID uuid.UUID)([]models.AuditLog, error){
+    var logs []models.AuditLog
+    err := r.db.WithContext(ctx).
+        Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+        Order("created_at DESC").
+        Find(&logs).Error
+    return logs, err
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type cardPinRepository struct {
+	db *gorm.DB
+}
+
+
+func NewCardPinRepository(db *gorm.DB) CardPinRepository{
+   return &cardPinRepository{db: db}
+}
+
+type CardPinRepository interface{
+	FindByCardID(ctx context.Context, cardID uuid.UUID)(*models.CardPin, error)
+	Save(ctx context.Context, pin *models.CardPin) error
+}
+
+
+func (r *cardPinRepository) FindByCardID(ctx context.Context, cardID uuid.UUID)(*models.CardPin, error){
+    var pin models.CardPin
+    err := r.db.WithContext(ctx).Where("card_id = ?", cardID).First(&pin).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return &pin, nil
+}
+
+func (r *cardPinRepository) Save(ctx context.Context, pin *models.CardPin) error {
+    return r.db.WithContext(ctx).Save(pin).Error
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type cardRepository struct {
@@ -27,6 +28,28 @@ type CardRepository interface{
     FindCardsExpiringBetween(ctx context.Context, start, end time.Time) ([]models.Card, error)
     ExpireCardsBetween(ctx context.Context, start, end time.Time ) ([]models.Card, error)
     FindCardsByReference(ctx context.Context, data models.WebhookReq) (models.Card, error)
+    FindCardForUpdate(ctx context.Context, data models.GetCardReq) (models.Card, error)
+    FindCardByUUID(ctx context.Context, id uuid.UUID) (models.Card, error)
+    FindCardByPANFingerprint(ctx context.Context, fingerprint string) (models.Card, error)
+    FindCardsWithoutFingerprint(ctx context.Context, afterID uuid.UUID, limit int) ([]models.Card, error)
+    SetPANFingerprint(ctx context.Context, cardID uuid.UUID, fingerprint string) error
+    FindCardsNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int) ([]models.Card, error)
+    UpdateCardCiphertext(ctx context.Context, cardID uuid.UUID, oldPAN, newPAN string) (bool, error)
+    FindCardsWithStoredCvv(ctx context.Context, afterID uuid.UUID, limit int) ([]models.Card, error)
+    ClearStoredCvv(ctx context.Context, cardID uuid.UUID) error
+    CreateRevealToken(ctx context.Context, token *models.CardRevealToken) error
+    IsFrozenByFraudCase(ctx context.Context, cardID uuid.UUID) (bool, error)
+    UseRevealToken(ctx context.Context, tokenHash string, at time.Time) (*models.CardRevealToken, error)
+    RunInTransaction(ctx context.Context, fn func(cards CardRepository, txns TransactionRepository) error) error
+}
+
+// RunInTransaction runs fn against card and transaction repositories that
+// share one database transaction, so balance moves and their ledger entries
+// commit or roll back together.
+func (r *cardRepository) RunInTransaction(ctx context.Context, fn func(cards CardRepository, txns TransactionRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&cardRepository{db: tx}, &transactionRepository{db: tx})
+	})
 }
 
 
@@ -62,8 +85,11 @@ func (r *cardRepository) FindCardByID(ctx context.Context, data models.GetCardRe
     return Card, nil
 }
 
+// Update saves a card. The PAN ciphertext is left out: it never changes
+// after issuance except through UpdateCardCiphertext, and writing back the
+// copy that was loaded could undo a re-encryption.
 func (r *cardRepository) Update(ctx context.Context, card models.Card) error {
-    return r.db.WithContext(ctx).Save(&card).Error
+    return r.db.WithContext(ctx).Omit("pan_encrypted").Save(&card).Error
 }
 
 
@@ -102,4 +128,136 @@ func(r *cardRepository)FindCardsByReference(ctx context.Context, data models.Web
     }
 
     return Card, nil
-}
\ No newline at end of file
+}
+
+// FindCardForUpdate loads a card with a row lock; call it inside RunInTransaction.
+func (r *cardRepository) FindCardForUpdate(ctx context.Context, data models.GetCardReq) (models.Card, error) {
+    var Card models.Card
+    err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+        Where("id = ? AND user_id = ?", data.CardId, data.UserId).First(&Card).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return models.Card{}, nil
+        }
+        return models.Card{}, err
+    }
+
+    return Card, nil
+}
+
+// FindCardByUUID loads a card by ID alone, for callers such as the network
+// that do not act on behalf of a user.
+func (r *cardRepository) FindCardByUUID(ctx context.Context, id uuid.UUID) (models.Card, error) {
+    var Card models.Card
+    err := r.db.WithContext(ctx).Where("id = ?", id).First(&Card).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return models.Card{}, nil
+        }
+        return models.Card{}, err
+    }
+
+    return Card, nil
+}
+
+func (r *cardRepository) FindCardByPANFingerprint(ctx context.Context, fingerprint string) (models.Card, error) {
+    var Card models.Card
+    err := r.db.WithContext(ctx).Where("pan_fingerprint = ?", fingerprint).First(&Card).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return models.Card{}, nil
+        }
+        return models.Card{}, err
+    }
+
+    return Card, nil
+}
+
+// FindCardsWithoutFingerprint pages through cards issued before PAN
+// fingerprints, in ID order starting after afterID.
+func (r *cardRepository) FindCardsWithoutFingerprint(ctx context.Context, afterID uuid.UUID, limit int) ([]models.Card, error) {
+    var cards []models.Card
+    err := r.db.WithContext(ctx).
+        Where("pan_fingerprint IS NULL AND id > ?", afterID).
+        Order("id").Limit(limit).Find(&cards).Error
+    if err != nil {
+        return nil, err
+    }
+
+    return cards, nil
+}
+
+func (r *cardRepository) SetPANFingerprint(ctx context.Context, cardID uuid.UUID, fingerprint string) error {
+    return r.db.WithContext(ctx).Model(&models.Card{}).Where("id = ?", cardID).Update("pan_fingerprint", fingerprint).Error
+}
+
+// FindCardsNotOnKey pages through cards whose PAN ciphertext was written
+// with a key other than keyID, in ID order after afterID.
+func (r *cardRepository) FindCardsNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int) ([]models.Card, error) {
+    prefix := keyID + ":"
+    var cards []models.Card
+    err := r.db.WithContext(ctx).
+        Where("id > ?", afterID).
+        Where("LEFT(pan_encrypted, ?) <> ?", len(prefix), prefix).
+        Order("id").Limit(limit).Find(&cards).Error
+    if err != nil {
+        return nil, err
+    }
+
+    return cards, nil
+}
+
+// UpdateCardCiphertext swaps a card's PAN ciphertext for one under another
+// key, and reports false if the row no longer holds oldPAN.
+func (r *cardRepository) UpdateCardCiphertext(ctx context.Context, cardID uuid.UUID, oldPAN, newPAN string) (bool, error) {
+    res := r.db.WithContext(ctx).Model(&models.Card{}).Where("id = ? AND pan_encrypted = ?", cardID, oldPAN).Update("pan_encrypted", newPAN)
+    return res.RowsAffected == 1, res.Error
+}
+
+// FindCardsWithStoredCvv pages through cards issued before CVVs were derived
+// that still hold an encrypted CVV, in ID order after afterID. The column is
+// gone once migration 0024 has run.
+func (r *cardRepository) FindCardsWithStoredCvv(ctx context.Context, afterID uuid.UUID, limit int) ([]models.Card, error) {
+    var cards []models.Card
+    err := r.db.WithContext(ctx).
+        Where("COALESCE(cvv_encrypted, '') <> '' AND id > ?", afterID).
+        Order("id").Limit(limit).Find(&cards).Error
+    if err != nil {
+        return nil, err
+    }
+
+    return cards, nil
+}
+
+func (r *cardRepository) ClearStoredCvv(ctx context.Context, cardID uuid.UUID) error {
+    return r.db.WithContext(ctx).Model(&models.Card{}).Where("id = ?", cardID).Update("cvv_encrypted", nil).Error
+}
+
+func (r *cardRepository) CreateRevealToken(ctx context.Context, token *models.CardRevealToken) error {
+    return r.db.WithContext(ctx).Create(token).Error
+}
+
+// UseRevealToken marks an unexpired token used and returns it, or nil if it
+// is unknown, expired or already used, so two redemptions racing with the
+// same token can't both succeed.
+func (r *cardRepository) UseRevealToken(ctx context.Context, tokenHash string, at time.Time) (*models.CardRevealToken, error) {
+    var tokens []models.CardRevealToken
+    err := r.db.WithContext(ctx).Model(&tokens).Clauses(clause.Returning{}).
+        Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, at).
+        Update("used_at", at).Error
+    if err != nil || len(tokens) == 0 {
+        return nil, err
+    }
+    return &tokens[0], nil
+}
+
+// IsFrozenByFraudCase reports whether an open fraud case froze the card, so
+// the freeze holds until the case is resolved.
+func (r *cardRepository) IsFrozenByFraudCase(ctx context.Context, cardID uuid.UUID) (bool, error) {
+    var count int64
+    err := r.db.WithContext(ctx).Model(&models.FraudCaseCard{}).
+        Joins("JOIN fraud_cases ON fraud_cases.id = fraud_case_cards.fraud_case_id").
+        Where("fraud_case_cards.card_id = ? AND fraud_case_cards.action = ? AND fraud_cases.status = ?", cardID, "frozen", "open").
+        Count(&count).Error
+    return count > 0, err
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type disputeRepository struct {
+	db *gorm.DB
+}
+
+
+func NewDisputeRepository(db *gorm.DB) DisputeRepository{
+   return &disputeRepository{db: db}
+}
+
+type DisputeRepository interface{
+	CreateDispute(ctx context.Context, dispute *models.Dispute) error
+	FindDisputedTransactionIDs(ctx context.Context, txnIDs []uuid.UUID)([]uuid.UUID, error)
+	RunInTransaction(ctx context.Context, fn func(cards CardRepository, txns TransactionRepository, disputes DisputeRepository, fraud FraudRepository) error) error
+}
+
+// RunInTransaction runs fn against card, transaction, dispute and fraud
+// repositories that share one database transaction, so a card report blocks
+// the card, opens its disputes and its fraud case together or not at all.
+func (r *disputeRepository) RunInTransaction(ctx context.Context, fn func(cards CardRepository, txns TransactionRepository, disputes DisputeRepository, fraud FraudRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&cardRepository{db: tx}, &transactionRepository{db: tx}, &disputeRepository{db: tx}, &fraudRepository{db: tx})
+	})
+}
+
+func (r *disputeRepository) CreateDispute(ctx context.Context, dispute *models.Dispute) error {
+	return r.db.WithContext(ctx).Omit("User", "Card", "Transaction").Create(dispute).Error
+}
+
+// FindDisputedTransactionIDs returns which of the given transactions already
+// have a dispute.
+func (r *disputeRepository) FindDisputedTransactionIDs(ctx context.Context, txnIDs []uuid.UUID)([]uuid.UUID, error){
+	var ids []uuid.UUID
+	if len(txnIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.Dispute{}).
+		Where("transaction_id IN ?", txnIDs).Pluck("transaction_id", &ids).Error
+	return ids, err
+}
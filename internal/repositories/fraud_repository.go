@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type fraudRepository struct {
+	db *gorm.DB
+}
+
+
+func NewFraudRepository(db *gorm.DB) FraudRepository{
+   return &fraudRepository{db: db}
+}
+
+type FraudRepository interface{
+	CreateRule(ctx context.Context, rule *models.FraudRule) error
+	UpdateRule(ctx context.Context, rule *models.FraudRule) error
+	FindRule(ctx context.Context, id uuid.UUID)(*models.FraudRule, error)
+	FindRules(ctx context.Context)([]models.FraudRule, error)
+	FindEnabledRules(ctx context.Context)([]models.FraudRule, error)
+	FindRuleHits(ctx context.Context, ruleID uuid.UUID, limit int)([]models.Transaction, error)
+	CreateCase(ctx context.Context, fraudCase *models.FraudCase) error
+	UpdateCase(ctx context.Context, fraudCase *models.FraudCase) error
+	FindCase(ctx context.Context, id uuid.UUID)(*models.FraudCase, error)
+	FindCases(ctx context.Context, status string, limit int)([]models.FraudCase, error)
+	FindOpenCase(ctx context.Context, userID uuid.UUID, source string)(*models.FraudCase, error)
+	LinkTransaction(ctx context.Context, link *models.FraudCaseTransaction) error
+	LinkCard(ctx context.Context, caseID, cardID uuid.UUID) error
+	UpdateCaseTransaction(ctx context.Context, link *models.FraudCaseTransaction) error
+	UpdateCaseCard(ctx context.Context, link *models.FraudCaseCard) error
+	FindCaseTransactions(ctx context.Context, caseID uuid.UUID)([]models.FraudCaseTransaction, error)
+	FindCaseTransactionByToken(ctx context.Context, tokenHash string)(*models.FraudCaseTransaction, error)
+	FindCaseCards(ctx context.Context, caseID uuid.UUID)([]models.FraudCaseCard, error)
+	FindUserTransactions(ctx context.Context, userID uuid.UUID, ids []uuid.UUID)([]models.Transaction, error)
+}
+
+func (r *fraudRepository) CreateRule(ctx context.Context, rule *models.FraudRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *fraudRepository) UpdateRule(ctx context.Context, rule *models.FraudRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *fraudRepository) FindRule(ctx context.Context, id uuid.UUID)(*models.FraudRule, error){
+	var rule models.FraudRule
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *fraudRepository) FindRules(ctx context.Context)([]models.FraudRule, error){
+	var rules []models.FraudRule
+	err := r.db.WithContext(ctx).Order("created_at").Find(&rules).Error
+	return rules, err
+}
+
+// FindEnabledRules loads the rules checked on an authorization. It runs on
+// every authorization so rule changes apply straight away.
+func (r *fraudRepository) FindEnabledRules(ctx context.Context)([]models.FraudRule, error){
+	var rules []models.FraudRule
+	err := r.db.WithContext(ctx).Where("enabled = ?", true).Order("created_at").Find(&rules).Error
+	return rules, err
+}
+
+// FindRuleHits lists the latest authorizations a rule matched, in active or
+// shadow mode.
+func (r *fraudRepository) FindRuleHits(ctx context.Context, ruleID uuid.UUID, limit int)([]models.Transaction, error){
+	var txns []models.Transaction
+	err := r.db.WithContext(ctx).
+		Where("fraud_rule_hits @> ?::jsonb", `[{"rule_id":"`+ruleID.String()+`"}]`).
+		Order("created_at DESC").Limit(limit).Find(&txns).Error
+	return txns, err
+}
+
+func (r *fraudRepository) CreateCase(ctx context.Context, fraudCase *models.FraudCase) error {
+	return r.db.WithContext(ctx).Omit("User").Create(fraudCase).Error
+}
+
+func (r *fraudRepository) UpdateCase(ctx context.Context, fraudCase *models.FraudCase) error {
+	return r.db.WithContext(ctx).Omit("User").Save(fraudCase).Error
+}
+
+func (r *fraudRepository) FindCase(ctx context.Context, id uuid.UUID)(*models.FraudCase, error){
+	var fraudCase models.FraudCase
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&fraudCase).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &fraudCase, nil
+}
+
+func (r *fraudRepository) FindCases(ctx context.Context, status string, limit int)([]models.FraudCase, error){
+	var cases []models.FraudCase
+	query := r.db.WithContext(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").Limit(limit).Find(&cases).Error
+	return cases, err
+}
+
+// FindOpenCase returns the user's latest open case from a source, which new
+// hits are added to instead of opening a case each.
+func (r *fraudRepository) FindOpenCase(ctx context.Context, userID uuid.UUID, source string)(*models.FraudCase, error){
+	var fraudCase models.FraudCase
+	err := r.db.WithContext(ctx).Where("user_id = ? AND source = ? AND status = ?", userID, source, "open").
+		Order("created_at DESC").First(&fraudCase).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &fraudCase, nil
+}
+
+// LinkTransaction adds a transaction to a case; linking it again keeps the
+// existing link.
+func (r *fraudRepository) LinkTransaction(ctx context.Context, link *models.FraudCaseTransaction) error {
+	return r.db.WithContext(ctx).Omit("FraudCase", "Transaction").
+		Clauses(clause.OnConflict{DoNothing: true}).Create(link).Error
+}
+
+func (r *fraudRepository) LinkCard(ctx context.Context, caseID, cardID uuid.UUID) error {
+	link := &models.FraudCaseCard{FraudCaseID: caseID, CardID: cardID}
+	return r.db.WithContext(ctx).Omit("FraudCase", "Card").
+		Clauses(clause.OnConflict{DoNothing: true}).Create(link).Error
+}
+
+func (r *fraudRepository) UpdateCaseTransaction(ctx context.Context, link *models.FraudCaseTransaction) error {
+	return r.db.WithContext(ctx).Omit("FraudCase", "Transaction").Save(link).Error
+}
+
+func (r *fraudRepository) UpdateCaseCard(ctx context.Context, link *models.FraudCaseCard) error {
+	return r.db.WithContext(ctx).Omit("FraudCase", "Card").Save(link).Error
+}
+
+func (r *fraudRepository) FindCaseTransactions(ctx context.Context, caseID uuid.UUID)([]models.FraudCaseTransaction, error){
+	var links []models.FraudCaseTransaction
+	err := r.db.WithContext(ctx).Preload("Transaction").Where("fraud_case_id = ?", caseID).Order("created_at").Find(&links).Error
+	return links, err
+}
+
+func (r *fraudRepository) FindCaseTransactionByToken(ctx context.Context, tokenHash string)(*models.FraudCaseTransaction, error){
+	var link models.FraudCaseTransaction
+	err := r.db.WithContext(ctx).Preload("Transaction").Where("response_token_hash = ?", tokenHash).First(&link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *fraudRepository) FindCaseCards(ctx context.Context, caseID uuid.UUID)([]models.FraudCaseCard, error){
+	var links []models.FraudCaseCard
+	err := r.db.WithContext(ctx).Preload("Card").Where("fraud_case_id = ?", caseID).Order("created_at").Find(&links).Error
+	return links, err
+}
+
+// FindUserTransactions loads the given transactions that belong to the user.
+func (r *fraudRepository) FindUserTransactions(ctx context.Context, userID uuid.UUID, ids []uuid.UUID)([]models.Transaction, error){
+	var txns []models.Transaction
+	if len(ids) == 0 {
+		return txns, nil
+	}
+	err := r.db.WithContext(ctx).Where("user_id = ? AND id IN ?", userID, ids).Find(&txns).Error
+	return txns, err
+}
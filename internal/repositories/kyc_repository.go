@@ -4,10 +4,12 @@ import (
 	"CardFlow/internal/models"
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 
@@ -27,6 +29,31 @@ type KycRepository interface{
 	CreateKycDocsSubmission(KycDocs *models.KYCDocument) error
 	UpdateKycSubmission(kyc *models.KYCSubmission) error
 	RunInTransaction(ctx context.Context, fn func(repo KycRepository) error) error
+	FindDocumentsNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int)([]models.KYCDocument, error)
+	UpdateDocumentCiphertext(ctx context.Context, docID uuid.UUID, old, encrypted []byte, keyID string)(bool, error)
+	FindDocumentsInDatabase(ctx context.Context, afterID uuid.UUID, limit int)([]models.KYCDocument, error)
+	SetDocumentObject(ctx context.Context, docID uuid.UUID, key, checksum string, size int64, keyID string) error
+	CreateUpload(ctx context.Context, upload *models.KYCUpload) error
+	FindUpload(ctx context.Context, uploadID, userID uuid.UUID)(*models.KYCUpload, error)
+	UpdateUploadStatus(ctx context.Context, uploadID uuid.UUID, status string) error
+	FindSubmissionByID(ctx context.Context, id uuid.UUID)(*models.KYCSubmission, error)
+	FindDocumentsBySubmission(ctx context.Context, submissionID uuid.UUID)([]models.KYCDocument, error)
+	CreateProviderCheck(ctx context.Context, check *models.KYCProviderCheck) error
+	UpdateProviderCheck(ctx context.Context, check *models.KYCProviderCheck) error
+	FindProviderChecks(ctx context.Context, submissionID uuid.UUID)([]models.KYCProviderCheck, error)
+	FindDocumentTypes(ctx context.Context, submissionID uuid.UUID)([]string, error)
+	FindApprovedByUserID(ctx context.Context, userID uuid.UUID)(*models.KYCSubmission, error)
+	UpdateSubmissionReview(ctx context.Context, kyc *models.KYCSubmission) error
+	FindSubmissionsDueReminder(ctx context.Context, expiringBefore time.Time)([]models.KYCSubmission, error)
+	MarkExpiryReminderSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error
+	ExpireSubmissions(ctx context.Context, now time.Time)([]models.KYCSubmission, error)
+	FindDocumentSummaries(ctx context.Context, submissionID uuid.UUID)([]models.KYCDocument, error)
+	UpdateDocumentReview(ctx context.Context, doc *models.KYCDocument) error
+	FindProfile(ctx context.Context, submissionID uuid.UUID)(*models.KYCProfile, error)
+	SaveProfile(ctx context.Context, profile *models.KYCProfile) error
+	SetProfileDateOfBirth(ctx context.Context, submissionID uuid.UUID, dob time.Time) error
+	FindProfilesNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int)([]models.KYCProfile, error)
+	UpdateProfileIDNumber(ctx context.Context, profileID uuid.UUID, old, encrypted string)(bool, error)
 }
 
 func (r *kycRepository) RunInTransaction(ctx context.Context, fn func(repo KycRepository) error) error {
@@ -38,9 +65,11 @@ func (r *kycRepository) RunInTransaction(ctx context.Context, fn func(repo KycRe
 
 
 
+// FindByUserID returns the user's latest submission, which is the one still
+// being filled in when the user is re-verifying.
 func (r *kycRepository)FindByUserID(userID uuid.UUID)(*models.KYCSubmission, error){
 	var profile models.KYCSubmission
-	err := r.db.Where("user_id = ?", userID).First(&profile).Error
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&profile).Error
     if err != nil {
         if errors.Is(err, gorm.ErrRecordNotFound) {
             return nil, nil
@@ -62,12 +91,245 @@ func (r *kycRepository) CreateKycDocsSubmission(doc *models.KYCDocument) error {
 				return errors.New("document already uploaded")
 			}
 		}
+		return err
 	}
 	return nil
 }
 
 func (r *kycRepository) UpdateKycSubmission(kyc *models.KYCSubmission) error {
-	return r.db.Model(&models.KYCSubmission{}).Where("user_id = ?", kyc.UserID).Updates(map[string]interface{}{
+	return r.db.Model(&models.KYCSubmission{}).Where("id = ?", kyc.ID).Updates(map[string]interface{}{
 		"status": kyc.Status,
 	}).Error
-}
\ No newline at end of file
+}
+
+// FindDocumentsNotOnKey pages through documents encrypted with a key other
+// than keyID, in ID order after afterID.
+func (r *kycRepository) FindDocumentsNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int)([]models.KYCDocument, error){
+	var docs []models.KYCDocument
+	err := r.db.WithContext(ctx).Where("encryption_version <> ? AND id > ?", keyID, afterID).Order("id").Limit(limit).Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// UpdateDocumentCiphertext swaps a document's ciphertext for one under
+// another key, and reports false if the row no longer holds old.
+func (r *kycRepository) UpdateDocumentCiphertext(ctx context.Context, docID uuid.UUID, old, encrypted []byte, keyID string)(bool, error){
+	res := r.db.WithContext(ctx).Model(&models.KYCDocument{}).Where("id = ? AND encrypted_data = ?", docID, old).Updates(map[string]interface{}{
+		"encrypted_data":     encrypted,
+		"encryption_version": keyID,
+	})
+	return res.RowsAffected == 1, res.Error
+}
+
+// FindDocumentsInDatabase pages through documents whose ciphertext is still
+// stored in Postgres, in ID order after afterID.
+func (r *kycRepository) FindDocumentsInDatabase(ctx context.Context, afterID uuid.UUID, limit int)([]models.KYCDocument, error){
+	var docs []models.KYCDocument
+	err := r.db.WithContext(ctx).Where("storage_key IS NULL AND id > ?", afterID).Order("id").Limit(limit).Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// SetDocumentObject points a document at its object in the DocumentStore and
+// drops any ciphertext still held in the row.
+func (r *kycRepository) SetDocumentObject(ctx context.Context, docID uuid.UUID, key, checksum string, size int64, keyID string) error {
+	return r.db.WithContext(ctx).Model(&models.KYCDocument{}).Where("id = ?", docID).Updates(map[string]interface{}{
+		"storage_key":        key,
+		"checksum":           checksum,
+		"size_bytes":         size,
+		"encryption_version": keyID,
+		"encrypted_data":     nil,
+	}).Error
+}
+
+func (r *kycRepository) CreateUpload(ctx context.Context, upload *models.KYCUpload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+func (r *kycRepository) FindUpload(ctx context.Context, uploadID, userID uuid.UUID)(*models.KYCUpload, error){
+	var upload models.KYCUpload
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", uploadID, userID).First(&upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *kycRepository) UpdateUploadStatus(ctx context.Context, uploadID uuid.UUID, status string) error {
+	return r.db.WithContext(ctx).Model(&models.KYCUpload{}).Where("id = ?", uploadID).Update("status", status).Error
+}
+
+func (r *kycRepository) FindSubmissionByID(ctx context.Context, id uuid.UUID)(*models.KYCSubmission, error){
+	var sub models.KYCSubmission
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&sub).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *kycRepository) FindDocumentsBySubmission(ctx context.Context, submissionID uuid.UUID)([]models.KYCDocument, error){
+	var docs []models.KYCDocument
+	err := r.db.WithContext(ctx).Where("kyc_submission_id = ?", submissionID).Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// FindDocumentTypes lists the types of a submission's documents that have
+// not been rejected, without loading the documents.
+func (r *kycRepository) FindDocumentTypes(ctx context.Context, submissionID uuid.UUID)([]string, error){
+	var types []string
+	err := r.db.WithContext(ctx).Model(&models.KYCDocument{}).Where("kyc_submission_id = ? AND status <> ?", submissionID, "rejected").Pluck("document_type", &types).Error
+	if err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+func (r *kycRepository) CreateProviderCheck(ctx context.Context, check *models.KYCProviderCheck) error {
+	return r.db.WithContext(ctx).Create(check).Error
+}
+
+func (r *kycRepository) UpdateProviderCheck(ctx context.Context, check *models.KYCProviderCheck) error {
+	return r.db.WithContext(ctx).Save(check).Error
+}
+
+// FindProviderChecks returns a submission's provider checks, newest first.
+func (r *kycRepository) FindProviderChecks(ctx context.Context, submissionID uuid.UUID)([]models.KYCProviderCheck, error){
+	var checks []models.KYCProviderCheck
+	err := r.db.WithContext(ctx).Where("kyc_submission_id = ?", submissionID).Order("created_at DESC").Find(&checks).Error
+	if err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// FindApprovedByUserID returns the user's most recently approved submission,
+// including one that has since expired.
+func (r *kycRepository) FindApprovedByUserID(ctx context.Context, userID uuid.UUID)(*models.KYCSubmission, error){
+	var sub models.KYCSubmission
+	err := r.db.WithContext(ctx).Where("user_id = ? AND status IN ?", userID, []string{"verified", "expired"}).
+		Order("reviewed_at DESC").First(&sub).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *kycRepository) UpdateSubmissionReview(ctx context.Context, kyc *models.KYCSubmission) error {
+	return r.db.WithContext(ctx).Model(&models.KYCSubmission{}).Where("id = ?", kyc.ID).Updates(map[string]interface{}{
+		"status":                 kyc.Status,
+		"rejection_reason":       kyc.RejectionReason,
+		"reviewed_by":            kyc.ReviewedBy,
+		"reviewed_at":            kyc.ReviewedAt,
+		"id_document_expires_on": kyc.IDDocumentExpiresOn,
+		"expires_at":             kyc.ExpiresAt,
+	}).Error
+}
+
+// FindSubmissionsDueReminder lists verified submissions expiring before the
+// given time whose holders have not been reminded yet.
+func (r *kycRepository) FindSubmissionsDueReminder(ctx context.Context, expiringBefore time.Time)([]models.KYCSubmission, error){
+	var subs []models.KYCSubmission
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ? AND expiry_reminder_sent_at IS NULL", "verified", expiringBefore).
+		Find(&subs).Error
+	return subs, err
+}
+
+func (r *kycRepository) MarkExpiryReminderSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.KYCSubmission{}).Where("id = ?", id).
+		Update("expiry_reminder_sent_at", sentAt).Error
+}
+
+// ExpireSubmissions moves verified submissions past their expiry to expired
+// and returns them.
+func (r *kycRepository) ExpireSubmissions(ctx context.Context, now time.Time)([]models.KYCSubmission, error){
+	var subs []models.KYCSubmission
+	err := r.db.WithContext(ctx).Model(&subs).Clauses(clause.Returning{}).
+		Where("status = ? AND expires_at <= ?", "verified", now).
+		Update("status", "expired").Error
+	return subs, err
+}
+
+// FindDocumentSummaries lists a submission's documents, oldest first,
+// without any legacy document bytes.
+func (r *kycRepository) FindDocumentSummaries(ctx context.Context, submissionID uuid.UUID)([]models.KYCDocument, error){
+	var docs []models.KYCDocument
+	err := r.db.WithContext(ctx).Omit("encrypted_data").Where("kyc_submission_id = ?", submissionID).
+		Order("created_at ASC").Find(&docs).Error
+	return docs, err
+}
+
+func (r *kycRepository) UpdateDocumentReview(ctx context.Context, doc *models.KYCDocument) error {
+	return r.db.WithContext(ctx).Model(&models.KYCDocument{}).Where("id = ?", doc.ID).Updates(map[string]interface{}{
+		"status":           doc.Status,
+		"rejection_reason": doc.RejectionReason,
+		"reviewed_at":      doc.ReviewedAt,
+	}).Error
+}
+
+func (r *kycRepository) FindProfile(ctx context.Context, submissionID uuid.UUID)(*models.KYCProfile, error){
+	var profile models.KYCProfile
+	err := r.db.WithContext(ctx).Where("kyc_submission_id = ?", submissionID).First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// SaveProfile creates or replaces the profile of a submission.
+func (r *kycRepository) SaveProfile(ctx context.Context, profile *models.KYCProfile) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "kyc_submission_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"date_of_birth", "nationality", "address_line1", "address_line2", "city", "state", "postal_code", "country",
+			"id_type", "id_number_encrypted", "id_number_last4", "id_issuing_country", "id_expires_on", "updated_at",
+		}),
+	}).Create(profile).Error
+}
+
+// SetProfileDateOfBirth records the date of birth given with the selfie,
+// creating the profile if the user has not filled it in yet.
+func (r *kycRepository) SetProfileDateOfBirth(ctx context.Context, submissionID uuid.UUID, dob time.Time) error {
+	profile := &models.KYCProfile{KYCSubmissionID: submissionID, DateOfBirth: &dob}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kyc_submission_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"date_of_birth", "updated_at"}),
+	}).Create(profile).Error
+}
+
+func (r *kycRepository) FindProfilesNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int)([]models.KYCProfile, error){
+	prefix := keyID + ":"
+	var profiles []models.KYCProfile
+	err := r.db.WithContext(ctx).
+		Where("id > ? AND COALESCE(id_number_encrypted, '') <> '' AND LEFT(id_number_encrypted, ?) <> ?", afterID, len(prefix), prefix).
+		Order("id").Limit(limit).Find(&profiles).Error
+	return profiles, err
+}
+
+// UpdateProfileIDNumber swaps a profile's ID number ciphertext for one under
+// another key, and reports false if the row no longer holds old.
+func (r *kycRepository) UpdateProfileIDNumber(ctx context.Context, profileID uuid.UUID, old, encrypted string)(bool, error){
+	res := r.db.WithContext(ctx).Model(&models.KYCProfile{}).Where("id = ? AND id_number_encrypted = ?", profileID, old).
+		Update("id_number_encrypted", encrypted)
+	return res.RowsAffected == 1, res.Error
+}
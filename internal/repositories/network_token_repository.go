@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type networkTokenRepository struct {
+	db *gorm.DB
+}
+
+
+func NewNetworkTokenRepository(db *gorm.DB) NetworkTokenRepository{
+   return &networkTokenRepository{db: db}
+}
+
+type NetworkTokenRepository interface{
+	Create(ctx context.Context, token *models.NetworkToken) error
+	Update(ctx context.Context, token *models.NetworkToken) error
+	FindByID(ctx context.Context, id uuid.UUID)(*models.NetworkToken, error)
+	FindByReference(ctx context.Context, reference string)(*models.NetworkToken, error)
+	FindByCardID(ctx context.Context, cardID uuid.UUID)([]models.NetworkToken, error)
+}
+
+
+func (r *networkTokenRepository) Create(ctx context.Context, token *models.NetworkToken) error {
+    return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *networkTokenRepository) Update(ctx context.Context, token *models.NetworkToken) error {
+    return r.db.WithContext(ctx).Save(token).Error
+}
+
+func (r *networkTokenRepository) FindByID(ctx context.Context, id uuid.UUID)(*models.NetworkToken, error){
+    var token models.NetworkToken
+    err := r.db.WithContext(ctx).Where("id = ?", id).First(&token).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return &token, nil
+}
+
+func (r *networkTokenRepository) FindByReference(ctx context.Context, reference string)(*models.NetworkToken, error){
+    var token models.NetworkToken
+    err := r.db.WithContext(ctx).Where("token_reference = ?", reference).First(&token).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, err
+    }
+
+    return &token, nil
+}
+
+// FindByCardID returns every token of a card, deleted ones included, newest
+// first.
+func (r *networkTokenRepository) FindByCardID(ctx context.Context, cardID uuid.UUID)([]models.NetworkToken, error){
+    var tokens []models.NetworkToken
+    err := r.db.WithContext(ctx).Where("card_id = ?", cardID).Order("created_at DESC").Find(&tokens).Error
+    if err != nil {
+        return nil, err
+    }
+
+    return tokens, nil
+}
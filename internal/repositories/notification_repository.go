@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository{
+   return &notificationRepository{db: db}
+}
+
+type NotificationRepository interface{
+	Create(ctx context.Context, notification *models.Notification) error
+	FindByUser(ctx context.Context, userID uuid.UUID, channel string, limit int)([]models.Notification, error)
+	MarkRead(ctx context.Context, userID, notificationID uuid.UUID)(bool, error)
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	return r.db.WithContext(ctx).Omit("User").Create(notification).Error
+}
+
+func (r *notificationRepository) FindByUser(ctx context.Context, userID uuid.UUID, channel string, limit int)([]models.Notification, error){
+	var notifications []models.Notification
+	err := r.db.WithContext(ctx).Where("user_id = ? AND channel = ?", userID, channel).
+		Order("created_at DESC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkRead marks one of the user's unread notifications as read and reports
+// whether there was one.
+func (r *notificationRepository) MarkRead(ctx context.Context, userID, notificationID uuid.UUID)(bool, error){
+	res := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND status = ?", notificationID, userID, "unread").
+		Update("status", "read")
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository{
+   return &organizationRepository{db: db}
+}
+
+type OrganizationRepository interface{
+	CreateOrganization(ctx context.Context, org *models.Organization) error
+	UpdateOrganization(ctx context.Context, org *models.Organization) error
+	FindOrganization(ctx context.Context, id uuid.UUID)(*models.Organization, error)
+	FindOrganizationForUpdate(ctx context.Context, id uuid.UUID)(*models.Organization, error)
+	CreateMember(ctx context.Context, member *models.OrganizationMember) error
+	UpdateMember(ctx context.Context, member *models.OrganizationMember) error
+	FindMember(ctx context.Context, orgID, userID uuid.UUID)(*models.OrganizationMember, error)
+	FindMemberByID(ctx context.Context, orgID, memberID uuid.UUID)(*models.OrganizationMember, error)
+	FindMemberByEmail(ctx context.Context, orgID uuid.UUID, email string)(*models.OrganizationMember, error)
+	FindMemberByInviteHash(ctx context.Context, hash string)(*models.OrganizationMember, error)
+	FindMembers(ctx context.Context, orgID uuid.UUID)([]models.OrganizationMember, error)
+	FindMembershipsByUser(ctx context.Context, userID uuid.UUID)([]models.OrganizationMember, error)
+	CountActiveOwners(ctx context.Context, orgID uuid.UUID)(int64, error)
+	CreateLedgerEntry(ctx context.Context, entry *models.OrganizationLedger) error
+	FindLedger(ctx context.Context, orgID uuid.UUID, limit int)([]models.OrganizationLedger, error)
+	FindLedgerByReference(ctx context.Context, reference string)(*models.OrganizationLedger, error)
+	FindOrganizationCards(ctx context.Context, orgID uuid.UUID)([]models.Card, error)
+	SuspendMemberCards(ctx context.Context, orgID, userID uuid.UUID)([]models.Card, error)
+	FindOrganizationCard(ctx context.Context, orgID, cardID uuid.UUID)(models.Card, error)
+	FindOrganizationCardForUpdate(ctx context.Context, orgID, cardID uuid.UUID)(models.Card, error)
+	FindOrganizationTransactions(ctx context.Context, orgID uuid.UUID, limit int)([]models.Transaction, error)
+	ReplaceDirectors(ctx context.Context, orgID uuid.UUID, directors []models.OrganizationDirector) error
+	FindDirectors(ctx context.Context, orgID uuid.UUID)([]models.OrganizationDirector, error)
+	CreateKybDocument(ctx context.Context, doc *models.KYBDocument) error
+	FindKybDocuments(ctx context.Context, orgID uuid.UUID)([]models.KYBDocument, error)
+	FindKybDocumentsNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int)([]models.KYBDocument, error)
+	SetKybDocumentObject(ctx context.Context, docID uuid.UUID, key, checksum string, size int64, keyID string) error
+	RunInTransaction(ctx context.Context, fn func(orgs OrganizationRepository, cards CardRepository, txns TransactionRepository) error) error
+}
+
+// RunInTransaction runs fn against organization, card and transaction
+// repositories sharing one database transaction, so moves between the
+// organization balance and its cards commit or roll back together.
+func (r *organizationRepository) RunInTransaction(ctx context.Context, fn func(orgs OrganizationRepository, cards CardRepository, txns TransactionRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&organizationRepository{db: tx}, &cardRepository{db: tx}, &transactionRepository{db: tx})
+	})
+}
+
+func (r *organizationRepository) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	return r.db.WithContext(ctx).Create(org).Error
+}
+
+func (r *organizationRepository) UpdateOrganization(ctx context.Context, org *models.Organization) error {
+	return r.db.WithContext(ctx).Save(org).Error
+}
+
+func (r *organizationRepository) FindOrganization(ctx context.Context, id uuid.UUID)(*models.Organization, error){
+	var org models.Organization
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&org).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// FindOrganizationForUpdate loads an organization with a row lock; call it
+// inside RunInTransaction before changing the balance.
+func (r *organizationRepository) FindOrganizationForUpdate(ctx context.Context, id uuid.UUID)(*models.Organization, error){
+	var org models.Organization
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&org).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) CreateMember(ctx context.Context, member *models.OrganizationMember) error {
+	return r.db.WithContext(ctx).Create(member).Error
+}
+
+func (r *organizationRepository) UpdateMember(ctx context.Context, member *models.OrganizationMember) error {
+	return r.db.WithContext(ctx).Save(member).Error
+}
+
+func (r *organizationRepository) findMember(ctx context.Context, query string, args ...interface{})(*models.OrganizationMember, error){
+	var member models.OrganizationMember
+	err := r.db.WithContext(ctx).Where(query, args...).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *organizationRepository) FindMember(ctx context.Context, orgID, userID uuid.UUID)(*models.OrganizationMember, error){
+	return r.findMember(ctx, "organization_id = ? AND user_id = ?", orgID, userID)
+}
+
+func (r *organizationRepository) FindMemberByID(ctx context.Context, orgID, memberID uuid.UUID)(*models.OrganizationMember, error){
+	return r.findMember(ctx, "organization_id = ? AND id = ?", orgID, memberID)
+}
+
+func (r *organizationRepository) FindMemberByEmail(ctx context.Context, orgID uuid.UUID, email string)(*models.OrganizationMember, error){
+	return r.findMember(ctx, "organization_id = ? AND email = ?", orgID, email)
+}
+
+func (r *organizationRepository) FindMemberByInviteHash(ctx context.Context, hash string)(*models.OrganizationMember, error){
+	return r.findMember(ctx, "invite_token_hash = ?", hash)
+}
+
+func (r *organizationRepository) FindMembers(ctx context.Context, orgID uuid.UUID)([]models.OrganizationMember, error){
+	var members []models.OrganizationMember
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND status <> ?", orgID, "removed").
+		Order("created_at").Find(&members).Error
+	return members, err
+}
+
+// FindMembershipsByUser returns the user's active memberships with their
+// organizations.
+func (r *organizationRepository) FindMembershipsByUser(ctx context.Context, userID uuid.UUID)([]models.OrganizationMember, error){
+	var members []models.OrganizationMember
+	err := r.db.WithContext(ctx).Preload("Organization").
+		Where("user_id = ? AND status = ?", userID, "active").
+		Order("created_at").Find(&members).Error
+	return members, err
+}
+
+func (r *organizationRepository) CountActiveOwners(ctx context.Context, orgID uuid.UUID)(int64, error){
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ? AND status = ?", orgID, "owner", "active").Count(&count).Error
+	return count, err
+}
+
+func (r *organizationRepository) CreateLedgerEntry(ctx context.Context, entry *models.OrganizationLedger) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *organizationRepository) FindLedger(ctx context.Context, orgID uuid.UUID, limit int)([]models.OrganizationLedger, error){
+	var entries []models.OrganizationLedger
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).
+		Order("created_at DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (r *organizationRepository) FindLedgerByReference(ctx context.Context, reference string)(*models.OrganizationLedger, error){
+	var entry models.OrganizationLedger
+	err := r.db.WithContext(ctx).Where("reference = ?", reference).First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FindOrganizationCards returns every card the organization owns together
+// with its holder.
+func (r *organizationRepository) FindOrganizationCards(ctx context.Context, orgID uuid.UUID)([]models.Card, error){
+	var cards []models.Card
+	err := r.db.WithContext(ctx).Preload("User").Where("organization_id = ?", orgID).
+		Order("created_at").Find(&cards).Error
+	return cards, err
+}
+
+// SuspendMemberCards suspends the organization's cards held by a user that
+// can still be used and returns them. Unlike a freeze, the holder cannot
+// lift a suspension.
+func (r *organizationRepository) SuspendMemberCards(ctx context.Context, orgID, userID uuid.UUID)([]models.Card, error){
+	var cards []models.Card
+	err := r.db.WithContext(ctx).Model(&cards).Clauses(clause.Returning{}).
+		Where("organization_id = ? AND user_id = ? AND status IN ?", orgID, userID, []string{"active", "frozen"}).
+		Update("status", "suspended").Error
+	return cards, err
+}
+
+// FindOrganizationCardForUpdate loads one of the organization's cards with a
+// row lock, whoever holds it.
+func (r *organizationRepository) FindOrganizationCard(ctx context.Context, orgID, cardID uuid.UUID)(models.Card, error){
+	var card models.Card
+	err := r.db.WithContext(ctx).Where("id = ? AND organization_id = ?", cardID, orgID).First(&card).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Card{}, nil
+		}
+		return models.Card{}, err
+	}
+	return card, nil
+}
+
+func (r *organizationRepository) FindOrganizationCardForUpdate(ctx context.Context, orgID, cardID uuid.UUID)(models.Card, error){
+	var card models.Card
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND organization_id = ?", cardID, orgID).First(&card).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Card{}, nil
+		}
+		return models.Card{}, err
+	}
+	return card, nil
+}
+
+// FindOrganizationTransactions returns the latest transactions on any of the
+// organization's cards.
+func (r *organizationRepository) FindOrganizationTransactions(ctx context.Context, orgID uuid.UUID, limit int)([]models.Transaction, error){
+	var txns []models.Transaction
+	err := r.db.WithContext(ctx).
+		Joins("JOIN cards ON cards.id = transactions.card_id").
+		Where("cards.organization_id = ?", orgID).
+		Order("transactions.transaction_timestamp DESC").Limit(limit).Find(&txns).Error
+	return txns, err
+}
+
+// ReplaceDirectors swaps the declared directors for a new list.
+func (r *organizationRepository) ReplaceDirectors(ctx context.Context, orgID uuid.UUID, directors []models.OrganizationDirector) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", orgID).Delete(&models.OrganizationDirector{}).Error; err != nil {
+			return err
+		}
+		if len(directors) == 0 {
+			return nil
+		}
+		return tx.Create(&directors).Error
+	})
+}
+
+func (r *organizationRepository) FindDirectors(ctx context.Context, orgID uuid.UUID)([]models.OrganizationDirector, error){
+	var directors []models.OrganizationDirector
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("created_at").Find(&directors).Error
+	return directors, err
+}
+
+func (r *organizationRepository) CreateKybDocument(ctx context.Context, doc *models.KYBDocument) error {
+	return r.db.WithContext(ctx).Create(doc).Error
+}
+
+func (r *organizationRepository) FindKybDocuments(ctx context.Context, orgID uuid.UUID)([]models.KYBDocument, error){
+	var docs []models.KYBDocument
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("created_at").Find(&docs).Error
+	return docs, err
+}
+
+// FindKybDocumentsNotOnKey pages through KYB documents written with a key
+// other than keyID, in ID order after afterID.
+func (r *organizationRepository) FindKybDocumentsNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int)([]models.KYBDocument, error){
+	var docs []models.KYBDocument
+	err := r.db.WithContext(ctx).Where("id > ? AND encryption_version <> ?", afterID, keyID).
+		Order("id").Limit(limit).Find(&docs).Error
+	return docs, err
+}
+
+func (r *organizationRepository) SetKybDocumentObject(ctx context.Context, docID uuid.UUID, key, checksum string, size int64, keyID string) error {
+	return r.db.WithContext(ctx).Model(&models.KYBDocument{}).Where("id = ?", docID).Updates(map[string]interface{}{
+		"storage_key":        key,
+		"checksum":           checksum,
+		"size_bytes":         size,
+		"encryption_version": keyID,
+	}).Error
+}
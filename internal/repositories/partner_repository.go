@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type partnerRepository struct {
+	db *gorm.DB
+}
+
+
+func NewPartnerRepository(db *gorm.DB) PartnerRepository{
+   return &partnerRepository{db: db}
+}
+
+type PartnerRepository interface{
+	CreatePartner(ctx context.Context, partner *models.Partner) error
+	FindPartner(ctx context.Context, id uuid.UUID)(*models.Partner, error)
+	FindPartners(ctx context.Context)([]models.Partner, error)
+	CreateKey(ctx context.Context, key *models.PartnerAPIKey) error
+	UpdateKey(ctx context.Context, key *models.PartnerAPIKey) error
+	FindKey(ctx context.Context, partnerID, keyID uuid.UUID)(*models.PartnerAPIKey, error)
+	FindKeyByPrefix(ctx context.Context, prefix string)(*models.PartnerAPIKey, error)
+	FindKeys(ctx context.Context, partnerID uuid.UUID)([]models.PartnerAPIKey, error)
+	TouchKey(ctx context.Context, keyID uuid.UUID, ip string, at time.Time) error
+	SaveLink(ctx context.Context, link *models.PartnerLink) error
+	FindLink(ctx context.Context, partnerID, userID uuid.UUID)(*models.PartnerLink, error)
+	FindUserLinks(ctx context.Context, userID uuid.UUID)([]models.PartnerLink, error)
+}
+
+func (r *partnerRepository) CreatePartner(ctx context.Context, partner *models.Partner) error {
+	return r.db.WithContext(ctx).Create(partner).Error
+}
+
+func (r *partnerRepository) FindPartner(ctx context.Context, id uuid.UUID)(*models.Partner, error){
+	var partner models.Partner
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&partner).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &partner, nil
+}
+
+func (r *partnerRepository) FindPartners(ctx context.Context)([]models.Partner, error){
+	var partners []models.Partner
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&partners).Error
+	return partners, err
+}
+
+func (r *partnerRepository) CreateKey(ctx context.Context, key *models.PartnerAPIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *partnerRepository) UpdateKey(ctx context.Context, key *models.PartnerAPIKey) error {
+	return r.db.WithContext(ctx).Omit("Partner").Save(key).Error
+}
+
+func (r *partnerRepository) FindKey(ctx context.Context, partnerID, keyID uuid.UUID)(*models.PartnerAPIKey, error){
+	var key models.PartnerAPIKey
+	err := r.db.WithContext(ctx).Where("id = ? AND partner_id = ?", keyID, partnerID).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// FindKeyByPrefix loads a key with its partner for authenticating a request.
+func (r *partnerRepository) FindKeyByPrefix(ctx context.Context, prefix string)(*models.PartnerAPIKey, error){
+	var key models.PartnerAPIKey
+	err := r.db.WithContext(ctx).Preload("Partner").Where("prefix = ?", prefix).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *partnerRepository) FindKeys(ctx context.Context, partnerID uuid.UUID)([]models.PartnerAPIKey, error){
+	var keys []models.PartnerAPIKey
+	err := r.db.WithContext(ctx).Where("partner_id = ?", partnerID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// TouchKey records when and from where a key was last used.
+func (r *partnerRepository) TouchKey(ctx context.Context, keyID uuid.UUID, ip string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.PartnerAPIKey{}).Where("id = ?", keyID).Updates(map[string]interface{}{
+		"last_used_at": at,
+		"last_used_ip": ip,
+	}).Error
+}
+
+func (r *partnerRepository) SaveLink(ctx context.Context, link *models.PartnerLink) error {
+	return r.db.WithContext(ctx).Omit("Partner", "User").Save(link).Error
+}
+
+func (r *partnerRepository) FindLink(ctx context.Context, partnerID, userID uuid.UUID)(*models.PartnerLink, error){
+	var link models.PartnerLink
+	err := r.db.WithContext(ctx).Where("partner_id = ? AND user_id = ?", partnerID, userID).First(&link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *partnerRepository) FindUserLinks(ctx context.Context, userID uuid.UUID)([]models.PartnerLink, error){
+	var links []models.PartnerLink
+	err := r.db.WithContext(ctx).Preload("Partner").
+		Where("user_id = ? AND status = ?", userID, "active").Order("created_at DESC").Find(&links).Error
+	return links, err
+}
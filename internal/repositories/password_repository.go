@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type passwordRepository struct {
+	db *gorm.DB
+}
+
+
+func NewPasswordRepository(db *gorm.DB) PasswordRepository{
+   return &passwordRepository{db: db}
+}
+
+type PasswordRepository interface{
+	CreateResetToken(ctx context.Context, token *models.PasswordResetToken) error
+	FindResetToken(ctx context.Context, tokenHash string)(*models.PasswordResetToken, error)
+	UseResetToken(ctx context.Context, id uuid.UUID, usedAt time.Time)(bool, error)
+	InvalidateResetTokens(ctx context.Context, userID uuid.UUID, at time.Time) error
+	AddHistory(ctx context.Context, entry *models.PasswordHistory) error
+	FindRecentHashes(ctx context.Context, userID uuid.UUID, limit int)([]string, error)
+	RevokeRefreshTokens(ctx context.Context, userID uuid.UUID, at time.Time) error
+	RunInTransaction(ctx context.Context, fn func(users UserRepository, passwords PasswordRepository, sessions SessionRepository) error) error
+}
+
+// RunInTransaction runs fn against user, password and session repositories
+// that share one database transaction, so a new password and the sign-out
+// of every session commit together.
+func (r *passwordRepository) RunInTransaction(ctx context.Context, fn func(users UserRepository, passwords PasswordRepository, sessions SessionRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&userRepository{db: tx}, &passwordRepository{db: tx}, &sessionRepository{db: tx})
+	})
+}
+
+func (r *passwordRepository) CreateResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Omit("User").Create(token).Error
+}
+
+func (r *passwordRepository) FindResetToken(ctx context.Context, tokenHash string)(*models.PasswordResetToken, error){
+	var token models.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// UseResetToken marks a token used, and reports false if it already was, so
+// two requests racing with the same link can't both succeed.
+func (r *passwordRepository) UseResetToken(ctx context.Context, id uuid.UUID, usedAt time.Time)(bool, error){
+	res := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).Update("used_at", usedAt)
+	return res.RowsAffected == 1, res.Error
+}
+
+// InvalidateResetTokens retires the user's unused reset links.
+func (r *passwordRepository) InvalidateResetTokens(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).Update("used_at", at).Error
+}
+
+func (r *passwordRepository) AddHistory(ctx context.Context, entry *models.PasswordHistory) error {
+	return r.db.WithContext(ctx).Omit("User").Create(entry).Error
+}
+
+// FindRecentHashes returns the user's latest earlier password hashes, newest
+// first.
+func (r *passwordRepository) FindRecentHashes(ctx context.Context, userID uuid.UUID, limit int)([]string, error){
+	var hashes []string
+	err := r.db.WithContext(ctx).Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Pluck("password_hash", &hashes).Error
+	return hashes, err
+}
+
+func (r *passwordRepository) RevokeRefreshTokens(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked = ?", userID, false).
+		Updates(map[string]any{"revoked": true, "revoked_at": at}).Error
+}
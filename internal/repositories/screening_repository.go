@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type screeningRepository struct {
+	db *gorm.DB
+}
+
+
+func NewScreeningRepository(db *gorm.DB) ScreeningRepository{
+   return &screeningRepository{db: db}
+}
+
+type ScreeningRepository interface{
+	CreateCase(ctx context.Context, c *models.ScreeningCase) error
+	UpdateCase(ctx context.Context, c *models.ScreeningCase) error
+	FindCaseByID(ctx context.Context, id uuid.UUID)(*models.ScreeningCase, error)
+	FindCasesByUser(ctx context.Context, userID uuid.UUID)([]models.ScreeningCase, error)
+	FindCasesByStatus(ctx context.Context, status string, limit int)([]models.ScreeningCase, error)
+	FindUsersToScreen(ctx context.Context, afterID uuid.UUID, limit int)([]models.User, error)
+	SetScreenedAt(ctx context.Context, userID uuid.UUID) error
+}
+
+
+func (r *screeningRepository) CreateCase(ctx context.Context, c *models.ScreeningCase) error {
+    return r.db.WithContext(ctx).Create(c).Error
+}
+
+func (r *screeningRepository) UpdateCase(ctx context.Context, c *models.ScreeningCase) error {
+    return r.db.WithContext(ctx).Save(c).Error
+}
+
+func (r *screeningRepository) FindCaseByID(ctx context.Context, id uuid.UUID)(*models.ScreeningCase, error){
+    var c models.ScreeningCase
+    err := r.db.WithContext(ctx).Where("id = ?", id).First(&c).Error
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    return &c, nil
+}
+
+func (r *screeningRepository) FindCasesByUser(ctx context.Context, userID uuid.UUID)([]models.ScreeningCase, error){
+    var cases []models.ScreeningCase
+    err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&cases).Error
+    if err != nil {
+        return nil, err
+    }
+    return cases, nil
+}
+
+// FindCasesByStatus returns the oldest cases first, so the review queue is
+// worked in order.
+func (r *screeningRepository) FindCasesByStatus(ctx context.Context, status string, limit int)([]models.ScreeningCase, error){
+    var cases []models.ScreeningCase
+    err := r.db.WithContext(ctx).Where("status = ?", status).Order("created_at").Limit(limit).Find(&cases).Error
+    if err != nil {
+        return nil, err
+    }
+    return cases, nil
+}
+
+// FindUsersToScreen pages through all users in ID order after afterID.
+func (r *screeningRepository) FindUsersToScreen(ctx context.Context, afterID uuid.UUID, limit int)([]models.User, error){
+    var users []models.User
+    err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&users).Error
+    if err != nil {
+        return nil, err
+    }
+    return users, nil
+}
+
+func (r *screeningRepository) SetScreenedAt(ctx context.Context, userID uuid.UUID) error {
+    return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("screened_at", gorm.Expr("CURRENT_TIMESTAMP")).Error
+}
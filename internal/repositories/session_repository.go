@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+
+func NewSessionRepository(db *gorm.DB) SessionRepository{
+   return &sessionRepository{db: db}
+}
+
+type SessionRepository interface{
+	Create(ctx context.Context, session *models.Session) error
+	FindByID(ctx context.Context, id uuid.UUID)(*models.Session, error)
+	FindActiveByUserID(ctx context.Context, userID uuid.UUID, now time.Time)([]models.Session, error)
+	HasDevice(ctx context.Context, userID uuid.UUID, fingerprint string)(bool, error)
+	HasAny(ctx context.Context, userID uuid.UUID)(bool, error)
+	Touch(ctx context.Context, id uuid.UUID, seenAt time.Time) error
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID, revokedAt time.Time)(int64, error)
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Omit("User").Create(session).Error
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id uuid.UUID)(*models.Session, error){
+	var session models.Session
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindActiveByUserID lists the user's sessions that are neither revoked nor
+// expired, most recently used first.
+func (r *sessionRepository) FindActiveByUserID(ctx context.Context, userID uuid.UUID, now time.Time)([]models.Session, error){
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// HasDevice reports whether the user has logged in from the device before.
+func (r *sessionRepository) HasDevice(ctx context.Context, userID uuid.UUID, fingerprint string)(bool, error){
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND device_fingerprint = ?", userID, fingerprint).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// HasAny reports whether the user has ever logged in.
+func (r *sessionRepository) HasAny(ctx context.Context, userID uuid.UUID)(bool, error){
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Session{}).Where("user_id = ?", userID).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).Where("id = ?", id).Update("last_seen_at", seenAt).Error
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", revokedAt).Error
+}
+
+
+// RevokeAllForUser signs the user out everywhere and returns how many
+// sessions were still live.
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID, revokedAt time.Time)(int64, error){
+	res := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, revokedAt).Update("revoked_at", revokedAt)
+	return res.RowsAffected, res.Error
+}
@@ -4,7 +4,9 @@ import (
 	"CardFlow/internal/models"
 	"context"
 	"errors"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -24,6 +26,9 @@ type TransactionRepository interface{
 	Update(ctx context.Context, card models.Transaction) error
 	FindByIdempotencyKey(ctx context.Context, idempotencykey string)(models.Transaction, error)
     FindCardTransactions(ctx context.Context, data models.GetCardTransactionsReq)([]models.Transaction, error)
+    SumUserFundingSince(ctx context.Context, userID uuid.UUID, since time.Time)(float64, error)
+    FindCardAuthorizationsSince(ctx context.Context, cardID uuid.UUID, since time.Time)([]models.Transaction, error)
+    CountCardAuthorizations(ctx context.Context, cardID uuid.UUID)(int64, error)
 }
 
 
@@ -78,4 +83,39 @@ func (r *transactionRepository)FindByIdempotencyKey(ctx context.Context, idempot
 
 func (r *transactionRepository) Update(ctx context.Context, data models.Transaction) error {
     return r.db.WithContext(ctx).Save(&data).Error
-}
\ No newline at end of file
+}
+// SumUserFundingSince adds up the completed card top-ups a user has made
+// since the given time. Funding of business cards by their organization
+// does not count.
+func (r *transactionRepository) SumUserFundingSince(ctx context.Context, userID uuid.UUID, since time.Time)(float64, error){
+	var total float64
+	err := r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("user_id = ? AND type = ? AND status = ? AND transaction_timestamp >= ?", userID, "funding", "completed", since).
+		Where("source IS NULL OR source <> ?", "organization").
+		Select("COALESCE(SUM(amount), 0)").Scan(&total).Error
+	return total, err
+}
+
+// cardAuthorizationTypes are the types an authorization goes through; a
+// captured or reversed one keeps its row with the later type.
+var cardAuthorizationTypes = []string{"authorization", "capture", "reversal"}
+
+// FindCardAuthorizationsSince lists a card's authorization attempts,
+// declined ones included, received since the given time.
+func (r *transactionRepository) FindCardAuthorizationsSince(ctx context.Context, cardID uuid.UUID, since time.Time)([]models.Transaction, error){
+	var txns []models.Transaction
+	err := r.db.WithContext(ctx).
+		Where("card_id = ? AND type IN ? AND created_at >= ?", cardID, cardAuthorizationTypes, since).
+		Order("created_at").Find(&txns).Error
+	return txns, err
+}
+
+// CountCardAuthorizations counts the authorizations a card has had
+// approved.
+func (r *transactionRepository) CountCardAuthorizations(ctx context.Context, cardID uuid.UUID)(int64, error){
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("card_id = ? AND type IN ? AND status <> ?", cardID, cardAuthorizationTypes, "declined").
+		Count(&count).Error
+	return count, err
+}
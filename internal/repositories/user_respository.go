@@ -4,6 +4,7 @@ import (
 	"CardFlow/internal/models"
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -25,6 +26,8 @@ type UserRepository interface {
     FindByID(ctx context.Context, id uuid.UUID) (*models.User, error)
     UpdateUserOTP(ctx context.Context, userID uuid.UUID, otp string) error
     Update(ctx context.Context, user *models.User) error
+    UpdateLastLogin(ctx context.Context, userID uuid.UUID, at time.Time) error
+    UseTotpStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
     FindUsersByIDs(ctx context.Context, ids []uuid.UUID)([]models.User, error)
 
 }
@@ -73,6 +76,24 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
     return r.db.WithContext(ctx).Save(user).Error
 }
 
+// UpdateLastLogin writes only last_login_at, so a login can't put back
+// columns such as the OTP or password hash that changed after the user was
+// loaded.
+func (r *userRepository) UpdateLastLogin(ctx context.Context, userID uuid.UUID, at time.Time) error {
+    return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("last_login_at", at).Error
+}
+
+// UseTotpStep records the TOTP time step a user just authenticated with, and
+// reports false if that step or a later one was already used, so a code
+// can't be accepted twice. totp_last_step is left off models.User so a Save
+// of a stale user can't move it back.
+func (r *userRepository) UseTotpStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+    res := r.db.WithContext(ctx).Model(&models.User{}).
+        Where("id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", userID, step).
+        Update("totp_last_step", step)
+    return res.RowsAffected == 1, res.Error
+}
+
 // func (r *userRepository)FindUsers(id uuid.UUID) ([]models.User, error){
 //     var user []models.User
 
@@ -2,27 +2,39 @@ package routes
 
 import (
 	"CardFlow/internal/handlers"
+	"CardFlow/internal/integrations"
 	"CardFlow/internal/middleware"
 	"CardFlow/internal/repositories"
 	"CardFlow/internal/services"
+	"CardFlow/internal/utils"
+	"log"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
-func Routes(app *fiber.App, db *gorm.DB) {
-    UserRoutes(app, db)
-    KycRoutes(app, db)
-    CardRoutes(app, db)
-    TransactionRoutes(app, db)
+func Routes(app *fiber.App, db *gorm.DB, screening services.ScreeningService, sessions services.SessionService) {
+    UserRoutes(app, db, sessions)
+    KycRoutes(app, db, screening)
+    CardRoutes(app, db, screening)
+    TransactionRoutes(app, db, screening)
+    AdminRoutes(app, db)
+    ScreeningRoutes(app, screening)
+    OrganizationRoutes(app, db, screening)
+    PartnerRoutes(app, db, screening)
+    NotificationRoutes(app, db)
+    FraudRoutes(app, db, screening)
 }
 
 
 
-func UserRoutes(app *fiber.App, db *gorm.DB) {
+func UserRoutes(app *fiber.App, db *gorm.DB, sessions services.SessionService) {
     userRepo := repositories.NewUserRepository(db)
-    userService := services.NewUserService(userRepo)
+    userService := services.NewUserService(userRepo, sessions)
     userHandler := handlers.NewUserHandler(userService)
+    sessionHandler := handlers.NewSessionHandler(sessions)
+    passwordService := services.NewPasswordService(repositories.NewPasswordRepository(db), userRepo, repositories.NewAuditRepository(db))
+    passwordHandler := handlers.NewPasswordHandler(passwordService)
 
     api := app.Group("/api/v1/users")
     api.Post("/", userHandler.CreateUser)
@@ -32,44 +44,244 @@ func UserRoutes(app *fiber.App, db *gorm.DB) {
     api.Post("/otp", middleware.JWTProtected(), userHandler.VerifyOtp)
     api.Post("/mfa/setup", middleware.JWTProtected(), userHandler.EnableMFA)
     api.Post("/mfa/verify", middleware.JWTProtected(), userHandler.VerifyMFA)
+    api.Post("/step-up/otp", middleware.JWTProtected(), userHandler.SendStepUpOtp)
+    api.Get("/sessions", middleware.JWTProtected(), sessionHandler.FetchSessions)
+    api.Delete("/sessions/:id", middleware.JWTProtected(), sessionHandler.RevokeSession)// signs that device out
+    api.Post("/password/forgot", middleware.LoginRateLimit(), passwordHandler.ForgotPassword)// emails a reset link
+    api.Post("/password/reset", middleware.LoginRateLimit(), passwordHandler.ResetPassword)
+    api.Put("/password", middleware.JWTProtected(), passwordHandler.ChangePassword)// signs out every session
     
 }
 
-func KycRoutes(app *fiber.App, db *gorm.DB){
+func KycRoutes(app *fiber.App, db *gorm.DB, screening services.ScreeningService){
     userRepo := repositories.NewUserRepository(db)
     kycRepo := repositories.NewKycRepository(db)
-    kycService := services.NewKycService(kycRepo, userRepo)
+    documentStore, err := integrations.NewDocumentStore()
+    if err != nil {
+        log.Fatalf("failed to set up kyc document store: %v", err)
+    }
+    kycProvider, err := integrations.NewKycProvider()
+    if err != nil {
+        log.Fatalf("failed to set up kyc provider: %v", err)
+    }
+    auditRepo := repositories.NewAuditRepository(db)
+    kycService := services.NewKycService(kycRepo, userRepo, documentStore, kycProvider, auditRepo, screening)
     kycHandler := handlers.NewKycHandler(kycService)
     api := app.Group("/api/v1/kyc")
     api.Post("/selfie", middleware.JWTProtected(), kycHandler.Uploadimage)
     api.Post("/document", middleware.JWTProtected(), kycHandler.UploadKycDocument)//a picture of an id, nin or voters card or passport
     api.Post("/proof-of-address", middleware.JWTProtected(), kycHandler.UploadProofOfAddress)
+    api.Post("/documents/:type", middleware.JWTProtected(), kycHandler.UploadKycFile)// multipart/form-data, field "file"
+    api.Post("/uploads", middleware.JWTProtected(), kycHandler.CreateKycUpload)// presigned URL for uploading straight to storage
+    api.Post("/uploads/:id/confirm", middleware.JWTProtected(), kycHandler.ConfirmKycUpload)
+    api.Get("/status", middleware.JWTProtected(), kycHandler.FetchKycStatus)// where the submission stands and which documents are outstanding
+    api.Get("/tier", middleware.JWTProtected(), kycHandler.FetchKycTier)// current limits and what the next tier unlocks
+    api.Put("/profile", middleware.JWTProtected(), kycHandler.SaveKycProfile)// date of birth, nationality, address and id document details
+    api.Get("/profile", middleware.JWTProtected(), kycHandler.FetchKycProfile)
+
+    review := app.Group("/api/v1/admin/kyc", middleware.AdminProtected("superadmin", "admin", "compliance_officer"))
+    review.Get("/:id/profile", kycHandler.FetchProfileForReview)
+    review.Get("/:id/checks", kycHandler.FetchProviderChecks)
+    review.Post("/:id/checks", kycHandler.RerunProviderCheck)
+    review.Post("/:id/review", middleware.AdminProtected("superadmin", "compliance_officer"), kycHandler.ReviewSubmission)// approve or reject
 }
 
-func CardRoutes(app *fiber.App, db *gorm.DB) {
+func CardRoutes(app *fiber.App, db *gorm.DB, screening services.ScreeningService) {
     cardRepo := repositories.NewCardRepository(db)
     kycRepo := repositories.NewKycRepository(db)
     userRepo:= repositories.NewUserRepository(db)
     txnRepo := repositories.NewTransactionRepository(db)
-    cardService := services.NewCardService(userRepo, kycRepo, cardRepo, txnRepo)
+    auditRepo := repositories.NewAuditRepository(db)
+    payoutClient := integrations.NewKorapayClient()
+    pinRepo := repositories.NewCardPinRepository(db)
+    tokenRepo := repositories.NewNetworkTokenRepository(db)
+    tspClient := integrations.NewTspClient()
+    cardService := services.NewCardService(userRepo, kycRepo, cardRepo, txnRepo, auditRepo, payoutClient, pinRepo, tokenRepo, tspClient, screening, repositories.NewDisputeRepository(db), repositories.NewNotificationRepository(db))
     cardHandler := handlers.NewCardHandler(cardService)
 
     api := app.Group("/api/v1/cards")
     api.Post("/top-up/:id", middleware.JWTProtected(), cardHandler.TopUpCard)
+    api.Post("/transfer", middleware.JWTProtected(), cardHandler.TransferBetweenCards)
+    api.Post("/withdraw/:id", middleware.JWTProtected(), cardHandler.WithdrawCardBalance)
+    api.Post("/reissue/:id", middleware.JWTProtected(), cardHandler.ReissueCard)
+    api.Post("/:id/report", middleware.JWTProtected(), cardHandler.ReportCard)// lost, stolen or compromised: blocks the card and disputes what the user doesn't recognize
+    api.Post("/reveal/:id", middleware.JWTProtected(), middleware.RevealRateLimit(), cardHandler.RevealCard)
+    api.Post("/reveal-token/redeem", cardHandler.RedeemRevealToken)// called by the PCI-scoped display service
+    api.Post("/pin/:id", middleware.JWTProtected(), cardHandler.SetCardPin)
+    api.Put("/pin/:id", middleware.JWTProtected(), cardHandler.ChangeCardPin)
+    api.Post("/tokens/webhook", cardHandler.HandleTokenWebhook)// token lifecycle events from the token service provider
+    api.Post("/:id/tokens", middleware.JWTProtected(), cardHandler.ProvisionToken)
+    api.Get("/:id/tokens", middleware.JWTProtected(), cardHandler.FetchCardTokens)
+    api.Patch("/:id/tokens/:tokenId/:action", middleware.JWTProtected(), cardHandler.ModifyToken)
     api.Patch("/:status", middleware.JWTProtected(), cardHandler.ModifyCardStatus)
     api.Get("/:id",middleware.JWTProtected(), cardHandler.FetchCardById)
     api.Get("/", middleware.JWTProtected(), cardHandler.FetchAllCards)
     api.Post("/",middleware.JWTProtected(), cardHandler.CreateCard)
 }
 
-func TransactionRoutes(app *fiber.App, db *gorm.DB) {
+func TransactionRoutes(app *fiber.App, db *gorm.DB, screening services.ScreeningService) {
     cardRepo := repositories.NewCardRepository(db)
     userRepo := repositories.NewUserRepository(db)
     transactionRepo := repositories.NewTransactionRepository(db)
-    transactionService := services.NewTransactionService(transactionRepo, cardRepo, userRepo)
+    pinRepo := repositories.NewCardPinRepository(db)
+    tokenRepo := repositories.NewNetworkTokenRepository(db)
+    kycRepo := repositories.NewKycRepository(db)
+    auditRepo := repositories.NewAuditRepository(db)
+    cardService := services.NewCardService(userRepo, kycRepo, cardRepo, transactionRepo, auditRepo, integrations.NewKorapayClient(), pinRepo, tokenRepo, integrations.NewTspClient(), screening, repositories.NewDisputeRepository(db), repositories.NewNotificationRepository(db))
+    fraudService := services.NewFraudService(repositories.NewFraudRepository(db), transactionRepo, kycRepo, cardRepo, userRepo, auditRepo, repositories.NewNotificationRepository(db), cardService)
+    transactionService := services.NewTransactionService(transactionRepo, cardRepo, userRepo, pinRepo, tokenRepo, kycRepo, fraudService, cardService)
     transactionHandler := handlers.NewTransactionHandler(transactionService)
 
     api := app.Group("/api/v1/transactions")
     api.Post("/webhook", transactionHandler.HandleWebhook)// receive authorize and capture
+    api.Post("/payout-webhook", transactionHandler.HandlePayoutWebhook)// withdrawal payout status from korapay
     api.Get("/:id",middleware.JWTProtected(), transactionHandler.GetCardTransactions)
 }
+
+func AdminRoutes(app *fiber.App, db *gorm.DB) {
+    adminRepo := repositories.NewAdminRepository(db)
+    cardRepo := repositories.NewCardRepository(db)
+    auditRepo := repositories.NewAuditRepository(db)
+    adminService := services.NewAdminService(adminRepo, cardRepo, auditRepo)
+    adminHandler := handlers.NewAdminHandler(adminService)
+
+    api := app.Group("/api/v1/admin")
+    api.Post("/login", middleware.LoginRateLimit(), adminHandler.Login)
+    api.Post("/cards/search", middleware.AdminProtected("superadmin", "admin", "compliance_officer"), adminHandler.FindCardByPAN)
+}
+
+func ScreeningRoutes(app *fiber.App, screening services.ScreeningService) {
+    screeningHandler := handlers.NewScreeningHandler(screening)
+
+    api := app.Group("/api/v1/admin/screening")
+    api.Get("/cases", middleware.AdminProtected("superadmin", "admin", "compliance_officer"), screeningHandler.FetchCases)
+    api.Patch("/cases/:id/:action", middleware.AdminProtected("superadmin", "compliance_officer"), screeningHandler.ResolveCase)// clear or confirm
+}
+
+func OrganizationRoutes(app *fiber.App, db *gorm.DB, screening services.ScreeningService) {
+    orgRepo := repositories.NewOrganizationRepository(db)
+    userRepo := repositories.NewUserRepository(db)
+    cardRepo := repositories.NewCardRepository(db)
+    auditRepo := repositories.NewAuditRepository(db)
+    documentStore, err := integrations.NewDocumentStore()
+    if err != nil {
+        log.Fatalf("failed to set up kyb document store: %v", err)
+    }
+    approvalRepo := repositories.NewApprovalRepository(db)
+    notificationRepo := repositories.NewNotificationRepository(db)
+    cardService := services.NewCardService(userRepo, repositories.NewKycRepository(db), cardRepo, repositories.NewTransactionRepository(db), auditRepo, integrations.NewKorapayClient(), repositories.NewCardPinRepository(db), repositories.NewNetworkTokenRepository(db), integrations.NewTspClient(), screening, repositories.NewDisputeRepository(db), notificationRepo)
+    orgService := services.NewOrganizationService(orgRepo, userRepo, cardRepo, auditRepo, documentStore, screening, approvalRepo, notificationRepo, cardService)
+    orgHandler := handlers.NewOrganizationHandler(orgService)
+
+    api := app.Group("/api/v1/organizations", middleware.JWTProtected())
+    api.Post("/", orgHandler.CreateOrganization)
+    api.Get("/", orgHandler.FetchOrganizations)
+    api.Post("/invites/accept", orgHandler.AcceptInvite)
+    api.Get("/:id", orgHandler.FetchOrganization)
+    api.Get("/:id/members", orgHandler.FetchMembers)
+    api.Post("/:id/members", orgHandler.InviteMember)
+    api.Patch("/:id/members/:memberId", orgHandler.UpdateMemberRole)
+    api.Delete("/:id/members/:memberId", orgHandler.RemoveMember)// suspends the cards the member holds
+    api.Post("/:id/deposits", orgHandler.Deposit)
+    api.Get("/:id/ledger", orgHandler.FetchLedger)
+    api.Get("/:id/cards", orgHandler.FetchCards)
+    api.Post("/:id/cards", orgHandler.IssueCard)
+    api.Post("/:id/cards/:cardId/fund", orgHandler.FundCard)// from the organization balance
+    api.Post("/:id/cards/:cardId/return", orgHandler.ReturnCardFunds)// back to the organization balance
+    api.Get("/:id/transactions", orgHandler.FetchTransactions)
+    api.Get("/:id/kyb", orgHandler.FetchKybStatus)
+    api.Put("/:id/kyb", orgHandler.SaveKybDetails)// registration number, country and directors
+    api.Post("/:id/kyb/documents/:type", orgHandler.UploadKybDocument)// multipart/form-data, field "file"
+    api.Post("/:id/kyb/submit", orgHandler.SubmitKyb)
+    api.Get("/:id/approval-policies", orgHandler.FetchApprovalPolicies)
+    api.Put("/:id/approval-policies", orgHandler.SaveApprovalPolicies)// replaces every tier
+    api.Post("/:id/approvals", orgHandler.RequestApproval)// card or top_up
+    api.Get("/:id/approvals", orgHandler.FetchApprovalRequests)
+    api.Get("/:id/approvals/:requestId", orgHandler.FetchApprovalRequest)
+    api.Post("/:id/approvals/:requestId/approve", orgHandler.ApproveRequest)// runs the action once enough approvers agree
+    api.Post("/:id/approvals/:requestId/reject", orgHandler.RejectRequest)
+    api.Post("/:id/approvals/:requestId/cancel", orgHandler.CancelRequest)
+
+    review := app.Group("/api/v1/admin/organizations", middleware.AdminProtected("superadmin", "admin", "compliance_officer"))
+    review.Get("/:id/kyb", orgHandler.FetchKybForReview)
+    review.Post("/:id/kyb/review", middleware.AdminProtected("superadmin", "compliance_officer"), orgHandler.ReviewKyb)// approve or reject
+}
+
+func PartnerRoutes(app *fiber.App, db *gorm.DB, screening services.ScreeningService) {
+    partnerRepo := repositories.NewPartnerRepository(db)
+    auditRepo := repositories.NewAuditRepository(db)
+    partnerService := services.NewPartnerService(partnerRepo, auditRepo)
+    partnerHandler := handlers.NewPartnerHandler(partnerService)
+
+    admin := app.Group("/api/v1/admin/partners", middleware.AdminProtected("superadmin", "admin"))
+    admin.Post("/", partnerHandler.CreatePartner)
+    admin.Get("/", partnerHandler.FetchPartners)
+    admin.Post("/:id/keys", partnerHandler.CreateKey)// the key is only returned here
+    admin.Get("/:id/keys", partnerHandler.FetchKeys)
+    admin.Delete("/:id/keys/:keyId", partnerHandler.RevokeKey)
+
+    links := app.Group("/api/v1/users/partners", middleware.JWTProtected())
+    links.Get("/", partnerHandler.FetchLinkedPartners)
+    links.Post("/:id", partnerHandler.LinkPartner)// lets the partner act on the user's behalf
+    links.Delete("/:id", partnerHandler.UnlinkPartner)
+
+    // server-to-server API for partners, authenticated by API key instead of
+    // the gateway secret; every call names the user in X-On-Behalf-Of
+    cardRepo := repositories.NewCardRepository(db)
+    kycRepo := repositories.NewKycRepository(db)
+    userRepo := repositories.NewUserRepository(db)
+    txnRepo := repositories.NewTransactionRepository(db)
+    pinRepo := repositories.NewCardPinRepository(db)
+    tokenRepo := repositories.NewNetworkTokenRepository(db)
+    cardService := services.NewCardService(userRepo, kycRepo, cardRepo, txnRepo, auditRepo, integrations.NewKorapayClient(), pinRepo, tokenRepo, integrations.NewTspClient(), screening, repositories.NewDisputeRepository(db), repositories.NewNotificationRepository(db))
+    cardHandler := handlers.NewCardHandler(cardService)
+    fraudService := services.NewFraudService(repositories.NewFraudRepository(db), txnRepo, kycRepo, cardRepo, userRepo, auditRepo, repositories.NewNotificationRepository(db), cardService)
+    transactionService := services.NewTransactionService(txnRepo, cardRepo, userRepo, pinRepo, tokenRepo, kycRepo, fraudService, cardService)
+    transactionHandler := handlers.NewTransactionHandler(transactionService)
+
+    api := app.Group("/api/v1/partner")
+    api.Get("/cards", middleware.PartnerProtected(partnerService, utils.ScopeCardsRead), cardHandler.FetchAllCards)
+    api.Get("/cards/:id", middleware.PartnerProtected(partnerService, utils.ScopeCardsRead), cardHandler.FetchCardById)
+    api.Post("/cards", middleware.PartnerProtected(partnerService, utils.ScopeCardsWrite), cardHandler.CreateCard)
+    api.Post("/cards/top-up/:id", middleware.PartnerProtected(partnerService, utils.ScopeCardsWrite), cardHandler.TopUpCard)
+    api.Patch("/cards/:status", middleware.PartnerProtected(partnerService, utils.ScopeCardsWrite), cardHandler.ModifyCardStatus)
+    api.Get("/transactions/:id", middleware.PartnerProtected(partnerService, utils.ScopeTransactionsRead), transactionHandler.GetCardTransactions)
+}
+
+func NotificationRoutes(app *fiber.App, db *gorm.DB) {
+    notificationRepo := repositories.NewNotificationRepository(db)
+    notificationService := services.NewNotificationService(notificationRepo)
+    notificationHandler := handlers.NewNotificationHandler(notificationService)
+
+    api := app.Group("/api/v1/notifications", middleware.JWTProtected())
+    api.Get("/", notificationHandler.FetchNotifications)
+    api.Patch("/:id/read", notificationHandler.MarkRead)
+}
+
+func FraudRoutes(app *fiber.App, db *gorm.DB, screening services.ScreeningService) {
+    cardRepo := repositories.NewCardRepository(db)
+    kycRepo := repositories.NewKycRepository(db)
+    userRepo := repositories.NewUserRepository(db)
+    txnRepo := repositories.NewTransactionRepository(db)
+    auditRepo := repositories.NewAuditRepository(db)
+    cardService := services.NewCardService(userRepo, kycRepo, cardRepo, txnRepo, auditRepo, integrations.NewKorapayClient(), repositories.NewCardPinRepository(db), repositories.NewNetworkTokenRepository(db), integrations.NewTspClient(), screening, repositories.NewDisputeRepository(db), repositories.NewNotificationRepository(db))
+    fraudService := services.NewFraudService(repositories.NewFraudRepository(db), txnRepo, kycRepo, cardRepo, userRepo, auditRepo, repositories.NewNotificationRepository(db), cardService)
+    fraudHandler := handlers.NewFraudHandler(fraudService)
+
+    api := app.Group("/api/v1/admin/fraud-rules", middleware.AdminProtected("superadmin", "admin", "compliance_officer"))
+    api.Get("/", fraudHandler.FetchRules)
+    api.Post("/", middleware.AdminProtected("superadmin", "compliance_officer"), fraudHandler.CreateRule)// starts in shadow mode unless mode is active
+    api.Patch("/:id", middleware.AdminProtected("superadmin", "compliance_officer"), fraudHandler.UpdateRule)
+    api.Get("/:id/hits", fraudHandler.FetchRuleHits)// latest authorizations the rule matched
+
+    cases := app.Group("/api/v1/admin/fraud-cases", middleware.AdminProtected("superadmin", "admin", "compliance_officer"))
+    cases.Get("/", fraudHandler.FetchCases)// ?status=open (default), resolved or all
+    cases.Get("/:id", fraudHandler.FetchCase)
+    cases.Post("/:id/links", fraudHandler.LinkToCase)// related transactions and cards of the same user
+    cases.Post("/:id/cards/:cardId/:action", fraudHandler.CaseCardAction)// freeze or terminate
+    cases.Post("/:id/resolve", fraudHandler.ResolveCase)
+
+    user := app.Group("/api/v1/fraud")
+    user.Post("/reports", middleware.JWTProtected(), fraudHandler.ReportFraud)// freezes the card
+    user.Post("/responses", fraudHandler.RespondToTransaction)// confirm or deny link; the token authenticates
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AdminService interface {
+	Login(ctx context.Context, req models.AdminLoginReq) (models.AdminLoginResp, error)
+	FindCardByPAN(ctx context.Context, req models.AdminFindCardReq) (models.AdminCardResp, error)
+}
+
+type adminService struct {
+	adminrepo repositories.AdminRepository
+	cardrepo  repositories.CardRepository
+	auditrepo repositories.AuditRepository
+}
+
+func NewAdminService(adminRepo repositories.AdminRepository, cardRepo repositories.CardRepository, auditRepo repositories.AuditRepository) AdminService {
+	return &adminService{adminrepo: adminRepo, cardrepo: cardRepo, auditrepo: auditRepo}
+}
+
+// Login authenticates a back-office admin. Admins with MFA enabled must send
+// their TOTP code with the password.
+func (s *adminService) Login(ctx context.Context, req models.AdminLoginReq) (models.AdminLoginResp, error) {
+	admin, err := s.adminrepo.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return models.AdminLoginResp{}, errors.New("something went wrong, please try again later")
+	}
+	if admin == nil || admin.Status != "active" {
+		return models.AdminLoginResp{}, errors.New("invalid email or password")
+	}
+	if err := utils.CompareHashAndPassword(admin.PasswordHash, req.Password); err != nil {
+		return models.AdminLoginResp{}, errors.New("invalid email or password")
+	}
+	if admin.MFAEnabled {
+		if req.TotpCode == "" || admin.MFASecret == nil {
+			return models.AdminLoginResp{}, errors.New("MFA required")
+		}
+		step, err := utils.ValidateTotpStep(req.TotpCode, *admin.MFASecret)
+		if err != nil {
+			return models.AdminLoginResp{}, err
+		}
+		fresh, err := s.adminrepo.UseTotpStep(ctx, admin.ID, step)
+		if err != nil {
+			return models.AdminLoginResp{}, errors.New("something went wrong, please try again later")
+		}
+		if !fresh {
+			return models.AdminLoginResp{}, errors.New("authentication code already used")
+		}
+	}
+
+	token, err := utils.GenerateAdminJWT(admin.ID, admin.Role)
+	if err != nil {
+		return models.AdminLoginResp{}, errors.New("something went wrong, please try again later")
+	}
+	now := time.Now()
+	admin.LastLoginAt = &now
+	if err := s.adminrepo.Update(ctx, admin); err != nil {
+		log.Printf("failed to record login for admin %s: %v", admin.ID, err)
+	}
+	if err := recordAdminAudit(ctx, s.auditrepo, admin.ID, "admin.login", "admin", admin.ID, req.Client, nil); err != nil {
+		log.Printf("failed to audit login for admin %s: %v", admin.ID, err)
+	}
+	return models.AdminLoginResp{Token: token, Role: admin.Role}, nil
+}
+
+// FindCardByPAN looks a card up through its PAN fingerprint. Every search is
+// audited with the reason given, and the search fails if it cannot be.
+func (s *adminService) FindCardByPAN(ctx context.Context, req models.AdminFindCardReq) (models.AdminCardResp, error) {
+	pan := strings.ReplaceAll(strings.TrimSpace(req.PAN), " ", "")
+	if len(pan) < 12 || len(pan) > 19 || !isDigits(pan) {
+		return models.AdminCardResp{}, errors.New("invalid card number")
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return models.AdminCardResp{}, errors.New("a reason is required for card searches")
+	}
+	fingerprint, err := utils.PanFingerprint(pan)
+	if err != nil {
+		log.Println(err)
+		return models.AdminCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	card, err := s.cardrepo.FindCardByPANFingerprint(ctx, fingerprint)
+	if err != nil {
+		return models.AdminCardResp{}, errors.New("something went wrong, please try again later")
+	}
+
+	meta := map[string]any{"reason": req.Reason, "last_four": pan[len(pan)-4:], "found": card.ID != uuid.Nil}
+	if err := recordAdminAudit(ctx, s.auditrepo, req.AdminId, "admin.card_search", "card", card.ID, req.Client, meta); err != nil {
+		return models.AdminCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return models.AdminCardResp{}, errors.New("card not found")
+	}
+	return models.AdminCardResp{
+		Cardid:    card.ID,
+		Userid:    card.UserID,
+		MaskedPAN: card.MaskedPAN,
+		CardType:  card.CardType,
+		Status:    card.Status,
+		Currency:  card.Currency,
+		IssuedAt:  card.IssuedAt,
+	}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
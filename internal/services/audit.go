@@ -0,0 +1,48 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// recordAudit writes an audit log entry. Callers decide whether a failed write
+// should block the action; access to sensitive data always fails closed.
+func recordAudit(ctx context.Context, repo repositories.AuditRepository, userID uuid.UUID, action, entityType string, entityID uuid.UUID, client models.ClientInfo, metadata map[string]any) error {
+	entry := &models.AuditLog{}
+	if userID != uuid.Nil {
+		entry.UserID = &userID
+	}
+	return writeAudit(ctx, repo, entry, action, entityType, entityID, client, metadata)
+}
+
+// recordAdminAudit is recordAudit for actions taken by a back-office admin.
+func recordAdminAudit(ctx context.Context, repo repositories.AuditRepository, adminID uuid.UUID, action, entityType string, entityID uuid.UUID, client models.ClientInfo, metadata map[string]any) error {
+	entry := &models.AuditLog{AdminID: &adminID}
+	return writeAudit(ctx, repo, entry, action, entityType, entityID, client, metadata)
+}
+
+func writeAudit(ctx context.Context, repo repositories.AuditRepository, entry *models.AuditLog, action, entityType string, entityID uuid.UUID, client models.ClientInfo, metadata map[string]any) error {
+	entry.Action = action
+	entry.EntityType = entityType
+	if entityID != uuid.Nil {
+		entry.EntityID = &entityID
+	}
+	if client.IPAddress != "" {
+		entry.IPAddress = &client.IPAddress
+	}
+	if client.UserAgent != "" {
+		entry.UserAgent = &client.UserAgent
+	}
+	if metadata != nil {
+		raw, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		entry.Metadata = raw
+	}
+	return repo.Create(ctx, entry)
+}
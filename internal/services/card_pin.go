@@ -0,0 +1,246 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxPinAttempts is the number of consecutive wrong PINs after which the
+// card is locked until a new PIN is set.
+const MaxPinAttempts = 3
+
+func pinBlockFormat() string {
+	if config.PinBlockFormat == utils.PinBlockFormat0 {
+		return utils.PinBlockFormat0
+	}
+	return utils.PinBlockFormat4
+}
+
+// validatePin rejects PINs that are trivially guessable.
+func validatePin(pin string) error {
+	if len(pin) < 4 || len(pin) > 6 {
+		return errors.New("PIN must be 4 to 6 digits")
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return errors.New("PIN must contain digits only")
+		}
+	}
+	if strings.Count(pin, pin[:1]) == len(pin) || strings.Contains("01234567890", pin) || strings.Contains("09876543210", pin) {
+		return errors.New("PIN is too easy to guess")
+	}
+	return nil
+}
+
+// SetCardPin sets the first PIN of a card, or resets the PIN of a card that
+// was locked after repeated PIN failures, which also unlocks the card.
+func (s *cardService) SetCardPin(ctx context.Context, data models.CardPinReq) error {
+	card, err := s.pinnableCard(ctx, data)
+	if err != nil {
+		return err
+	}
+	existing, err := s.pinrepo.FindByCardID(ctx, card.ID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if existing != nil && existing.LockedAt == nil {
+		return errors.New("PIN already set, use change PIN instead")
+	}
+	if err := verifyStepUp(ctx, s.userrepo, data.UserId, data.TotpCode, data.Otp); err != nil {
+		return err
+	}
+	if err := s.storePin(ctx, card, existing, data.Pin); err != nil {
+		return err
+	}
+	if card.Status == "locked" {
+		card.Status = "active"
+		if err := s.cardrepo.Update(ctx, card); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		s.cascadeTokenStatus(ctx, card.ID, card.Status)
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.UserId, "card.pin_set", "card", card.ID, data.Client, nil); err != nil {
+		log.Printf("failed to audit PIN set for card %s: %v", card.ID, err)
+	}
+	return nil
+}
+
+// ChangeCardPin replaces the PIN after checking the current one. Wrong
+// current PINs count towards the lock, the same as failed PINs at a terminal.
+func (s *cardService) ChangeCardPin(ctx context.Context, data models.CardPinReq) error {
+	card, err := s.pinnableCard(ctx, data)
+	if err != nil {
+		return err
+	}
+	existing, err := s.pinrepo.FindByCardID(ctx, card.ID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if existing == nil {
+		return errors.New("no PIN set for this card")
+	}
+	if existing.LockedAt != nil {
+		return errors.New("PIN is locked, set a new PIN to unlock the card")
+	}
+	if err := verifyStepUp(ctx, s.userrepo, data.UserId, data.TotpCode, data.Otp); err != nil {
+		return err
+	}
+	current, err := decodeCardPin(card, existing)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if subtle.ConstantTimeCompare([]byte(current), []byte(data.CurrentPin)) != 1 {
+		if err := recordPinResult(ctx, s.cardrepo, s.pinrepo, &card, existing, false); err != nil {
+			return err
+		}
+		if card.Status == "locked" {
+			s.cascadeTokenStatus(ctx, card.ID, card.Status)
+		}
+		return errors.New("current PIN is incorrect")
+	}
+	if err := s.storePin(ctx, card, existing, data.Pin); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.UserId, "card.pin_changed", "card", card.ID, data.Client, nil); err != nil {
+		log.Printf("failed to audit PIN change for card %s: %v", card.ID, err)
+	}
+	return nil
+}
+
+func (s *cardService) pinnableCard(ctx context.Context, data models.CardPinReq) (models.Card, error) {
+	if err := validatePin(data.Pin); err != nil {
+		return models.Card{}, err
+	}
+	card, err := s.cardrepo.FindCardByID(ctx, models.GetCardReq{UserId: data.UserId, CardId: data.CardId})
+	if err != nil {
+		return models.Card{}, errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return models.Card{}, errors.New("card not found")
+	}
+	// only a PIN lock is the holder's to lift; a freeze, a suspension or a
+	// block must not be turned into one by failing the current PIN
+	switch card.Status {
+	case "active", "locked":
+		return card, nil
+	case "terminated":
+		return models.Card{}, errors.New("card is already terminated")
+	case "expired":
+		return models.Card{}, errors.New("card has expired")
+	default:
+		return models.Card{}, errors.New("card is not active")
+	}
+}
+
+func (s *cardService) storePin(ctx context.Context, card models.Card, existing *models.CardPin, pin string) error {
+	pan, err := utils.DecryptWithKeyring(card.PANencrypted)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	key, err := utils.PinEncryptionKey()
+	if err != nil {
+		log.Println(err)
+		return errors.New("something went wrong, please try again later")
+	}
+	format := pinBlockFormat()
+	block, err := utils.EncodePinBlock(pin, pan, format, key)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	record := existing
+	if record == nil {
+		record = &models.CardPin{CardID: card.ID}
+	}
+	record.PinBlock = block
+	record.Format = format
+	record.FailedAttempts = 0
+	record.LockedAt = nil
+	if err := s.pinrepo.Save(ctx, record); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	return nil
+}
+
+func decodeCardPin(card models.Card, record *models.CardPin) (string, error) {
+	pan, err := utils.DecryptWithKeyring(card.PANencrypted)
+	if err != nil {
+		return "", err
+	}
+	key, err := utils.PinEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	return utils.DecodePinBlock(record.PinBlock, pan, record.Format, key)
+}
+
+// recordPinResult tracks consecutive PIN failures. Reaching MaxPinAttempts
+// locks the PIN, and the card too if it is active, so a stronger status such
+// as frozen or suspended is never replaced by a lock the holder can lift; a
+// correct PIN resets the counter.
+func recordPinResult(ctx context.Context, cardrepo repositories.CardRepository, pinrepo repositories.CardPinRepository, card *models.Card, record *models.CardPin, verified bool) error {
+	if verified {
+		if record.FailedAttempts == 0 {
+			return nil
+		}
+		record.FailedAttempts = 0
+		if err := pinrepo.Save(ctx, record); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		return nil
+	}
+	record.FailedAttempts++
+	if record.FailedAttempts >= MaxPinAttempts {
+		now := time.Now()
+		record.LockedAt = &now
+		if card.Status == "active" {
+			card.Status = "locked"
+			if err := cardrepo.Update(ctx, *card); err != nil {
+				return errors.New("something went wrong, please try again later")
+			}
+		}
+	}
+	if err := pinrepo.Save(ctx, record); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	return nil
+}
+
+// applyPinVerification records the PIN outcome reported on an authorization.
+// An empty result means the transaction did not use a PIN.
+func (s *transactionService) applyPinVerification(ctx context.Context, card *models.Card, result string) error {
+	if result == "" {
+		return nil
+	}
+	record, err := s.pinrepo.FindByCardID(ctx, card.ID)
+	if err != nil {
+		return errors.New("something went wrong")
+	}
+	if record == nil {
+		return errors.New("no PIN set for this card")
+	}
+	switch result {
+	case "verified":
+		return recordPinResult(ctx, s.cardrepo, s.pinrepo, card, record, true)
+	case "failed":
+		if err := recordPinResult(ctx, s.cardrepo, s.pinrepo, card, record, false); err != nil {
+			return err
+		}
+		if card.Status == "locked" {
+			s.tokens.SyncCardTokens(ctx, card.ID, card.Status)
+			return errors.New("incorrect PIN, card locked after too many attempts")
+		}
+		return errors.New("incorrect PIN")
+	default:
+		return errors.New("invalid pin verification result")
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Card report reasons.
+const (
+	CardReportLost        = "lost"
+	CardReportStolen      = "stolen"
+	CardReportCompromised = "compromised"
+)
+
+// disputeWindow is how far back a transaction can be disputed when a card is
+// reported.
+const disputeWindow = 120 * 24 * time.Hour
+
+func validCardReportReason(reason string) bool {
+	return reason == CardReportLost || reason == CardReportStolen || reason == CardReportCompromised
+}
+
+// ValidateDisputedTransactions checks that every transaction the user
+// doesn't recognize is a recent, successful debit on the reported card.
+func ValidateDisputedTransactions(cardID uuid.UUID, txns []models.Transaction, now time.Time) error {
+	for _, txn := range txns {
+		if txn.CardID != cardID {
+			return errors.New("transaction not found")
+		}
+		if txn.Direction != "debit" || txn.Status == "declined" || txn.Status == "reversed" {
+			return fmt.Errorf("transaction %s can't be disputed, only card payments can", txn.TransactionReference)
+		}
+		if txn.TransactionTimestamp.Before(now.Add(-disputeWindow)) {
+			return fmt.Errorf("transaction %s is too old to dispute", txn.TransactionReference)
+		}
+	}
+	return nil
+}
+
+// ReportCard handles a card reported lost, stolen or compromised. In one
+// database transaction the card is blocked, or terminated and reissued if
+// the user asked for a new one, a dispute is opened for every transaction the
+// user doesn't recognize, and a fraud case is opened for the security team. Either
+// all of it happens or none of it does.
+func (s *cardService) ReportCard(ctx context.Context, data models.ReportCardReq) (models.ReportCardResp, error) {
+	if !validCardReportReason(data.Reason) {
+		return models.ReportCardResp{}, errors.New("reason must be lost, stolen or compromised")
+	}
+	description := strings.TrimSpace(data.Description)
+	if len(description) > maxFraudDescription {
+		return models.ReportCardResp{}, fmt.Errorf("description must be at most %d characters", maxFraudDescription)
+	}
+	if len(data.Transactionids) > maxFraudCaseLinks {
+		return models.ReportCardResp{}, fmt.Errorf("at most %d transactions can be disputed at once", maxFraudCaseLinks)
+	}
+	txnIDs, err := parseUUIDs(data.Transactionids, "transaction")
+	if err != nil {
+		return models.ReportCardResp{}, err
+	}
+
+	var card, newCard models.Card
+	var creds cardCredentials
+	var fraudCase *models.FraudCase
+	var caseCreated bool
+	var disputes []models.Dispute
+	err = s.disputerepo.RunInTransaction(ctx, func(cards repositories.CardRepository, txns repositories.TransactionRepository, disputeRepo repositories.DisputeRepository, fraud repositories.FraudRepository) error {
+		var err error
+		card, err = cards.FindCardForUpdate(ctx, models.GetCardReq{UserId: data.Userid, CardId: data.Cardid})
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if card.ID == uuid.Nil {
+			return errors.New("card not found")
+		}
+		switch card.Status {
+		case "terminated":
+			return errors.New("card is already terminated")
+		case "expired":
+			return errors.New("card has expired")
+		case "suspended":
+			// blocking it would let the holder reissue it later
+			return errors.New("card was suspended by its organization")
+		case "blocked":
+			if !data.Reissue {
+				return errors.New("card was already reported, reissue it to get a new card")
+			}
+		}
+		if data.Reissue {
+			if err := checkReissuable(ctx, cards, card); err != nil {
+				return err
+			}
+		}
+		if data.Reissue && card.HeldBalance > 0 {
+			return errors.New("card has pending authorizations, report it without reissuing and reissue it once they settle")
+		}
+
+		disputed, err := fraud.FindUserTransactions(ctx, data.Userid, txnIDs)
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if len(disputed) != len(txnIDs) {
+			return errors.New("transaction not found")
+		}
+		if err := ValidateDisputedTransactions(card.ID, disputed, time.Now()); err != nil {
+			return err
+		}
+		existing, err := disputeRepo.FindDisputedTransactionIDs(ctx, txnIDs)
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if len(existing) > 0 {
+			return errors.New("a transaction you reported is already disputed")
+		}
+
+		// a reissued card is terminated by replaceCard below, so it is only
+		// blocked when it is kept
+		if !data.Reissue {
+			card.Status = "blocked"
+			if err := cards.Update(ctx, card); err != nil {
+				return errors.New("something went wrong, please try again later")
+			}
+		}
+
+		source, summary := FraudCaseFromReport, fmt.Sprintf("card ending %s reported %s", card.LastFour, data.Reason)
+		if len(disputed) > 0 {
+			source = FraudCaseFromDispute
+			summary += fmt.Sprintf(", %d transaction(s) disputed", len(disputed))
+		}
+		if description != "" {
+			summary += ": " + description
+		}
+		fraudCase, caseCreated, err = openFraudCase(ctx, fraud, FraudCaseOpen{
+			UserID:         data.Userid,
+			Source:         source,
+			Summary:        summary,
+			CardIDs:        []uuid.UUID{card.ID},
+			TransactionIDs: txnIDs,
+		})
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+
+		for _, txn := range disputed {
+			dispute := models.Dispute{
+				UserID:        data.Userid,
+				CardID:        card.ID,
+				TransactionID: txn.ID,
+				FraudCaseID:   &fraudCase.ID,
+				Reason:        data.Reason,
+				Status:        "open",
+				Amount:        txn.Amount,
+				Currency:      txn.Currency,
+				Description:   description,
+			}
+			if err := disputeRepo.CreateDispute(ctx, &dispute); err != nil {
+				return errors.New("something went wrong, please try again later")
+			}
+			disputes = append(disputes, dispute)
+		}
+
+		if data.Reissue {
+			newCard, creds, err = replaceCard(ctx, cards, txns, &card)
+			if err != nil {
+				return err
+			}
+		}
+		return setFraudCaseCardAction(ctx, fraud, fraudCase.ID, card.ID, card.Status)
+	})
+	if err != nil {
+		return models.ReportCardResp{}, err
+	}
+
+	if caseCreated {
+		auditFraudCaseOpened(ctx, s.auditrepo, fraudCase)
+	}
+	meta := map[string]any{"reason": data.Reason, "case_id": fraudCase.ID, "transactions": txnIDs, "reissued": data.Reissue}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "card.reported", "card", card.ID, data.Client, meta); err != nil {
+		log.Printf("failed to audit report of card %s: %v", card.ID, err)
+	}
+	s.cascadeTokenStatus(ctx, card.ID, card.Status)
+
+	res := models.ReportCardResp{
+		Cardid:   card.ID,
+		Status:   card.Status,
+		Caseid:   &fraudCase.ID,
+		Disputes: make([]models.DisputeResp, 0, len(disputes)),
+	}
+	for _, d := range disputes {
+		res.Disputes = append(res.Disputes, models.DisputeResp{Disputeid: d.ID, Transactionid: d.TransactionID, Amount: d.Amount, Currency: d.Currency, Status: d.Status})
+	}
+	title, body := "Card blocked", fmt.Sprintf("Your card ending %s was blocked after you reported it %s.", card.LastFour, data.Reason)
+	if data.Reissue {
+		title, body = "Card replaced", fmt.Sprintf("Your card ending %s was cancelled after you reported it %s.", card.LastFour, data.Reason)
+	}
+	if len(disputes) > 0 {
+		body += fmt.Sprintf(" We opened disputes for %d transaction(s) you don't recognize.", len(disputes))
+	}
+	if data.Reissue {
+		replacement := reissueResp(card, newCard, creds)
+		res.Replacement = &replacement
+		body += fmt.Sprintf(" Your new card ends %s.", newCard.LastFour)
+		s.sendReissueEmail(ctx, card, newCard)
+	}
+	notifyInApp(ctx, s.notificationrepo, data.Userid, "card_reported", title, body)
+	s.alertSecurityTeam(card, data.Reason, fraudCase.ID, disputes, data.Reissue)
+	return res, nil
+}
+
+// setFraudCaseCardAction records what happened to a card on its case.
+func setFraudCaseCardAction(ctx context.Context, repo repositories.FraudRepository, caseID, cardID uuid.UUID, action string) error {
+	links, err := repo.FindCaseCards(ctx, caseID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	for i := range links {
+		if links[i].CardID != cardID {
+			continue
+		}
+		links[i].Action = &action
+		if err := repo.UpdateCaseCard(ctx, &links[i]); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+	}
+	return nil
+}
+
+// alertSecurityTeam emails the security team about a card report. Without a
+// SECURITY_TEAM_EMAIL the fraud case queue is the only record.
+func (s *cardService) alertSecurityTeam(card models.Card, reason string, caseID uuid.UUID, disputes []models.Dispute, reissued bool) {
+	if config.SecurityTeamEmail == "" {
+		log.Printf("SECURITY_TEAM_EMAIL is not set, card %s report is only on fraud case %s", card.ID, caseID)
+		return
+	}
+	var total float64
+	for _, d := range disputes {
+		total += d.Amount
+	}
+	summary := fmt.Sprintf("Card %s ending %s of user %s, fraud case %s. %d transaction(s) disputed totalling %.2f %s. Reissued: %t.",
+		card.ID, card.LastFour, card.UserID, caseID, len(disputes), total, card.Currency, reissued)
+	email := map[string]string{
+		"Email":   config.SecurityTeamEmail,
+		"Reason":  reason,
+		"Summary": summary,
+	}
+	go func() {
+		if err := utils.SendWithRetry(3, 2*time.Second, func() error {
+			return SendSecurityAlertEmail(email)
+		}); err != nil {
+			log.Printf("failed to alert the security team about card %s: %v", card.ID, err)
+		}
+	}()
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateDisputedTransactions(t *testing.T) {
+	now := time.Now()
+	cardID := uuid.New()
+	payment := func(change func(*models.Transaction)) models.Transaction {
+		txn := models.Transaction{CardID: cardID, TransactionReference: "TXN-1", Direction: "debit", Status: "completed", TransactionTimestamp: now.Add(-24 * time.Hour)}
+		if change != nil {
+			change(&txn)
+		}
+		return txn
+	}
+	cases := []struct {
+		name string
+		txn  models.Transaction
+		ok   bool
+	}{
+		{"recent payment", payment(nil), true},
+		{"pending authorization", payment(func(t *models.Transaction) { t.Status = "authorized" }), true},
+		{"another card", payment(func(t *models.Transaction) { t.CardID = uuid.New() }), false},
+		{"top-up", payment(func(t *models.Transaction) { t.Direction = "credit" }), false},
+		{"declined", payment(func(t *models.Transaction) { t.Status = "declined" }), false},
+		{"reversed", payment(func(t *models.Transaction) { t.Status = "reversed" }), false},
+		{"too old", payment(func(t *models.Transaction) { t.TransactionTimestamp = now.Add(-disputeWindow - time.Hour) }), false},
+	}
+	for _, c := range cases {
+		err := ValidateDisputedTransactions(cardID, []models.Transaction{c.txn}, now)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: got error %v, want ok %v", c.name, err, c.ok)
+		}
+	}
+	if err := ValidateDisputedTransactions(cardID, nil, now); err != nil {
+		t.Errorf("reporting without transactions: %v", err)
+	}
+}
+
+func TestValidCardReportReason(t *testing.T) {
+	for _, reason := range []string{"lost", "stolen", "compromised"} {
+		if !validCardReportReason(reason) {
+			t.Errorf("%s should be a valid reason", reason)
+		}
+	}
+	for _, reason := range []string{"", "frozen", "Lost"} {
+		if validCardReportReason(reason) {
+			t.Errorf("%q should not be a valid reason", reason)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// revealTTL bounds how long revealed card data, or a token for it, is valid.
+const revealTTL = 60 * time.Second
+
+// issueRevealToken stores a one-time token for the PCI-scoped display
+// service. Only the card ID and the token's hash are kept, the PAN is
+// decrypted on redemption.
+func (s *cardService) issueRevealToken(ctx context.Context, card models.Card, expiresAt time.Time) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	err := s.cardrepo.CreateRevealToken(ctx, &models.CardRevealToken{
+		CardID:    card.ID,
+		UserID:    card.UserID,
+		TokenHash: hashLinkToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevealCard returns the full card number and CVV after a fresh step-up
+// check. In token mode only a one-time token is returned, which the
+// PCI-scoped display service exchanges through RedeemRevealToken. Every
+// attempt, allowed or not, is written to the audit log.
+func (s *cardService) RevealCard(ctx context.Context, data models.RevealCardReq) (models.RevealCardResp, error) {
+	card, err := s.cardrepo.FindCardByID(ctx, models.GetCardReq{UserId: data.UserId, CardId: data.CardId})
+	if err != nil {
+		return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return models.RevealCardResp{}, errors.New("card not found")
+	}
+	if card.Status == "terminated" {
+		return models.RevealCardResp{}, errors.New("card is terminated")
+	}
+	mode := data.Mode
+	if mode == "" {
+		mode = "direct"
+	}
+	if mode != "direct" && mode != "token" {
+		return models.RevealCardResp{}, errors.New("invalid reveal mode")
+	}
+
+	if err := verifyStepUp(ctx, s.userrepo, data.UserId, data.TotpCode, data.Otp); err != nil {
+		if auditErr := recordAudit(ctx, s.auditrepo, data.UserId, "card.reveal_denied", "card", card.ID, data.Client, map[string]any{
+			"mode":   mode,
+			"reason": err.Error(),
+		}); auditErr != nil {
+			log.Printf("failed to audit denied reveal of card %s: %v", card.ID, auditErr)
+		}
+		return models.RevealCardResp{}, err
+	}
+
+	expiresAt := time.Now().Add(revealTTL)
+	if mode == "token" {
+		token, err := s.issueRevealToken(ctx, card, expiresAt)
+		if err != nil {
+			return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+		}
+		if err := recordAudit(ctx, s.auditrepo, data.UserId, "card.reveal", "card", card.ID, data.Client, map[string]any{"mode": mode}); err != nil {
+			if _, err := s.cardrepo.UseRevealToken(ctx, hashLinkToken(token), time.Now()); err != nil {
+				log.Printf("failed to retire unaudited reveal token for card %s: %v", card.ID, err)
+			}
+			return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+		}
+		return models.RevealCardResp{Cardid: card.ID, Token: token, ExpiresAt: expiresAt}, nil
+	}
+
+	if err := recordAudit(ctx, s.auditrepo, data.UserId, "card.reveal", "card", card.ID, data.Client, map[string]any{"mode": mode}); err != nil {
+		return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	return revealCardDetails(card, expiresAt)
+}
+
+func (s *cardService) RedeemRevealToken(ctx context.Context, data models.RedeemRevealTokenReq) (models.RevealCardResp, error) {
+	t, err := s.cardrepo.UseRevealToken(ctx, hashLinkToken(data.Token), time.Now())
+	if err != nil {
+		return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	if t == nil {
+		return models.RevealCardResp{}, errors.New("invalid or expired reveal token")
+	}
+	card, err := s.cardrepo.FindCardByID(ctx, models.GetCardReq{UserId: t.UserID, CardId: t.CardID.String()})
+	if err != nil || card.ID == uuid.Nil {
+		return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, t.UserID, "card.reveal_token_redeemed", "card", card.ID, data.Client, nil); err != nil {
+		return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	return revealCardDetails(card, t.ExpiresAt)
+}
+
+func revealCardDetails(card models.Card, expiresAt time.Time) (models.RevealCardResp, error) {
+	pan, err := utils.DecryptWithKeyring(card.PANencrypted)
+	if err != nil {
+		return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	cvv, err := utils.DeriveCVV(pan, card.ExpiryMonth, card.ExpiryYear)
+	if err != nil {
+		return models.RevealCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	return models.RevealCardResp{
+		Cardid:      card.ID,
+		PAN:         pan,
+		Cvv:         cvv,
+		ExpiryMonth: card.ExpiryMonth,
+		ExpiryYear:  card.ExpiryYear,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryUserRepo keeps users in memory for the step-up paths.
+type memoryUserRepo struct {
+	repositories.UserRepository
+	users     map[uuid.UUID]models.User
+	totpSteps map[uuid.UUID]int64
+}
+
+func (m *memoryUserRepo) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (m *memoryUserRepo) Update(ctx context.Context, user *models.User) error {
+	m.users[user.ID] = *user
+	return nil
+}
+
+func (m *memoryUserRepo) UseTotpStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	if last, ok := m.totpSteps[userID]; ok && last >= step {
+		return false, nil
+	}
+	m.totpSteps[userID] = step
+	return true, nil
+}
+
+type memoryAuditRepo struct {
+	repositories.AuditRepository
+	actions []string
+}
+
+func (m *memoryAuditRepo) Create(ctx context.Context, entry *models.AuditLog) error {
+	m.actions = append(m.actions, entry.Action)
+	return nil
+}
+
+type revealTest struct {
+	users *memoryUserRepo
+	cards *memoryCardRepo
+	audit *memoryAuditRepo
+	svc   *cardService
+	user  models.User
+	card  models.Card
+}
+
+func newRevealTest(t *testing.T, mfa bool) *revealTest {
+	key := func(c string) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(c, 32))) }
+	saved := []string{config.EncryptionKey, config.CvvKey}
+	t.Cleanup(func() { config.EncryptionKey, config.CvvKey = saved[0], saved[1] })
+	config.EncryptionKey, config.CvvKey = key("e"), key("c")
+
+	user := models.User{ID: uuid.New(), Email: "ada@example.com"}
+	if mfa {
+		secret, _, err := utils.GenerateMFASecret(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.MFAEnabled, user.MFASecret = true, secret
+	}
+	pan, err := utils.EncryptWithKeyring("4111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := models.Card{ID: uuid.New(), UserID: user.ID, Status: "active", PANencrypted: pan, ExpiryMonth: "09", ExpiryYear: "2030"}
+
+	r := &revealTest{
+		users: &memoryUserRepo{users: map[uuid.UUID]models.User{user.ID: user}, totpSteps: map[uuid.UUID]int64{}},
+		cards: &memoryCardRepo{cards: map[uuid.UUID]models.Card{card.ID: card}},
+		audit: &memoryAuditRepo{},
+		user:  user,
+		card:  card,
+	}
+	r.svc = r.service()
+	return r
+}
+
+// service builds another API instance over the same storage.
+func (r *revealTest) service() *cardService {
+	return &cardService{userrepo: r.users, cardrepo: r.cards, auditrepo: r.audit}
+}
+
+func (r *revealTest) sendOtp(otp string, expiresAt time.Time) {
+	user := r.users.users[r.user.ID]
+	user.OTP, user.OTPExpiresAt = otp, expiresAt
+	r.users.users[r.user.ID] = user
+}
+
+func TestRevealCardWithTotp(t *testing.T) {
+	ctx := context.Background()
+	r := newRevealTest(t, true)
+	req := models.RevealCardReq{UserId: r.user.ID, CardId: r.card.ID.String()}
+
+	if _, err := r.svc.RevealCard(ctx, req); !errors.Is(err, ErrStepUpRequired) {
+		t.Fatalf("expected a reveal without a code to need step-up, got %v", err)
+	}
+
+	code, err := utils.GenerateTotp(r.user.MFASecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.TotpCode = code
+	res, err := r.svc.RevealCard(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cvv, _ := utils.DeriveCVV("4111111111111111", "09", "2030")
+	if res.PAN != "4111111111111111" || res.Cvv != cvv || res.Token != "" {
+		t.Fatalf("expected the card details, got %+v", res)
+	}
+
+	// the same code, even on another instance, must not pass step-up again
+	if _, err := r.service().RevealCard(ctx, req); err == nil {
+		t.Fatalf("expected a replayed TOTP code to be rejected")
+	}
+	want := []string{"card.reveal_denied", "card.reveal", "card.reveal_denied"}
+	if strings.Join(r.audit.actions, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected every attempt audited as %v, got %v", want, r.audit.actions)
+	}
+}
+
+func TestRevealTokenWithOtp(t *testing.T) {
+	ctx := context.Background()
+	r := newRevealTest(t, false)
+	req := models.RevealCardReq{UserId: r.user.ID, CardId: r.card.ID.String(), Mode: "token", Otp: "123456"}
+
+	r.sendOtp("123456", time.Now().Add(-time.Minute))
+	if _, err := r.svc.RevealCard(ctx, req); err == nil {
+		t.Fatalf("expected an expired OTP to be rejected")
+	}
+	r.sendOtp("654321", time.Now().Add(5*time.Minute))
+	if _, err := r.svc.RevealCard(ctx, req); err == nil {
+		t.Fatalf("expected a wrong OTP to be rejected")
+	}
+
+	r.sendOtp("123456", time.Now().Add(5*time.Minute))
+	res, err := r.svc.RevealCard(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Token == "" || res.PAN != "" || r.users.users[r.user.ID].OTP != "" {
+		t.Fatalf("expected only a token and the OTP consumed, got %+v", res)
+	}
+	if r.cards.reveals[0].TokenHash == res.Token {
+		t.Fatalf("expected only the token's hash to be stored")
+	}
+	if _, err := r.svc.RevealCard(ctx, req); err == nil {
+		t.Fatalf("expected a used OTP to be rejected")
+	}
+
+	// any instance can redeem the token, but only once
+	redeemed, err := r.service().RedeemRevealToken(ctx, models.RedeemRevealTokenReq{Token: res.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redeemed.PAN != "4111111111111111" || redeemed.Cardid != r.card.ID {
+		t.Fatalf("expected the redeemed token to reveal the card, got %+v", redeemed)
+	}
+	if _, err := r.svc.RedeemRevealToken(ctx, models.RedeemRevealTokenReq{Token: res.Token}); err == nil {
+		t.Fatalf("expected a reveal token to be redeemable once")
+	}
+
+	r.sendOtp("111111", time.Now().Add(5*time.Minute))
+	req.Otp = "111111"
+	res, err = r.svc.RevealCard(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.cards.reveals[len(r.cards.reveals)-1].ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := r.svc.RedeemRevealToken(ctx, models.RedeemRevealTokenReq{Token: res.Token}); err == nil {
+		t.Fatalf("expected an expired reveal token to be rejected")
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"CardFlow/internal/config"
+	"CardFlow/internal/integrations"
 	"CardFlow/internal/models"
 	"CardFlow/internal/repositories"
 	"CardFlow/internal/utils"
@@ -9,6 +10,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,8 +22,21 @@ type CardService interface {
     CreateCard(context.Context, models.CreateCardReq)(any, error)
 	GetAllCards(context.Context, uuid.UUID)([]models.GetAllCardsResp, error)
 	GetCardById(context.Context, models.GetCardReq)(models.GetCardResp, error)
-	ModifyCardStatus(ctx context.Context, data models.GetCardReq, status string) error
+	ModifyCardStatus(ctx context.Context, data models.ModifyCardStatusReq, status string) error
 	TopUpCard(ctx context.Context, data models.TopUpCardReq)(any, error)
+	TransferBetweenCards(ctx context.Context, data models.TransferReq)(models.TransferResp, error)
+	WithdrawCardBalance(ctx context.Context, data models.WithdrawReq)(models.WithdrawResp, error)
+	ReissueCard(ctx context.Context, data models.GetCardReq)(models.ReissueCardResp, error)
+	RevealCard(ctx context.Context, data models.RevealCardReq)(models.RevealCardResp, error)
+	RedeemRevealToken(ctx context.Context, data models.RedeemRevealTokenReq)(models.RevealCardResp, error)
+	SetCardPin(ctx context.Context, data models.CardPinReq) error
+	ChangeCardPin(ctx context.Context, data models.CardPinReq) error
+	ProvisionToken(ctx context.Context, data models.ProvisionTokenReq)(models.ProvisionTokenResp, error)
+	GetCardTokens(ctx context.Context, data models.GetCardReq)([]models.NetworkTokenResp, error)
+	ModifyToken(ctx context.Context, data models.ModifyTokenReq)(models.NetworkTokenResp, error)
+	TokenLifecycleWebhook(ctx context.Context, data models.TokenLifecycleWebhookReq) error
+	ReportCard(ctx context.Context, data models.ReportCardReq)(models.ReportCardResp, error)
+	SyncCardTokens(ctx context.Context, cardID uuid.UUID, cardStatus string)
 }
 
 type cardService struct {
@@ -27,81 +44,83 @@ type cardService struct {
     kycrepo repositories.KycRepository
 	cardrepo repositories.CardRepository
 	Txnrepo repositories.TransactionRepository
+	auditrepo repositories.AuditRepository
+	payout integrations.PayoutClient
+	pinrepo repositories.CardPinRepository
+	tokenrepo repositories.NetworkTokenRepository
+	tsp integrations.TokenServiceProvider
+	screening ScreeningService
+	disputerepo repositories.DisputeRepository
+	notificationrepo repositories.NotificationRepository
 }
 
-func NewCardService(userRepo repositories.UserRepository,  kycrepo repositories.KycRepository, cardRepo repositories.CardRepository, txnRepo repositories.TransactionRepository) CardService {
-    return &cardService{userrepo:userRepo, kycrepo:kycrepo, cardrepo: cardRepo, Txnrepo:txnRepo}
+func NewCardService(userRepo repositories.UserRepository,  kycrepo repositories.KycRepository, cardRepo repositories.CardRepository, txnRepo repositories.TransactionRepository, auditRepo repositories.AuditRepository, payout integrations.PayoutClient, pinRepo repositories.CardPinRepository, tokenRepo repositories.NetworkTokenRepository, tsp integrations.TokenServiceProvider, screening ScreeningService, disputeRepo repositories.DisputeRepository, notificationRepo repositories.NotificationRepository) CardService {
+    return &cardService{userrepo:userRepo, kycrepo:kycrepo, cardrepo: cardRepo, Txnrepo:txnRepo, auditrepo: auditRepo, payout: payout, pinrepo: pinRepo, tokenrepo: tokenRepo, tsp: tsp, screening: screening, disputerepo: disputeRepo, notificationrepo: notificationRepo}
 }
 
 var ErrUserNotFound = errors.New("user not found")
 
+const CardTypeMerchantLocked = "merchant-locked"
+
 
 func (s *cardService) CreateCard(ctx context.Context, data models.CreateCardReq)(any, error){
-	var ExpiryMonth, ExpiryYear string
-	var ExpiresAt time.Time
-	//first check if kyc for user has been verified
-	user, err := s.kycrepo.FindByUserID(data.Userid)
+	//first check the card is allowed at the user's kyc tier
+	tier, _, err := resolveKycTier(ctx, s.userrepo, s.kycrepo, data.Userid)
 	if err != nil{
+		log.Printf("failed to resolve kyc tier for user %s: %v", data.Userid, err)
 		return nil, errors.New("Something Went Wrong, Please try again later")
 	}
-	if user.Status != "verified"{
-		return nil, errors.New("incomplete kyc")
-	}
-	//set expiry month and year to 1 year from now if its single use or 3 years if multi use
-	switch data.CardType {
-	case "single-use":
-		ExpiryMonth, ExpiryYear, ExpiresAt = utils.GetExpiryDate(1)
-	case "multi-use":
-		ExpiryMonth, ExpiryYear, ExpiresAt = utils.GetExpiryDate(3)
-	default:
-		return nil, errors.New("invalid card type")
-	}
-	//generate card reference
-	CardReference := GenerateCardReference("CRDFLW")
-	//generate card
-	IIN := config.IIN
-	MiddleDigits := utils.GenerateNumberString(8)
-	CardNumber := "4" + IIN + MiddleDigits
-	Cvv := utils.GenerateNumberString(3)
-	MaskedCardNumber := "4" + IIN + MiddleDigits[:4] + "****" + MiddleDigits[6:]
-	//luhn check digit
-	CheckDigit := utils.ComputeLuhnCheckDigit(CardNumber)
-	FullCardNumber := CardNumber + CheckDigit
-	//encrypt card number
-	PANENcrypted, err := utils.EncryptString(FullCardNumber, config.EncryptionKey)
+	existing, err := s.cardrepo.FindCardsByID(ctx, data.Userid)
 	if err != nil{
 		return nil, errors.New("Something Went Wrong, Please try again later")
 	}
-	CvvEncrypted, err := utils.EncryptString(Cvv, config.EncryptionKey)
-	if err != nil{
-		return nil, errors.New("Something Went Wrong, Please try again later")
+	activeCards := 0
+	for _, card := range existing {
+		if cardIsActive(card) && card.OrganizationID == nil {
+			activeCards++
+		}
 	}
-	//hash card number
-	HashedCardNumber, err := utils.HashString(FullCardNumber)
-	if err != nil{
-		return nil, errors.New("Something Went Wrong, Please try again later")
+	if err := CheckCardLimits(tier, data.CardType, activeCards); err != nil {
+		return nil, err
 	}
-	HashedCvv, err := utils.HashString(Cvv)
-	if err != nil{
-		return nil, errors.New("Something Went Wrong, Please try again later")
+	//no cards while a sanctions screening hit is open or confirmed
+	if err := s.screening.CheckCardIssuance(ctx, data.Userid); err != nil {
+		return nil, err
+	}
+	var lockedMerchant *string
+	var recurringAmount, recurringTolerance *float64
+	if data.CardType == CardTypeMerchantLocked {
+		if data.RecurringTolerance < 0 || data.RecurringTolerance > 100 {
+			return nil, errors.New("recurring tolerance must be between 0 and 100")
+		}
+		if data.RecurringAmount < 0 {
+			return nil, errors.New("invalid recurring amount")
+		}
+		if name := strings.TrimSpace(data.MerchantName); name != "" {
+			lockedMerchant = &name
+		}
+		if data.RecurringAmount > 0 {
+			recurringAmount = &data.RecurringAmount
+		}
+		if data.RecurringTolerance > 0 {
+			recurringTolerance = &data.RecurringTolerance
+		}
+	}
+	creds, err := generateCardCredentials(ctx, s.cardrepo, data.CardType)
+	if err != nil {
+		return nil, err
 	}
 	card := &models.Card{
 		UserID:        data.Userid,
 		CardType:     data.CardType,
 		Currency:     data.Currency,
 		SpendingLimitAmount: data.SpendingLimit,
-		CardReference: CardReference,
-		PANencrypted: PANENcrypted,
-		CVVencrypted: CvvEncrypted,
-		PANHash: HashedCardNumber,
-		CVVHash: HashedCvv,
-		MaskedPAN: MaskedCardNumber,
-		LastFour: FullCardNumber[len(FullCardNumber)-4:],
 		Status: "active",
-		ExpiryMonth: ExpiryMonth,
-		ExpiryYear: ExpiryYear,
-		ExpiresAt: ExpiresAt,
+		LockedMerchantName: lockedMerchant,
+		RecurringAmount: recurringAmount,
+		RecurringTolerance: recurringTolerance,
 	}
+	creds.applyTo(card)
 	err = s.cardrepo.CreateCard(ctx, card)
 	if err != nil{
 		return nil, errors.New("Something Went Wrong, Please try again later")
@@ -109,19 +128,105 @@ func (s *cardService) CreateCard(ctx context.Context, data models.CreateCardReq)
 	
 	resp := &models.CreateCardResp{
 		CardType: data.CardType,
-		MaskedPAN: MaskedCardNumber,
+		MaskedPAN: creds.MaskedPAN,
 		Currency: data.Currency,
 		SpendingLimit: data.SpendingLimit,
 		Balance: 0.00,
-		Cvv: Cvv,
+		Cvv: creds.Cvv,
 		Status: "active",
-		ExpiryMonth: ExpiryMonth,
-		ExpiryYear: ExpiryYear,
+		ExpiryMonth: creds.ExpiryMonth,
+		ExpiryYear: creds.ExpiryYear,
+		LockedMerchant: lockedMerchant,
 	}
 
 	return resp, nil
 }
 
+// cardCredentials is a freshly generated card number, CVV and expiry. Only the
+// encrypted PAN and its fingerprint are stored; Cvv is derived from the PAN
+// and expiry and handed to the user once.
+type cardCredentials struct {
+	Reference      string
+	PANencrypted   string
+	PANFingerprint string
+	MaskedPAN      string
+	LastFour       string
+	Cvv            string
+	ExpiryMonth    string
+	ExpiryYear     string
+	ExpiresAt      time.Time
+}
+
+// maxPanAttempts bounds how often issuance redraws the random middle digits
+// when the PAN it drew already belongs to another card.
+const maxPanAttempts = 5
+
+func generateCardCredentials(ctx context.Context, cards repositories.CardRepository, cardType string) (cardCredentials, error) {
+	var creds cardCredentials
+	//set expiry month and year to 1 year from now if its single use or 3 years if multi use
+	switch cardType {
+	case "single-use":
+		creds.ExpiryMonth, creds.ExpiryYear, creds.ExpiresAt = utils.GetExpiryDate(1)
+	case "multi-use", CardTypeMerchantLocked:
+		creds.ExpiryMonth, creds.ExpiryYear, creds.ExpiresAt = utils.GetExpiryDate(3)
+	default:
+		return cardCredentials{}, errors.New("invalid card type")
+	}
+	//generate card reference
+	creds.Reference = GenerateCardReference("CRDFLW")
+	//generate card
+	IIN := config.IIN
+	var FullCardNumber, MiddleDigits string
+	for attempt := 1; ; attempt++ {
+		MiddleDigits = utils.GenerateNumberString(8)
+		CardNumber := "4" + IIN + MiddleDigits
+		//luhn check digit
+		FullCardNumber = CardNumber + utils.ComputeLuhnCheckDigit(CardNumber)
+		fingerprint, err := utils.PanFingerprint(FullCardNumber)
+		if err != nil {
+			log.Println(err)
+			return cardCredentials{}, errors.New("Something Went Wrong, Please try again later")
+		}
+		existing, err := cards.FindCardByPANFingerprint(ctx, fingerprint)
+		if err != nil {
+			return cardCredentials{}, errors.New("Something Went Wrong, Please try again later")
+		}
+		if existing.ID == uuid.Nil {
+			creds.PANFingerprint = fingerprint
+			break
+		}
+		if attempt == maxPanAttempts {
+			log.Printf("no free PAN after %d attempts for IIN %s", attempt, IIN)
+			return cardCredentials{}, errors.New("Something Went Wrong, Please try again later")
+		}
+	}
+	creds.MaskedPAN = "4" + IIN + MiddleDigits[:4] + "****" + MiddleDigits[6:]
+	creds.LastFour = FullCardNumber[len(FullCardNumber)-4:]
+	var err error
+	creds.Cvv, err = utils.DeriveCVV(FullCardNumber, creds.ExpiryMonth, creds.ExpiryYear)
+	if err != nil {
+		log.Println(err)
+		return cardCredentials{}, errors.New("Something Went Wrong, Please try again later")
+	}
+	//encrypt card number
+	creds.PANencrypted, err = utils.EncryptWithKeyring(FullCardNumber)
+	if err != nil{
+		return cardCredentials{}, errors.New("Something Went Wrong, Please try again later")
+	}
+	return creds, nil
+}
+
+func (c cardCredentials) applyTo(card *models.Card) {
+	card.CardReference = c.Reference
+	card.PANencrypted = c.PANencrypted
+	card.PANFingerprint = &c.PANFingerprint
+	card.MaskedPAN = c.MaskedPAN
+	card.LastFour = c.LastFour
+	card.ExpiryMonth = c.ExpiryMonth
+	card.ExpiryYear = c.ExpiryYear
+	card.ExpiresAt = c.ExpiresAt
+}
+
 func GenerateCardReference(prefix string) string {
 	uniqueID := utils.GenerateRandomString(10)
 	return prefix + uniqueID
@@ -134,16 +239,11 @@ func (s *cardService) GetAllCards(ctx context.Context, Userid uuid.UUID)([]model
 		return nil,  errors.New("something went wrong, please try again later")
 	}
 	for _, card := range cards{
-		CvvDecrypt, err := utils.DecryptString(card.CVVencrypted, config.EncryptionKey)
-		if err != nil {
-			return nil, errors.New("Something Went Wrong, Please try again later")
-		}
 		resp := models.GetAllCardsResp{
 			Cardid: card.ID,
 			CardType: card.CardType,
 			MaskedPAN: card.MaskedPAN,
 			Lastfour: card.LastFour,
-			Cvv: CvvDecrypt,
 			Currency: card.Currency,
 			Status: card.Status,
 		}
@@ -158,19 +258,14 @@ func (s *cardService) GetCardById(ctx context.Context, data models.GetCardReq)(m
 	if err != nil {
 		return models.GetCardResp{},  errors.New("something went wrong, please try again later")
 	}
-	Pan, err := utils.DecryptString(card.PANencrypted, config.EncryptionKey)
-	if err != nil {
-		return models.GetCardResp{}, errors.New("Something Went Wrong, Please try again later")
-	}
-	Cvv, err := utils.DecryptString(card.CVVencrypted, config.EncryptionKey)
-	if err != nil {
-		return models.GetCardResp{}, errors.New("Something Went Wrong, Please try again later")
+	if card.ID == uuid.Nil {
+		return models.GetCardResp{}, errors.New("card not found")
 	}
+	// full card details are only available through RevealCard
 	res := models.GetCardResp{
 		Cardid: card.ID,
 		CardType: card.CardType,
-		PAN: Pan,
-		Cvv: Cvv,
+		MaskedPAN: card.MaskedPAN,
 		Lastfour: card.LastFour,
 		Currency: card.Currency,
 		Status: card.Status,
@@ -178,12 +273,28 @@ func (s *cardService) GetCardById(ctx context.Context, data models.GetCardReq)(m
 		CurrentBalance: card.CurrentBalance,
 		ExpiryMonth: card.ExpiryMonth,
 		ExpiryYear: card.ExpiryYear,
+		LockedMerchant: card.LockedMerchantName,
+		RecurringAmount: card.RecurringAmount,
+		RecurringTolerance: card.RecurringTolerance,
+		ReplacesCardid: card.ReplacesCardID,
+		ReplacedByCardid: card.ReplacedByCardID,
+	}
+	txns, err := cardHistory(ctx, s.cardrepo, s.Txnrepo, card)
+	if err != nil {
+		// the card itself is still useful without the recurring charge estimate
+		log.Printf("failed to load transactions for card %s: %v", card.ID, err)
+		return res, nil
 	}
+	res.NextExpectedCharge = DetectRecurringCharge(txns)
 	
 	return res, nil
 }
 
-func (s *cardService) ModifyCardStatus(ctx context.Context, data models.GetCardReq, status string) error{
+func (s *cardService) ModifyCardStatus(ctx context.Context, req models.ModifyCardStatusReq, status string) error{
+	if err := checkPartnerCardStatusChange(req, status); err != nil {
+		return err
+	}
+	data := models.GetCardReq{UserId: req.UserId, CardId: req.CardId}
 	switch status {
 	case "freeze":
 		card, err := s.cardrepo.FindCardByID(ctx, data)
@@ -195,12 +306,19 @@ func (s *cardService) ModifyCardStatus(ctx context.Context, data models.GetCardR
 			return errors.New("card is already frozen")
 		case "expired":
 			return errors.New("card has expired")
+		case "locked":
+			return errors.New("card is locked, set a new PIN to unlock it")
+		case "suspended":
+			return errors.New("card was suspended by its organization")
+		case "blocked":
+			return errors.New("card was reported lost or compromised, reissue it to get a new card")
 		}
 		card.Status = "frozen"
 		err = s.cardrepo.Update(ctx, card)
 		if err != nil {
 			return errors.New("something went wrong, please try again later")
 		}
+		s.cascadeTokenStatus(ctx, card.ID, card.Status)
 	case "unfreeze":
 		card, err := s.cardrepo.FindCardByID(ctx, data)
 		if err != nil {
@@ -211,12 +329,27 @@ func (s *cardService) ModifyCardStatus(ctx context.Context, data models.GetCardR
 			return errors.New("card is already active")
 		case "expired":
 			return errors.New("card has expired")
+		case "locked":
+			return errors.New("card is locked, set a new PIN to unlock it")
+		case "suspended":
+			return errors.New("card was suspended by its organization")
+		case "blocked":
+			return errors.New("card was reported lost or compromised, reissue it to get a new card")
+		}
+		// a fraud freeze holds until the case is resolved
+		frozen, err := s.cardrepo.IsFrozenByFraudCase(ctx, card.ID)
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if frozen {
+			return errors.New("card is frozen while a fraud case is reviewed")
 		}
 		card.Status = "active"
 		err = s.cardrepo.Update(ctx, card)
 		if err != nil {
 			return errors.New("something went wrong, please try again later")
 		}
+		s.cascadeTokenStatus(ctx, card.ID, card.Status)
 	case "terminate":
 		card, err := s.cardrepo.FindCardByID(ctx, data)
 		if err != nil {
@@ -228,13 +361,296 @@ func (s *cardService) ModifyCardStatus(ctx context.Context, data models.GetCardR
 		case "expired":
 			return errors.New("card has expired")
 		}
-		card.Status = "terminated"
-		err = s.cardrepo.Update(ctx, card)
+		return s.terminateCard(ctx, req)
+	}
+	
+	return nil
+}
+
+// ReissueCard replaces a card with a new number, CVV and expiry. The balance,
+// spending controls and merchant lock move to the new card, the two cards are
+// linked to each other and the old card is terminated in the same database
+// transaction.
+func (s *cardService) ReissueCard(ctx context.Context, data models.GetCardReq) (models.ReissueCardResp, error) {
+	var oldCard, newCard models.Card
+	var creds cardCredentials
+	err := s.cardrepo.RunInTransaction(ctx, func(cards repositories.CardRepository, txns repositories.TransactionRepository) error {
+		var err error
+		oldCard, err = cards.FindCardForUpdate(ctx, data)
 		if err != nil {
 			return errors.New("something went wrong, please try again later")
 		}
+		if oldCard.ID == uuid.Nil {
+			return errors.New("card not found")
+		}
+		if err := checkReissuable(ctx, cards, oldCard); err != nil {
+			return err
+		}
+		newCard, creds, err = replaceCard(ctx, cards, txns, &oldCard)
+		return err
+	})
+	if err != nil {
+		return models.ReissueCardResp{}, err
+	}
+	s.cascadeTokenStatus(ctx, oldCard.ID, oldCard.Status)
+	s.sendReissueEmail(ctx, oldCard, newCard)
+
+	return reissueResp(oldCard, newCard, creds), nil
+}
+
+// checkReissuable refuses to replace a card whose status its holder can't
+// lift: a replacement starts active, so reissuing a card suspended by its
+// organization, PIN-locked or frozen by a fraud case would undo that.
+func checkReissuable(ctx context.Context, cards repositories.CardRepository, card models.Card) error {
+	switch card.Status {
+	case "terminated":
+		return errors.New("card is already terminated")
+	case "expired":
+		return errors.New("card has expired")
+	case "suspended":
+		return errors.New("card was suspended by its organization")
+	case "locked":
+		return errors.New("card is locked, set a new PIN to unlock it")
+	}
+	frozen, err := cards.IsFrozenByFraudCase(ctx, card.ID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if frozen {
+		return errors.New("card is frozen while a fraud case is reviewed")
+	}
+	return nil
+}
+
+// replaceCard issues the replacement for a card locked with
+// FindCardForUpdate: the new card takes over the balance, spending controls
+// and merchant lock, and the old card is terminated and linked to it. Call
+// it inside RunInTransaction.
+func replaceCard(ctx context.Context, cards repositories.CardRepository, txns repositories.TransactionRepository, oldCard *models.Card) (models.Card, cardCredentials, error) {
+	if oldCard.HeldBalance > 0 {
+		return models.Card{}, cardCredentials{}, errors.New("card has pending authorizations, please try again once they settle")
+	}
+
+	creds, err := generateCardCredentials(ctx, cards, oldCard.CardType)
+	if err != nil {
+		return models.Card{}, cardCredentials{}, err
+	}
+	newCard := models.Card{
+		UserID:              oldCard.UserID,
+		OrganizationID:      oldCard.OrganizationID,
+		CardType:            oldCard.CardType,
+		Currency:            oldCard.Currency,
+		SpendingLimitAmount: oldCard.SpendingLimitAmount,
+		LockedMerchantName:  oldCard.LockedMerchantName,
+		RecurringAmount:     oldCard.RecurringAmount,
+		RecurringTolerance:  oldCard.RecurringTolerance,
+		CurrentBalance:      oldCard.CurrentBalance,
+		Status:              "active",
+		ReplacesCardID:      &oldCard.ID,
+	}
+	creds.applyTo(&newCard)
+	if err := cards.CreateCard(ctx, &newCard); err != nil {
+		return models.Card{}, cardCredentials{}, errors.New("something went wrong, please try again later")
+	}
+
+	balance := oldCard.CurrentBalance
+	oldCard.CurrentBalance = 0
+	oldCard.Status = "terminated"
+	oldCard.ReplacedByCardID = &newCard.ID
+	if err := cards.Update(ctx, *oldCard); err != nil {
+		return models.Card{}, cardCredentials{}, errors.New("something went wrong, please try again later")
+	}
+	if balance > 0 {
+		if err := recordReissueTransfer(ctx, txns, *oldCard, newCard, balance); err != nil {
+			return models.Card{}, cardCredentials{}, err
+		}
+	}
+	return newCard, creds, nil
+}
+
+func (s *cardService) sendReissueEmail(ctx context.Context, oldCard, newCard models.Card) {
+	user, err := s.userrepo.FindByID(ctx, newCard.UserID)
+	if err != nil {
+		log.Printf("failed to load user for reissue email of card %s: %v", newCard.ID, err)
+		return
+	}
+	res := map[string]string{
+		"firstname":   user.FirstName,
+		"email":       user.Email,
+		"oldlastfour": oldCard.LastFour,
+		"newlastfour": newCard.LastFour,
+		"balance":     fmt.Sprintf("%.2f", newCard.CurrentBalance),
+	}
+	go func() {
+		if err := utils.SendWithRetry(3, 2*time.Second, func() error {
+			return SendCardReissueEmail(res)
+		}); err != nil {
+			log.Printf("failed to send reissue email for card %s: %v", newCard.ID, err)
+		}
+	}()
+}
+
+func reissueResp(oldCard, newCard models.Card, creds cardCredentials) models.ReissueCardResp {
+	return models.ReissueCardResp{
+		Cardid:         newCard.ID,
+		ReplacedCardid: oldCard.ID,
+		CreateCardResp: models.CreateCardResp{
+			CardType:       newCard.CardType,
+			MaskedPAN:      newCard.MaskedPAN,
+			Currency:       newCard.Currency,
+			SpendingLimit:  newCard.SpendingLimitAmount,
+			Balance:        newCard.CurrentBalance,
+			Cvv:            creds.Cvv,
+			Status:         newCard.Status,
+			ExpiryMonth:    newCard.ExpiryMonth,
+			ExpiryYear:     newCard.ExpiryYear,
+			LockedMerchant: newCard.LockedMerchantName,
+		},
+	}
+}
+
+// recordReissueTransfer writes the balance move of a reissue as a fee-free
+// debit on the old card and credit on the new one.
+func recordReissueTransfer(ctx context.Context, txns repositories.TransactionRepository, oldCard, newCard models.Card, balance float64) error {
+	reference := GenerateCardReference("RSS")
+	source := "card_reissue"
+	now := time.Now()
+	debit := &models.Transaction{
+		UserID:               oldCard.UserID,
+		CardID:               oldCard.ID,
+		TransactionReference: reference,
+		Amount:               balance,
+		Currency:             oldCard.Currency,
+		Type:                 "reissue_transfer",
+		Direction:            "debit",
+		Status:               "completed",
+		Source:               &source,
+		TransactionTimestamp: now,
+	}
+	credit := &models.Transaction{
+		UserID:               newCard.UserID,
+		CardID:               newCard.ID,
+		TransactionReference: reference + "-CR",
+		Amount:               balance,
+		Currency:             newCard.Currency,
+		Type:                 "reissue_transfer",
+		Direction:            "credit",
+		Status:               "completed",
+		Source:               &source,
+		TransactionTimestamp: now,
+	}
+	for _, txn := range []*models.Transaction{debit, credit} {
+		if err := txns.CreateTransaction(ctx, txn); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+	}
+	ledgers := []models.BalanceLedger{
+		{CardID: oldCard.ID, TransactionID: debit.ID, EntryType: "Reissue Transfer Out", Amount: balance, FeeCharged: 0, BalanceAfter: 0},
+		{CardID: newCard.ID, TransactionID: credit.ID, EntryType: "Reissue Transfer In", Amount: balance, FeeCharged: 0, BalanceAfter: newCard.CurrentBalance},
+	}
+	for _, ledger := range ledgers {
+		if err := txns.CreateLedger(ctx, ledger); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+	}
+	return nil
+}
+
+// cardHistory returns the transactions of a card together with those of every
+// card it replaced, so a reissued card keeps its history.
+func cardHistory(ctx context.Context, cardrepo repositories.CardRepository, txnrepo repositories.TransactionRepository, card models.Card) ([]models.Transaction, error) {
+	var all []models.Transaction
+	seen := make(map[uuid.UUID]bool)
+	for card.ID != uuid.Nil && !seen[card.ID] {
+		seen[card.ID] = true
+		txns, err := txnrepo.FindCardTransactions(ctx, models.GetCardTransactionsReq{Userid: card.UserID, Cardid: card.ID.String()})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, txns...)
+		if card.ReplacesCardID == nil {
+			break
+		}
+		previous, err := cardrepo.FindCardByID(ctx, models.GetCardReq{UserId: card.UserID, CardId: card.ReplacesCardID.String()})
+		if err != nil {
+			return nil, err
+		}
+		card = previous
+	}
+	return all, nil
+}
+
+// terminateCard terminates a card and, in the same database transaction,
+// moves whatever is left on it to another card or into a pending withdrawal.
+// Cards with authorizations still on hold cannot be terminated.
+func (s *cardService) terminateCard(ctx context.Context, req models.ModifyCardStatusReq) error {
+	data := models.GetCardReq{UserId: req.UserId, CardId: req.CardId}
+	var pending *models.Transaction
+	var terminatedID uuid.UUID
+	err := s.cardrepo.RunInTransaction(ctx, func(cards repositories.CardRepository, txns repositories.TransactionRepository) error {
+		card, err := cards.FindCardForUpdate(ctx, data)
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if card.ID == uuid.Nil {
+			return errors.New("card not found")
+		}
+		terminatedID = card.ID
+		if card.HeldBalance > 0 {
+			return errors.New("card has pending authorizations, please try again once they settle")
+		}
+		if card.OrganizationID != nil && card.CurrentBalance > 0 {
+			return errors.New("card has a remaining balance, ask an organization admin to return it before terminating")
+		}
+		if card.CurrentBalance > 0 {
+			key := "terminate-" + card.ID.String()
+			switch {
+			case req.TransferToCardId != "":
+				_, err = transferFunds(ctx, cards, txns, models.TransferReq{
+					Userid:         req.UserId,
+					FromCardid:     req.CardId,
+					ToCardid:       req.TransferToCardId,
+					Amount:         card.CurrentBalance,
+					IdempotencyKey: key,
+					ClosingCard:    true,
+				})
+			case req.Payout != nil:
+				var txn models.Transaction
+				txn, err = debitForWithdrawal(ctx, cards, txns, models.WithdrawReq{
+					Userid:         req.UserId,
+					Cardid:         req.CardId,
+					Amount:         card.CurrentBalance,
+					IdempotencyKey: key,
+					Destination:    *req.Payout,
+					ClosingCard:    true,
+				})
+				pending = &txn
+			default:
+				return errors.New("card has a remaining balance, transfer it to another card or withdraw it before terminating")
+			}
+			if err != nil {
+				return err
+			}
+			// the balance move saved the card, reload it before changing the status
+			if card, err = cards.FindCardForUpdate(ctx, data); err != nil {
+				return errors.New("something went wrong, please try again later")
+			}
+		}
+		card.Status = "terminated"
+		if err := cards.Update(ctx, card); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.cascadeTokenStatus(ctx, terminatedID, "terminated")
+	if pending != nil {
+		if _, err := s.disburseWithdrawal(ctx, *pending, *req.Payout); err != nil {
+			return errors.New("card terminated but the withdrawal failed, the balance can still be withdrawn from the card")
+		}
 	}
-	
 	return nil
 }
 
@@ -270,6 +686,14 @@ func (s *cardService) TopUpCard(ctx context.Context, data models.TopUpCardReq)(a
 			return nil, errors.New("card has expired")
 		case "terminated":
 			return nil, errors.New("card is already terminated")		
+		case "blocked":
+			return nil, errors.New("card was reported lost or compromised, reissue it to get a new card")
+	}
+	if card.OrganizationID != nil {
+		return nil, ErrBusinessCard
+	}
+	if err := s.checkTopUpLimits(ctx, data.Userid, data.Amount); err != nil {
+		return nil, err
 	}
 	fee := data.Amount * 0.01
 	newAmount := data.Amount - fee 
@@ -331,3 +755,119 @@ func (s *cardService) TopUpCard(ctx context.Context, data models.TopUpCardReq)(a
 	
 	return nil, nil
 }
+
+
+// CheckMerchantLock enforces the merchant binding of a merchant-locked card on
+// an authorization. The first authorization binds the card to its merchant
+// (and to its amount when a tolerance was requested without an amount); every
+// later authorization must come from that merchant and stay within tolerance.
+// It reports whether the card was modified and needs to be saved.
+func CheckMerchantLock(card *models.Card, merchantName string, amount float64) (bool, error) {
+	if card.CardType != CardTypeMerchantLocked {
+		return false, nil
+	}
+	name := strings.TrimSpace(merchantName)
+	if name == "" {
+		return false, errors.New("merchant is required for merchant-locked cards")
+	}
+	bound := false
+	if card.LockedMerchantName == nil {
+		card.LockedMerchantName = &name
+		bound = true
+	} else if normalizeMerchant(*card.LockedMerchantName) != normalizeMerchant(name) {
+		return false, errors.New("card is locked to a different merchant")
+	}
+	if card.RecurringTolerance == nil {
+		return bound, nil
+	}
+	if card.RecurringAmount == nil {
+		card.RecurringAmount = &amount
+		return true, nil
+	}
+	expected := *card.RecurringAmount
+	if math.Abs(amount-expected) > expected*(*card.RecurringTolerance)/100 {
+		return false, errors.New("amount outside recurring tolerance")
+	}
+	return bound, nil
+}
+
+func normalizeMerchant(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// DetectRecurringCharge looks for a merchant that charges the card at a steady
+// interval and predicts its next charge. At least two settled charges from the
+// same merchant are needed, and every gap between them has to sit close to the
+// median gap, otherwise nil is returned.
+func DetectRecurringCharge(txns []models.Transaction) *models.NextExpectedCharge {
+	byMerchant := make(map[string][]models.Transaction)
+	for _, txn := range txns {
+		if txn.Type != "capture" || txn.Status != "completed" || txn.MerchantName == nil {
+			continue
+		}
+		key := normalizeMerchant(*txn.MerchantName)
+		if key == "" {
+			continue
+		}
+		byMerchant[key] = append(byMerchant[key], txn)
+	}
+
+	var best *models.NextExpectedCharge
+	bestCount := 0
+	for _, charges := range byMerchant {
+		if len(charges) < 2 || len(charges) < bestCount {
+			continue
+		}
+		sort.Slice(charges, func(i, j int) bool {
+			return charges[i].TransactionTimestamp.Before(charges[j].TransactionTimestamp)
+		})
+		gaps := make([]float64, 0, len(charges)-1)
+		for i := 1; i < len(charges); i++ {
+			gaps = append(gaps, charges[i].TransactionTimestamp.Sub(charges[i-1].TransactionTimestamp).Hours()/24)
+		}
+		interval := median(gaps)
+		if interval < 1 {
+			continue
+		}
+		slack := math.Max(2, interval*0.1)
+		steady := true
+		for _, gap := range gaps {
+			if math.Abs(gap-interval) > slack {
+				steady = false
+				break
+			}
+		}
+		if !steady {
+			continue
+		}
+		last := charges[len(charges)-1]
+		amount := last.CapturedAmount
+		if amount == 0 {
+			amount = last.Amount
+		}
+		days := int(math.Round(interval))
+		candidate := &models.NextExpectedCharge{
+			MerchantName: *last.MerchantName,
+			Amount:       amount,
+			Currency:     last.Currency,
+			IntervalDays: days,
+			ExpectedAt:   last.TransactionTimestamp.AddDate(0, 0, days),
+		}
+		// with equally long histories the charge that is due first wins
+		if best == nil || len(charges) > bestCount || candidate.ExpectedAt.Before(best.ExpectedAt) {
+			best = candidate
+			bestCount = len(charges)
+		}
+	}
+	return best
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
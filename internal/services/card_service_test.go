@@ -0,0 +1,247 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func capture(merchant string, amount float64, at time.Time) models.Transaction {
+	return models.Transaction{
+		Type:                 "capture",
+		Status:               "completed",
+		MerchantName:         &merchant,
+		Amount:               amount,
+		CapturedAmount:       amount,
+		Currency:             "USD",
+		TransactionTimestamp: at,
+	}
+}
+
+func TestDetectRecurringCharge_Monthly(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	txns := []models.Transaction{
+		capture("Netflix", 15.99, start.AddDate(0, 2, 0)),
+		capture("Netflix", 15.99, start),
+		capture("netflix ", 15.99, start.AddDate(0, 1, 0)),
+		capture("Coffee Shop", 4.50, start.AddDate(0, 0, 3)),
+	}
+
+	next := DetectRecurringCharge(txns)
+	if next == nil {
+		t.Fatalf("expected a recurring charge, got nil")
+	}
+	if next.IntervalDays < 28 || next.IntervalDays > 31 {
+		t.Fatalf("expected a monthly interval, got %d days", next.IntervalDays)
+	}
+	if !next.ExpectedAt.After(start.AddDate(0, 2, 0)) {
+		t.Fatalf("expected next charge after the last one, got %v", next.ExpectedAt)
+	}
+}
+
+func TestDetectRecurringCharge_Irregular(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	txns := []models.Transaction{
+		capture("Store", 10, start),
+		capture("Store", 10, start.AddDate(0, 0, 2)),
+		capture("Store", 10, start.AddDate(0, 0, 40)),
+	}
+
+	if next := DetectRecurringCharge(txns); next != nil {
+		t.Fatalf("expected no recurring charge, got %+v", next)
+	}
+}
+
+func TestCheckMerchantLock(t *testing.T) {
+	tolerance := 10.0
+	card := &models.Card{CardType: CardTypeMerchantLocked, RecurringTolerance: &tolerance}
+
+	changed, err := CheckMerchantLock(card, "Spotify", 9.99)
+	if err != nil || !changed {
+		t.Fatalf("expected first authorization to bind the card, got changed=%v err=%v", changed, err)
+	}
+	if _, err := CheckMerchantLock(card, " spotify", 10.50); err != nil {
+		t.Fatalf("expected same merchant within tolerance to pass, got %v", err)
+	}
+	if _, err := CheckMerchantLock(card, "Spotify", 15); err == nil {
+		t.Fatalf("expected amount outside tolerance to be declined")
+	}
+	if _, err := CheckMerchantLock(card, "Apple", 9.99); err == nil {
+		t.Fatalf("expected a different merchant to be declined")
+	}
+}
+
+func TestValidatePin(t *testing.T) {
+	for _, pin := range []string{"4821", "903715"} {
+		if err := validatePin(pin); err != nil {
+			t.Fatalf("expected %q to be accepted, got %v", pin, err)
+		}
+	}
+	for _, pin := range []string{"123", "1234567", "12a4", "0000", "1234", "9876"} {
+		if err := validatePin(pin); err == nil {
+			t.Fatalf("expected %q to be rejected", pin)
+		}
+	}
+}
+
+func TestCheckPartnerCardStatusChange(t *testing.T) {
+	partner := uuid.New()
+	payout := &models.PayoutDestination{BankCode: "058", AccountNumber: "0123456789", AccountName: "Ada Obi"}
+	cases := []struct {
+		name   string
+		req    models.ModifyCardStatusReq
+		status string
+		ok     bool
+	}{
+		{"cardholder payout", models.ModifyCardStatusReq{Payout: payout}, "terminate", true},
+		{"cardholder transfer", models.ModifyCardStatusReq{TransferToCardId: uuid.NewString()}, "terminate", true},
+		{"partner freeze", models.ModifyCardStatusReq{PartnerId: &partner}, "freeze", true},
+		{"partner unfreeze", models.ModifyCardStatusReq{PartnerId: &partner}, "unfreeze", true},
+		{"partner terminate", models.ModifyCardStatusReq{PartnerId: &partner}, "terminate", true},
+		{"partner payout", models.ModifyCardStatusReq{PartnerId: &partner, Payout: payout}, "terminate", false},
+		{"partner transfer", models.ModifyCardStatusReq{PartnerId: &partner, TransferToCardId: uuid.NewString()}, "terminate", false},
+		{"partner other status", models.ModifyCardStatusReq{PartnerId: &partner}, "activate", false},
+	}
+	for _, c := range cases {
+		err := checkPartnerCardStatusChange(c.req, c.status)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: got error %v, want ok %v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestReissueCard(t *testing.T) {
+	key := func(c string) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(c, 32))) }
+	saved := []string{config.IIN, config.EncryptionKey, config.PanFingerprintKey, config.CvvKey}
+	defer func() {
+		config.IIN, config.EncryptionKey, config.PanFingerprintKey, config.CvvKey = saved[0], saved[1], saved[2], saved[3]
+	}()
+	config.IIN, config.EncryptionKey, config.PanFingerprintKey, config.CvvKey = "12345", key("e"), key("f"), key("c")
+
+	ctx := context.Background()
+	m := newMoneyTest()
+	merchant := "Netflix"
+	old := m.card("frozen", 75)
+	old.CardType, old.SpendingLimitAmount, old.LockedMerchantName, old.LastFour = "multi-use", 500, &merchant, "1111"
+	m.cards.cards[old.ID] = old
+
+	res, err := m.svc.ReissueCard(ctx, models.GetCardReq{UserId: m.userID, CardId: old.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaced, issued := m.cards.cards[old.ID], m.cards.cards[res.Cardid]
+	if replaced.Status != "terminated" || replaced.CurrentBalance != 0 || replaced.ReplacedByCardID == nil || *replaced.ReplacedByCardID != issued.ID {
+		t.Fatalf("expected the old card terminated, emptied and linked, got %+v", replaced)
+	}
+	if issued.Status != "active" || issued.CurrentBalance != 75 || issued.ReplacesCardID == nil || *issued.ReplacesCardID != old.ID {
+		t.Fatalf("expected an active replacement holding the balance, got %+v", issued)
+	}
+	if issued.SpendingLimitAmount != 500 || issued.LockedMerchantName == nil || *issued.LockedMerchantName != merchant || issued.CardType != "multi-use" {
+		t.Fatalf("expected the controls to move to the new card, got %+v", issued)
+	}
+	if issued.PANencrypted == "" || issued.PANFingerprint == nil || issued.LastFour == "" || res.ReplacedCardid != old.ID {
+		t.Fatalf("expected the replacement to get its own card number")
+	}
+	if len(m.txns.txns) != 2 || len(m.txns.ledgers) != 2 {
+		t.Fatalf("expected the balance move to be recorded on both cards, got %d transactions and %d ledger entries", len(m.txns.txns), len(m.txns.ledgers))
+	}
+
+	held := m.card("active", 20)
+	held.CardType, held.HeldBalance = "multi-use", 5
+	m.cards.cards[held.ID] = held
+	caseFrozen := m.card("frozen", 20)
+	m.cards.fraudFrozen[caseFrozen.ID] = true
+	for name, cardID := range map[string]string{
+		"already terminated":     old.ID.String(),
+		"pending authorizations": held.ID.String(),
+		"unknown card":           uuid.NewString(),
+		"suspended by the org":   m.card("suspended", 20).ID.String(),
+		"PIN-locked":             m.card("locked", 20).ID.String(),
+		"frozen by a fraud case": caseFrozen.ID.String(),
+	} {
+		if _, err := m.svc.ReissueCard(ctx, models.GetCardReq{UserId: m.userID, CardId: cardID}); err == nil {
+			t.Errorf("%s: expected the reissue to be rejected", name)
+		}
+	}
+	if m.cards.cards[held.ID].Status != "active" || len(m.cards.cards) != 6 {
+		t.Fatalf("a rejected reissue must leave the card as it was and issue nothing")
+	}
+}
+
+type memoryPinRepo struct {
+	pins map[uuid.UUID]*models.CardPin
+}
+
+func (m *memoryPinRepo) FindByCardID(ctx context.Context, cardID uuid.UUID) (*models.CardPin, error) {
+	return m.pins[cardID], nil
+}
+
+func (m *memoryPinRepo) Save(ctx context.Context, pin *models.CardPin) error {
+	m.pins[pin.CardID] = pin
+	return nil
+}
+
+func TestPinLockKeepsStrongerStatus(t *testing.T) {
+	ctx := context.Background()
+	m := newMoneyTest()
+	pins := &memoryPinRepo{pins: map[uuid.UUID]*models.CardPin{}}
+	m.svc.pinrepo = pins
+
+	for _, status := range []string{"frozen", "suspended", "blocked"} {
+		card := m.card(status, 10)
+		if _, err := m.svc.pinnableCard(ctx, models.CardPinReq{UserId: m.userID, CardId: card.ID.String(), Pin: "4829"}); err == nil {
+			t.Errorf("%s: expected PIN changes to be refused", status)
+		}
+		record := &models.CardPin{CardID: card.ID}
+		for i := 0; i < MaxPinAttempts; i++ {
+			if err := recordPinResult(ctx, m.cards, pins, &card, record, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if record.LockedAt == nil || m.cards.cards[card.ID].Status != status {
+			t.Errorf("%s: expected the PIN locked and the card left %s, got %s", status, status, m.cards.cards[card.ID].Status)
+		}
+	}
+
+	card := m.card("active", 10)
+	record := &models.CardPin{CardID: card.ID}
+	for i := 0; i < MaxPinAttempts; i++ {
+		if err := recordPinResult(ctx, m.cards, pins, &card, record, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.cards.cards[card.ID].Status != "locked" {
+		t.Fatalf("expected an active card to lock, got %s", m.cards.cards[card.ID].Status)
+	}
+	if _, err := m.svc.pinnableCard(ctx, models.CardPinReq{UserId: m.userID, CardId: card.ID.String(), Pin: "4829"}); err != nil {
+		t.Fatalf("expected a PIN-locked card to accept a new PIN, got %v", err)
+	}
+}
+
+func TestFraudFreezeHoldsWhileCaseIsOpen(t *testing.T) {
+	ctx := context.Background()
+	m := newMoneyTest()
+	card := m.card("frozen", 10)
+	req := models.ModifyCardStatusReq{UserId: m.userID, CardId: card.ID.String()}
+
+	m.cards.fraudFrozen[card.ID] = true
+	if err := m.svc.ModifyCardStatus(ctx, req, "unfreeze"); err == nil || m.cards.cards[card.ID].Status != "frozen" {
+		t.Fatalf("expected the unfreeze to be refused while the case is open, got %v", err)
+	}
+	partnerID := uuid.New()
+	req.PartnerId = &partnerID
+	if err := m.svc.ModifyCardStatus(ctx, req, "unfreeze"); err == nil {
+		t.Fatalf("expected a partner key not to lift a fraud freeze either")
+	}
+
+	m.cards.fraudFrozen[card.ID] = false
+	if err := m.svc.ModifyCardStatus(ctx, req, "unfreeze"); err != nil || m.cards.cards[card.ID].Status != "active" {
+		t.Fatalf("expected the card to unfreeze once the case is resolved, got %v", err)
+	}
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxTokensPerCard caps how many wallets and devices one card can live on.
+const MaxTokensPerCard = 5
+
+// newCardVerificationWindow is how long after issuance a card needs step-up
+// verification before it can be added to a wallet.
+const newCardVerificationWindow = 24 * time.Hour
+
+var supportedWallets = map[string]bool{
+	"apple_pay":   true,
+	"google_pay":  true,
+	"samsung_pay": true,
+}
+
+// Provisioning decisions, in the green/yellow/red sense the networks use.
+const (
+	ProvisionApproved            = "approved"
+	ProvisionDeclined            = "declined"
+	ProvisionRequireVerification = "require_verification"
+)
+
+// ProvisioningDecision decides whether a card may be tokenized onto a device.
+// It returns the decision and, unless approved, the reason for it.
+func ProvisioningDecision(card models.Card, kycVerified bool, tokens []models.NetworkToken, req models.ProvisionTokenReq, now time.Time) (string, string) {
+	if !supportedWallets[req.Wallet] {
+		return ProvisionDeclined, "unsupported_wallet"
+	}
+	if card.Status != "active" {
+		return ProvisionDeclined, "card_not_active"
+	}
+	if card.CardType == "single-use" {
+		return ProvisionDeclined, "card_type_not_eligible"
+	}
+	if !card.ExpiresAt.IsZero() && card.ExpiresAt.Before(now) {
+		return ProvisionDeclined, "card_expired"
+	}
+	if !kycVerified {
+		return ProvisionDeclined, "kyc_not_verified"
+	}
+	live := 0
+	for _, t := range tokens {
+		if t.Status == "deleted" {
+			continue
+		}
+		if t.Wallet == req.Wallet && t.DeviceID == req.DeviceId {
+			return ProvisionDeclined, "already_provisioned"
+		}
+		live++
+	}
+	if live >= MaxTokensPerCard {
+		return ProvisionDeclined, "token_limit_reached"
+	}
+	if now.Sub(card.CreatedAt) < newCardVerificationWindow {
+		return ProvisionRequireVerification, "new_card"
+	}
+	return ProvisionApproved, ""
+}
+
+// ProvisionToken pushes a card into a mobile wallet. Declines are returned as
+// a decision rather than an error so the app can explain them; cards that
+// need verification are approved once the request carries a step-up code.
+func (s *cardService) ProvisionToken(ctx context.Context, data models.ProvisionTokenReq) (models.ProvisionTokenResp, error) {
+	data.Wallet = strings.ToLower(strings.TrimSpace(data.Wallet))
+	card, err := s.cardrepo.FindCardByID(ctx, models.GetCardReq{UserId: data.UserId, CardId: data.CardId})
+	if err != nil {
+		return models.ProvisionTokenResp{}, errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return models.ProvisionTokenResp{}, errors.New("card not found")
+	}
+	kyc, err := s.kycrepo.FindApprovedByUserID(ctx, data.UserId)
+	if err != nil {
+		return models.ProvisionTokenResp{}, errors.New("something went wrong, please try again later")
+	}
+	tokens, err := s.tokenrepo.FindByCardID(ctx, card.ID)
+	if err != nil {
+		return models.ProvisionTokenResp{}, errors.New("something went wrong, please try again later")
+	}
+
+	decision, reason := ProvisioningDecision(card, kyc != nil && kyc.Status == KycVerified && !KycRestricted(kyc, time.Now()), tokens, data, time.Now())
+	if decision == ProvisionRequireVerification && (data.TotpCode != "" || data.Otp != "") {
+		if err := verifyStepUp(ctx, s.userrepo, data.UserId, data.TotpCode, data.Otp); err != nil {
+			return models.ProvisionTokenResp{}, err
+		}
+		decision, reason = ProvisionApproved, ""
+	}
+	if decision != ProvisionApproved {
+		meta := map[string]any{"wallet": data.Wallet, "decision": decision, "reason": reason}
+		if err := recordAudit(ctx, s.auditrepo, data.UserId, "card.token_provision_declined", "card", card.ID, data.Client, meta); err != nil {
+			log.Printf("failed to audit token decision for card %s: %v", card.ID, err)
+		}
+		return models.ProvisionTokenResp{Decision: decision, Reason: reason}, nil
+	}
+
+	pan, err := utils.DecryptWithKeyring(card.PANencrypted)
+	if err != nil {
+		return models.ProvisionTokenResp{}, errors.New("something went wrong, please try again later")
+	}
+	result, err := s.tsp.ProvisionToken(ctx, integrations.TokenRequest{
+		PAN:         pan,
+		ExpiryMonth: card.ExpiryMonth,
+		ExpiryYear:  card.ExpiryYear,
+		Wallet:      data.Wallet,
+		DeviceID:    data.DeviceId,
+		DeviceName:  data.DeviceName,
+	})
+	if err != nil {
+		log.Printf("token provisioning failed for card %s: %v", card.ID, err)
+		return models.ProvisionTokenResp{}, errors.New("could not add card to wallet, please try again later")
+	}
+
+	token := &models.NetworkToken{
+		CardID:         card.ID,
+		TokenReference: result.TokenReference,
+		TokenLastFour:  result.TokenLastFour,
+		Wallet:         data.Wallet,
+		DeviceID:       data.DeviceId,
+		DeviceName:     data.DeviceName,
+		Status:         "active",
+	}
+	if err := s.tokenrepo.Create(ctx, token); err != nil {
+		// the network holds a token we have no record of, so take it back down
+		if tspErr := s.tsp.UpdateTokenStatus(ctx, result.TokenReference, "deleted"); tspErr != nil {
+			log.Printf("failed to delete orphaned token for card %s: %v", card.ID, tspErr)
+		}
+		return models.ProvisionTokenResp{}, errors.New("something went wrong, please try again later")
+	}
+	meta := map[string]any{"wallet": data.Wallet, "token_id": token.ID}
+	if err := recordAudit(ctx, s.auditrepo, data.UserId, "card.token_provisioned", "card", card.ID, data.Client, meta); err != nil {
+		log.Printf("failed to audit token provisioning for card %s: %v", card.ID, err)
+	}
+
+	res := tokenResp(*token)
+	return models.ProvisionTokenResp{Decision: ProvisionApproved, Token: &res}, nil
+}
+
+func (s *cardService) GetCardTokens(ctx context.Context, data models.GetCardReq) ([]models.NetworkTokenResp, error) {
+	card, err := s.cardrepo.FindCardByID(ctx, data)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return nil, errors.New("card not found")
+	}
+	tokens, err := s.tokenrepo.FindByCardID(ctx, card.ID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.NetworkTokenResp, 0, len(tokens))
+	for _, t := range tokens {
+		res = append(res, tokenResp(t))
+	}
+	return res, nil
+}
+
+// ModifyToken lets a user suspend, resume or delete one wallet token without
+// touching the card or its other tokens.
+func (s *cardService) ModifyToken(ctx context.Context, data models.ModifyTokenReq) (models.NetworkTokenResp, error) {
+	card, err := s.cardrepo.FindCardByID(ctx, models.GetCardReq{UserId: data.UserId, CardId: data.CardId})
+	if err != nil {
+		return models.NetworkTokenResp{}, errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return models.NetworkTokenResp{}, errors.New("card not found")
+	}
+	tokenID, err := uuid.Parse(data.TokenId)
+	if err != nil {
+		return models.NetworkTokenResp{}, errors.New("token not found")
+	}
+	token, err := s.tokenrepo.FindByID(ctx, tokenID)
+	if err != nil {
+		return models.NetworkTokenResp{}, errors.New("something went wrong, please try again later")
+	}
+	if token == nil || token.CardID != card.ID {
+		return models.NetworkTokenResp{}, errors.New("token not found")
+	}
+
+	var status string
+	switch data.Action {
+	case "suspend":
+		if token.Status != "active" {
+			return models.NetworkTokenResp{}, errors.New("token is not active")
+		}
+		status = "suspended"
+	case "resume":
+		if token.Status != "suspended" {
+			return models.NetworkTokenResp{}, errors.New("token is not suspended")
+		}
+		if card.Status != "active" {
+			return models.NetworkTokenResp{}, errors.New("card is not active")
+		}
+		status = "active"
+	case "delete":
+		if token.Status == "deleted" {
+			return models.NetworkTokenResp{}, errors.New("token is already deleted")
+		}
+		status = "deleted"
+	default:
+		return models.NetworkTokenResp{}, errors.New("invalid token action")
+	}
+
+	if err := s.tsp.UpdateTokenStatus(ctx, token.TokenReference, status); err != nil {
+		log.Printf("token status update failed for token %s: %v", token.ID, err)
+		return models.NetworkTokenResp{}, errors.New("could not update token, please try again later")
+	}
+	token.Status = status
+	token.StatusReason = "user"
+	if err := s.tokenrepo.Update(ctx, token); err != nil {
+		return models.NetworkTokenResp{}, errors.New("something went wrong, please try again later")
+	}
+	meta := map[string]any{"token_id": token.ID, "status": status}
+	if err := recordAudit(ctx, s.auditrepo, data.UserId, "card.token_"+data.Action, "card", card.ID, data.Client, meta); err != nil {
+		log.Printf("failed to audit token change for card %s: %v", card.ID, err)
+	}
+	return tokenResp(*token), nil
+}
+
+// TokenLifecycleWebhook applies token state changes made on the network
+// side, such as a user removing the card from their wallet app.
+func (s *cardService) TokenLifecycleWebhook(ctx context.Context, data models.TokenLifecycleWebhookReq) error {
+	token, err := s.tokenrepo.FindByReference(ctx, data.TokenReference)
+	if err != nil {
+		return errors.New("something went wrong")
+	}
+	if token == nil {
+		return errors.New("token not found")
+	}
+	if token.Status == "deleted" {
+		return nil
+	}
+
+	var status string
+	switch data.Event {
+	case "token.suspended":
+		status = "suspended"
+	case "token.resumed":
+		card, err := s.cardrepo.FindCardByUUID(ctx, token.CardID)
+		if err != nil {
+			return errors.New("something went wrong")
+		}
+		if card.Status != "active" {
+			return errors.New("card is not active")
+		}
+		status = "active"
+	case "token.deleted":
+		status = "deleted"
+	default:
+		return errors.New("unsupported event")
+	}
+	token.Status = status
+	token.StatusReason = "network"
+	if err := s.tokenrepo.Update(ctx, token); err != nil {
+		return errors.New("something went wrong")
+	}
+	return nil
+}
+
+// CardTokenSyncer brings a card's wallet tokens in line with a status the
+// card was moved to outside CardService, such as a PIN lock at a terminal or
+// an organization suspending a removed member's cards.
+type CardTokenSyncer interface {
+	SyncCardTokens(ctx context.Context, cardID uuid.UUID, cardStatus string)
+}
+
+func (s *cardService) SyncCardTokens(ctx context.Context, cardID uuid.UUID, cardStatus string) {
+	s.cascadeTokenStatus(ctx, cardID, cardStatus)
+}
+
+// cascadeTokenStatus brings a card's tokens in line with a card status
+// change. Freezing, locking and suspending suspend live tokens, and a card
+// becoming active again resumes only the ones a freeze or lock suspended;
+// terminating deletes them all. The card status is already committed, and
+// authorizations check it, so network failures are logged rather than
+// returned.
+func (s *cardService) cascadeTokenStatus(ctx context.Context, cardID uuid.UUID, cardStatus string) {
+	tokens, err := s.tokenrepo.FindByCardID(ctx, cardID)
+	if err != nil {
+		log.Printf("failed to load tokens for card %s: %v", cardID, err)
+		return
+	}
+	for i := range tokens {
+		token := &tokens[i]
+		var status, reason string
+		switch {
+		case cardStatus == "frozen" && token.Status == "active":
+			status, reason = "suspended", "card_frozen"
+		case cardStatus == "locked" && token.Status == "active":
+			status, reason = "suspended", "card_locked"
+		case cardStatus == "suspended" && token.Status == "active":
+			status, reason = "suspended", "card_suspended"
+		case cardStatus == "active" && token.Status == "suspended" && (token.StatusReason == "card_frozen" || token.StatusReason == "card_locked"):
+			status, reason = "active", ""
+		case cardStatus == "terminated" && token.Status != "deleted":
+			status, reason = "deleted", "card_terminated"
+		case cardStatus == "blocked" && token.Status != "deleted":
+			status, reason = "deleted", "card_blocked"
+		default:
+			continue
+		}
+		if err := s.tsp.UpdateTokenStatus(ctx, token.TokenReference, status); err != nil {
+			log.Printf("token status update failed for token %s: %v", token.ID, err)
+		}
+		token.Status = status
+		token.StatusReason = reason
+		if err := s.tokenrepo.Update(ctx, token); err != nil {
+			log.Printf("failed to update token %s: %v", token.ID, err)
+		}
+	}
+}
+
+func tokenResp(t models.NetworkToken) models.NetworkTokenResp {
+	return models.NetworkTokenResp{
+		Tokenid:       t.ID,
+		Cardid:        t.CardID,
+		TokenLastFour: t.TokenLastFour,
+		Wallet:        t.Wallet,
+		DeviceName:    t.DeviceName,
+		Status:        t.Status,
+		CreatedAt:     t.CreatedAt,
+	}
+}
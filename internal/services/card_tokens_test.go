@@ -0,0 +1,159 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryTokenRepo struct {
+	tokens map[uuid.UUID]*models.NetworkToken
+}
+
+func (m *memoryTokenRepo) Create(ctx context.Context, token *models.NetworkToken) error {
+	token.ID = uuid.New()
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *memoryTokenRepo) Update(ctx context.Context, token *models.NetworkToken) error {
+	copied := *token
+	m.tokens[token.ID] = &copied
+	return nil
+}
+
+func (m *memoryTokenRepo) FindByID(ctx context.Context, id uuid.UUID) (*models.NetworkToken, error) {
+	return m.tokens[id], nil
+}
+
+func (m *memoryTokenRepo) FindByReference(ctx context.Context, reference string) (*models.NetworkToken, error) {
+	for _, t := range m.tokens {
+		if t.TokenReference == reference {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryTokenRepo) FindByCardID(ctx context.Context, cardID uuid.UUID) ([]models.NetworkToken, error) {
+	var out []models.NetworkToken
+	for _, t := range m.tokens {
+		if t.CardID == cardID {
+			out = append(out, *t)
+		}
+	}
+	return out, nil
+}
+
+func TestProvisioningDecision(t *testing.T) {
+	now := time.Now()
+	card := models.Card{Status: "active", CardType: "multi-use", CreatedAt: now.Add(-72 * time.Hour), ExpiresAt: now.AddDate(3, 0, 0)}
+	req := models.ProvisionTokenReq{Wallet: "apple_pay", DeviceId: "device-1"}
+
+	if d, _ := ProvisioningDecision(card, true, nil, req, now); d != ProvisionApproved {
+		t.Fatalf("expected approval, got %s", d)
+	}
+	if d, r := ProvisioningDecision(card, false, nil, req, now); d != ProvisionDeclined || r != "kyc_not_verified" {
+		t.Fatalf("expected kyc decline, got %s %s", d, r)
+	}
+	existing := []models.NetworkToken{{Wallet: "apple_pay", DeviceID: "device-1", Status: "active"}}
+	if d, r := ProvisioningDecision(card, true, existing, req, now); d != ProvisionDeclined || r != "already_provisioned" {
+		t.Fatalf("expected duplicate decline, got %s %s", d, r)
+	}
+	existing[0].Status = "deleted"
+	if d, _ := ProvisioningDecision(card, true, existing, req, now); d != ProvisionApproved {
+		t.Fatalf("expected deleted token to allow re-provisioning, got %s", d)
+	}
+	fresh := card
+	fresh.CreatedAt = now.Add(-time.Hour)
+	if d, _ := ProvisioningDecision(fresh, true, nil, req, now); d != ProvisionRequireVerification {
+		t.Fatalf("expected new card to need verification, got %s", d)
+	}
+	single := card
+	single.CardType = "single-use"
+	if d, r := ProvisioningDecision(single, true, nil, req, now); d != ProvisionDeclined || r != "card_type_not_eligible" {
+		t.Fatalf("expected single-use decline, got %s %s", d, r)
+	}
+}
+
+func TestCascadeTokenStatus(t *testing.T) {
+	ctx := context.Background()
+	tsp := integrations.NewTspSimulator()
+	repo := &memoryTokenRepo{tokens: map[uuid.UUID]*models.NetworkToken{}}
+	s := &cardService{tokenrepo: repo, tsp: tsp}
+	cardID := uuid.New()
+
+	var refs []string
+	for _, device := range []string{"phone", "watch"} {
+		res, err := tsp.ProvisionToken(ctx, integrations.TokenRequest{PAN: "4000000000000002", Wallet: "apple_pay", DeviceID: device})
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, res.TokenReference)
+		_ = repo.Create(ctx, &models.NetworkToken{CardID: cardID, TokenReference: res.TokenReference, Wallet: "apple_pay", DeviceID: device, Status: "active"})
+	}
+	// the user suspended the watch token themselves; unfreezing must not resume it
+	watch, _ := repo.FindByReference(ctx, refs[1])
+	watch.Status, watch.StatusReason = "suspended", "user"
+	_ = tsp.UpdateTokenStatus(ctx, refs[1], "suspended")
+
+	s.cascadeTokenStatus(ctx, cardID, "frozen")
+	if tsp.Status(refs[0]) != "suspended" {
+		t.Fatalf("expected freeze to suspend the token on the network")
+	}
+	s.cascadeTokenStatus(ctx, cardID, "active")
+	if tsp.Status(refs[0]) != "active" || tsp.Status(refs[1]) != "suspended" {
+		t.Fatalf("expected unfreeze to resume only freeze-suspended tokens, got %s and %s", tsp.Status(refs[0]), tsp.Status(refs[1]))
+	}
+	s.cascadeTokenStatus(ctx, cardID, "terminated")
+	for _, ref := range refs {
+		token, _ := repo.FindByReference(ctx, ref)
+		if tsp.Status(ref) != "deleted" || token.Status != "deleted" {
+			t.Fatalf("expected terminate to delete token %s", ref)
+		}
+	}
+}
+
+func TestCascadeTokenStatusLockAndSuspend(t *testing.T) {
+	ctx := context.Background()
+	tsp := integrations.NewTspSimulator()
+	repo := &memoryTokenRepo{tokens: map[uuid.UUID]*models.NetworkToken{}}
+	s := &cardService{tokenrepo: repo, tsp: tsp}
+
+	provision := func(cardID uuid.UUID) string {
+		res, err := tsp.ProvisionToken(ctx, integrations.TokenRequest{PAN: "4000000000000002", Wallet: "google_pay", DeviceID: cardID.String()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = repo.Create(ctx, &models.NetworkToken{CardID: cardID, TokenReference: res.TokenReference, Wallet: "google_pay", DeviceID: cardID.String(), Status: "active"})
+		return res.TokenReference
+	}
+
+	// a PIN lock suspends the token and setting a new PIN resumes it
+	locked := uuid.New()
+	ref := provision(locked)
+	s.SyncCardTokens(ctx, locked, "locked")
+	if token, _ := repo.FindByReference(ctx, ref); tsp.Status(ref) != "suspended" || token.StatusReason != "card_locked" {
+		t.Fatalf("expected a PIN lock to suspend the token, got %s", tsp.Status(ref))
+	}
+	s.SyncCardTokens(ctx, locked, "active")
+	if tsp.Status(ref) != "active" {
+		t.Fatalf("expected unlocking to resume the token, got %s", tsp.Status(ref))
+	}
+
+	// a suspended business card's token stays suspended
+	suspended := uuid.New()
+	ref = provision(suspended)
+	s.SyncCardTokens(ctx, suspended, "suspended")
+	if tsp.Status(ref) != "suspended" {
+		t.Fatalf("expected suspending the card to suspend the token, got %s", tsp.Status(ref))
+	}
+	s.SyncCardTokens(ctx, suspended, "active")
+	if tsp.Status(ref) != "suspended" {
+		t.Fatalf("expected only freeze and lock suspensions to be lifted, got %s", tsp.Status(ref))
+	}
+}
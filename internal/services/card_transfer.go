@@ -0,0 +1,414 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TransferFeeRate   = 0.005
+	WithdrawalFeeRate = 0.01
+)
+
+var ErrDuplicateRequest = errors.New("idempotency key already used for a different request")
+
+// transferMeta is stored on the debit leg of a transfer so a replayed request
+// can be answered without recomputing fees or rates.
+type transferMeta struct {
+	ToCardID       string  `json:"to_card_id"`
+	Fee            float64 `json:"fee"`
+	ToCurrency     string  `json:"to_currency"`
+	Rate           float64 `json:"rate"`
+	CreditedAmount float64 `json:"credited_amount"`
+}
+
+type withdrawalMeta struct {
+	Fee           float64 `json:"fee"`
+	PayoutAmount  float64 `json:"payout_amount"`
+	BankCode      string  `json:"bank_code"`
+	AccountNumber string  `json:"account_number"`
+	AccountName   string  `json:"account_name"`
+}
+
+// checkMoveAmount rejects amounts that can't be moved off a card, whichever
+// path the request came in by.
+func checkMoveAmount(amount float64) error {
+	if !(amount > 0) {
+		return errors.New("amount must be greater than zero")
+	}
+	return nil
+}
+
+// checkSourceCard lets money leave only an active card. Terminating a card
+// sweeps its balance whatever state it was in, short of already being gone.
+func checkSourceCard(card models.Card, closing bool) error {
+	switch card.Status {
+	case "active":
+		return nil
+	case "terminated":
+		return errors.New("card is already terminated")
+	case "expired":
+		return errors.New("card has expired")
+	}
+	if closing {
+		return nil
+	}
+	return errors.New("card is not active")
+}
+
+func (s *cardService) TransferBetweenCards(ctx context.Context, data models.TransferReq) (models.TransferResp, error) {
+	if existing, _ := s.Txnrepo.FindByIdempotencyKey(ctx, data.IdempotencyKey); existing.ID != uuid.Nil {
+		return transferRespFromTxn(existing, data)
+	}
+
+	var resp models.TransferResp
+	err := s.cardrepo.RunInTransaction(ctx, func(cards repositories.CardRepository, txns repositories.TransactionRepository) error {
+		var err error
+		resp, err = transferFunds(ctx, cards, txns, data)
+		return err
+	})
+	if err != nil {
+		return models.TransferResp{}, err
+	}
+	return resp, nil
+}
+
+func transferRespFromTxn(txn models.Transaction, data models.TransferReq) (models.TransferResp, error) {
+	var meta transferMeta
+	if txn.UserID != data.Userid || txn.Type != "transfer" || txn.CardID.String() != data.FromCardid {
+		return models.TransferResp{}, ErrDuplicateRequest
+	}
+	if err := json.Unmarshal(txn.MetadataJSON, &meta); err != nil || meta.ToCardID != data.ToCardid {
+		return models.TransferResp{}, ErrDuplicateRequest
+	}
+	return models.TransferResp{
+		Reference:      txn.TransactionReference,
+		FromCardid:     txn.CardID.String(),
+		ToCardid:       meta.ToCardID,
+		Amount:         txn.Amount,
+		Fee:            meta.Fee,
+		FromCurrency:   txn.Currency,
+		ToCurrency:     meta.ToCurrency,
+		Rate:           meta.Rate,
+		CreditedAmount: meta.CreditedAmount,
+	}, nil
+}
+
+// transferFunds moves money between two cards of the same user. The fee is
+// taken from the transferred amount and the rest is converted into the
+// destination card's currency. It must run inside RunInTransaction.
+func transferFunds(ctx context.Context, cards repositories.CardRepository, txns repositories.TransactionRepository, data models.TransferReq) (models.TransferResp, error) {
+	if err := checkMoveAmount(data.Amount); err != nil {
+		return models.TransferResp{}, err
+	}
+	if data.FromCardid == data.ToCardid {
+		return models.TransferResp{}, errors.New("cannot transfer to the same card")
+	}
+	// lock both rows in a fixed order so concurrent transfers cannot deadlock
+	first, second := data.FromCardid, data.ToCardid
+	if second < first {
+		first, second = second, first
+	}
+	locked := make(map[string]models.Card, 2)
+	for _, id := range []string{first, second} {
+		card, err := cards.FindCardForUpdate(ctx, models.GetCardReq{UserId: data.Userid, CardId: id})
+		if err != nil {
+			return models.TransferResp{}, errors.New("something went wrong, please try again later")
+		}
+		if card.ID == uuid.Nil {
+			return models.TransferResp{}, errors.New("card not found")
+		}
+		locked[id] = card
+	}
+	from, to := locked[data.FromCardid], locked[data.ToCardid]
+	if from.OrganizationID != nil || to.OrganizationID != nil {
+		return models.TransferResp{}, ErrBusinessCard
+	}
+	if err := checkSourceCard(from, data.ClosingCard); err != nil {
+		return models.TransferResp{}, err
+	}
+
+	if to.Status != "active" {
+		return models.TransferResp{}, errors.New("destination card is not active")
+	}
+	if from.CurrentBalance-from.HeldBalance < data.Amount {
+		return models.TransferResp{}, errors.New("insufficient available balance")
+	}
+
+	fee := utils.RoundAmount(data.Amount * TransferFeeRate)
+	credited, rate, err := utils.ConvertAmount(data.Amount-fee, from.Currency, to.Currency)
+	if err != nil {
+		return models.TransferResp{}, errors.New("currency conversion is not available for these cards")
+	}
+
+	from.CurrentBalance = utils.RoundAmount(from.CurrentBalance - data.Amount)
+	to.CurrentBalance = utils.RoundAmount(to.CurrentBalance + credited)
+	if err := cards.Update(ctx, from); err != nil {
+		return models.TransferResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := cards.Update(ctx, to); err != nil {
+		return models.TransferResp{}, errors.New("something went wrong, please try again later")
+	}
+
+	reference := GenerateCardReference("TRF")
+	meta, _ := json.Marshal(transferMeta{
+		ToCardID:       to.ID.String(),
+		Fee:            fee,
+		ToCurrency:     to.Currency,
+		Rate:           rate,
+		CreditedAmount: credited,
+	})
+	source := "internal_transfer"
+	now := time.Now()
+	creditKey := data.IdempotencyKey + ":credit"
+	debit := &models.Transaction{
+		UserID:               from.UserID,
+		CardID:               from.ID,
+		TransactionReference: reference,
+		IdempotencyKey:       &data.IdempotencyKey,
+		Amount:               data.Amount,
+		Currency:             from.Currency,
+		Type:                 "transfer",
+		Direction:            "debit",
+		Status:               "completed",
+		Source:               &source,
+		MetadataJSON:         meta,
+		TransactionTimestamp: now,
+	}
+	credit := &models.Transaction{
+		UserID:               to.UserID,
+		CardID:               to.ID,
+		TransactionReference: reference + "-CR",
+		IdempotencyKey:       &creditKey,
+		Amount:               credited,
+		Currency:             to.Currency,
+		Type:                 "transfer",
+		Direction:            "credit",
+		Status:               "completed",
+		Source:               &source,
+		MetadataJSON:         meta,
+		TransactionTimestamp: now,
+	}
+	for _, txn := range []*models.Transaction{debit, credit} {
+		if err := txns.CreateTransaction(ctx, txn); err != nil {
+			return models.TransferResp{}, errors.New("something went wrong, please try again later")
+		}
+	}
+	ledgers := []models.BalanceLedger{
+		{CardID: from.ID, TransactionID: debit.ID, EntryType: "Transfer Out", Amount: data.Amount, FeeCharged: fee, BalanceAfter: from.CurrentBalance},
+		{CardID: to.ID, TransactionID: credit.ID, EntryType: "Transfer In", Amount: credited, FeeCharged: 0, BalanceAfter: to.CurrentBalance},
+	}
+	for _, ledger := range ledgers {
+		if err := txns.CreateLedger(ctx, ledger); err != nil {
+			return models.TransferResp{}, errors.New("something went wrong, please try again later")
+		}
+	}
+
+	return models.TransferResp{
+		Reference:      reference,
+		FromCardid:     from.ID.String(),
+		ToCardid:       to.ID.String(),
+		Amount:         data.Amount,
+		Fee:            fee,
+		FromCurrency:   from.Currency,
+		ToCurrency:     to.Currency,
+		Rate:           rate,
+		CreditedAmount: credited,
+	}, nil
+}
+
+func (s *cardService) WithdrawCardBalance(ctx context.Context, data models.WithdrawReq) (models.WithdrawResp, error) {
+	if existing, _ := s.Txnrepo.FindByIdempotencyKey(ctx, data.IdempotencyKey); existing.ID != uuid.Nil {
+		if existing.UserID != data.Userid || existing.Type != "withdrawal" || existing.CardID.String() != data.Cardid {
+			return models.WithdrawResp{}, ErrDuplicateRequest
+		}
+		return withdrawRespFromTxn(existing), nil
+	}
+
+	var txn models.Transaction
+	err := s.cardrepo.RunInTransaction(ctx, func(cards repositories.CardRepository, txns repositories.TransactionRepository) error {
+		var err error
+		txn, err = debitForWithdrawal(ctx, cards, txns, data)
+		return err
+	})
+	if err != nil {
+		return models.WithdrawResp{}, err
+	}
+	return s.disburseWithdrawal(ctx, txn, data.Destination)
+}
+
+// debitForWithdrawal takes the withdrawn amount off the card and records a
+// pending withdrawal. The payout itself happens after the database commit.
+func debitForWithdrawal(ctx context.Context, cards repositories.CardRepository, txns repositories.TransactionRepository, data models.WithdrawReq) (models.Transaction, error) {
+	if err := checkMoveAmount(data.Amount); err != nil {
+		return models.Transaction{}, err
+	}
+	card, err := cards.FindCardForUpdate(ctx, models.GetCardReq{UserId: data.Userid, CardId: data.Cardid})
+	if err != nil {
+		return models.Transaction{}, errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return models.Transaction{}, errors.New("card not found")
+	}
+	if card.OrganizationID != nil {
+		return models.Transaction{}, ErrBusinessCard
+	}
+	if err := checkSourceCard(card, data.ClosingCard); err != nil {
+		return models.Transaction{}, err
+	}
+	if card.CurrentBalance-card.HeldBalance < data.Amount {
+		return models.Transaction{}, errors.New("insufficient available balance")
+	}
+
+	fee := utils.RoundAmount(data.Amount * WithdrawalFeeRate)
+	card.CurrentBalance = utils.RoundAmount(card.CurrentBalance - data.Amount)
+	if err := cards.Update(ctx, card); err != nil {
+		return models.Transaction{}, errors.New("something went wrong, please try again later")
+	}
+
+	meta, _ := json.Marshal(withdrawalMeta{
+		Fee:           fee,
+		PayoutAmount:  utils.RoundAmount(data.Amount - fee),
+		BankCode:      data.Destination.BankCode,
+		AccountNumber: data.Destination.AccountNumber,
+		AccountName:   data.Destination.AccountName,
+	})
+	source := "bank_transfer"
+	txn := &models.Transaction{
+		UserID:               card.UserID,
+		CardID:               card.ID,
+		TransactionReference: GenerateCardReference("WDR"),
+		IdempotencyKey:       &data.IdempotencyKey,
+		Amount:               data.Amount,
+		Currency:             card.Currency,
+		Type:                 "withdrawal",
+		Direction:            "debit",
+		Status:               "pending",
+		Source:               &source,
+		MetadataJSON:         meta,
+		TransactionTimestamp: time.Now(),
+	}
+	if err := txns.CreateTransaction(ctx, txn); err != nil {
+		return models.Transaction{}, errors.New("something went wrong, please try again later")
+	}
+	ledger := models.BalanceLedger{
+		CardID:        card.ID,
+		TransactionID: txn.ID,
+		EntryType:     "Card Withdrawal",
+		Amount:        data.Amount,
+		FeeCharged:    fee,
+		BalanceAfter:  card.CurrentBalance,
+	}
+	if err := txns.CreateLedger(ctx, ledger); err != nil {
+		return models.Transaction{}, errors.New("something went wrong, please try again later")
+	}
+	return *txn, nil
+}
+
+// disburseWithdrawal sends the payout for a pending withdrawal. A payout the
+// provider refuses outright is reversed straight away; one it accepts, or
+// one whose outcome is unknown after a timeout or a bad response, stays
+// pending until the payout webhook reports the final status.
+func (s *cardService) disburseWithdrawal(ctx context.Context, txn models.Transaction, destination models.PayoutDestination) (models.WithdrawResp, error) {
+	user, err := s.userrepo.FindByID(ctx, txn.UserID)
+	if err != nil {
+		if rerr := reverseWithdrawal(ctx, s.cardrepo, txn, "user lookup failed"); rerr != nil {
+			log.Printf("failed to reverse withdrawal %s: %v", txn.TransactionReference, rerr)
+		}
+		return models.WithdrawResp{}, errors.New("something went wrong, please try again later")
+	}
+	var meta withdrawalMeta
+	_ = json.Unmarshal(txn.MetadataJSON, &meta)
+
+	result, err := s.payout.Disburse(ctx, integrations.PayoutRequest{
+		Reference:     txn.TransactionReference,
+		Amount:        meta.PayoutAmount,
+		Currency:      txn.Currency,
+		Narration:     "CardFlow card withdrawal",
+		CustomerName:  user.FirstName + " " + user.LastName,
+		CustomerEmail: user.Email,
+		Destination:   destination,
+	})
+	if errors.Is(err, integrations.ErrPayoutRejected) {
+		log.Printf("payout for withdrawal %s failed: %v", txn.TransactionReference, err)
+		if rerr := reverseWithdrawal(ctx, s.cardrepo, txn, err.Error()); rerr != nil {
+			log.Printf("failed to reverse withdrawal %s: %v", txn.TransactionReference, rerr)
+		}
+		return models.WithdrawResp{}, errors.New("withdrawal failed, your balance has been restored")
+	}
+	if err != nil {
+		// the provider may have paid it; reversing now could pay out twice
+		log.Printf("payout for withdrawal %s has an unknown outcome, waiting for the webhook: %v", txn.TransactionReference, err)
+		return withdrawRespFromTxn(txn), nil
+	}
+	if result.Status == "success" {
+		txn.Status = "completed"
+		if err := s.Txnrepo.Update(ctx, txn); err != nil {
+			log.Printf("failed to complete withdrawal %s: %v", txn.TransactionReference, err)
+		}
+	}
+	return withdrawRespFromTxn(txn), nil
+}
+
+func withdrawRespFromTxn(txn models.Transaction) models.WithdrawResp {
+	var meta withdrawalMeta
+	_ = json.Unmarshal(txn.MetadataJSON, &meta)
+	return models.WithdrawResp{
+		Reference:    txn.TransactionReference,
+		Cardid:       txn.CardID.String(),
+		Amount:       txn.Amount,
+		Fee:          meta.Fee,
+		PayoutAmount: meta.PayoutAmount,
+		Currency:     txn.Currency,
+		Status:       txn.Status,
+	}
+}
+
+// reverseWithdrawal puts the full withdrawn amount, fee included, back on the
+// card and marks the withdrawal failed. It is a no-op for withdrawals that are
+// no longer pending, so repeated provider callbacks are safe.
+func reverseWithdrawal(ctx context.Context, cardrepo repositories.CardRepository, txn models.Transaction, reason string) error {
+	return cardrepo.RunInTransaction(ctx, func(cards repositories.CardRepository, txns repositories.TransactionRepository) error {
+		current, err := txns.FindTxnByReference(ctx, txn.TransactionReference)
+		if err != nil {
+			return err
+		}
+		if current.ID == uuid.Nil || current.Status != "pending" {
+			return nil
+		}
+		card, err := cards.FindCardForUpdate(ctx, models.GetCardReq{UserId: current.UserID, CardId: current.CardID.String()})
+		if err != nil {
+			return err
+		}
+		if card.ID == uuid.Nil {
+			return fmt.Errorf("card %s for withdrawal %s not found", current.CardID, current.TransactionReference)
+		}
+		card.CurrentBalance = utils.RoundAmount(card.CurrentBalance + current.Amount)
+		if err := cards.Update(ctx, card); err != nil {
+			return err
+		}
+		current.Status = "failed"
+		current.DeclineReason = &reason
+		if err := txns.Update(ctx, current); err != nil {
+			return err
+		}
+		return txns.CreateLedger(ctx, models.BalanceLedger{
+			CardID:        card.ID,
+			TransactionID: current.ID,
+			EntryType:     "Withdrawal Reversal",
+			Amount:        current.Amount,
+			FeeCharged:    0,
+			BalanceAfter:  card.CurrentBalance,
+		})
+	})
+}
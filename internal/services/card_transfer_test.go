@@ -0,0 +1,329 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryCardRepo keeps cards in memory. Only the methods the transfer,
+// withdrawal, terminate, reissue and reveal paths use are implemented; RunInTransaction does
+// not roll back, so tests only check state after successful calls or after
+// rejections made before anything is written.
+type memoryCardRepo struct {
+	repositories.CardRepository
+	cards       map[uuid.UUID]models.Card
+	txns        *memoryTxnRepo
+	reveals     []models.CardRevealToken
+	fraudFrozen map[uuid.UUID]bool
+}
+
+func (m *memoryCardRepo) find(data models.GetCardReq) models.Card {
+	id, err := uuid.Parse(data.CardId)
+	if err != nil {
+		return models.Card{}
+	}
+	card, ok := m.cards[id]
+	if !ok || card.UserID != data.UserId {
+		return models.Card{}
+	}
+	return card
+}
+
+func (m *memoryCardRepo) FindCardByID(ctx context.Context, data models.GetCardReq) (models.Card, error) {
+	return m.find(data), nil
+}
+
+func (m *memoryCardRepo) FindCardForUpdate(ctx context.Context, data models.GetCardReq) (models.Card, error) {
+	return m.find(data), nil
+}
+
+func (m *memoryCardRepo) CreateCard(ctx context.Context, card *models.Card) error {
+	card.ID = uuid.New()
+	m.cards[card.ID] = *card
+	return nil
+}
+
+func (m *memoryCardRepo) FindCardByPANFingerprint(ctx context.Context, fingerprint string) (models.Card, error) {
+	for _, card := range m.cards {
+		if card.PANFingerprint != nil && *card.PANFingerprint == fingerprint {
+			return card, nil
+		}
+	}
+	return models.Card{}, nil
+}
+
+func (m *memoryCardRepo) Update(ctx context.Context, card models.Card) error {
+	m.cards[card.ID] = card
+	return nil
+}
+
+func (m *memoryCardRepo) IsFrozenByFraudCase(ctx context.Context, cardID uuid.UUID) (bool, error) {
+	return m.fraudFrozen[cardID], nil
+}
+
+func (m *memoryCardRepo) CreateRevealToken(ctx context.Context, token *models.CardRevealToken) error {
+	m.reveals = append(m.reveals, *token)
+	return nil
+}
+
+func (m *memoryCardRepo) UseRevealToken(ctx context.Context, tokenHash string, at time.Time) (*models.CardRevealToken, error) {
+	for i, t := range m.reveals {
+		if t.TokenHash == tokenHash && t.UsedAt == nil && t.ExpiresAt.After(at) {
+			m.reveals[i].UsedAt = &at
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryCardRepo) RunInTransaction(ctx context.Context, fn func(cards repositories.CardRepository, txns repositories.TransactionRepository) error) error {
+	return fn(m, m.txns)
+}
+
+type memoryTxnRepo struct {
+	repositories.TransactionRepository
+	txns    map[string]models.Transaction
+	ledgers []models.BalanceLedger
+}
+
+func (m *memoryTxnRepo) CreateTransaction(ctx context.Context, txn *models.Transaction) error {
+	txn.ID = uuid.New()
+	m.txns[txn.TransactionReference] = *txn
+	return nil
+}
+
+func (m *memoryTxnRepo) CreateLedger(ctx context.Context, ledger models.BalanceLedger) error {
+	m.ledgers = append(m.ledgers, ledger)
+	return nil
+}
+
+func (m *memoryTxnRepo) FindTxnByReference(ctx context.Context, reference string) (models.Transaction, error) {
+	return m.txns[reference], nil
+}
+
+func (m *memoryTxnRepo) Update(ctx context.Context, txn models.Transaction) error {
+	m.txns[txn.TransactionReference] = txn
+	return nil
+}
+
+func (m *memoryTxnRepo) FindByIdempotencyKey(ctx context.Context, key string) (models.Transaction, error) {
+	for _, txn := range m.txns {
+		if txn.IdempotencyKey != nil && *txn.IdempotencyKey == key {
+			return txn, nil
+		}
+	}
+	return models.Transaction{}, nil
+}
+
+type stubUserRepo struct {
+	repositories.UserRepository
+}
+
+func (stubUserRepo) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return &models.User{ID: id, FirstName: "Ada", LastName: "Obi", Email: "ada@example.com"}, nil
+}
+
+type stubPayout struct {
+	status string
+	err    error
+	calls  int
+}
+
+func (p *stubPayout) Disburse(ctx context.Context, req integrations.PayoutRequest) (integrations.PayoutResult, error) {
+	p.calls++
+	if p.err != nil {
+		return integrations.PayoutResult{}, p.err
+	}
+	return integrations.PayoutResult{Reference: req.Reference, Status: p.status}, nil
+}
+
+type moneyTest struct {
+	userID uuid.UUID
+	cards  *memoryCardRepo
+	txns   *memoryTxnRepo
+	payout *stubPayout
+	svc    *cardService
+}
+
+func newMoneyTest() *moneyTest {
+	txns := &memoryTxnRepo{txns: map[string]models.Transaction{}}
+	cards := &memoryCardRepo{cards: map[uuid.UUID]models.Card{}, txns: txns, fraudFrozen: map[uuid.UUID]bool{}}
+	payout := &stubPayout{status: "processing"}
+	svc := &cardService{
+		userrepo:  stubUserRepo{},
+		cardrepo:  cards,
+		Txnrepo:   txns,
+		payout:    payout,
+		tokenrepo: &memoryTokenRepo{tokens: map[uuid.UUID]*models.NetworkToken{}},
+		tsp:       integrations.NewTspSimulator(),
+	}
+	return &moneyTest{userID: uuid.New(), cards: cards, txns: txns, payout: payout, svc: svc}
+}
+
+func (m *moneyTest) card(status string, balance float64) models.Card {
+	card := models.Card{ID: uuid.New(), UserID: m.userID, Status: status, Currency: "USD", CurrentBalance: balance}
+	m.cards.cards[card.ID] = card
+	return card
+}
+
+func (m *moneyTest) balance(card models.Card) float64 {
+	return m.cards.cards[card.ID].CurrentBalance
+}
+
+func TestTransferBetweenCards(t *testing.T) {
+	ctx := context.Background()
+	m := newMoneyTest()
+	from, to := m.card("active", 200), m.card("active", 0)
+
+	res, err := m.svc.TransferBetweenCards(ctx, models.TransferReq{Userid: m.userID, FromCardid: from.ID.String(), ToCardid: to.ID.String(), Amount: 100, IdempotencyKey: "trf-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Fee != 0.5 || res.CreditedAmount != 99.5 || m.balance(from) != 100 || m.balance(to) != 99.5 {
+		t.Fatalf("unexpected transfer: %+v, balances %v and %v", res, m.balance(from), m.balance(to))
+	}
+
+	frozen := m.card("frozen", 50)
+	inactiveTo := m.card("frozen", 0)
+	rejected := []struct {
+		name string
+		req  models.TransferReq
+	}{
+		{"zero amount", models.TransferReq{FromCardid: from.ID.String(), ToCardid: to.ID.String(), Amount: 0}},
+		{"negative amount", models.TransferReq{FromCardid: from.ID.String(), ToCardid: to.ID.String(), Amount: -10}},
+		{"frozen source", models.TransferReq{FromCardid: frozen.ID.String(), ToCardid: to.ID.String(), Amount: 10}},
+		{"frozen destination", models.TransferReq{FromCardid: from.ID.String(), ToCardid: inactiveTo.ID.String(), Amount: 10}},
+		{"insufficient balance", models.TransferReq{FromCardid: from.ID.String(), ToCardid: to.ID.String(), Amount: 1000}},
+		{"same card", models.TransferReq{FromCardid: from.ID.String(), ToCardid: from.ID.String(), Amount: 10}},
+	}
+	for i, c := range rejected {
+		c.req.Userid = m.userID
+		c.req.IdempotencyKey = "rejected-" + string(rune('a'+i))
+		if _, err := m.svc.TransferBetweenCards(ctx, c.req); err == nil {
+			t.Errorf("%s: expected the transfer to be rejected", c.name)
+		}
+	}
+	if m.balance(from) != 100 || m.balance(frozen) != 50 {
+		t.Fatalf("rejected transfers must not move money")
+	}
+
+	// terminating a frozen card may still sweep its balance
+	err = m.cards.RunInTransaction(ctx, func(cards repositories.CardRepository, txns repositories.TransactionRepository) error {
+		_, err := transferFunds(ctx, cards, txns, models.TransferReq{Userid: m.userID, FromCardid: frozen.ID.String(), ToCardid: to.ID.String(), Amount: 50, IdempotencyKey: "sweep", ClosingCard: true})
+		return err
+	})
+	if err != nil || m.balance(frozen) != 0 {
+		t.Fatalf("expected closing a frozen card to sweep it, got %v", err)
+	}
+}
+
+func TestWithdrawCardBalance(t *testing.T) {
+	ctx := context.Background()
+	m := newMoneyTest()
+	card := m.card("active", 100)
+	dest := models.PayoutDestination{BankCode: "058", AccountNumber: "0123456789", AccountName: "Ada Obi"}
+
+	res, err := m.svc.WithdrawCardBalance(ctx, models.WithdrawReq{Userid: m.userID, Cardid: card.ID.String(), Amount: 40, IdempotencyKey: "wdr-1", Destination: dest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "pending" || res.Fee != 0.4 || res.PayoutAmount != 39.6 || m.balance(card) != 60 {
+		t.Fatalf("unexpected withdrawal: %+v, balance %v", res, m.balance(card))
+	}
+
+	// a payout the provider refuses is reversed, fee included
+	m.payout.err = fmt.Errorf("%w: account not found", integrations.ErrPayoutRejected)
+	if _, err := m.svc.WithdrawCardBalance(ctx, models.WithdrawReq{Userid: m.userID, Cardid: card.ID.String(), Amount: 20, IdempotencyKey: "wdr-2", Destination: dest}); err == nil {
+		t.Fatalf("expected a refused payout to fail the withdrawal")
+	}
+	if m.balance(card) != 60 {
+		t.Fatalf("expected the refused withdrawal to be reversed, balance %v", m.balance(card))
+	}
+
+	// a payout that may have gone through is left for the webhook
+	m.payout.err = errors.New("context deadline exceeded")
+	res, err = m.svc.WithdrawCardBalance(ctx, models.WithdrawReq{Userid: m.userID, Cardid: card.ID.String(), Amount: 20, IdempotencyKey: "wdr-3", Destination: dest})
+	if err != nil || res.Status != "pending" || m.balance(card) != 40 {
+		t.Fatalf("expected a timed-out payout to stay pending, got %+v %v, balance %v", res, err, m.balance(card))
+	}
+	m.payout.err = nil
+
+	frozen := m.card("frozen", 100)
+	calls := m.payout.calls
+	for name, req := range map[string]models.WithdrawReq{
+		"negative amount": {Cardid: card.ID.String(), Amount: -5},
+		"frozen card":     {Cardid: frozen.ID.String(), Amount: 5},
+		"over balance":    {Cardid: card.ID.String(), Amount: 500},
+	} {
+		req.Userid, req.IdempotencyKey, req.Destination = m.userID, "rejected-"+name, dest
+		if _, err := m.svc.WithdrawCardBalance(ctx, req); err == nil {
+			t.Errorf("%s: expected the withdrawal to be rejected", name)
+		}
+	}
+	if m.payout.calls != calls || m.balance(frozen) != 100 {
+		t.Fatalf("rejected withdrawals must not pay out")
+	}
+}
+
+func TestTerminateWithPayoutAndWebhook(t *testing.T) {
+	ctx := context.Background()
+	m := newMoneyTest()
+	txnSvc := &transactionService{cardrepo: m.cards, Txnrepo: m.txns}
+	dest := &models.PayoutDestination{BankCode: "058", AccountNumber: "0123456789", AccountName: "Ada Obi"}
+
+	// a frozen card with money on it is terminated and paid out
+	card := m.card("frozen", 80)
+	if err := m.svc.ModifyCardStatus(ctx, models.ModifyCardStatusReq{UserId: m.userID, CardId: card.ID.String(), Payout: dest}, "terminate"); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.cards.cards[card.ID]; got.Status != "terminated" || got.CurrentBalance != 0 || m.payout.calls != 1 {
+		t.Fatalf("expected a terminated, empty card and one payout, got %s %v %d", got.Status, got.CurrentBalance, m.payout.calls)
+	}
+	withdrawal, _ := m.txns.FindByIdempotencyKey(ctx, "terminate-"+card.ID.String())
+	if withdrawal.Status != "pending" {
+		t.Fatalf("expected the payout to wait for the webhook, got %q", withdrawal.Status)
+	}
+
+	webhook := func(event, reference string) error {
+		data, _ := json.Marshal(models.PayoutWebhookData{Reference: reference})
+		return txnSvc.PayoutWebhook(ctx, models.PayoutWebhookReq{Event: event, Data: data})
+	}
+	// a failed payout puts the money back on the card, once
+	for i := 0; i < 2; i++ {
+		if err := webhook("transfer.failed", withdrawal.TransactionReference); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.balance(card) != 80 || m.txns.txns[withdrawal.TransactionReference].Status != "failed" {
+		t.Fatalf("expected the failed payout to be reversed once, balance %v", m.balance(card))
+	}
+
+	// a successful payout completes the withdrawal
+	other := m.card("active", 30)
+	if err := m.svc.ModifyCardStatus(ctx, models.ModifyCardStatusReq{UserId: m.userID, CardId: other.ID.String(), Payout: dest}, "terminate"); err != nil {
+		t.Fatal(err)
+	}
+	paid, _ := m.txns.FindByIdempotencyKey(ctx, "terminate-"+other.ID.String())
+	if err := webhook("transfer.success", paid.TransactionReference); err != nil {
+		t.Fatal(err)
+	}
+	if m.txns.txns[paid.TransactionReference].Status != "completed" || m.balance(other) != 0 {
+		t.Fatalf("expected the payout to complete")
+	}
+
+	if err := webhook("transfer.reversed", paid.TransactionReference); err != nil {
+		t.Fatalf("expected a settled withdrawal to acknowledge retries, got %v", err)
+	}
+	if err := webhook("transfer.success", "WDR-unknown"); err == nil {
+		t.Fatalf("expected an unknown reference to be rejected")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"CardFlow/internal/models"
 	"CardFlow/internal/repositories"
 	"context"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,16 +14,21 @@ import (
 type CronService interface{
 	NotifyCardsExpiringSoon(ctx context.Context)
 	ExpireCards(ctx context.Context)
+	RescreenUsers(ctx context.Context)
+	NotifyKycExpiringSoon(ctx context.Context)
+	ExpireKycSubmissions(ctx context.Context)
 
 }
 
 type cronService struct {
     userRepo repositories.UserRepository
 	cardRepo repositories.CardRepository
+	kycRepo repositories.KycRepository
+	screening ScreeningService
 }
 
-func NewCronService(userrepo repositories.UserRepository, cardRepo repositories.CardRepository) CronService {
-    return &cronService{userRepo:userrepo, cardRepo: cardRepo}
+func NewCronService(userrepo repositories.UserRepository, cardRepo repositories.CardRepository, kycRepo repositories.KycRepository, screening ScreeningService) CronService {
+    return &cronService{userRepo:userrepo, cardRepo: cardRepo, kycRepo: kycRepo, screening: screening}
 }
 
 func CronJobs(ctx context.Context, cronSvc CronService) {
@@ -36,6 +42,16 @@ func CronJobs(ctx context.Context, cronSvc CronService) {
 	if _, err := c.AddFunc("5 7 * * *", func() { cronSvc.ExpireCards(ctx) }); err != nil {
 		//log error for devs
 	}
+	if _, err := c.AddFunc("10 7 * * *", func() { cronSvc.NotifyKycExpiringSoon(ctx) }); err != nil {
+		//log error for devs
+	}
+	if _, err := c.AddFunc("15 7 * * *", func() { cronSvc.ExpireKycSubmissions(ctx) }); err != nil {
+		//log error for devs
+	}
+	// rescreen everyone against freshly loaded watchlists at 2:00 AM
+	if _, err := c.AddFunc("0 2 * * *", func() { cronSvc.RescreenUsers(ctx) }); err != nil {
+		//log error for devs
+	}
 
 	c.Start()
 	<-ctx.Done()
@@ -102,6 +118,13 @@ func (s *cronService) ExpireCards(ctx context.Context) {
 	}
 }
 
+func (s *cronService) RescreenUsers(ctx context.Context) {
+	stats, err := s.screening.RescreenAll(ctx)
+	if err != nil {
+		log.Printf("nightly rescreening stopped early: %v", err)
+	}
+	log.Printf("nightly rescreening: %d screened, %d with new hits, %d failed", stats.Screened, stats.Hits, stats.Failed)
+}
 
 func extractUserIDs(cards []models.Card) []uuid.UUID {
 	set := make(map[uuid.UUID]struct{})
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/repositories"
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+type DocumentMigrationStats struct {
+	Moved  int
+	Failed int
+}
+
+// DocumentMigrationJob moves KYC document ciphertexts still held in Postgres
+// into the DocumentStore. The ciphertext is copied as is, so no keys are
+// needed; each row is switched over only after its object is written, and
+// rows already moved are never selected again, so the job can be rerun.
+type DocumentMigrationJob struct {
+	kycrepo   repositories.KycRepository
+	store     integrations.DocumentStore
+	BatchSize int
+}
+
+func NewDocumentMigrationJob(kycRepo repositories.KycRepository, store integrations.DocumentStore) *DocumentMigrationJob {
+	return &DocumentMigrationJob{kycrepo: kycRepo, store: store, BatchSize: 100}
+}
+
+func (j *DocumentMigrationJob) Run(ctx context.Context) (DocumentMigrationStats, error) {
+	var stats DocumentMigrationStats
+	after := uuid.Nil
+	for ctx.Err() == nil {
+		docs, err := j.kycrepo.FindDocumentsInDatabase(ctx, after, j.BatchSize)
+		if err != nil {
+			return stats, err
+		}
+		if len(docs) == 0 {
+			break
+		}
+		for _, doc := range docs {
+			after = doc.ID
+			if len(doc.EncryptedData) == 0 {
+				log.Printf("document migration: kyc document %s has no data", doc.ID)
+				stats.Failed++
+				continue
+			}
+			key := documentObjectKey(doc.KYCSubmissionID, doc.DocumentType)
+			if err := j.store.Put(ctx, key, doc.EncryptedData); err != nil {
+				log.Printf("document migration: kyc document %s: %v", doc.ID, err)
+				stats.Failed++
+				continue
+			}
+			err := j.kycrepo.SetDocumentObject(ctx, doc.ID, key, documentChecksum(doc.EncryptedData), int64(len(doc.EncryptedData)), doc.EncryptionVersion)
+			if err != nil {
+				if delErr := j.store.Delete(ctx, key); delErr != nil {
+					log.Printf("document migration: failed to delete orphaned object %s: %v", key, delErr)
+				}
+				return stats, err
+			}
+			stats.Moved++
+		}
+	}
+	return stats, ctx.Err()
+}
@@ -139,4 +139,213 @@ func SendRefundEmail(data map[string]string) error{
 
 		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
 		return err
-}
\ No newline at end of file
+}
+
+func SendCardReissueEmail(data map[string]string) error{
+	email := data["email"]
+	firstname := data["firstname"]
+	old_last_four := data["oldlastfour"]
+	new_last_four := data["newlastfour"]
+	balance := data["balance"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+	subject := "Your Card Has Been Replaced"
+		body := fmt.Sprintf("Dear %s, your card ending with %s has been terminated and replaced by a new card ending with %s.\n Balance moved to the new card: %s.\n If you did not request this, please contact support immediately.", firstname, old_last_four, new_last_four, balance)
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
+
+func SendKycExpiryEmail(data map[string]string) error{
+	email := data["Email"]
+	firstname := data["FirstName"]
+	expires_at := data["ExpiresAt"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+	var subject, body string
+	switch data["Status"] {
+	case "expiring":
+		subject = "Please Renew Your Identity Verification"
+		body = fmt.Sprintf("Dear %s, your identity verification expires on %s. Please resubmit your documents before then to keep topping up and creating cards.", firstname, expires_at)
+	case "expired":
+		subject = "Your Identity Verification Has Expired"
+		body = fmt.Sprintf("Dear %s, your identity verification has expired. Your existing cards keep working, but top-ups and new cards are paused until you resubmit your documents.", firstname)
+	default:
+		return nil
+	}
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
+
+func SendOrganizationInviteEmail(data map[string]string) error{
+	email := data["Email"]
+	organization := data["OrganizationName"]
+	role := data["Role"]
+	token := data["Token"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+		subject := "You Have Been Invited to " + organization
+		body := fmt.Sprintf("Hello, you have been invited to join %s on CardFlow as %s. Sign in with this email address and accept the invitation with this code: %s. The invitation expires in 7 days.", organization, role, token)
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
+
+func SendApprovalRequestEmail(data map[string]string) error{
+	email := data["Email"]
+	organization := data["OrganizationName"]
+	requester := data["RequesterEmail"]
+	summary := data["Summary"]
+	approvals := data["RequiredApprovals"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+		subject := "Approval Needed in " + organization
+		body := fmt.Sprintf("Hello, %s has asked for %s in %s. It needs %s approval(s). Sign in to CardFlow to approve or reject the request.", requester, summary, organization, approvals)
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
+
+func SendFraudAlertEmail(data map[string]string) error{
+	email := data["Email"]
+	firstname := data["FirstName"]
+	lastfour := data["LastFour"]
+	amount := data["Amount"]
+	merchant := data["Merchant"]
+	link := data["Link"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+		subject := "Was This You?"
+		body := fmt.Sprintf("Hello %s, we noticed a payment of %s at %s on your card ending %s that looks unusual. Please confirm or deny it here: %s. If you don't recognize it, denying it freezes your card straight away.", firstname, amount, merchant, lastfour, link)
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
+
+func SendSecurityAlertEmail(data map[string]string) error{
+	email := data["Email"]
+	summary := data["Summary"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+		subject := "Card Reported " + data["Reason"]
+		body := fmt.Sprintf("A card was reported %s and has been blocked. %s", data["Reason"], summary)
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
+
+func SendNewDeviceLoginEmail(data map[string]string) error{
+	email := data["Email"]
+	firstname := data["FirstName"]
+	device := data["Device"]
+	ip := data["IPAddress"]
+	location := data["Location"]
+	at := data["Time"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+		subject := "New Sign-In to Your CardFlow Account"
+		body := fmt.Sprintf("Hello %s, your account was signed in to from a new device on %s: %s, IP address %s (%s). If this wasn't you, sign that session out from your active sessions and change your password.", firstname, at, device, ip, location)
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
+
+func SendPasswordResetEmail(data map[string]string) error{
+	email := data["Email"]
+	firstname := data["FirstName"]
+	link := data["Link"]
+	minutes := data["Minutes"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+		subject := "Reset Your CardFlow Password"
+		body := fmt.Sprintf("Hello %s, we received a request to reset your password. Use this link within %s minutes to choose a new one: %s. If you didn't ask for this, you can ignore this email.", firstname, minutes, link)
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
+
+func SendPasswordChangedEmail(data map[string]string) error{
+	email := data["Email"]
+	firstname := data["FirstName"]
+	at := data["Time"]
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+	senderEmail := config.AppEmail
+	senderPassword := config.AppPassword
+	auth := smtp.PlainAuth("", senderEmail, senderPassword, smtpHost)
+		subject := "Your CardFlow Password Was Changed"
+		body := fmt.Sprintf("Hello %s, the password on your account was changed on %s and every device was signed out. If this wasn't you, reset your password straight away and contact support.", firstname, at)
+		message := []byte("Subject: " + subject + "\r\n" +
+			"To: " + email + "\r\n" +
+			"From: " + senderEmail + "\r\n" +
+			"\r\n" +
+			body + "\r\n")
+
+		err := smtp.SendMail(smtpHost+":"+smtpPort, auth, senderEmail, []string{email}, message)
+		return err
+}
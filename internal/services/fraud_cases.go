@@ -0,0 +1,656 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Fraud case sources.
+const (
+	FraudCaseFromRule    = "fraud_rule"
+	FraudCaseFromReport  = "user_report"
+	FraudCaseFromDispute = "dispute"
+)
+
+// Fraud case outcomes. customer_confirmed is set when the customer confirmed
+// every transaction they were asked about.
+const (
+	FraudOutcomeConfirmed         = "confirmed_fraud"
+	FraudOutcomeFalsePositive     = "false_positive"
+	FraudOutcomeCustomerConfirmed = "customer_confirmed"
+)
+
+const (
+	fraudResponseTTL    = 7 * 24 * time.Hour
+	fraudCaseListLimit  = 100
+	maxFraudDescription = 1000
+	maxFraudCaseLinks   = 20
+)
+
+// CardStatusModifier changes a card's status the way the cardholder would,
+// see CardService.ModifyCardStatus. Cases freeze and terminate cards through
+// it so the same checks and token updates apply.
+type CardStatusModifier interface {
+	ModifyCardStatus(ctx context.Context, data models.ModifyCardStatusReq, status string) error
+}
+
+// FraudCaseOpen describes a new case, or what to add to the open one.
+type FraudCaseOpen struct {
+	UserID         uuid.UUID
+	Source         string
+	Summary        string
+	CardIDs        []uuid.UUID
+	TransactionIDs []uuid.UUID
+}
+
+// newLinkToken makes the one-time token for an emailed link, and the
+// SHA-256 that is stored in its place.
+func newLinkToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(raw)
+	return token, hashLinkToken(token), nil
+}
+
+func hashLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// fraudResponseLink is the confirm or deny link sent to the customer.
+func fraudResponseLink(token string) string {
+	return linkWithToken(config.FraudResponseUrl, token)
+}
+
+// linkWithToken adds a token to the page the link points at. Without a page
+// configured the bare token is sent.
+func linkWithToken(base, token string) string {
+	if base == "" {
+		return token
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "token=" + url.QueryEscape(token)
+}
+
+// parseUUIDs parses a list of IDs, dropping repeats.
+func parseUUIDs(ids []string, what string) ([]uuid.UUID, error) {
+	seen := map[uuid.UUID]bool{}
+	res := make([]uuid.UUID, 0, len(ids))
+	for _, raw := range ids {
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s not found", what)
+		}
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res, nil
+}
+
+// OpenCase adds to the user's open case from the same source, or opens one,
+// and links the given cards and transactions to it.
+func (s *fraudService) OpenCase(ctx context.Context, data FraudCaseOpen) (*models.FraudCase, error) {
+	fraudCase, created, err := openFraudCase(ctx, s.fraudrepo, data)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		auditFraudCaseOpened(ctx, s.auditrepo, fraudCase)
+	}
+	return fraudCase, nil
+}
+
+// openFraudCase is OpenCase against the given repository, so it can run
+// inside a caller's database transaction. It reports whether the case is
+// new; auditing that is left to the caller, once the transaction commits.
+func openFraudCase(ctx context.Context, repo repositories.FraudRepository, data FraudCaseOpen) (*models.FraudCase, bool, error) {
+	fraudCase, err := repo.FindOpenCase(ctx, data.UserID, data.Source)
+	if err != nil {
+		return nil, false, err
+	}
+	created := fraudCase == nil
+	if created {
+		fraudCase = &models.FraudCase{UserID: data.UserID, Source: data.Source, Status: "open", Summary: data.Summary}
+		if err := repo.CreateCase(ctx, fraudCase); err != nil {
+			return nil, false, err
+		}
+	} else if data.Summary != "" && !strings.Contains(fraudCase.Summary, data.Summary) {
+		fraudCase.Summary += "\n" + data.Summary
+		if err := repo.UpdateCase(ctx, fraudCase); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := linkFraudCase(ctx, repo, fraudCase, data.CardIDs, data.TransactionIDs); err != nil {
+		return nil, false, err
+	}
+	return fraudCase, created, nil
+}
+
+func auditFraudCaseOpened(ctx context.Context, auditRepo repositories.AuditRepository, fraudCase *models.FraudCase) {
+	meta := map[string]any{"user_id": fraudCase.UserID, "source": fraudCase.Source}
+	if err := recordAudit(ctx, auditRepo, fraudCase.UserID, "fraud.case_opened", "fraud_case", fraudCase.ID, models.ClientInfo{}, meta); err != nil {
+		log.Printf("failed to audit fraud case %s: %v", fraudCase.ID, err)
+	}
+}
+
+// RecordAuthorization opens or extends a case when active rules declined or
+// flagged an authorization. A declined card is frozen straight away; for a
+// flagged one the customer is asked to confirm or deny the transaction.
+// Failures are logged, the authorization's outcome stands either way.
+func (s *fraudService) RecordAuthorization(ctx context.Context, card models.Card, txn models.Transaction, result FraudResult) {
+	if result.Decision == FraudApprove {
+		return
+	}
+	var names []string
+	for _, hit := range result.Hits {
+		if !hit.Shadow {
+			names = append(names, hit.Name)
+		}
+	}
+	summary := fmt.Sprintf("%s %.2f %s at %s by %s", txn.Status, txn.Amount, txn.Currency, derefString(txn.MerchantName), strings.Join(names, ", "))
+	fraudCase, err := s.OpenCase(ctx, FraudCaseOpen{
+		UserID:  card.UserID,
+		Source:  FraudCaseFromRule,
+		Summary: summary,
+		CardIDs: []uuid.UUID{card.ID},
+	})
+	if err != nil {
+		log.Printf("failed to open fraud case for transaction %s: %v", txn.ID, err)
+		return
+	}
+
+	link := &models.FraudCaseTransaction{FraudCaseID: fraudCase.ID, TransactionID: txn.ID}
+	var token string
+	if result.Decision == FraudFlag {
+		var hash string
+		token, hash, err = newLinkToken()
+		if err != nil {
+			log.Printf("failed to create response token for transaction %s: %v", txn.ID, err)
+			token = ""
+		} else {
+			expiresAt := time.Now().Add(fraudResponseTTL)
+			link.ResponseTokenHash, link.ResponseExpiresAt = &hash, &expiresAt
+		}
+	}
+	if err := s.fraudrepo.LinkTransaction(ctx, link); err != nil {
+		log.Printf("failed to link transaction %s to fraud case %s: %v", txn.ID, fraudCase.ID, err)
+		return
+	}
+
+	if result.Decision == FraudDecline {
+		s.freezeCaseCard(ctx, fraudCase, card, uuid.Nil, models.ClientInfo{})
+		notifyInApp(ctx, s.notificationrepo, card.UserID, "fraud_card_frozen", "Card frozen",
+			fmt.Sprintf("We declined a %.2f %s payment at %s on your card ending %s and froze the card. Contact support if this was you.", txn.Amount, txn.Currency, derefString(txn.MerchantName), card.LastFour))
+		return
+	}
+	if token == "" {
+		return
+	}
+	notifyInApp(ctx, s.notificationrepo, card.UserID, "fraud_confirm_transaction", "Was this you?",
+		fmt.Sprintf("Please confirm a %.2f %s payment at %s on your card ending %s.", txn.Amount, txn.Currency, derefString(txn.MerchantName), card.LastFour))
+	user, err := s.userrepo.FindByID(ctx, card.UserID)
+	if err != nil {
+		log.Printf("failed to load user %s for fraud alert: %v", card.UserID, err)
+		return
+	}
+	email := map[string]string{
+		"Email":     user.Email,
+		"FirstName": user.FirstName,
+		"LastFour":  card.LastFour,
+		"Amount":    fmt.Sprintf("%.2f %s", txn.Amount, txn.Currency),
+		"Merchant":  derefString(txn.MerchantName),
+		"Link":      fraudResponseLink(token),
+	}
+	go func() {
+		if err := utils.SendWithRetry(3, 2*time.Second, func() error {
+			return SendFraudAlertEmail(email)
+		}); err != nil {
+			log.Printf("failed to send fraud alert for transaction %s: %v", txn.ID, err)
+		}
+	}()
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// freezeCaseCard freezes a card from a case and records it on the case. A
+// card that can't be frozen is left as it is, and one its holder already
+// froze is only recorded. Neither the holder nor a partner key can unfreeze
+// or reissue the card while the case is open.
+func (s *fraudService) freezeCaseCard(ctx context.Context, fraudCase *models.FraudCase, card models.Card, adminID uuid.UUID, client models.ClientInfo) {
+	if card.Status != "active" && card.Status != "frozen" {
+		return
+	}
+	if card.Status == "active" {
+		if err := s.cards.ModifyCardStatus(ctx, models.ModifyCardStatusReq{UserId: card.UserID, CardId: card.ID.String()}, "freeze"); err != nil {
+			log.Printf("failed to freeze card %s for fraud case %s: %v", card.ID, fraudCase.ID, err)
+			return
+		}
+	}
+	s.recordCardAction(ctx, fraudCase, card.ID, "frozen", adminID, client)
+}
+
+func (s *fraudService) recordCardAction(ctx context.Context, fraudCase *models.FraudCase, cardID uuid.UUID, action string, adminID uuid.UUID, client models.ClientInfo) {
+	links, err := s.fraudrepo.FindCaseCards(ctx, fraudCase.ID)
+	if err == nil {
+		for i := range links {
+			if links[i].CardID == cardID {
+				links[i].Action = &action
+				err = s.fraudrepo.UpdateCaseCard(ctx, &links[i])
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("failed to record %s card %s on fraud case %s: %v", action, cardID, fraudCase.ID, err)
+	}
+	meta := map[string]any{"case_id": fraudCase.ID, "action": action}
+	if adminID != uuid.Nil {
+		err = recordAdminAudit(ctx, s.auditrepo, adminID, "fraud.card_"+action, "card", cardID, client, meta)
+	} else {
+		err = recordAudit(ctx, s.auditrepo, fraudCase.UserID, "fraud.card_"+action, "card", cardID, client, meta)
+	}
+	if err != nil {
+		log.Printf("failed to audit %s card %s: %v", action, cardID, err)
+	}
+}
+
+// ReportFraud opens a case for activity the user doesn't recognize on their
+// card and freezes the card.
+func (s *fraudService) ReportFraud(ctx context.Context, data models.FraudReportReq) (models.FraudCaseResp, error) {
+	description := strings.TrimSpace(data.Description)
+	if description == "" {
+		return models.FraudCaseResp{}, errors.New("please describe what you don't recognize")
+	}
+	if len(description) > maxFraudDescription {
+		return models.FraudCaseResp{}, fmt.Errorf("description must be at most %d characters", maxFraudDescription)
+	}
+	if len(data.Transactionids) > maxFraudCaseLinks {
+		return models.FraudCaseResp{}, fmt.Errorf("at most %d transactions can be reported at once", maxFraudCaseLinks)
+	}
+	card, err := s.cardrepo.FindCardByID(ctx, models.GetCardReq{UserId: data.Userid, CardId: data.Cardid})
+	if err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return models.FraudCaseResp{}, errors.New("card not found")
+	}
+	txnIDs, err := parseUUIDs(data.Transactionids, "transaction")
+	if err != nil {
+		return models.FraudCaseResp{}, err
+	}
+	txns, err := s.fraudrepo.FindUserTransactions(ctx, data.Userid, txnIDs)
+	if err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	if len(txns) != len(txnIDs) {
+		return models.FraudCaseResp{}, errors.New("transaction not found")
+	}
+	for _, txn := range txns {
+		if txn.CardID != card.ID {
+			return models.FraudCaseResp{}, errors.New("transaction not found")
+		}
+	}
+
+	fraudCase, err := s.OpenCase(ctx, FraudCaseOpen{
+		UserID:         data.Userid,
+		Source:         FraudCaseFromReport,
+		Summary:        fmt.Sprintf("card ending %s: %s", card.LastFour, description),
+		CardIDs:        []uuid.UUID{card.ID},
+		TransactionIDs: txnIDs,
+	})
+	if err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "fraud.reported", "fraud_case", fraudCase.ID, data.Client, map[string]any{"card_id": card.ID, "transactions": txnIDs}); err != nil {
+		log.Printf("failed to audit fraud report %s: %v", fraudCase.ID, err)
+	}
+	s.freezeCaseCard(ctx, fraudCase, card, uuid.Nil, data.Client)
+	return s.caseResp(ctx, fraudCase)
+}
+
+// RespondToTransaction records the customer's answer from a confirm or deny
+// link. Denying freezes the card; once every transaction asked about on a
+// rule case is confirmed, the case closes as customer_confirmed.
+func (s *fraudService) RespondToTransaction(ctx context.Context, data models.FraudResponseReq) error {
+	if data.Response != "confirmed" && data.Response != "denied" {
+		return errors.New("response must be confirmed or denied")
+	}
+	if data.Token == "" {
+		return errors.New("invalid or expired link")
+	}
+	link, err := s.fraudrepo.FindCaseTransactionByToken(ctx, hashLinkToken(data.Token))
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if link == nil || link.ResponseExpiresAt == nil || time.Now().After(*link.ResponseExpiresAt) {
+		return errors.New("invalid or expired link")
+	}
+	if link.CustomerResponse != nil {
+		if *link.CustomerResponse == data.Response {
+			return nil
+		}
+		return errors.New("this transaction has already been answered, please contact support")
+	}
+	fraudCase, err := s.fraudrepo.FindCase(ctx, link.FraudCaseID)
+	if err != nil || fraudCase == nil {
+		return errors.New("something went wrong, please try again later")
+	}
+
+	now := time.Now()
+	link.CustomerResponse, link.RespondedAt = &data.Response, &now
+	if err := s.fraudrepo.UpdateCaseTransaction(ctx, link); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, fraudCase.UserID, "fraud.transaction_"+data.Response, "transaction", link.TransactionID, data.Client, map[string]any{"case_id": fraudCase.ID}); err != nil {
+		log.Printf("failed to audit response on transaction %s: %v", link.TransactionID, err)
+	}
+
+	if data.Response == "denied" {
+		card, err := s.cardrepo.FindCardByUUID(ctx, link.Transaction.CardID)
+		if err != nil || card.ID == uuid.Nil {
+			log.Printf("failed to load card %s to freeze: %v", link.Transaction.CardID, err)
+			return nil
+		}
+		if fraudCase.Status == "resolved" {
+			// a late denial reopens the case for review
+			fraudCase.Status, fraudCase.Outcome, fraudCase.ResolvedAt, fraudCase.ResolvedBy = "open", nil, nil, nil
+			if err := s.fraudrepo.UpdateCase(ctx, fraudCase); err != nil {
+				log.Printf("failed to reopen fraud case %s: %v", fraudCase.ID, err)
+			}
+		}
+		s.freezeCaseCard(ctx, fraudCase, card, uuid.Nil, data.Client)
+		return nil
+	}
+
+	if fraudCase.Status != "open" || fraudCase.Source != FraudCaseFromRule {
+		return nil
+	}
+	links, err := s.fraudrepo.FindCaseTransactions(ctx, fraudCase.ID)
+	if err != nil {
+		log.Printf("failed to load transactions of fraud case %s: %v", fraudCase.ID, err)
+		return nil
+	}
+	if !customerConfirmedAll(links) {
+		return nil
+	}
+	outcome := FraudOutcomeCustomerConfirmed
+	fraudCase.Status, fraudCase.Outcome, fraudCase.ResolvedAt = "resolved", &outcome, &now
+	if err := s.fraudrepo.UpdateCase(ctx, fraudCase); err != nil {
+		log.Printf("failed to close fraud case %s: %v", fraudCase.ID, err)
+		return nil
+	}
+	if err := recordAudit(ctx, s.auditrepo, fraudCase.UserID, "fraud.case_resolved", "fraud_case", fraudCase.ID, data.Client, map[string]any{"outcome": outcome}); err != nil {
+		log.Printf("failed to audit fraud case %s: %v", fraudCase.ID, err)
+	}
+	return nil
+}
+
+// customerConfirmedAll reports whether the customer was asked about the
+// case's transactions and confirmed every one. A declined transaction, which
+// they are not asked about, keeps the case open for review.
+func customerConfirmedAll(links []models.FraudCaseTransaction) bool {
+	if len(links) == 0 {
+		return false
+	}
+	for _, link := range links {
+		if link.CustomerResponse == nil || *link.CustomerResponse != "confirmed" {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *fraudService) caseResp(ctx context.Context, fraudCase *models.FraudCase) (models.FraudCaseResp, error) {
+	res := models.FraudCaseResp{
+		Caseid:         fraudCase.ID,
+		Userid:         fraudCase.UserID,
+		Source:         fraudCase.Source,
+		Status:         fraudCase.Status,
+		Summary:        fraudCase.Summary,
+		Outcome:        fraudCase.Outcome,
+		ResolutionNote: fraudCase.ResolutionNote,
+		ResolvedAt:     fraudCase.ResolvedAt,
+		CreatedAt:      fraudCase.CreatedAt,
+	}
+	txns, err := s.fraudrepo.FindCaseTransactions(ctx, fraudCase.ID)
+	if err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	for _, link := range txns {
+		txn := link.Transaction
+		res.Transactions = append(res.Transactions, models.FraudCaseTransactionResp{
+			Transactionid:    txn.ID,
+			Cardid:           txn.CardID,
+			Amount:           txn.Amount,
+			Currency:         txn.Currency,
+			MerchantName:     txn.MerchantName,
+			Status:           txn.Status,
+			FraudDecision:    txn.FraudDecision,
+			CustomerResponse: link.CustomerResponse,
+			RespondedAt:      link.RespondedAt,
+		})
+	}
+	cards, err := s.fraudrepo.FindCaseCards(ctx, fraudCase.ID)
+	if err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	for _, link := range cards {
+		res.Cards = append(res.Cards, models.FraudCaseCardResp{Cardid: link.CardID, LastFour: link.Card.LastFour, Status: link.Card.Status, Action: link.Action})
+	}
+	return res, nil
+}
+
+func (s *fraudService) findCase(ctx context.Context, caseID string) (*models.FraudCase, error) {
+	id, err := uuid.Parse(caseID)
+	if err != nil {
+		return nil, errors.New("case not found")
+	}
+	fraudCase, err := s.fraudrepo.FindCase(ctx, id)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	if fraudCase == nil {
+		return nil, errors.New("case not found")
+	}
+	return fraudCase, nil
+}
+
+// GetCases lists cases by status, open by default.
+func (s *fraudService) GetCases(ctx context.Context, status string) ([]models.FraudCaseResp, error) {
+	if status == "" {
+		status = "open"
+	}
+	if status != "open" && status != "resolved" && status != "all" {
+		return nil, errors.New("status must be open, resolved or all")
+	}
+	if status == "all" {
+		status = ""
+	}
+	cases, err := s.fraudrepo.FindCases(ctx, status, fraudCaseListLimit)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.FraudCaseResp, 0, len(cases))
+	for _, c := range cases {
+		res = append(res, models.FraudCaseResp{
+			Caseid:     c.ID,
+			Userid:     c.UserID,
+			Source:     c.Source,
+			Status:     c.Status,
+			Summary:    c.Summary,
+			Outcome:    c.Outcome,
+			ResolvedAt: c.ResolvedAt,
+			CreatedAt:  c.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+func (s *fraudService) GetCase(ctx context.Context, caseID string) (models.FraudCaseResp, error) {
+	fraudCase, err := s.findCase(ctx, caseID)
+	if err != nil {
+		return models.FraudCaseResp{}, err
+	}
+	return s.caseResp(ctx, fraudCase)
+}
+
+// LinkToCase adds related transactions and cards of the case's user.
+func (s *fraudService) LinkToCase(ctx context.Context, data models.FraudCaseLinkReq) (models.FraudCaseResp, error) {
+	fraudCase, err := s.findCase(ctx, data.Caseid)
+	if err != nil {
+		return models.FraudCaseResp{}, err
+	}
+	if len(data.Transactionids)+len(data.Cardids) == 0 {
+		return models.FraudCaseResp{}, errors.New("nothing to link")
+	}
+	if len(data.Transactionids) > maxFraudCaseLinks || len(data.Cardids) > maxFraudCaseLinks {
+		return models.FraudCaseResp{}, fmt.Errorf("at most %d transactions and %d cards can be linked at once", maxFraudCaseLinks, maxFraudCaseLinks)
+	}
+	txnIDs, err := parseUUIDs(data.Transactionids, "transaction")
+	if err != nil {
+		return models.FraudCaseResp{}, err
+	}
+	cardIDs, err := parseUUIDs(data.Cardids, "card")
+	if err != nil {
+		return models.FraudCaseResp{}, err
+	}
+	txns, err := s.fraudrepo.FindUserTransactions(ctx, fraudCase.UserID, txnIDs)
+	if err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	if len(txns) != len(txnIDs) {
+		return models.FraudCaseResp{}, errors.New("transaction not found")
+	}
+	for _, cardID := range cardIDs {
+		card, err := s.cardrepo.FindCardByUUID(ctx, cardID)
+		if err != nil {
+			return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+		}
+		if card.ID == uuid.Nil || card.UserID != fraudCase.UserID {
+			return models.FraudCaseResp{}, errors.New("card not found")
+		}
+	}
+	// the cards the transactions were made with belong to the case too
+	for _, txn := range txns {
+		cardIDs = append(cardIDs, txn.CardID)
+	}
+	if err := linkFraudCase(ctx, s.fraudrepo, fraudCase, cardIDs, txnIDs); err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	meta := map[string]any{"transactions": txnIDs, "cards": cardIDs}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "fraud.case_linked", "fraud_case", fraudCase.ID, data.Client, meta); err != nil {
+		log.Printf("failed to audit fraud case %s: %v", fraudCase.ID, err)
+	}
+	return s.caseResp(ctx, fraudCase)
+}
+
+// linkFraudCase links cards and transactions to a case.
+func linkFraudCase(ctx context.Context, repo repositories.FraudRepository, fraudCase *models.FraudCase, cardIDs, txnIDs []uuid.UUID) error {
+	for _, cardID := range cardIDs {
+		if err := repo.LinkCard(ctx, fraudCase.ID, cardID); err != nil {
+			return err
+		}
+	}
+	for _, txnID := range txnIDs {
+		if err := repo.LinkTransaction(ctx, &models.FraudCaseTransaction{FraudCaseID: fraudCase.ID, TransactionID: txnID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CaseCardAction freezes or terminates a card linked to the case, with the
+// same checks as when the cardholder does it.
+func (s *fraudService) CaseCardAction(ctx context.Context, data models.FraudCaseCardActionReq) (models.FraudCaseResp, error) {
+	if data.Action != "freeze" && data.Action != "terminate" {
+		return models.FraudCaseResp{}, errors.New("action must be freeze or terminate")
+	}
+	fraudCase, err := s.findCase(ctx, data.Caseid)
+	if err != nil {
+		return models.FraudCaseResp{}, err
+	}
+	cardID, err := uuid.Parse(data.Cardid)
+	if err != nil {
+		return models.FraudCaseResp{}, errors.New("card not found")
+	}
+	links, err := s.fraudrepo.FindCaseCards(ctx, fraudCase.ID)
+	if err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	linked := false
+	for _, link := range links {
+		linked = linked || link.CardID == cardID
+	}
+	if !linked {
+		return models.FraudCaseResp{}, errors.New("card is not linked to this case")
+	}
+	req := models.ModifyCardStatusReq{
+		UserId:           fraudCase.UserID,
+		CardId:           cardID.String(),
+		TransferToCardId: data.TransferToCardId,
+		Payout:           data.Payout,
+	}
+	if err := s.cards.ModifyCardStatus(ctx, req, data.Action); err != nil {
+		return models.FraudCaseResp{}, err
+	}
+	action := "frozen"
+	if data.Action == "terminate" {
+		action = "terminated"
+	}
+	s.recordCardAction(ctx, fraudCase, cardID, action, data.AdminId, data.Client)
+	return s.caseResp(ctx, fraudCase)
+}
+
+// ResolveCase closes a case with the reviewer's outcome.
+func (s *fraudService) ResolveCase(ctx context.Context, data models.FraudCaseResolveReq) (models.FraudCaseResp, error) {
+	if data.Outcome != FraudOutcomeConfirmed && data.Outcome != FraudOutcomeFalsePositive {
+		return models.FraudCaseResp{}, errors.New("outcome must be confirmed_fraud or false_positive")
+	}
+	note := strings.TrimSpace(data.Note)
+	if note == "" {
+		return models.FraudCaseResp{}, errors.New("a resolution note is required")
+	}
+	fraudCase, err := s.findCase(ctx, data.Caseid)
+	if err != nil {
+		return models.FraudCaseResp{}, err
+	}
+	if fraudCase.Status == "resolved" {
+		return models.FraudCaseResp{}, errors.New("case is already resolved")
+	}
+	now := time.Now()
+	fraudCase.Status = "resolved"
+	fraudCase.Outcome, fraudCase.ResolutionNote = &data.Outcome, &note
+	fraudCase.ResolvedBy, fraudCase.ResolvedAt = &data.AdminId, &now
+	if err := s.fraudrepo.UpdateCase(ctx, fraudCase); err != nil {
+		return models.FraudCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "fraud.case_resolved", "fraud_case", fraudCase.ID, data.Client, map[string]any{"outcome": data.Outcome, "note": note}); err != nil {
+		log.Printf("failed to audit fraud case %s: %v", fraudCase.ID, err)
+	}
+	return s.caseResp(ctx, fraudCase)
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCustomerConfirmedAll(t *testing.T) {
+	confirmed, denied := "confirmed", "denied"
+	cases := []struct {
+		name  string
+		links []models.FraudCaseTransaction
+		want  bool
+	}{
+		{"no transactions", nil, false},
+		{"all confirmed", []models.FraudCaseTransaction{{CustomerResponse: &confirmed}, {CustomerResponse: &confirmed}}, true},
+		{"one unanswered", []models.FraudCaseTransaction{{CustomerResponse: &confirmed}, {}}, false},
+		{"one denied", []models.FraudCaseTransaction{{CustomerResponse: &confirmed}, {CustomerResponse: &denied}}, false},
+	}
+	for _, c := range cases {
+		if got := customerConfirmedAll(c.links); got != c.want {
+			t.Errorf("%s: customerConfirmedAll = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFraudResponseToken(t *testing.T) {
+	token, hash, err := newLinkToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 || hash != hashLinkToken(token) || hash == token {
+		t.Fatalf("unexpected token %q with hash %q", token, hash)
+	}
+
+	saved := config.FraudResponseUrl
+	defer func() { config.FraudResponseUrl = saved }()
+	config.FraudResponseUrl = "https://app.cardflow.test/fraud/respond"
+	if got := fraudResponseLink("abc"); got != "https://app.cardflow.test/fraud/respond?token=abc" {
+		t.Errorf("fraudResponseLink = %q", got)
+	}
+	config.FraudResponseUrl = "https://app.cardflow.test/respond?lang=en"
+	if got := fraudResponseLink("abc"); got != "https://app.cardflow.test/respond?lang=en&token=abc" {
+		t.Errorf("fraudResponseLink = %q", got)
+	}
+}
+
+func TestParseUUIDs(t *testing.T) {
+	id := uuid.New()
+	ids, err := parseUUIDs([]string{id.String(), " " + id.String() + " "}, "card")
+	if err != nil || len(ids) != 1 || ids[0] != id {
+		t.Fatalf("parseUUIDs = %v, %v", ids, err)
+	}
+	if _, err := parseUUIDs([]string{"not-a-uuid"}, "card"); err == nil || err.Error() != "card not found" {
+		t.Errorf("expected card not found, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Fraud rule types.
+const (
+	FraudRuleVelocity        = "velocity"         // more than max_count authorizations within window_minutes
+	FraudRuleHighRiskMCC     = "high_risk_mcc"    // a card's first authorization is at one of mccs
+	FraudRuleCountryMismatch = "country_mismatch" // the merchant is outside the cardholder's country
+	FraudRuleNearLimit       = "near_limit"       // the amount is at least percent of the spending limit
+	FraudRuleCardTesting     = "card_testing"     // min_count authorizations of at most max_amount within window_minutes
+)
+
+// Fraud rule actions, and the decision on an authorization.
+const (
+	FraudDecline = "decline"
+	FraudFlag    = "flag"
+	FraudApprove = "approve"
+)
+
+// Fraud rule modes. Shadow rules are evaluated and recorded but never change
+// the decision.
+const (
+	FraudModeActive = "active"
+	FraudModeShadow = "shadow"
+)
+
+// maxFraudWindowMinutes bounds how far back a rule can look.
+const maxFraudWindowMinutes = 24 * 60
+
+// FraudRuleParams holds the settings of every rule type; each type reads the
+// fields it needs.
+type FraudRuleParams struct {
+	MaxCount      int      `json:"max_count,omitempty"`
+	MinCount      int      `json:"min_count,omitempty"`
+	WindowMinutes int      `json:"window_minutes,omitempty"`
+	MCCs          []string `json:"mccs,omitempty"`
+	Percent       float64  `json:"percent,omitempty"`
+	MaxAmount     float64  `json:"max_amount,omitempty"`
+}
+
+// FraudAttempt is an earlier authorization on the card.
+type FraudAttempt struct {
+	Amount float64
+	At     time.Time
+}
+
+// FraudInput is what the rules know about an authorization.
+type FraudInput struct {
+	Amount          float64
+	SpendingLimit   float64
+	MCC             string
+	MerchantCountry string
+	HomeCountry     string // the cardholder's country, empty when unknown
+	PriorApproved   int64  // authorizations the card has had approved
+	Recent          []FraudAttempt
+	Now             time.Time
+}
+
+// FraudRuleHit is a rule that matched an authorization, as stored on the
+// transaction.
+type FraudRuleHit struct {
+	RuleID uuid.UUID `json:"rule_id"`
+	Name   string    `json:"name"`
+	Type   string    `json:"type"`
+	Action string    `json:"action"`
+	Shadow bool      `json:"shadow,omitempty"`
+	Detail string    `json:"detail"`
+}
+
+// FraudResult is the outcome of the rules on an authorization.
+type FraudResult struct {
+	Decision string
+	Hits     []FraudRuleHit
+}
+
+// ValidateFraudRuleParams checks and normalizes the settings of a rule type.
+func ValidateFraudRuleParams(kind string, raw json.RawMessage) (FraudRuleParams, error) {
+	var p FraudRuleParams
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return p, errors.New("invalid rule params")
+		}
+	}
+	checkWindow := func() error {
+		if p.WindowMinutes < 1 || p.WindowMinutes > maxFraudWindowMinutes {
+			return fmt.Errorf("window_minutes must be between 1 and %d", maxFraudWindowMinutes)
+		}
+		return nil
+	}
+	switch kind {
+	case FraudRuleVelocity:
+		if p.MaxCount < 1 {
+			return p, errors.New("max_count must be at least 1")
+		}
+		if err := checkWindow(); err != nil {
+			return p, err
+		}
+		return FraudRuleParams{MaxCount: p.MaxCount, WindowMinutes: p.WindowMinutes}, nil
+	case FraudRuleHighRiskMCC:
+		mccs := make([]string, 0, len(p.MCCs))
+		for _, mcc := range p.MCCs {
+			mcc = strings.TrimSpace(mcc)
+			if len(mcc) != 4 || strings.Trim(mcc, "0123456789") != "" {
+				return p, fmt.Errorf("invalid merchant category code %q", mcc)
+			}
+			mccs = append(mccs, mcc)
+		}
+		if len(mccs) == 0 {
+			return p, errors.New("mccs must list at least one merchant category code")
+		}
+		return FraudRuleParams{MCCs: mccs}, nil
+	case FraudRuleCountryMismatch:
+		return FraudRuleParams{}, nil
+	case FraudRuleNearLimit:
+		if p.Percent < 50 || p.Percent > 100 {
+			return p, errors.New("percent must be between 50 and 100")
+		}
+		return FraudRuleParams{Percent: p.Percent}, nil
+	case FraudRuleCardTesting:
+		if p.MaxAmount <= 0 {
+			return p, errors.New("max_amount must be greater than zero")
+		}
+		if p.MinCount < 2 {
+			return p, errors.New("min_count must be at least 2")
+		}
+		if err := checkWindow(); err != nil {
+			return p, err
+		}
+		return FraudRuleParams{MaxAmount: p.MaxAmount, MinCount: p.MinCount, WindowMinutes: p.WindowMinutes}, nil
+	}
+	return p, errors.New("unknown rule type")
+}
+
+// fraudLookback is how far back the given rules need the card's
+// authorizations.
+func fraudLookback(rules []models.FraudRule) time.Duration {
+	minutes := 0
+	for _, rule := range rules {
+		var p FraudRuleParams
+		if json.Unmarshal(rule.Params, &p) == nil && p.WindowMinutes > minutes {
+			minutes = p.WindowMinutes
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// matchFraudRule reports whether a rule matches, with a short explanation.
+func matchFraudRule(kind string, p FraudRuleParams, in FraudInput) (bool, string) {
+	since := in.Now.Add(-time.Duration(p.WindowMinutes) * time.Minute)
+	switch kind {
+	case FraudRuleVelocity:
+		count := 1 // this authorization
+		for _, a := range in.Recent {
+			if !a.At.Before(since) {
+				count++
+			}
+		}
+		if count > p.MaxCount {
+			return true, fmt.Sprintf("%d authorizations in %d minutes", count, p.WindowMinutes)
+		}
+	case FraudRuleHighRiskMCC:
+		if in.PriorApproved > 0 {
+			return false, ""
+		}
+		for _, mcc := range p.MCCs {
+			if mcc == in.MCC {
+				return true, "first authorization at high-risk MCC " + mcc
+			}
+		}
+	case FraudRuleCountryMismatch:
+		if in.HomeCountry != "" && in.MerchantCountry != "" && !strings.EqualFold(in.HomeCountry, in.MerchantCountry) {
+			return true, fmt.Sprintf("merchant in %s, cardholder in %s", strings.ToUpper(in.MerchantCountry), strings.ToUpper(in.HomeCountry))
+		}
+	case FraudRuleNearLimit:
+		if in.SpendingLimit > 0 && in.Amount <= in.SpendingLimit && in.Amount >= in.SpendingLimit*p.Percent/100 {
+			return true, fmt.Sprintf("%.2f is within %.0f%% of the %.2f limit", in.Amount, p.Percent, in.SpendingLimit)
+		}
+	case FraudRuleCardTesting:
+		if in.Amount > p.MaxAmount {
+			return false, ""
+		}
+		count := 1
+		for _, a := range in.Recent {
+			if !a.At.Before(since) && a.Amount <= p.MaxAmount {
+				count++
+			}
+		}
+		if count >= p.MinCount {
+			return true, fmt.Sprintf("%d authorizations of at most %.2f in %d minutes", count, p.MaxAmount, p.WindowMinutes)
+		}
+	}
+	return false, ""
+}
+
+// EvaluateFraudRules runs the rules over an authorization. An active decline
+// rule declines it, otherwise an active flag rule approves and flags it.
+// Shadow hits are recorded without changing the decision. A rule whose
+// params no longer parse is skipped.
+func EvaluateFraudRules(rules []models.FraudRule, in FraudInput) FraudResult {
+	res := FraudResult{Decision: FraudApprove}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		p, err := ValidateFraudRuleParams(rule.Type, json.RawMessage(rule.Params))
+		if err != nil {
+			continue
+		}
+		matched, detail := matchFraudRule(rule.Type, p, in)
+		if !matched {
+			continue
+		}
+		shadow := rule.Mode != FraudModeActive
+		res.Hits = append(res.Hits, FraudRuleHit{RuleID: rule.ID, Name: rule.Name, Type: rule.Type, Action: rule.Action, Shadow: shadow, Detail: detail})
+		if shadow {
+			continue
+		}
+		if rule.Action == FraudDecline {
+			res.Decision = FraudDecline
+		} else if res.Decision == FraudApprove {
+			res.Decision = FraudFlag
+		}
+	}
+	return res
+}
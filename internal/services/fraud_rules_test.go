@@ -0,0 +1,132 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func fraudRule(t *testing.T, kind, action, mode string, params FraudRuleParams) models.FraudRule {
+	t.Helper()
+	raw, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.FraudRule{ID: uuid.New(), Name: kind, Type: kind, Params: raw, Action: action, Mode: mode, Enabled: true}
+}
+
+func TestValidateFraudRuleParams(t *testing.T) {
+	valid := map[string]string{
+		FraudRuleVelocity:        `{"max_count": 5, "window_minutes": 10}`,
+		FraudRuleHighRiskMCC:     `{"mccs": ["7995", "6051"]}`,
+		FraudRuleCountryMismatch: `{}`,
+		FraudRuleNearLimit:       `{"percent": 95}`,
+		FraudRuleCardTesting:     `{"max_amount": 2, "min_count": 3, "window_minutes": 15}`,
+	}
+	for kind, raw := range valid {
+		if _, err := ValidateFraudRuleParams(kind, json.RawMessage(raw)); err != nil {
+			t.Errorf("%s: valid params rejected: %v", kind, err)
+		}
+	}
+
+	invalid := map[string]string{
+		FraudRuleVelocity:    `{"max_count": 5, "window_minutes": 0}`,
+		FraudRuleHighRiskMCC: `{"mccs": ["79x5"]}`,
+		FraudRuleNearLimit:   `{"percent": 120}`,
+		FraudRuleCardTesting: `{"max_amount": 2, "min_count": 1, "window_minutes": 15}`,
+		"geo_velocity":       `{}`,
+	}
+	for kind, raw := range invalid {
+		if _, err := ValidateFraudRuleParams(kind, json.RawMessage(raw)); err == nil {
+			t.Errorf("%s: expected %s to be rejected", kind, raw)
+		}
+	}
+}
+
+func TestEvaluateFraudRules(t *testing.T) {
+	now := time.Now()
+	velocity := fraudRule(t, FraudRuleVelocity, FraudDecline, FraudModeActive, FraudRuleParams{MaxCount: 2, WindowMinutes: 10})
+	mcc := fraudRule(t, FraudRuleHighRiskMCC, FraudFlag, FraudModeActive, FraudRuleParams{MCCs: []string{"7995"}})
+	country := fraudRule(t, FraudRuleCountryMismatch, FraudDecline, FraudModeShadow, FraudRuleParams{})
+	nearLimit := fraudRule(t, FraudRuleNearLimit, FraudFlag, FraudModeActive, FraudRuleParams{Percent: 90})
+	cardTesting := fraudRule(t, FraudRuleCardTesting, FraudDecline, FraudModeActive, FraudRuleParams{MaxAmount: 2, MinCount: 3, WindowMinutes: 15})
+	rules := []models.FraudRule{velocity, mcc, country, nearLimit, cardTesting}
+
+	base := FraudInput{Amount: 50, SpendingLimit: 1000, MCC: "5411", MerchantCountry: "NG", HomeCountry: "NG", PriorApproved: 4, Now: now}
+
+	cases := []struct {
+		name     string
+		in       func(FraudInput) FraudInput
+		decision string
+		hits     int
+	}{
+		{"clean", func(in FraudInput) FraudInput { return in }, FraudApprove, 0},
+		{"velocity", func(in FraudInput) FraudInput {
+			in.Recent = []FraudAttempt{{Amount: 30, At: now.Add(-5 * time.Minute)}, {Amount: 40, At: now.Add(-2 * time.Minute)}}
+			return in
+		}, FraudDecline, 1},
+		{"velocity outside the window", func(in FraudInput) FraudInput {
+			in.Recent = []FraudAttempt{{Amount: 30, At: now.Add(-time.Hour)}, {Amount: 40, At: now.Add(-2 * time.Minute)}}
+			return in
+		}, FraudApprove, 0},
+		{"first use at a high-risk mcc", func(in FraudInput) FraudInput {
+			in.MCC, in.PriorApproved = "7995", 0
+			return in
+		}, FraudFlag, 1},
+		{"high-risk mcc on a used card", func(in FraudInput) FraudInput {
+			in.MCC = "7995"
+			return in
+		}, FraudApprove, 0},
+		{"country mismatch in shadow", func(in FraudInput) FraudInput {
+			in.MerchantCountry = "US"
+			return in
+		}, FraudApprove, 1},
+		{"unknown home country", func(in FraudInput) FraudInput {
+			in.MerchantCountry, in.HomeCountry = "US", ""
+			return in
+		}, FraudApprove, 0},
+		{"just under the limit", func(in FraudInput) FraudInput {
+			in.Amount = 990
+			return in
+		}, FraudFlag, 1},
+		{"card testing", func(in FraudInput) FraudInput {
+			in.Amount = 1
+			in.Recent = []FraudAttempt{{Amount: 1, At: now.Add(-3 * time.Minute)}, {Amount: 0.5, At: now.Add(-12 * time.Minute)}}
+			return in
+		}, FraudDecline, 1},
+	}
+	for _, c := range cases {
+		res := EvaluateFraudRules(rules, c.in(base))
+		if res.Decision != c.decision || len(res.Hits) != c.hits {
+			t.Errorf("%s: got %s with %d hits, want %s with %d", c.name, res.Decision, len(res.Hits), c.decision, c.hits)
+		}
+	}
+}
+
+func TestEvaluateFraudRulesShadowAndDisabled(t *testing.T) {
+	decline := fraudRule(t, FraudRuleNearLimit, FraudDecline, FraudModeShadow, FraudRuleParams{Percent: 90})
+	disabled := fraudRule(t, FraudRuleNearLimit, FraudDecline, FraudModeActive, FraudRuleParams{Percent: 90})
+	disabled.Enabled = false
+
+	res := EvaluateFraudRules([]models.FraudRule{decline, disabled}, FraudInput{Amount: 95, SpendingLimit: 100, Now: time.Now()})
+	if res.Decision != FraudApprove {
+		t.Fatalf("shadow and disabled rules changed the decision to %s", res.Decision)
+	}
+	if len(res.Hits) != 1 || !res.Hits[0].Shadow || res.Hits[0].RuleID != decline.ID {
+		t.Fatalf("expected only the shadow hit to be recorded, got %+v", res.Hits)
+	}
+}
+
+func TestFraudLookback(t *testing.T) {
+	rules := []models.FraudRule{
+		fraudRule(t, FraudRuleVelocity, FraudDecline, FraudModeActive, FraudRuleParams{MaxCount: 3, WindowMinutes: 10}),
+		fraudRule(t, FraudRuleCardTesting, FraudDecline, FraudModeShadow, FraudRuleParams{MaxAmount: 1, MinCount: 3, WindowMinutes: 60}),
+		fraudRule(t, FraudRuleCountryMismatch, FraudFlag, FraudModeActive, FraudRuleParams{}),
+	}
+	if got := fraudLookback(rules); got != time.Hour {
+		t.Errorf("fraudLookback = %v, want 1h", got)
+	}
+}
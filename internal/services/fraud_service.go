@@ -0,0 +1,282 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+const (
+	maxFraudRuleName   = 100
+	fraudRuleHitsLimit = 100
+)
+
+type FraudService interface {
+	CreateRule(ctx context.Context, data models.FraudRuleReq) (models.FraudRuleResp, error)
+	UpdateRule(ctx context.Context, data models.FraudRuleReq) (models.FraudRuleResp, error)
+	GetRules(ctx context.Context) ([]models.FraudRuleResp, error)
+	GetRuleHits(ctx context.Context, ruleID string) ([]models.FraudRuleHitResp, error)
+	Evaluate(ctx context.Context, card models.Card, data models.WebhookReq) FraudResult
+	RecordAuthorization(ctx context.Context, card models.Card, txn models.Transaction, result FraudResult)
+	OpenCase(ctx context.Context, data FraudCaseOpen) (*models.FraudCase, error)
+	ReportFraud(ctx context.Context, data models.FraudReportReq) (models.FraudCaseResp, error)
+	RespondToTransaction(ctx context.Context, data models.FraudResponseReq) error
+	GetCases(ctx context.Context, status string) ([]models.FraudCaseResp, error)
+	GetCase(ctx context.Context, caseID string) (models.FraudCaseResp, error)
+	LinkToCase(ctx context.Context, data models.FraudCaseLinkReq) (models.FraudCaseResp, error)
+	CaseCardAction(ctx context.Context, data models.FraudCaseCardActionReq) (models.FraudCaseResp, error)
+	ResolveCase(ctx context.Context, data models.FraudCaseResolveReq) (models.FraudCaseResp, error)
+}
+
+type fraudService struct {
+	fraudrepo        repositories.FraudRepository
+	txnrepo          repositories.TransactionRepository
+	kycrepo          repositories.KycRepository
+	cardrepo         repositories.CardRepository
+	userrepo         repositories.UserRepository
+	auditrepo        repositories.AuditRepository
+	notificationrepo repositories.NotificationRepository
+	cards            CardStatusModifier
+}
+
+func NewFraudService(fraudRepo repositories.FraudRepository, txnRepo repositories.TransactionRepository, kycRepo repositories.KycRepository, cardRepo repositories.CardRepository, userRepo repositories.UserRepository, auditRepo repositories.AuditRepository, notificationRepo repositories.NotificationRepository, cards CardStatusModifier) FraudService {
+	return &fraudService{fraudrepo: fraudRepo, txnrepo: txnRepo, kycrepo: kycRepo, cardrepo: cardRepo, userrepo: userRepo, auditrepo: auditRepo, notificationrepo: notificationRepo, cards: cards}
+}
+
+func fraudRuleResp(rule models.FraudRule) models.FraudRuleResp {
+	params := json.RawMessage(rule.Params)
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	return models.FraudRuleResp{
+		Ruleid:    rule.ID,
+		Name:      rule.Name,
+		Type:      rule.Type,
+		Params:    params,
+		Action:    rule.Action,
+		Mode:      rule.Mode,
+		Enabled:   rule.Enabled,
+		CreatedAt: rule.CreatedAt,
+		UpdatedAt: rule.UpdatedAt,
+	}
+}
+
+func validFraudAction(action string) bool {
+	return action == FraudDecline || action == FraudFlag
+}
+
+func validFraudMode(mode string) bool {
+	return mode == FraudModeActive || mode == FraudModeShadow
+}
+
+// CreateRule adds a fraud rule. New rules start in shadow mode unless the
+// admin asks for active, so their hits can be reviewed first.
+func (s *fraudService) CreateRule(ctx context.Context, data models.FraudRuleReq) (models.FraudRuleResp, error) {
+	name := strings.TrimSpace(data.Name)
+	if name == "" || len(name) > maxFraudRuleName {
+		return models.FraudRuleResp{}, errors.New("name must be between 1 and 100 characters")
+	}
+	params, err := ValidateFraudRuleParams(data.Type, data.Params)
+	if err != nil {
+		return models.FraudRuleResp{}, err
+	}
+	if !validFraudAction(data.Action) {
+		return models.FraudRuleResp{}, errors.New("action must be decline or flag")
+	}
+	mode := data.Mode
+	if mode == "" {
+		mode = FraudModeShadow
+	}
+	if !validFraudMode(mode) {
+		return models.FraudRuleResp{}, errors.New("mode must be active or shadow")
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return models.FraudRuleResp{}, errors.New("something went wrong, please try again later")
+	}
+	rule := &models.FraudRule{
+		Name:      name,
+		Type:      data.Type,
+		Params:    datatypes.JSON(raw),
+		Action:    data.Action,
+		Mode:      mode,
+		Enabled:   data.Enabled == nil || *data.Enabled,
+		CreatedBy: data.AdminId,
+	}
+	if err := s.fraudrepo.CreateRule(ctx, rule); err != nil {
+		return models.FraudRuleResp{}, errors.New("something went wrong, please try again later")
+	}
+	meta := map[string]any{"name": rule.Name, "type": rule.Type, "params": params, "action": rule.Action, "mode": rule.Mode, "enabled": rule.Enabled}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "fraud.rule_created", "fraud_rule", rule.ID, data.Client, meta); err != nil {
+		log.Printf("failed to audit fraud rule %s: %v", rule.ID, err)
+	}
+	return fraudRuleResp(*rule), nil
+}
+
+// UpdateRule changes the fields set in the request. The type of a rule is
+// fixed; a different check is a new rule.
+func (s *fraudService) UpdateRule(ctx context.Context, data models.FraudRuleReq) (models.FraudRuleResp, error) {
+	id, err := uuid.Parse(data.Ruleid)
+	if err != nil {
+		return models.FraudRuleResp{}, errors.New("rule not found")
+	}
+	rule, err := s.fraudrepo.FindRule(ctx, id)
+	if err != nil {
+		return models.FraudRuleResp{}, errors.New("something went wrong, please try again later")
+	}
+	if rule == nil {
+		return models.FraudRuleResp{}, errors.New("rule not found")
+	}
+	if data.Type != "" && data.Type != rule.Type {
+		return models.FraudRuleResp{}, errors.New("the type of a rule cannot be changed")
+	}
+
+	changes := map[string]any{}
+	if data.Name != "" {
+		name := strings.TrimSpace(data.Name)
+		if name == "" || len(name) > maxFraudRuleName {
+			return models.FraudRuleResp{}, errors.New("name must be between 1 and 100 characters")
+		}
+		rule.Name, changes["name"] = name, name
+	}
+	if len(data.Params) > 0 {
+		params, err := ValidateFraudRuleParams(rule.Type, data.Params)
+		if err != nil {
+			return models.FraudRuleResp{}, err
+		}
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return models.FraudRuleResp{}, errors.New("something went wrong, please try again later")
+		}
+		rule.Params, changes["params"] = datatypes.JSON(raw), params
+	}
+	if data.Action != "" {
+		if !validFraudAction(data.Action) {
+			return models.FraudRuleResp{}, errors.New("action must be decline or flag")
+		}
+		rule.Action, changes["action"] = data.Action, data.Action
+	}
+	if data.Mode != "" {
+		if !validFraudMode(data.Mode) {
+			return models.FraudRuleResp{}, errors.New("mode must be active or shadow")
+		}
+		rule.Mode, changes["mode"] = data.Mode, data.Mode
+	}
+	if data.Enabled != nil {
+		rule.Enabled, changes["enabled"] = *data.Enabled, *data.Enabled
+	}
+	if len(changes) == 0 {
+		return models.FraudRuleResp{}, errors.New("nothing to update")
+	}
+	rule.UpdatedBy = &data.AdminId
+	if err := s.fraudrepo.UpdateRule(ctx, rule); err != nil {
+		return models.FraudRuleResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "fraud.rule_updated", "fraud_rule", rule.ID, data.Client, changes); err != nil {
+		log.Printf("failed to audit fraud rule %s: %v", rule.ID, err)
+	}
+	return fraudRuleResp(*rule), nil
+}
+
+func (s *fraudService) GetRules(ctx context.Context) ([]models.FraudRuleResp, error) {
+	rules, err := s.fraudrepo.FindRules(ctx)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.FraudRuleResp, 0, len(rules))
+	for _, rule := range rules {
+		res = append(res, fraudRuleResp(rule))
+	}
+	return res, nil
+}
+
+// GetRuleHits lists the latest authorizations a rule matched, which is how a
+// shadow rule is judged before it is made active.
+func (s *fraudService) GetRuleHits(ctx context.Context, ruleID string) ([]models.FraudRuleHitResp, error) {
+	id, err := uuid.Parse(ruleID)
+	if err != nil {
+		return nil, errors.New("rule not found")
+	}
+	txns, err := s.fraudrepo.FindRuleHits(ctx, id, fraudRuleHitsLimit)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.FraudRuleHitResp, 0, len(txns))
+	for _, txn := range txns {
+		hit := models.FraudRuleHitResp{
+			Transactionid: txn.ID,
+			Cardid:        txn.CardID,
+			Amount:        txn.Amount,
+			Currency:      txn.Currency,
+			Status:        txn.Status,
+			Hits:          json.RawMessage(txn.FraudRuleHits),
+			CreatedAt:     txn.CreatedAt,
+		}
+		if txn.FraudDecision != nil {
+			hit.FraudDecision = *txn.FraudDecision
+		}
+		res = append(res, hit)
+	}
+	return res, nil
+}
+
+// Evaluate runs the enabled rules over an authorization. The engine fails
+// open: when the rules or the card's history cannot be loaded the
+// authorization goes on to the usual checks, and the error is logged.
+func (s *fraudService) Evaluate(ctx context.Context, card models.Card, data models.WebhookReq) FraudResult {
+	rules, err := s.fraudrepo.FindEnabledRules(ctx)
+	if err != nil {
+		log.Printf("failed to load fraud rules for card %s: %v", card.ID, err)
+		return FraudResult{Decision: FraudApprove}
+	}
+	if len(rules) == 0 {
+		return FraudResult{Decision: FraudApprove}
+	}
+
+	now := time.Now()
+	in := FraudInput{
+		Amount:          data.Amount,
+		SpendingLimit:   card.SpendingLimitAmount,
+		MCC:             data.Merchant.MCC,
+		MerchantCountry: data.Merchant.Country,
+		Now:             now,
+	}
+	if lookback := fraudLookback(rules); lookback > 0 {
+		recent, err := s.txnrepo.FindCardAuthorizationsSince(ctx, card.ID, now.Add(-lookback))
+		if err != nil {
+			log.Printf("failed to load recent authorizations of card %s: %v", card.ID, err)
+			return FraudResult{Decision: FraudApprove}
+		}
+		for _, txn := range recent {
+			in.Recent = append(in.Recent, FraudAttempt{Amount: txn.AuthorizedAmount, At: txn.CreatedAt})
+		}
+	}
+	if in.PriorApproved, err = s.txnrepo.CountCardAuthorizations(ctx, card.ID); err != nil {
+		log.Printf("failed to count authorizations of card %s: %v", card.ID, err)
+		return FraudResult{Decision: FraudApprove}
+	}
+	in.HomeCountry = s.homeCountry(ctx, card.UserID)
+	return EvaluateFraudRules(rules, in)
+}
+
+// homeCountry is the country on the cardholder's verified KYC profile, or
+// empty when there is none.
+func (s *fraudService) homeCountry(ctx context.Context, userID uuid.UUID) string {
+	sub, err := s.kycrepo.FindApprovedByUserID(ctx, userID)
+	if err != nil || sub == nil {
+		return ""
+	}
+	profile, err := s.kycrepo.FindProfile(ctx, sub.ID)
+	if err != nil || profile == nil {
+		return ""
+	}
+	return profile.Country
+}
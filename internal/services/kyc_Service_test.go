@@ -5,6 +5,7 @@ import (
 	"CardFlow/internal/repositories"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -37,6 +38,106 @@ func (f *fakeKycRepo) CreateKycDocsSubmission(doc *models.KYCDocument) error {
 	return f.createDocErr
 }
 
+func (f *fakeKycRepo) FindDocumentsNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int) ([]models.KYCDocument, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) UpdateDocumentCiphertext(ctx context.Context, docID uuid.UUID, old, encrypted []byte, keyID string) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeKycRepo) FindDocumentsInDatabase(ctx context.Context, afterID uuid.UUID, limit int) ([]models.KYCDocument, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) SetDocumentObject(ctx context.Context, docID uuid.UUID, key, checksum string, size int64, keyID string) error {
+	return nil
+}
+
+func (f *fakeKycRepo) CreateUpload(ctx context.Context, upload *models.KYCUpload) error {
+	return nil
+}
+
+func (f *fakeKycRepo) FindUpload(ctx context.Context, uploadID, userID uuid.UUID) (*models.KYCUpload, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) UpdateUploadStatus(ctx context.Context, uploadID uuid.UUID, status string) error {
+	return nil
+}
+
+func (f *fakeKycRepo) FindSubmissionByID(ctx context.Context, id uuid.UUID) (*models.KYCSubmission, error) {
+	return f.existingSubmission, f.findErr
+}
+
+func (f *fakeKycRepo) FindDocumentsBySubmission(ctx context.Context, submissionID uuid.UUID) ([]models.KYCDocument, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) CreateProviderCheck(ctx context.Context, check *models.KYCProviderCheck) error {
+	return nil
+}
+
+func (f *fakeKycRepo) UpdateProviderCheck(ctx context.Context, check *models.KYCProviderCheck) error {
+	return nil
+}
+
+func (f *fakeKycRepo) FindProviderChecks(ctx context.Context, submissionID uuid.UUID) ([]models.KYCProviderCheck, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) FindDocumentTypes(ctx context.Context, submissionID uuid.UUID) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) FindApprovedByUserID(ctx context.Context, userID uuid.UUID) (*models.KYCSubmission, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) UpdateSubmissionReview(ctx context.Context, kyc *models.KYCSubmission) error {
+	return nil
+}
+
+func (f *fakeKycRepo) FindSubmissionsDueReminder(ctx context.Context, expiringBefore time.Time) ([]models.KYCSubmission, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) MarkExpiryReminderSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	return nil
+}
+
+func (f *fakeKycRepo) ExpireSubmissions(ctx context.Context, now time.Time) ([]models.KYCSubmission, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) FindDocumentSummaries(ctx context.Context, submissionID uuid.UUID) ([]models.KYCDocument, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) UpdateDocumentReview(ctx context.Context, doc *models.KYCDocument) error {
+	return nil
+}
+
+func (f *fakeKycRepo) FindProfile(ctx context.Context, submissionID uuid.UUID) (*models.KYCProfile, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) SaveProfile(ctx context.Context, profile *models.KYCProfile) error {
+	return nil
+}
+
+func (f *fakeKycRepo) SetProfileDateOfBirth(ctx context.Context, submissionID uuid.UUID, dob time.Time) error {
+	return nil
+}
+
+func (f *fakeKycRepo) FindProfilesNotOnKey(ctx context.Context, keyID string, afterID uuid.UUID, limit int) ([]models.KYCProfile, error) {
+	return nil, nil
+}
+
+func (f *fakeKycRepo) UpdateProfileIDNumber(ctx context.Context, profileID uuid.UUID, old, encrypted string) (bool, error) {
+	return true, nil
+}
+
 
 func TestUploadImage_Success(t *testing.T) {
 	repo := &fakeKycRepo{
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// documentObjectKey names a document's object. The random suffix means a
+// re-upload never overwrites the object an existing row points at.
+func documentObjectKey(submissionID uuid.UUID, docType string) string {
+	return fmt.Sprintf("kyc/%s/%s-%s", submissionID, docType, uuid.NewString())
+}
+
+// uploadDocument validates and encrypts a document and puts the ciphertext
+// in the store, returning the document row to save. If saving the row fails
+// the caller removes the object with discardDocument.
+func uploadDocument(ctx context.Context, store integrations.DocumentStore, submissionID uuid.UUID, docType string, plain []byte) (*models.KYCDocument, error) {
+	encrypted, mime, err := utils.EncryptDocument(plain)
+	if err != nil {
+		return nil, err
+	}
+	key := documentObjectKey(submissionID, docType)
+	if err := store.Put(ctx, key, []byte(encrypted)); err != nil {
+		log.Printf("failed to store kyc document for submission %s: %v", submissionID, err)
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	return &models.KYCDocument{
+		KYCSubmissionID:   submissionID,
+		DocumentType:      docType,
+		MimeType:          mime,
+		EncryptionVersion: utils.KeyIDOf(encrypted),
+		StorageKey:        &key,
+		Checksum:          documentChecksum([]byte(encrypted)),
+		SizeBytes:         int64(len(encrypted)),
+		Status:            KycDocPending,
+	}, nil
+}
+
+func discardDocument(ctx context.Context, store integrations.DocumentStore, doc *models.KYCDocument) {
+	if doc == nil || doc.StorageKey == nil {
+		return
+	}
+	if err := store.Delete(ctx, *doc.StorageKey); err != nil {
+		log.Printf("failed to delete orphaned kyc document %s: %v", *doc.StorageKey, err)
+	}
+}
+
+// documentCiphertext returns a document's ciphertext from the store, checking
+// it against the recorded checksum, or from the row for documents that have
+// not been moved out of Postgres yet.
+func documentCiphertext(ctx context.Context, store integrations.DocumentStore, doc models.KYCDocument) ([]byte, error) {
+	if doc.StorageKey == nil {
+		return doc.EncryptedData, nil
+	}
+	data, err := store.Get(ctx, *doc.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	if documentChecksum(data) != doc.Checksum {
+		return nil, fmt.Errorf("kyc document %s failed its checksum", doc.ID)
+	}
+	return data, nil
+}
+
+func documentChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
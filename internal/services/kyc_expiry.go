@@ -0,0 +1,130 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrKycExpired = errors.New("your identity verification has expired, resubmit your documents to continue")
+
+func kycReverifyMonths() int {
+	if months, err := strconv.Atoi(config.KycReverifyMonths); err == nil && months > 0 {
+		return months
+	}
+	return 24
+}
+
+func kycExpiryReminderWindow() time.Duration {
+	if days, err := strconv.Atoi(config.KycExpiryReminderDays); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return 30 * 24 * time.Hour
+}
+
+// KycExpiryFor is when an approval made at approvedAt runs out: the end of
+// the day the ID document expires, or the end of the re-verification
+// period, whichever comes first.
+func KycExpiryFor(idExpiresOn, approvedAt time.Time, reverifyMonths int) time.Time {
+	expiry := approvedAt.AddDate(0, reverifyMonths, 0)
+	idEnd := time.Date(idExpiresOn.Year(), idExpiresOn.Month(), idExpiresOn.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	if idEnd.Before(expiry) {
+		return idEnd
+	}
+	return expiry
+}
+
+// KycRestricted reports whether an approved submission has run out, even if
+// the nightly job has not marked it expired yet.
+func KycRestricted(sub *models.KYCSubmission, now time.Time) bool {
+	if sub == nil {
+		return false
+	}
+	if sub.Status == KycExpired {
+		return true
+	}
+	return sub.Status == KycVerified && sub.ExpiresAt != nil && !sub.ExpiresAt.After(now)
+}
+
+// CanStartNewKyc reports whether the user's next upload opens a new
+// submission rather than going on their latest one. A rejected submission is
+// fixed in place by replacing the rejected documents.
+func CanStartNewKyc(sub *models.KYCSubmission, now time.Time, reminderWindow time.Duration) bool {
+	switch sub.Status {
+	case KycExpired:
+		return true
+	case KycVerified:
+		return sub.ExpiresAt != nil && sub.ExpiresAt.Before(now.Add(reminderWindow))
+	}
+	return false
+}
+
+// NotifyKycExpiringSoon reminds users whose verification runs out within the
+// reminder window to resubmit their documents. Each approval gets one
+// reminder.
+func (s *cronService) NotifyKycExpiringSoon(ctx context.Context) {
+	now := time.Now()
+	subs, err := s.kycRepo.FindSubmissionsDueReminder(ctx, now.Add(kycExpiryReminderWindow()))
+	if err != nil {
+		log.Printf("failed to find kyc submissions due a reminder: %v", err)
+		return
+	}
+	s.notifyKycHolders(ctx, subs, "expiring", func(sub models.KYCSubmission) {
+		if err := s.kycRepo.MarkExpiryReminderSent(ctx, sub.ID, now); err != nil {
+			log.Printf("failed to mark kyc expiry reminder for submission %s: %v", sub.ID, err)
+		}
+	})
+}
+
+// ExpireKycSubmissions restricts users whose verification has run out.
+func (s *cronService) ExpireKycSubmissions(ctx context.Context) {
+	subs, err := s.kycRepo.ExpireSubmissions(ctx, time.Now())
+	if err != nil {
+		log.Printf("failed to expire kyc submissions: %v", err)
+		return
+	}
+	s.notifyKycHolders(ctx, subs, "expired", nil)
+}
+
+func (s *cronService) notifyKycHolders(ctx context.Context, subs []models.KYCSubmission, status string, sent func(models.KYCSubmission)) {
+	if len(subs) == 0 {
+		return
+	}
+	ids := make([]uuid.UUID, 0, len(subs))
+	for _, sub := range subs {
+		ids = append(ids, sub.UserID)
+	}
+	users, err := s.userRepo.FindUsersByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("failed to load users for kyc notifications: %v", err)
+		return
+	}
+	userMap := mapUsersByID(users)
+	for _, sub := range subs {
+		user, ok := userMap[sub.UserID]
+		if !ok {
+			continue
+		}
+		data := map[string]string{
+			"Email":     user.Email,
+			"FirstName": user.FirstName,
+			"Status":    status,
+		}
+		if sub.ExpiresAt != nil {
+			data["ExpiresAt"] = sub.ExpiresAt.Format("2 January 2006")
+		}
+		if err := SendKycExpiryEmail(data); err != nil {
+			log.Printf("failed to send kyc %s email to user %s: %v", status, user.ID, err)
+			continue
+		}
+		if sent != nil {
+			sent(sub)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"testing"
+	"time"
+)
+
+func TestKycExpiryFor(t *testing.T) {
+	approved := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	// an ID expiring before the re-verification period ends caps the approval
+	got := KycExpiryFor(time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), approved, 24)
+	if want := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got = KycExpiryFor(time.Date(2035, 1, 1, 0, 0, 0, 0, time.UTC), approved, 24)
+	if want := approved.AddDate(2, 0, 0); !got.Equal(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestKycRestricted(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	if KycRestricted(nil, now) {
+		t.Fatal("no approval is not a restriction")
+	}
+	if KycRestricted(&models.KYCSubmission{Status: KycVerified, ExpiresAt: &future}, now) {
+		t.Fatal("a current approval should not be restricted")
+	}
+	if !KycRestricted(&models.KYCSubmission{Status: KycVerified, ExpiresAt: &past}, now) {
+		t.Fatal("a lapsed approval should be restricted before the nightly job runs")
+	}
+	if !KycRestricted(&models.KYCSubmission{Status: KycExpired}, now) {
+		t.Fatal("an expired submission should be restricted")
+	}
+}
+
+func TestCanStartNewKyc(t *testing.T) {
+	now := time.Now()
+	window := 30 * 24 * time.Hour
+	soon, later := now.Add(10*24*time.Hour), now.Add(200*24*time.Hour)
+
+	cases := []struct {
+		name string
+		sub  models.KYCSubmission
+		want bool
+	}{
+		{"in progress", models.KYCSubmission{Status: UnderReview}, false},
+		{"rejected, fixed in place", models.KYCSubmission{Status: KycRejected}, false},
+		{"expired", models.KYCSubmission{Status: KycExpired}, true},
+		{"verified, expiring soon", models.KYCSubmission{Status: KycVerified, ExpiresAt: &soon}, true},
+		{"verified, not due", models.KYCSubmission{Status: KycVerified, ExpiresAt: &later}, false},
+	}
+	for _, tc := range cases {
+		if got := CanStartNewKyc(&tc.sub, now, window); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KycIDTypes are the identity documents a profile can name.
+var KycIDTypes = []string{"passport", "national_id", "drivers_license", "voters_card"}
+
+var (
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	idNumberPattern    = regexp.MustCompile(`^[A-Z0-9]{5,20}$`)
+)
+
+const maxKycAge = 120
+
+func kycMinimumAge() int {
+	if age, err := strconv.Atoi(config.KycMinimumAge); err == nil && age > 0 {
+		return age
+	}
+	return 18
+}
+
+// ageOn is how many full years old someone born on dob is on now.
+func ageOn(dob, now time.Time) int {
+	age := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// checkDateOfBirth reads a YYYY-MM-DD date of birth and holds it to the
+// minimum age.
+func checkDateOfBirth(value string, now time.Time, minAge int) (time.Time, error) {
+	dob, err := time.Parse("2006-01-02", value)
+	if err != nil || dob.After(now) {
+		return time.Time{}, errors.New("invalid date of birth, use YYYY-MM-DD")
+	}
+	age := ageOn(dob, now)
+	if age > maxKycAge {
+		return time.Time{}, errors.New("invalid date of birth")
+	}
+	if age < minAge {
+		return time.Time{}, fmt.Errorf("you must be at least %d years old", minAge)
+	}
+	return dob, nil
+}
+
+// normalizeIDNumber drops the spaces and dashes people type into document
+// numbers and upper-cases the rest.
+func normalizeIDNumber(number string) string {
+	number = strings.ToUpper(number)
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
+
+func validKycIDType(idType string) bool {
+	for _, t := range KycIDTypes {
+		if t == idType {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateKycProfile checks the details a user gives for their profile and
+// returns them as a profile row, with the ID number still in plain text.
+func ValidateKycProfile(req models.KycProfileDetailsReq, now time.Time, minAge int) (*models.KYCProfile, string, error) {
+	dob, err := checkDateOfBirth(req.DateOfBirth, now, minAge)
+	if err != nil {
+		return nil, "", err
+	}
+	nationality := strings.ToUpper(strings.TrimSpace(req.Nationality))
+	if !countryCodePattern.MatchString(nationality) {
+		return nil, "", errors.New("nationality must be a two letter country code")
+	}
+
+	addr := req.Address
+	line1, line2 := strings.TrimSpace(addr.Line1), strings.TrimSpace(addr.Line2)
+	city, state := strings.TrimSpace(addr.City), strings.TrimSpace(addr.State)
+	postal := strings.TrimSpace(addr.PostalCode)
+	country := strings.ToUpper(strings.TrimSpace(addr.Country))
+	if line1 == "" || city == "" {
+		return nil, "", errors.New("address line 1 and city are required")
+	}
+	if len(line1) > 100 || len(line2) > 100 || len(city) > 100 || len(state) > 100 || len(postal) > 20 {
+		return nil, "", errors.New("address is too long")
+	}
+	if !countryCodePattern.MatchString(country) {
+		return nil, "", errors.New("address country must be a two letter country code")
+	}
+
+	id := req.IDDocument
+	if !validKycIDType(id.Type) {
+		return nil, "", errors.New("id type must be one of " + strings.Join(KycIDTypes, ", "))
+	}
+	number := normalizeIDNumber(id.Number)
+	if !idNumberPattern.MatchString(number) {
+		return nil, "", errors.New("invalid id number")
+	}
+	issuer := strings.ToUpper(strings.TrimSpace(id.IssuingCountry))
+	if !countryCodePattern.MatchString(issuer) {
+		return nil, "", errors.New("id issuing country must be a two letter country code")
+	}
+	idExpiry, err := time.Parse("2006-01-02", id.ExpiryDate)
+	if err != nil {
+		return nil, "", errors.New("invalid id expiry date, use YYYY-MM-DD")
+	}
+	if !idExpiry.After(now) {
+		return nil, "", errors.New("the id document has expired")
+	}
+
+	return &models.KYCProfile{
+		DateOfBirth:      &dob,
+		Nationality:      nationality,
+		AddressLine1:     line1,
+		AddressLine2:     line2,
+		City:             city,
+		State:            state,
+		PostalCode:       postal,
+		Country:          country,
+		IDType:           id.Type,
+		IDNumberLast4:    number[len(number)-4:],
+		IDIssuingCountry: issuer,
+		IDExpiresOn:      &idExpiry,
+	}, number, nil
+}
+
+// KycProfileComplete reports whether a profile has everything a submission
+// needs before it can go under review. A date of birth given with the
+// selfie alone does not make one.
+func KycProfileComplete(p *models.KYCProfile) bool {
+	return p != nil && p.DateOfBirth != nil && p.Nationality != "" && p.AddressLine1 != "" &&
+		p.City != "" && p.Country != "" && p.IDType != "" && p.IDNumberEncrypted != "" &&
+		p.IDIssuingCountry != "" && p.IDExpiresOn != nil
+}
+
+// kycProfileEditable is whether the user can still change the profile on a
+// submission in this status.
+func kycProfileEditable(status string) bool {
+	return status == "started" || status == DocsUploaded || status == KycRejected
+}
+
+// SaveKycProfile validates and stores the user's profile on their open
+// submission, encrypting the ID number, and sends the submission for review
+// if nothing else is outstanding.
+func (s *kycService) SaveKycProfile(ctx context.Context, data models.KycProfileDetailsReq) (models.KycProfileResp, error) {
+	profile, number, err := ValidateKycProfile(data, time.Now(), kycMinimumAge())
+	if err != nil {
+		return models.KycProfileResp{}, err
+	}
+	profile.IDNumberEncrypted, err = utils.EncryptWithKeyring(number)
+	if err != nil {
+		log.Printf("failed to encrypt id number for user %s: %v", data.Userid, err)
+		return models.KycProfileResp{}, errors.New("something went wrong, please try again later")
+	}
+
+	var reviewID uuid.UUID
+	err = s.kycrepo.RunInTransaction(ctx, func(repo repositories.KycRepository) error {
+		sub, err := openKycSubmission(repo, data.Userid)
+		if err != nil {
+			return err
+		}
+		if !kycProfileEditable(sub.Status) {
+			return fmt.Errorf("your profile cannot be changed while your verification is %s", strings.ReplaceAll(sub.Status, "_", " "))
+		}
+		profile.KYCSubmissionID = sub.ID
+		if err := repo.SaveProfile(ctx, profile); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		underReview, err := advanceKycSubmission(ctx, repo, sub)
+		if underReview {
+			reviewID = sub.ID
+		}
+		return err
+	})
+	if err != nil {
+		return models.KycProfileResp{}, err
+	}
+	if reviewID != uuid.Nil {
+		s.submittedForReview(data.Userid, reviewID)
+	}
+	return kycProfileResp(profile, maskIDNumber(profile.IDNumberLast4)), nil
+}
+
+// GetKycProfile shows the user the profile on their latest submission, with
+// the ID number masked.
+func (s *kycService) GetKycProfile(ctx context.Context, userID uuid.UUID) (models.KycProfileResp, error) {
+	sub, err := s.kycrepo.FindByUserID(userID)
+	if err != nil {
+		return models.KycProfileResp{}, errors.New("something went wrong, please try again later")
+	}
+	if sub == nil {
+		return models.KycProfileResp{}, errors.New("no kyc profile found")
+	}
+	profile, err := s.kycrepo.FindProfile(ctx, sub.ID)
+	if err != nil {
+		return models.KycProfileResp{}, errors.New("something went wrong, please try again later")
+	}
+	if profile == nil {
+		return models.KycProfileResp{Submissionid: sub.ID}, nil
+	}
+	return kycProfileResp(profile, maskIDNumber(profile.IDNumberLast4)), nil
+}
+
+// GetProfileForReview shows a reviewer a submission's profile with the full
+// ID number. Every view is audited.
+func (s *kycService) GetProfileForReview(ctx context.Context, data models.AdminKycCheckReq) (models.KycProfileResp, error) {
+	submissionID, err := uuid.Parse(data.Submissionid)
+	if err != nil {
+		return models.KycProfileResp{}, errors.New("kyc submission not found")
+	}
+	profile, err := s.kycrepo.FindProfile(ctx, submissionID)
+	if err != nil {
+		return models.KycProfileResp{}, errors.New("something went wrong, please try again later")
+	}
+	if profile == nil {
+		return models.KycProfileResp{}, errors.New("no kyc profile found")
+	}
+	var number string
+	if profile.IDNumberEncrypted != "" {
+		number, err = utils.DecryptWithKeyring(profile.IDNumberEncrypted)
+		if err != nil {
+			log.Printf("failed to decrypt id number on kyc profile %s: %v", profile.ID, err)
+			return models.KycProfileResp{}, errors.New("something went wrong, please try again later")
+		}
+	}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "kyc.profile_viewed", "kyc_submission", submissionID, data.Client, nil); err != nil {
+		return models.KycProfileResp{}, errors.New("something went wrong, please try again later")
+	}
+	return kycProfileResp(profile, number), nil
+}
+
+func maskIDNumber(last4 string) string {
+	if last4 == "" {
+		return ""
+	}
+	return "****" + last4
+}
+
+func kycProfileResp(p *models.KYCProfile, number string) models.KycProfileResp {
+	res := models.KycProfileResp{
+		Submissionid: p.KYCSubmissionID,
+		Nationality:  p.Nationality,
+		Address: models.KycAddress{
+			Line1:      p.AddressLine1,
+			Line2:      p.AddressLine2,
+			City:       p.City,
+			State:      p.State,
+			PostalCode: p.PostalCode,
+			Country:    p.Country,
+		},
+		IDDocument: models.KycIDDocument{
+			Type:           p.IDType,
+			Number:         number,
+			IssuingCountry: p.IDIssuingCountry,
+		},
+		Complete: KycProfileComplete(p),
+	}
+	if p.DateOfBirth != nil {
+		res.DateOfBirth = p.DateOfBirth.Format("2006-01-02")
+	}
+	if p.IDExpiresOn != nil {
+		res.IDDocument.ExpiryDate = p.IDExpiresOn.Format("2006-01-02")
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"testing"
+	"time"
+)
+
+func validKycProfileReq() models.KycProfileDetailsReq {
+	return models.KycProfileDetailsReq{
+		DateOfBirth: "1990-05-20",
+		Nationality: "ng",
+		Address:     models.KycAddress{Line1: "12 Marina Road", City: "Lagos", Country: "NG"},
+		IDDocument: models.KycIDDocument{
+			Type:           "passport",
+			Number:         "a123-456 78",
+			IssuingCountry: "NG",
+			ExpiryDate:     "2030-01-01",
+		},
+	}
+}
+
+func TestValidateKycProfile(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	profile, number, err := ValidateKycProfile(validKycProfileReq(), now, 18)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if number != "A12345678" || profile.IDNumberLast4 != "5678" {
+		t.Fatalf("got number %q last4 %q", number, profile.IDNumberLast4)
+	}
+	if profile.Nationality != "NG" {
+		t.Fatalf("nationality not normalized: %q", profile.Nationality)
+	}
+
+	cases := map[string]func(*models.KycProfileDetailsReq){
+		"under age":       func(r *models.KycProfileDetailsReq) { r.DateOfBirth = "2008-03-02" },
+		"bad nationality": func(r *models.KycProfileDetailsReq) { r.Nationality = "NGA" },
+		"no city":         func(r *models.KycProfileDetailsReq) { r.Address.City = " " },
+		"bad id type":     func(r *models.KycProfileDetailsReq) { r.IDDocument.Type = "library_card" },
+		"short id number": func(r *models.KycProfileDetailsReq) { r.IDDocument.Number = "12" },
+		"expired id":      func(r *models.KycProfileDetailsReq) { r.IDDocument.ExpiryDate = "2026-02-28" },
+	}
+	for name, change := range cases {
+		req := validKycProfileReq()
+		change(&req)
+		if _, _, err := ValidateKycProfile(req, now, 18); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	dob := time.Date(2008, 3, 2, 0, 0, 0, 0, time.UTC)
+	if got := ageOn(dob, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)); got != 17 {
+		t.Fatalf("got %d, want 17", got)
+	}
+	if got := ageOn(dob, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)); got != 18 {
+		t.Fatalf("got %d, want 18", got)
+	}
+}
@@ -1,26 +1,45 @@
 package services
 
 import (
+	"CardFlow/internal/integrations"
 	"CardFlow/internal/models"
 	"CardFlow/internal/repositories"
 	"CardFlow/internal/utils"
 	"context"
 	"errors"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type KycService interface {
     Uploadimage(context.Context, models.KycProfile)error
     UploadKycDocument(context.Context, models.KycDoc) error
 	UploadProofOfAddress(context.Context, models.KycDoc) error
+	UploadKycFile(context.Context, models.KycFile) error
+	CreateKycUpload(context.Context, models.KycUploadReq) (models.KycUploadResp, error)
+	ConfirmKycUpload(context.Context, models.ConfirmKycUploadReq) error
+	GetProviderChecks(context.Context, models.AdminKycCheckReq) ([]models.KycProviderCheckResp, error)
+	RerunProviderCheck(context.Context, models.AdminKycCheckReq) (models.KycProviderCheckResp, error)
+	GetKycTier(context.Context, uuid.UUID) (models.KycTierResp, error)
+	ReviewSubmission(context.Context, models.KycReviewReq) (models.KycReviewResp, error)
+	GetKycStatus(context.Context, uuid.UUID) (models.KycStatusResp, error)
+	SaveKycProfile(context.Context, models.KycProfileDetailsReq) (models.KycProfileResp, error)
+	GetKycProfile(context.Context, uuid.UUID) (models.KycProfileResp, error)
+	GetProfileForReview(context.Context, models.AdminKycCheckReq) (models.KycProfileResp, error)
 }
 
 type kycService struct {
     userRepo repositories.UserRepository
     kycrepo repositories.KycRepository
+    store integrations.DocumentStore
+    provider integrations.KycProvider
+    auditrepo repositories.AuditRepository
+    screening ScreeningService
 }
 
-func NewKycService(kycrepo repositories.KycRepository, userRepo repositories.UserRepository) KycService {
-    return &kycService{kycrepo:kycrepo, userRepo: userRepo}
+func NewKycService(kycrepo repositories.KycRepository, userRepo repositories.UserRepository, store integrations.DocumentStore, provider integrations.KycProvider, auditRepo repositories.AuditRepository, screening ScreeningService) KycService {
+    return &kycService{kycrepo:kycrepo, userRepo: userRepo, store: store, provider: provider, auditrepo: auditRepo, screening: screening}
 }
 
 const (
@@ -29,107 +48,112 @@ const (
     DocTypeProofOfAddr   = "proof_of_address"
 	DocsUploaded 		 = "documents_uploaded"
 	UnderReview 		 = "under_review"
+	KycVerified 		 = "verified"
+	KycRejected 		 = "rejected"
+	KycExpired 		 = "expired"
 )
 
 func (s *kycService) Uploadimage(ctx context.Context,data models.KycProfile) error {
-	return s.kycrepo.RunInTransaction(ctx, func(repo repositories.KycRepository) error {
-
-		existing, err := repo.FindByUserID(data.Userid)
-		if err != nil {
-			return errors.New("something went wrong, please try again later")
-		}
-		if existing != nil {
-			return errors.New("kyc submission already exists")
-		}
-
-		encrypted, mime, err := utils.EncryptBase64Document(data.ImageStr)
-		if err != nil {
-			return err
-		}
-
-		sub := &models.KYCSubmission{
-			UserID: data.Userid,
-			Status: "started",
-		}
-		if err := repo.CreateKycSubmission(sub); err != nil {
-			return err
-		}
-
-		doc := &models.KYCDocument{
-			KYCSubmissionID: sub.ID,
-			DocumentType:    DocTypeSelfie,
-			MimeType:        mime,
-			EncryptedData:   []byte(encrypted),
-		}
-
-		return repo.CreateKycDocsSubmission(doc)
-	})
+	dob, err := parseDateOfBirth(data.DOB)
+	if err != nil {
+		return err
+	}
+	plain, err := utils.DecodeDataURI(data.ImageStr)
+	if err != nil {
+		return err
+	}
+	return s.attachDocument(ctx, data.Userid, DocTypeSelfie, plain, dob)
 }
 
 
 func (s *kycService) UploadKycDocument(ctx context.Context, data models.KycDoc) error {
-	return s.kycrepo.RunInTransaction(ctx, func(repo repositories.KycRepository) error {
-
-		sub, err := repo.FindByUserID(data.Userid)
-		if err != nil {
-			return errors.New("something went wrong, please try again later")
-		}
-		if sub == nil {
-			return errors.New("kyc submission does not exist, upload selfie first")
-		}
-
-		encrypted, mime, err := utils.EncryptBase64Document(data.DocStr)
-		if err != nil {
-			return err
-		}
-
-		sub.Status = DocsUploaded
-		if err := repo.UpdateKycSubmission(sub); err != nil {
-			return err
-		}
-
-		doc := &models.KYCDocument{
-			KYCSubmissionID: sub.ID,
-			DocumentType:    DocTypeIDDocument,
-			MimeType:        mime,
-			EncryptedData:   []byte(encrypted),
-		}
-
-		return repo.CreateKycDocsSubmission(doc)
-	})
+	plain, err := utils.DecodeDataURI(data.DocStr)
+	if err != nil {
+		return err
+	}
+	return s.attachDocument(ctx, data.Userid, DocTypeIDDocument, plain, nil)
 }
 
 
 func (s *kycService) UploadProofOfAddress(ctx context.Context, data models.KycDoc) error {
-	return s.kycrepo.RunInTransaction(ctx, func(repo repositories.KycRepository) error {
+	plain, err := utils.DecodeDataURI(data.DocStr)
+	if err != nil {
+		return err
+	}
+	return s.attachDocument(ctx, data.Userid, DocTypeProofOfAddr, plain, nil)
+}
 
-		sub, err := repo.FindByUserID(data.Userid)
+// attachDocument stores a document and moves the submission along the
+// state machine in kyc_state.go. When nothing is outstanding the submission
+// goes under review, which sends it to the identity verification provider
+// and the user to sanctions screening. The date of birth, if given, goes on
+// the submission's profile.
+func (s *kycService) attachDocument(ctx context.Context, userID uuid.UUID, docType string, plain []byte, dob *time.Time) error {
+	var doc *models.KYCDocument
+	var reviewID uuid.UUID
+	err := s.kycrepo.RunInTransaction(ctx, func(repo repositories.KycRepository) error {
+
+		sub, err := openKycSubmission(repo, userID)
+		if err != nil {
+			return err
+		}
+		docs, err := repo.FindDocumentSummaries(ctx, sub.ID)
 		if err != nil {
 			return errors.New("something went wrong, please try again later")
 		}
-		if sub == nil {
-			return errors.New("kyc submission does not exist, upload selfie first")
+		if err := KycUploadAllowed(sub.Status, docs, docType); err != nil {
+			return err
+		}
+		if dob != nil {
+			if err := repo.SetProfileDateOfBirth(ctx, sub.ID, *dob); err != nil {
+				return errors.New("something went wrong, please try again later")
+			}
 		}
 
-		encrypted, mime, err := utils.EncryptBase64Document(data.DocStr)
+		doc, err = uploadDocument(ctx, s.store, sub.ID, docType, plain)
 		if err != nil {
 			return err
 		}
-
-		sub.Status = UnderReview
-		if err := repo.UpdateKycSubmission(sub); err != nil {
+		if err := repo.CreateKycDocsSubmission(doc); err != nil {
 			return err
 		}
 
-		doc := &models.KYCDocument{
-			KYCSubmissionID: sub.ID,
-			DocumentType:    DocTypeProofOfAddr,
-			MimeType:        mime,
-			EncryptedData:   []byte(encrypted),
+		underReview, err := advanceKycSubmission(ctx, repo, sub)
+		if underReview {
+			reviewID = sub.ID
 		}
-
-		return repo.CreateKycDocsSubmission(doc)
+		return err
 	})
+	if err != nil {
+		discardDocument(ctx, s.store, doc)
+		return err
+	}
+	if reviewID != uuid.Nil {
+		s.submittedForReview(userID, reviewID)
+	}
+	return nil
+}
+
+// submittedForReview starts the background checks on a submission that just
+// went under review.
+func (s *kycService) submittedForReview(userID, submissionID uuid.UUID) {
+	s.startProviderCheck(submissionID)
+	if s.screening != nil {
+		s.screening.ScreenUserInBackground(userID, "onboarding")
+	}
+}
+
+// parseDateOfBirth reads an optional YYYY-MM-DD date of birth, holding it
+// to the minimum age like the profile does.
+func parseDateOfBirth(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	dob, err := checkDateOfBirth(value, time.Now(), kycMinimumAge())
+	if err != nil {
+		return nil, err
+	}
+	return &dob, nil
 }
 
 
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KycRequiredDocuments must all be in, and none of them rejected, before a
+// submission goes under review. They can be uploaded in any order.
+var KycRequiredDocuments = []string{DocTypeSelfie, DocTypeIDDocument, DocTypeProofOfAddr}
+
+const (
+	KycDocPending  = "pending"
+	KycDocAccepted = "accepted"
+	KycDocRejected = "rejected"
+)
+
+// kycTransitions lists the statuses a submission may move to from each
+// status. A rejected submission goes back under review once every rejected
+// document has been replaced.
+var kycTransitions = map[string][]string{
+	"started":    {DocsUploaded, UnderReview},
+	DocsUploaded: {DocsUploaded, UnderReview},
+	UnderReview:  {KycVerified, KycRejected},
+	KycRejected:  {KycRejected, UnderReview},
+	KycVerified:  {KycExpired},
+}
+
+func checkKycTransition(from, to string) error {
+	for _, allowed := range kycTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("kyc submission cannot move from %s to %s", from, to)
+}
+
+// currentKycDocuments picks the document that counts for each type: the one
+// that has not been rejected, or else the latest rejected one. docs must be
+// oldest first.
+func currentKycDocuments(docs []models.KYCDocument) map[string]models.KYCDocument {
+	current := map[string]models.KYCDocument{}
+	for _, doc := range docs {
+		if existing, ok := current[doc.DocumentType]; ok && existing.Status != KycDocRejected && doc.Status == KycDocRejected {
+			continue
+		}
+		current[doc.DocumentType] = doc
+	}
+	return current
+}
+
+// outstandingKycDocuments lists the required document types that are
+// missing or rejected.
+func outstandingKycDocuments(current map[string]models.KYCDocument) []string {
+	outstanding := []string{}
+	for _, docType := range KycRequiredDocuments {
+		doc, ok := current[docType]
+		if !ok || doc.Status == KycDocRejected {
+			outstanding = append(outstanding, docType)
+		}
+	}
+	return outstanding
+}
+
+// KycUploadAllowed checks a document upload against the submission's status
+// and documents. A rejected submission only takes replacements for the
+// documents that were rejected.
+func KycUploadAllowed(status string, docs []models.KYCDocument, docType string) error {
+	doc, exists := currentKycDocuments(docs)[docType]
+	switch status {
+	case "started", DocsUploaded:
+		if exists && doc.Status != KycDocRejected {
+			return errors.New("document already uploaded")
+		}
+		return nil
+	case KycRejected:
+		if !exists || doc.Status != KycDocRejected {
+			return errors.New("only rejected documents can be resubmitted")
+		}
+		return nil
+	case UnderReview:
+		return errors.New("kyc submission is under review")
+	case KycVerified:
+		return errors.New("kyc is already verified")
+	default:
+		return errors.New("kyc submission is not accepting documents")
+	}
+}
+
+// KycStatusAfterUpload is where a submission goes once a document or the
+// profile has been added: under review when nothing is outstanding,
+// otherwise it keeps collecting documents.
+func KycStatusAfterUpload(status string, docs []models.KYCDocument, profileComplete bool) string {
+	if profileComplete && len(outstandingKycDocuments(currentKycDocuments(docs))) == 0 {
+		return UnderReview
+	}
+	if status == KycRejected || len(docs) == 0 {
+		return status
+	}
+	return DocsUploaded
+}
+
+// openKycSubmission returns the submission new documents and profile details
+// go on: the user's latest, or a new one when there is none or the last
+// approval has expired or is close to expiring.
+func openKycSubmission(repo repositories.KycRepository, userID uuid.UUID) (*models.KYCSubmission, error) {
+	sub, err := repo.FindByUserID(userID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	if sub != nil && !CanStartNewKyc(sub, time.Now(), kycExpiryReminderWindow()) {
+		return sub, nil
+	}
+	sub = &models.KYCSubmission{
+		UserID: userID,
+		Status: "started",
+	}
+	if err := repo.CreateKycSubmission(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// advanceKycSubmission moves a submission on after a document or the profile
+// was saved and reports whether it went under review.
+func advanceKycSubmission(ctx context.Context, repo repositories.KycRepository, sub *models.KYCSubmission) (bool, error) {
+	docs, err := repo.FindDocumentSummaries(ctx, sub.ID)
+	if err != nil {
+		return false, errors.New("something went wrong, please try again later")
+	}
+	profile, err := repo.FindProfile(ctx, sub.ID)
+	if err != nil {
+		return false, errors.New("something went wrong, please try again later")
+	}
+	next := KycStatusAfterUpload(sub.Status, docs, KycProfileComplete(profile))
+	if next == sub.Status {
+		return false, nil
+	}
+	if err := checkKycTransition(sub.Status, next); err != nil {
+		return false, err
+	}
+	sub.Status = next
+	if err := repo.UpdateKycSubmission(sub); err != nil {
+		return false, err
+	}
+	return next == UnderReview, nil
+}
+
+// reviewIDExpiry is the ID document expiry an approval rests on: the one the
+// reviewer read off the document, or else the one on the profile.
+func (s *kycService) reviewIDExpiry(ctx context.Context, submissionID uuid.UUID, value string) (time.Time, error) {
+	if value != "" {
+		idExpiry, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, errors.New("invalid id expiry date, use YYYY-MM-DD")
+		}
+		return idExpiry, nil
+	}
+	profile, err := s.kycrepo.FindProfile(ctx, submissionID)
+	if err != nil {
+		return time.Time{}, errors.New("something went wrong, please try again later")
+	}
+	if profile == nil || profile.IDExpiresOn == nil {
+		return time.Time{}, errors.New("id expiry date is required, the profile has none")
+	}
+	return *profile.IDExpiresOn, nil
+}
+
+// GetKycStatus shows the user where their latest submission stands and
+// which documents they still need to upload.
+func (s *kycService) GetKycStatus(ctx context.Context, userID uuid.UUID) (models.KycStatusResp, error) {
+	sub, err := s.kycrepo.FindByUserID(userID)
+	if err != nil {
+		return models.KycStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	if sub == nil {
+		return models.KycStatusResp{
+			Status:      "not_started",
+			Documents:   []models.KycDocumentStatus{},
+			Outstanding: append([]string{}, KycRequiredDocuments...),
+		}, nil
+	}
+	docs, err := s.kycrepo.FindDocumentSummaries(ctx, sub.ID)
+	if err != nil {
+		log.Printf("failed to load kyc documents for submission %s: %v", sub.ID, err)
+		return models.KycStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	profile, err := s.kycrepo.FindProfile(ctx, sub.ID)
+	if err != nil {
+		return models.KycStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	current := currentKycDocuments(docs)
+	res := models.KycStatusResp{
+		Submissionid:    &sub.ID,
+		Status:          sub.Status,
+		RejectionReason: sub.RejectionReason,
+		Documents:       []models.KycDocumentStatus{},
+		Outstanding:     outstandingKycDocuments(current),
+		SubmittedAt:     &sub.SubmittedAt,
+		ReviewedAt:      sub.ReviewedAt,
+		ExpiresAt:       sub.ExpiresAt,
+	}
+	if !KycProfileComplete(profile) {
+		res.Outstanding = append(res.Outstanding, "profile")
+	}
+	for _, docType := range KycRequiredDocuments {
+		if doc, ok := current[docType]; ok {
+			res.Documents = append(res.Documents, models.KycDocumentStatus{
+				DocumentType:    doc.DocumentType,
+				Status:          doc.Status,
+				RejectionReason: doc.RejectionReason,
+				UploadedAt:      doc.CreatedAt,
+			})
+		}
+	}
+	return res, nil
+}
+
+// reviewKycDocuments applies a review to the documents under review and
+// returns the ones that changed. Documents not rejected are accepted; a
+// rejection that names no documents rejects all of them.
+func reviewKycDocuments(current map[string]models.KYCDocument, status string, rejects []models.KycDocumentDecision, reason string, now time.Time) ([]models.KYCDocument, error) {
+	rejected := map[string]string{}
+	for _, r := range rejects {
+		doc, ok := current[r.DocumentType]
+		if !ok || doc.Status == KycDocRejected {
+			return nil, fmt.Errorf("there is no %s document to reject", r.DocumentType)
+		}
+		docReason := strings.TrimSpace(r.Reason)
+		if docReason == "" {
+			docReason = reason
+		}
+		rejected[r.DocumentType] = docReason
+	}
+	if status == KycVerified && len(rejected) > 0 {
+		return nil, errors.New("an approval cannot reject documents")
+	}
+	rejectAll := status == KycRejected && len(rejected) == 0
+
+	var changed []models.KYCDocument
+	for _, doc := range current {
+		if doc.Status == KycDocRejected {
+			continue
+		}
+		docReason, reject := rejected[doc.DocumentType]
+		switch {
+		case reject || rejectAll:
+			if rejectAll {
+				docReason = reason
+			}
+			doc.Status = KycDocRejected
+			doc.RejectionReason = &docReason
+		case doc.Status == KycDocPending:
+			doc.Status = KycDocAccepted
+		default:
+			continue
+		}
+		doc.ReviewedAt = &now
+		changed = append(changed, doc)
+	}
+	return changed, nil
+}
+
+// ReviewSubmission records a compliance officer's decision on a submission
+// under review. Approval needs the ID document's expiry date, which caps how
+// long the approval lasts; a rejection marks the documents the user has to
+// upload again.
+func (s *kycService) ReviewSubmission(ctx context.Context, data models.KycReviewReq) (models.KycReviewResp, error) {
+	submissionID, err := uuid.Parse(data.Submissionid)
+	if err != nil {
+		return models.KycReviewResp{}, errors.New("kyc submission not found")
+	}
+	sub, err := s.kycrepo.FindSubmissionByID(ctx, submissionID)
+	if err != nil {
+		return models.KycReviewResp{}, errors.New("something went wrong, please try again later")
+	}
+	if sub == nil {
+		return models.KycReviewResp{}, errors.New("kyc submission not found")
+	}
+
+	now := time.Now()
+	meta := map[string]any{"user_id": sub.UserID}
+	var next string
+	reason := strings.TrimSpace(data.Reason)
+	switch data.Decision {
+	case "approve":
+		next = KycVerified
+		idExpiry, err := s.reviewIDExpiry(ctx, sub.ID, data.IDExpiryDate)
+		if err != nil {
+			return models.KycReviewResp{}, err
+		}
+		expiresAt := KycExpiryFor(idExpiry, now, kycReverifyMonths())
+		if !expiresAt.After(now) {
+			return models.KycReviewResp{}, errors.New("the id document has expired")
+		}
+		sub.IDDocumentExpiresOn = &idExpiry
+		sub.ExpiresAt = &expiresAt
+		sub.RejectionReason = nil
+		meta["expires_at"] = expiresAt
+	case "reject":
+		next = KycRejected
+		if reason == "" {
+			return models.KycReviewResp{}, errors.New("a rejection reason is required")
+		}
+		sub.RejectionReason = &reason
+		meta["reason"] = reason
+	default:
+		return models.KycReviewResp{}, errors.New("invalid review decision")
+	}
+	if sub.Status != UnderReview {
+		return models.KycReviewResp{}, errors.New("kyc submission is not under review")
+	}
+	if err := checkKycTransition(sub.Status, next); err != nil {
+		return models.KycReviewResp{}, err
+	}
+
+	docs, err := s.kycrepo.FindDocumentSummaries(ctx, sub.ID)
+	if err != nil {
+		return models.KycReviewResp{}, errors.New("something went wrong, please try again later")
+	}
+	changed, err := reviewKycDocuments(currentKycDocuments(docs), next, data.Documents, reason, now)
+	if err != nil {
+		return models.KycReviewResp{}, err
+	}
+	sub.Status = next
+	sub.ReviewedBy = &data.AdminId
+	sub.ReviewedAt = &now
+	var rejectedTypes []string
+	for _, doc := range changed {
+		if doc.Status == KycDocRejected {
+			rejectedTypes = append(rejectedTypes, doc.DocumentType)
+		}
+	}
+	if len(rejectedTypes) > 0 {
+		meta["rejected_documents"] = rejectedTypes
+	}
+
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "kyc."+sub.Status, "kyc_submission", sub.ID, data.Client, meta); err != nil {
+		return models.KycReviewResp{}, errors.New("something went wrong, please try again later")
+	}
+	err = s.kycrepo.RunInTransaction(ctx, func(repo repositories.KycRepository) error {
+		for i := range changed {
+			if err := repo.UpdateDocumentReview(ctx, &changed[i]); err != nil {
+				return err
+			}
+		}
+		return repo.UpdateSubmissionReview(ctx, sub)
+	})
+	if err != nil {
+		log.Printf("failed to save kyc review for submission %s: %v", sub.ID, err)
+		return models.KycReviewResp{}, errors.New("something went wrong, please try again later")
+	}
+	return models.KycReviewResp{
+		Submissionid:      sub.ID,
+		Status:            sub.Status,
+		IDExpiresOn:       sub.IDDocumentExpiresOn,
+		ExpiresAt:         sub.ExpiresAt,
+		ReviewedAt:        sub.ReviewedAt,
+		RejectedDocuments: rejectedTypes,
+	}, nil
+}
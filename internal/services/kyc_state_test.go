@@ -0,0 +1,120 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"testing"
+	"time"
+)
+
+func kycDoc(docType, status string) models.KYCDocument {
+	return models.KYCDocument{DocumentType: docType, Status: status}
+}
+
+func TestKycStatusAfterUpload(t *testing.T) {
+	// proof of address first no longer skips the ID document
+	docs := []models.KYCDocument{kycDoc(DocTypeProofOfAddr, KycDocPending)}
+	if got := KycStatusAfterUpload("started", docs, true); got != DocsUploaded {
+		t.Fatalf("got %s, want %s", got, DocsUploaded)
+	}
+
+	docs = append(docs, kycDoc(DocTypeSelfie, KycDocPending), kycDoc(DocTypeIDDocument, KycDocPending))
+	// every document is in but the profile is not filled in yet
+	if got := KycStatusAfterUpload(DocsUploaded, docs, false); got != DocsUploaded {
+		t.Fatalf("got %s, want %s", got, DocsUploaded)
+	}
+	if got := KycStatusAfterUpload(DocsUploaded, docs, true); got != UnderReview {
+		t.Fatalf("got %s, want %s", got, UnderReview)
+	}
+
+	// saving the profile before any document leaves the submission started
+	if got := KycStatusAfterUpload("started", nil, true); got != "started" {
+		t.Fatalf("got %s, want started", got)
+	}
+
+	// a rejected submission stays rejected until every rejected document is replaced
+	docs = []models.KYCDocument{
+		kycDoc(DocTypeSelfie, KycDocAccepted),
+		kycDoc(DocTypeIDDocument, KycDocRejected),
+		kycDoc(DocTypeProofOfAddr, KycDocRejected),
+		kycDoc(DocTypeIDDocument, KycDocPending),
+	}
+	if got := KycStatusAfterUpload(KycRejected, docs, true); got != KycRejected {
+		t.Fatalf("got %s, want %s", got, KycRejected)
+	}
+	docs = append(docs, kycDoc(DocTypeProofOfAddr, KycDocPending))
+	if got := KycStatusAfterUpload(KycRejected, docs, true); got != UnderReview {
+		t.Fatalf("got %s, want %s", got, UnderReview)
+	}
+}
+
+func TestKycUploadAllowed(t *testing.T) {
+	docs := []models.KYCDocument{
+		kycDoc(DocTypeSelfie, KycDocAccepted),
+		kycDoc(DocTypeIDDocument, KycDocRejected),
+	}
+	if err := KycUploadAllowed(KycRejected, docs, DocTypeIDDocument); err != nil {
+		t.Fatalf("replacing a rejected document should be allowed: %v", err)
+	}
+	if err := KycUploadAllowed(KycRejected, docs, DocTypeSelfie); err == nil {
+		t.Fatal("re-uploading an accepted document should be refused")
+	}
+	if err := KycUploadAllowed(DocsUploaded, docs, DocTypeSelfie); err == nil {
+		t.Fatal("uploading a document twice should be refused")
+	}
+	if err := KycUploadAllowed(DocsUploaded, docs, DocTypeProofOfAddr); err != nil {
+		t.Fatalf("uploading a missing document should be allowed: %v", err)
+	}
+	if err := KycUploadAllowed(UnderReview, nil, DocTypeSelfie); err == nil {
+		t.Fatal("uploads under review should be refused")
+	}
+}
+
+func TestCheckKycTransition(t *testing.T) {
+	if err := checkKycTransition(UnderReview, KycVerified); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkKycTransition(DocsUploaded, KycVerified); err == nil {
+		t.Fatal("a submission must be reviewed before it is verified")
+	}
+	if err := checkKycTransition(KycVerified, UnderReview); err == nil {
+		t.Fatal("a verified submission cannot go back under review")
+	}
+}
+
+func TestReviewKycDocuments(t *testing.T) {
+	current := currentKycDocuments([]models.KYCDocument{
+		kycDoc(DocTypeSelfie, KycDocPending),
+		kycDoc(DocTypeIDDocument, KycDocPending),
+		kycDoc(DocTypeProofOfAddr, KycDocPending),
+	})
+	rejects := []models.KycDocumentDecision{{DocumentType: DocTypeProofOfAddr, Reason: "older than three months"}}
+	changed, err := reviewKycDocuments(current, KycRejected, rejects, "resubmit your proof of address", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]models.KYCDocument{}
+	for _, doc := range changed {
+		statuses[doc.DocumentType] = doc
+	}
+	if statuses[DocTypeSelfie].Status != KycDocAccepted || statuses[DocTypeIDDocument].Status != KycDocAccepted {
+		t.Fatalf("documents not rejected should be accepted: %+v", changed)
+	}
+	poa := statuses[DocTypeProofOfAddr]
+	if poa.Status != KycDocRejected || poa.RejectionReason == nil || *poa.RejectionReason != "older than three months" {
+		t.Fatalf("unexpected proof of address review: %+v", poa)
+	}
+
+	if _, err := reviewKycDocuments(current, KycVerified, rejects, "", time.Now()); err == nil {
+		t.Fatal("an approval should not reject documents")
+	}
+
+	changed, err = reviewKycDocuments(current, KycRejected, nil, "blurry", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range changed {
+		if doc.Status != KycDocRejected {
+			t.Fatalf("a rejection without documents should reject them all: %+v", doc)
+		}
+	}
+}
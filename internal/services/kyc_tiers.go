@@ -0,0 +1,239 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KycTier is a level of verification and the limits that come with it.
+// Amounts are in USD; balances on cards in other currencies are converted
+// with the FX_RATES table.
+type KycTier struct {
+	Level             int
+	Name              string
+	Requirements      []string
+	MaxCards          int
+	MaxBalance        float64
+	MonthlyFundingCap float64
+	CardTypes         []string
+	// set when the user's verification has expired: top-ups and new cards
+	// are blocked until they re-verify
+	Restricted bool
+}
+
+// KycTiers is ordered by level. Tier 0 covers users who have not yet
+// verified their email or added a phone number and cannot hold cards.
+var KycTiers = []KycTier{
+	{Level: 0, Name: "unverified", Requirements: nil},
+	{
+		Level:             1,
+		Name:              "basic",
+		Requirements:      []string{"verified email", "phone number"},
+		MaxCards:          1,
+		MaxBalance:        200,
+		MonthlyFundingCap: 500,
+		CardTypes:         []string{"single-use"},
+	},
+	{
+		Level:             2,
+		Name:              "standard",
+		Requirements:      []string{"verified ID document", "verified selfie"},
+		MaxCards:          3,
+		MaxBalance:        2000,
+		MonthlyFundingCap: 5000,
+		CardTypes:         []string{"single-use", "multi-use"},
+	},
+	{
+		Level:             3,
+		Name:              "full",
+		Requirements:      []string{"verified proof of address"},
+		MaxCards:          10,
+		MaxBalance:        20000,
+		MonthlyFundingCap: 50000,
+		CardTypes:         []string{"single-use", "multi-use", CardTypeMerchantLocked},
+	},
+}
+
+var ErrKycTierLimit = errors.New("this is not available at your verification level, complete more verification to unlock it")
+
+func (t KycTier) AllowsCardType(cardType string) bool {
+	for _, allowed := range t.CardTypes {
+		if allowed == cardType {
+			return true
+		}
+	}
+	return false
+}
+
+// KycTierLevel works out the tier a user has reached. Documents only count
+// once the submission holding them has been approved; an expired approval
+// keeps its level but leaves the user restricted.
+func KycTierLevel(user *models.User, sub *models.KYCSubmission, docTypes []string) int {
+	if user == nil || !user.EmailVerified || user.Phone == "" {
+		return 0
+	}
+	if sub == nil || (sub.Status != KycVerified && sub.Status != KycExpired) {
+		return 1
+	}
+	has := map[string]bool{}
+	for _, t := range docTypes {
+		has[t] = true
+	}
+	if !has[DocTypeSelfie] || !has[DocTypeIDDocument] {
+		return 1
+	}
+	if !has[DocTypeProofOfAddr] {
+		return 2
+	}
+	return 3
+}
+
+// CheckCardLimits applies a tier's card type and card count limits to a new
+// card. activeCards counts the cards the user can still spend with.
+func CheckCardLimits(tier KycTier, cardType string, activeCards int) error {
+	if tier.Level == 0 {
+		return errors.New("verify your email and add a phone number to get a card")
+	}
+	if tier.Restricted {
+		return ErrKycExpired
+	}
+	if !tier.AllowsCardType(cardType) {
+		return fmt.Errorf("%s cards are not available at your verification level", cardType)
+	}
+	if activeCards >= tier.MaxCards {
+		return fmt.Errorf("your verification level allows at most %d active cards", tier.MaxCards)
+	}
+	return nil
+}
+
+// CheckFundingLimits applies a tier's balance and monthly funding limits to
+// a top-up of amount.
+func CheckFundingLimits(tier KycTier, amount, totalBalance, fundedThisMonth float64) error {
+	if tier.Level == 0 {
+		return ErrKycTierLimit
+	}
+	if tier.Restricted {
+		return ErrKycExpired
+	}
+	if totalBalance+amount > tier.MaxBalance {
+		return fmt.Errorf("this top-up would take your balance over the %.2f USD allowed at your verification level", tier.MaxBalance)
+	}
+	if fundedThisMonth+amount > tier.MonthlyFundingCap {
+		return fmt.Errorf("this top-up would exceed the %.2f USD monthly funding limit at your verification level", tier.MonthlyFundingCap)
+	}
+	return nil
+}
+
+// resolveKycTier loads what is needed to place a user in a tier. It also
+// returns the approved submission the tier rests on, if any.
+func resolveKycTier(ctx context.Context, userrepo repositories.UserRepository, kycrepo repositories.KycRepository, userID uuid.UUID) (KycTier, *models.KYCSubmission, error) {
+	user, err := userrepo.FindByID(ctx, userID)
+	if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+		return KycTier{}, nil, err
+	}
+	sub, err := kycrepo.FindApprovedByUserID(ctx, userID)
+	if err != nil {
+		return KycTier{}, nil, err
+	}
+	var docTypes []string
+	if sub != nil {
+		if docTypes, err = kycrepo.FindDocumentTypes(ctx, sub.ID); err != nil {
+			return KycTier{}, nil, err
+		}
+	}
+	tier := KycTiers[KycTierLevel(user, sub, docTypes)]
+	tier.Restricted = KycRestricted(sub, time.Now())
+	return tier, sub, nil
+}
+
+// cardIsActive reports whether a card still counts towards the card limit.
+func cardIsActive(card models.Card) bool {
+	return card.Status != "terminated" && card.Status != "expired"
+}
+
+// usdBalance totals the balances of the user's active personal cards in
+// USD. Business cards are held to their organization's limits instead.
+func usdBalance(cards []models.Card) (float64, error) {
+	var total float64
+	for _, card := range cards {
+		if !cardIsActive(card) || card.OrganizationID != nil {
+			continue
+		}
+		currency := card.Currency
+		if currency == "" {
+			currency = "USD"
+		}
+		amount, _, err := utils.ConvertAmount(card.CurrentBalance, currency, "USD")
+		if err != nil {
+			return 0, err
+		}
+		total += amount
+	}
+	return total, nil
+}
+
+func startOfMonth(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// checkTopUpLimits applies the user's tier to a top-up.
+func (s *cardService) checkTopUpLimits(ctx context.Context, userID uuid.UUID, amount float64) error {
+	tier, _, err := resolveKycTier(ctx, s.userrepo, s.kycrepo, userID)
+	if err != nil {
+		log.Printf("failed to resolve kyc tier for user %s: %v", userID, err)
+		return errors.New("something went wrong, please try again later")
+	}
+	cards, err := s.cardrepo.FindCardsByID(ctx, userID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	balance, err := usdBalance(cards)
+	if err != nil {
+		log.Printf("failed to total balances for user %s: %v", userID, err)
+		return errors.New("something went wrong, please try again later")
+	}
+	funded, err := s.Txnrepo.SumUserFundingSince(ctx, userID, startOfMonth(time.Now()))
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	return CheckFundingLimits(tier, amount, balance, funded)
+}
+
+func kycTierInfo(tier KycTier) models.KycTierInfo {
+	return models.KycTierInfo{
+		Level:             tier.Level,
+		Name:              tier.Name,
+		Requirements:      tier.Requirements,
+		MaxCards:          tier.MaxCards,
+		MaxBalance:        tier.MaxBalance,
+		MonthlyFundingCap: tier.MonthlyFundingCap,
+		CardTypes:         tier.CardTypes,
+	}
+}
+
+// GetKycTier reports the user's tier and what the next one unlocks.
+func (s *kycService) GetKycTier(ctx context.Context, userID uuid.UUID) (models.KycTierResp, error) {
+	tier, sub, err := resolveKycTier(ctx, s.userRepo, s.kycrepo, userID)
+	if err != nil {
+		log.Printf("failed to resolve kyc tier for user %s: %v", userID, err)
+		return models.KycTierResp{}, errors.New("something went wrong, please try again later")
+	}
+	res := models.KycTierResp{Current: kycTierInfo(tier), Restricted: tier.Restricted}
+	if sub != nil {
+		res.ExpiresAt = sub.ExpiresAt
+	}
+	if tier.Level+1 < len(KycTiers) {
+		next := kycTierInfo(KycTiers[tier.Level+1])
+		res.Next = &next
+	}
+	return res, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"testing"
+)
+
+func TestKycTierLevel(t *testing.T) {
+	verifiedUser := &models.User{EmailVerified: true, Phone: "+2348000000000"}
+	allDocs := []string{DocTypeSelfie, DocTypeIDDocument, DocTypeProofOfAddr}
+
+	cases := []struct {
+		name string
+		user *models.User
+		sub  *models.KYCSubmission
+		docs []string
+		want int
+	}{
+		{"missing user", nil, nil, nil, 0},
+		{"unverified email", &models.User{Phone: "+2348000000000"}, nil, nil, 0},
+		{"no phone", &models.User{EmailVerified: true}, nil, nil, 0},
+		{"email and phone", verifiedUser, nil, nil, 1},
+		{"documents not yet verified", verifiedUser, &models.KYCSubmission{Status: UnderReview}, allDocs, 1},
+		{"verified id and selfie", verifiedUser, &models.KYCSubmission{Status: "verified"}, []string{DocTypeSelfie, DocTypeIDDocument}, 2},
+		{"verified without id", verifiedUser, &models.KYCSubmission{Status: "verified"}, []string{DocTypeSelfie}, 1},
+		{"fully verified", verifiedUser, &models.KYCSubmission{Status: "verified"}, allDocs, 3},
+	}
+	for _, tc := range cases {
+		if got := KycTierLevel(tc.user, tc.sub, tc.docs); got != tc.want {
+			t.Errorf("%s: got tier %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCheckCardLimits(t *testing.T) {
+	if err := CheckCardLimits(KycTiers[0], "single-use", 0); err == nil {
+		t.Fatal("tier 0 should not get cards")
+	}
+	if err := CheckCardLimits(KycTiers[1], "single-use", 0); err != nil {
+		t.Fatalf("tier 1 should get a single-use card: %v", err)
+	}
+	if err := CheckCardLimits(KycTiers[1], "multi-use", 0); err == nil {
+		t.Fatal("tier 1 should not get multi-use cards")
+	}
+	if err := CheckCardLimits(KycTiers[1], "single-use", 1); err == nil {
+		t.Fatal("tier 1 should be limited to one active card")
+	}
+	if err := CheckCardLimits(KycTiers[3], CardTypeMerchantLocked, 9); err != nil {
+		t.Fatalf("tier 3 should get merchant-locked cards: %v", err)
+	}
+}
+
+func TestCheckFundingLimits(t *testing.T) {
+	tier := KycTiers[1]
+	if err := CheckFundingLimits(tier, 100, 50, 0); err != nil {
+		t.Fatalf("top-up within limits rejected: %v", err)
+	}
+	if err := CheckFundingLimits(tier, 100, 150, 0); err == nil {
+		t.Fatal("top-up over the balance limit accepted")
+	}
+	if err := CheckFundingLimits(tier, 100, 0, 450); err == nil {
+		t.Fatal("top-up over the monthly funding cap accepted")
+	}
+	if err := CheckFundingLimits(KycTiers[0], 1, 0, 0); err == nil {
+		t.Fatal("tier 0 top-up accepted")
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// kycUploadTTL is how long a presigned upload URL, and the upload it
+// belongs to, stays valid.
+const kycUploadTTL = 15 * time.Minute
+
+var kycDocumentTypes = map[string]bool{
+	DocTypeSelfie:      true,
+	DocTypeIDDocument:  true,
+	DocTypeProofOfAddr: true,
+}
+
+// UploadKycFile attaches a document received as a multipart file.
+func (s *kycService) UploadKycFile(ctx context.Context, data models.KycFile) error {
+	if !kycDocumentTypes[data.DocumentType] {
+		return errors.New("invalid document type")
+	}
+	dob, err := parseDateOfBirth(data.DOB)
+	if err != nil {
+		return err
+	}
+	return s.attachDocument(ctx, data.Userid, data.DocumentType, data.Data, dob)
+}
+
+// CreateKycUpload hands out a presigned URL for uploading a document
+// straight to storage. Uploads land under kyc-staging/ in plaintext until
+// confirmed, so that prefix should have a short lifecycle expiry on the
+// bucket to clear out uploads that are never confirmed.
+func (s *kycService) CreateKycUpload(ctx context.Context, data models.KycUploadReq) (models.KycUploadResp, error) {
+	if !kycDocumentTypes[data.DocumentType] {
+		return models.KycUploadResp{}, errors.New("invalid document type")
+	}
+	store, ok := s.store.(integrations.DirectUploadStore)
+	if !ok {
+		return models.KycUploadResp{}, errors.New("direct uploads are not available, use the multipart upload instead")
+	}
+	upload := &models.KYCUpload{
+		ID:           uuid.New(),
+		UserID:       data.Userid,
+		DocumentType: data.DocumentType,
+		Status:       "pending",
+		ExpiresAt:    time.Now().Add(kycUploadTTL),
+	}
+	upload.StagingKey = "kyc-staging/" + data.Userid.String() + "/" + upload.ID.String()
+	url, err := store.PresignPut(upload.StagingKey, kycUploadTTL)
+	if err != nil {
+		log.Printf("failed to presign kyc upload for user %s: %v", data.Userid, err)
+		return models.KycUploadResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := s.kycrepo.CreateUpload(ctx, upload); err != nil {
+		return models.KycUploadResp{}, errors.New("something went wrong, please try again later")
+	}
+	return models.KycUploadResp{
+		Uploadid:  upload.ID,
+		Url:       url,
+		Method:    http.MethodPut,
+		MaxBytes:  utils.MaxKycDocumentBytes,
+		ExpiresAt: upload.ExpiresAt,
+	}, nil
+}
+
+// ConfirmKycUpload checks a directly uploaded document and attaches it to
+// the submission. Nothing the client uploaded is trusted until here: the
+// size, magic bytes and PDF encryption are checked on the stored object, and
+// a rejected upload is deleted.
+func (s *kycService) ConfirmKycUpload(ctx context.Context, data models.ConfirmKycUploadReq) error {
+	uploadID, err := uuid.Parse(data.Uploadid)
+	if err != nil {
+		return errors.New("upload not found")
+	}
+	dob, err := parseDateOfBirth(data.DOB)
+	if err != nil {
+		return err
+	}
+	store, ok := s.store.(integrations.DirectUploadStore)
+	if !ok {
+		return errors.New("direct uploads are not available, use the multipart upload instead")
+	}
+	upload, err := s.kycrepo.FindUpload(ctx, uploadID, data.Userid)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if upload == nil {
+		return errors.New("upload not found")
+	}
+	switch upload.Status {
+	case "attached":
+		return errors.New("upload already confirmed")
+	case "rejected":
+		return errors.New("upload was rejected, start a new upload")
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return errors.New("upload has expired, start a new upload")
+	}
+
+	size, err := store.Size(ctx, upload.StagingKey)
+	if errors.Is(err, integrations.ErrDocumentNotFound) {
+		return errors.New("file has not been uploaded yet")
+	}
+	if err != nil {
+		log.Printf("failed to check kyc upload %s: %v", upload.ID, err)
+		return errors.New("something went wrong, please try again later")
+	}
+	if size > utils.MaxKycDocumentBytes {
+		s.rejectUpload(ctx, store, upload)
+		return errors.New("document too large")
+	}
+	plain, err := store.Get(ctx, upload.StagingKey)
+	if err != nil {
+		log.Printf("failed to read kyc upload %s: %v", upload.ID, err)
+		return errors.New("something went wrong, please try again later")
+	}
+	if _, err := utils.DetectDocumentType(plain); err != nil {
+		s.rejectUpload(ctx, store, upload)
+		return err
+	}
+
+	// failures past this point, such as a submission under review, leave the upload
+	// pending so it can be confirmed again before it expires
+	if err := s.attachDocument(ctx, data.Userid, upload.DocumentType, plain, dob); err != nil {
+		return err
+	}
+	if err := s.kycrepo.UpdateUploadStatus(ctx, upload.ID, "attached"); err != nil {
+		log.Printf("failed to mark kyc upload %s attached: %v", upload.ID, err)
+	}
+	if err := store.Delete(ctx, upload.StagingKey); err != nil {
+		log.Printf("failed to delete staged kyc upload %s: %v", upload.ID, err)
+	}
+	return nil
+}
+
+func (s *kycService) rejectUpload(ctx context.Context, store integrations.DocumentStore, upload *models.KYCUpload) {
+	if err := store.Delete(ctx, upload.StagingKey); err != nil {
+		log.Printf("failed to delete rejected kyc upload %s: %v", upload.ID, err)
+	}
+	if err := s.kycrepo.UpdateUploadStatus(ctx, upload.ID, "rejected"); err != nil {
+		log.Printf("failed to mark kyc upload %s rejected: %v", upload.ID, err)
+	}
+}
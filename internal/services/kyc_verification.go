@@ -0,0 +1,180 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// providerCheckTimeout bounds one run through the verification provider.
+const providerCheckTimeout = 2 * time.Minute
+
+// startProviderCheck sends a submission that just went under review to the
+// identity verification provider, without holding up the upload response.
+func (s *kycService) startProviderCheck(submissionID uuid.UUID) {
+	if s.provider == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), providerCheckTimeout)
+		defer cancel()
+		if _, err := s.runProviderCheck(ctx, submissionID); err != nil {
+			log.Printf("kyc provider check failed for submission %s: %v", submissionID, err)
+		}
+	}()
+}
+
+// runProviderCheck records a check before calling the provider, so a check
+// that never returns still shows up as pending, and a failed one keeps its
+// error for the reviewer.
+func (s *kycService) runProviderCheck(ctx context.Context, submissionID uuid.UUID) (*models.KYCProviderCheck, error) {
+	check := &models.KYCProviderCheck{
+		KYCSubmissionID: submissionID,
+		Provider:        s.provider.Name(),
+		Status:          "pending",
+	}
+	if err := s.kycrepo.CreateProviderCheck(ctx, check); err != nil {
+		return nil, err
+	}
+
+	req, err := s.providerRequest(ctx, submissionID)
+	var result integrations.KycCheckResult
+	if err == nil {
+		result, err = s.provider.Verify(ctx, req)
+	}
+	now := time.Now()
+	check.CompletedAt = &now
+	if err != nil {
+		check.Status = "failed"
+		check.Error = err.Error()
+		if updateErr := s.kycrepo.UpdateProviderCheck(ctx, check); updateErr != nil {
+			log.Printf("failed to record kyc provider check %s: %v", check.ID, updateErr)
+		}
+		return check, err
+	}
+
+	check.Status = "completed"
+	check.ProviderReference = result.ProviderReference
+	check.DocumentAuthenticityScore = &result.DocumentAuthenticity
+	check.FaceMatchScore = &result.FaceMatch
+	check.LivenessScore = &result.Liveness
+	check.Decision = result.Decision
+	if len(result.Reasons) > 0 {
+		if check.Reasons, err = json.Marshal(result.Reasons); err != nil {
+			return check, err
+		}
+	}
+	if err := s.kycrepo.UpdateProviderCheck(ctx, check); err != nil {
+		return check, err
+	}
+	return check, nil
+}
+
+func (s *kycService) providerRequest(ctx context.Context, submissionID uuid.UUID) (integrations.KycCheckRequest, error) {
+	req := integrations.KycCheckRequest{Reference: submissionID.String()}
+	docs, err := s.kycrepo.FindDocumentsBySubmission(ctx, submissionID)
+	if err != nil {
+		return req, err
+	}
+	for _, doc := range docs {
+		if doc.Status == KycDocRejected {
+			continue
+		}
+		ciphertext, err := documentCiphertext(ctx, s.store, doc)
+		if err != nil {
+			return req, err
+		}
+		plain, err := utils.DecryptDocument(ciphertext)
+		if err != nil {
+			return req, fmt.Errorf("kyc document %s: %w", doc.ID, err)
+		}
+		file := integrations.KycDocumentFile{MimeType: doc.MimeType, Data: plain}
+		switch doc.DocumentType {
+		case DocTypeSelfie:
+			req.Selfie = file
+		case DocTypeIDDocument:
+			req.IDDocument = file
+		case DocTypeProofOfAddr:
+			req.ProofOfAddress = file
+		}
+	}
+	if req.Selfie.Data == nil || req.IDDocument.Data == nil {
+		return req, errors.New("submission is missing its selfie or id document")
+	}
+	return req, nil
+}
+
+// GetProviderChecks lists a submission's provider checks for the reviewing
+// compliance officer, newest first.
+func (s *kycService) GetProviderChecks(ctx context.Context, data models.AdminKycCheckReq) ([]models.KycProviderCheckResp, error) {
+	submissionID, err := uuid.Parse(data.Submissionid)
+	if err != nil {
+		return nil, errors.New("kyc submission not found")
+	}
+	checks, err := s.kycrepo.FindProviderChecks(ctx, submissionID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.KycProviderCheckResp, 0, len(checks))
+	for _, check := range checks {
+		res = append(res, providerCheckResp(check))
+	}
+	return res, nil
+}
+
+// RerunProviderCheck sends a submission under review to the provider again,
+// for example after a check failed.
+func (s *kycService) RerunProviderCheck(ctx context.Context, data models.AdminKycCheckReq) (models.KycProviderCheckResp, error) {
+	if s.provider == nil {
+		return models.KycProviderCheckResp{}, errors.New("no kyc provider is configured")
+	}
+	submissionID, err := uuid.Parse(data.Submissionid)
+	if err != nil {
+		return models.KycProviderCheckResp{}, errors.New("kyc submission not found")
+	}
+	sub, err := s.kycrepo.FindSubmissionByID(ctx, submissionID)
+	if err != nil {
+		return models.KycProviderCheckResp{}, errors.New("something went wrong, please try again later")
+	}
+	if sub == nil {
+		return models.KycProviderCheckResp{}, errors.New("kyc submission not found")
+	}
+	if sub.Status != UnderReview {
+		return models.KycProviderCheckResp{}, errors.New("kyc submission is not under review")
+	}
+	check, err := s.runProviderCheck(ctx, sub.ID)
+	if check == nil {
+		log.Printf("kyc provider check failed for submission %s: %v", sub.ID, err)
+		return models.KycProviderCheckResp{}, errors.New("something went wrong, please try again later")
+	}
+	meta := map[string]any{"check_id": check.ID, "status": check.Status, "decision": check.Decision}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "kyc.provider_check_rerun", "kyc_submission", sub.ID, data.Client, meta); err != nil {
+		log.Printf("failed to audit kyc provider check for submission %s: %v", sub.ID, err)
+	}
+	return providerCheckResp(*check), nil
+}
+
+func providerCheckResp(check models.KYCProviderCheck) models.KycProviderCheckResp {
+	return models.KycProviderCheckResp{
+		Checkid:                   check.ID,
+		Provider:                  check.Provider,
+		ProviderReference:         check.ProviderReference,
+		Status:                    check.Status,
+		DocumentAuthenticityScore: check.DocumentAuthenticityScore,
+		FaceMatchScore:            check.FaceMatchScore,
+		LivenessScore:             check.LivenessScore,
+		Decision:                  check.Decision,
+		Reasons:                   json.RawMessage(check.Reasons),
+		Error:                     check.Error,
+		CreatedAt:                 check.CreatedAt,
+		CompletedAt:               check.CompletedAt,
+	}
+}
@@ -1 +1,86 @@
-package services
\ No newline at end of file
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationChannelInApp marks notifications shown in the app. They start
+// unread and are marked read by the user.
+const NotificationChannelInApp = "in_app"
+
+const notificationListLimit = 100
+
+type NotificationService interface {
+	GetNotifications(context.Context, uuid.UUID) ([]models.NotificationResp, error)
+	MarkNotificationRead(context.Context, models.NotificationReq) error
+}
+
+type notificationService struct {
+	notificationrepo repositories.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo repositories.NotificationRepository) NotificationService {
+	return &notificationService{notificationrepo: notificationRepo}
+}
+
+// notifyInApp leaves a notification in the user's in-app inbox. A failure is
+// logged rather than failing the action the user is told about.
+func notifyInApp(ctx context.Context, repo repositories.NotificationRepository, userID uuid.UUID, kind, subject, body string) {
+	if repo == nil {
+		return
+	}
+	now := time.Now()
+	notification := &models.Notification{
+		UserID:  userID,
+		Type:    kind,
+		Channel: NotificationChannelInApp,
+		Subject: &subject,
+		Body:    body,
+		Status:  "unread",
+		SentAt:  &now,
+	}
+	if err := repo.Create(ctx, notification); err != nil {
+		log.Printf("failed to store %s notification for user %s: %v", kind, userID, err)
+	}
+}
+
+func (s *notificationService) GetNotifications(ctx context.Context, userID uuid.UUID) ([]models.NotificationResp, error) {
+	notifications, err := s.notificationrepo.FindByUser(ctx, userID, NotificationChannelInApp, notificationListLimit)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.NotificationResp, 0, len(notifications))
+	for _, n := range notifications {
+		res = append(res, models.NotificationResp{
+			Notificationid: n.ID,
+			Type:           n.Type,
+			Subject:        n.Subject,
+			Body:           n.Body,
+			Read:           n.Status == "read",
+			CreatedAt:      n.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+func (s *notificationService) MarkNotificationRead(ctx context.Context, data models.NotificationReq) error {
+	id, err := uuid.Parse(data.Notificationid)
+	if err != nil {
+		return errors.New("notification not found")
+	}
+	found, err := s.notificationrepo.MarkRead(ctx, data.Userid, id)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if !found {
+		return errors.New("notification not found")
+	}
+	return nil
+}
@@ -0,0 +1,587 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Approval request types.
+const (
+	ApprovalTypeCard  = "card"
+	ApprovalTypeTopUp = "top_up"
+)
+
+const (
+	maxApprovalTiers     = 10
+	maxRequiredApprovals = 5
+	maxApprovalNote      = 500
+)
+
+// approvalExecutionTimeout is how long a request may sit in executing before
+// another approval may take it over and finish it.
+const approvalExecutionTimeout = 10 * time.Minute
+
+var errApprovalCardIssued = errors.New("a card was already issued for this request")
+
+// approvalStatuses are the states a request can be listed by.
+var approvalStatuses = []string{"pending", "approved", "executing", "executed", "failed", "rejected", "cancelled"}
+
+func validApprovalType(kind string) bool {
+	return kind == ApprovalTypeCard || kind == ApprovalTypeTopUp
+}
+
+// ValidateApprovalPolicies checks an organization's approval tiers. Each
+// request type may have several tiers, at most one per starting amount.
+func ValidateApprovalPolicies(tiers []models.ApprovalPolicyTier) ([]models.ApprovalPolicy, error) {
+	if len(tiers) > maxApprovalTiers {
+		return nil, fmt.Errorf("at most %d approval tiers can be set", maxApprovalTiers)
+	}
+	seen := map[string]bool{}
+	policies := make([]models.ApprovalPolicy, 0, len(tiers))
+	for i, t := range tiers {
+		if !validApprovalType(t.RequestType) {
+			return nil, fmt.Errorf("tier %d: request type must be card or top_up", i+1)
+		}
+		minAmount := utils.RoundAmount(t.MinAmount)
+		if minAmount < 0 {
+			return nil, fmt.Errorf("tier %d: minimum amount cannot be negative", i+1)
+		}
+		if t.RequiredApprovals < 1 || t.RequiredApprovals > maxRequiredApprovals {
+			return nil, fmt.Errorf("tier %d: required approvals must be between 1 and %d", i+1, maxRequiredApprovals)
+		}
+		key := t.RequestType + "/" + strconv.FormatFloat(minAmount, 'f', 2, 64)
+		if seen[key] {
+			return nil, fmt.Errorf("tier %d: there is already a %s tier from %.2f", i+1, t.RequestType, minAmount)
+		}
+		seen[key] = true
+		policies = append(policies, models.ApprovalPolicy{
+			RequestType:       t.RequestType,
+			MinAmount:         minAmount,
+			RequiredApprovals: t.RequiredApprovals,
+		})
+	}
+	return policies, nil
+}
+
+// RequiredApprovals returns how many approvers a request needs: the tier
+// with the highest starting amount at or below the request's amount wins,
+// and one approval is enough when no tier matches.
+func RequiredApprovals(policies []models.ApprovalPolicy, kind string, amount float64) int {
+	required, from := 1, -1.0
+	for _, p := range policies {
+		if p.RequestType == kind && p.MinAmount <= amount && p.MinAmount > from {
+			required, from = p.RequiredApprovals, p.MinAmount
+		}
+	}
+	return required
+}
+
+func approvalPolicyTiers(policies []models.ApprovalPolicy) []models.ApprovalPolicyTier {
+	res := make([]models.ApprovalPolicyTier, 0, len(policies))
+	for _, p := range policies {
+		res = append(res, models.ApprovalPolicyTier{RequestType: p.RequestType, MinAmount: p.MinAmount, RequiredApprovals: p.RequiredApprovals})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].RequestType != res[j].RequestType {
+			return res[i].RequestType < res[j].RequestType
+		}
+		return res[i].MinAmount < res[j].MinAmount
+	})
+	return res
+}
+
+func approvalResp(req models.ApprovalRequest, decisions []models.ApprovalDecision) models.ApprovalRequestResp {
+	res := models.ApprovalRequestResp{
+		Requestid:         req.ID,
+		Type:              req.Type,
+		Requesterid:       req.RequesterID,
+		RequesterEmail:    req.Requester.Email,
+		Cardid:            req.CardID,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		CardType:          req.CardType,
+		Note:              req.Note,
+		Status:            req.Status,
+		RequiredApprovals: req.RequiredApprovals,
+		Approvals:         req.Approvals,
+		ResultCardid:      req.ResultCardID,
+		ResultReference:   req.ResultReference,
+		FailureReason:     req.FailureReason,
+		CreatedAt:         req.CreatedAt,
+		ExecutedAt:        req.ExecutedAt,
+	}
+	for _, d := range decisions {
+		res.Decisions = append(res.Decisions, models.ApprovalDecisionResp{Approverid: d.ApproverID, Decision: d.Decision, Comment: d.Comment, CreatedAt: d.CreatedAt})
+	}
+	return res
+}
+
+// approvalSummary describes a request in notifications.
+func approvalSummary(req models.ApprovalRequest) string {
+	if req.Type == ApprovalTypeCard {
+		return fmt.Sprintf("a new %s card with a spending limit of %.2f %s", req.CardType, req.Amount, req.Currency)
+	}
+	return fmt.Sprintf("a %.2f %s top-up of their card", req.Amount, req.Currency)
+}
+
+// approvalReference is the organization ledger reference of an approved
+// top-up. It is derived from the request so a retried execution cannot fund
+// the card twice.
+func approvalReference(requestID uuid.UUID) string {
+	return "APR-" + requestID.String()
+}
+
+func (s *organizationService) SaveApprovalPolicies(ctx context.Context, data models.ApprovalPoliciesReq) ([]models.ApprovalPolicyTier, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManagePolicy)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := ValidateApprovalPolicies(data.Policies)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.approvalrepo.ReplacePolicies(ctx, org.ID, policies); err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	tiers := approvalPolicyTiers(policies)
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "organization.approval_policy_updated", "organization", org.ID, data.Client, map[string]any{"policies": tiers}); err != nil {
+		log.Printf("failed to audit approval policy of organization %s: %v", org.ID, err)
+	}
+	return tiers, nil
+}
+
+func (s *organizationService) GetApprovalPolicies(ctx context.Context, data models.OrganizationReq) ([]models.ApprovalPolicyTier, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, "")
+	if err != nil {
+		return nil, err
+	}
+	policies, err := s.approvalrepo.FindPolicies(ctx, org.ID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	return approvalPolicyTiers(policies), nil
+}
+
+// approversFor returns the active members who may decide on a request,
+// leaving out whoever made it.
+func (s *organizationService) approversFor(ctx context.Context, orgID, requesterID uuid.UUID) ([]models.OrganizationMember, error) {
+	members, err := s.orgrepo.FindMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	var approvers []models.OrganizationMember
+	for _, m := range members {
+		if m.Status == "active" && m.UserID != nil && *m.UserID != requesterID && OrgRoleAllows(m.Role, OrgActionApprove) {
+			approvers = append(approvers, m)
+		}
+	}
+	return approvers, nil
+}
+
+// RequestApproval records a member's request for a card or a top-up and asks
+// the organization's approvers for a decision. Nothing happens to cards or
+// balances until enough of them approve.
+func (s *organizationService) RequestApproval(ctx context.Context, data models.ApprovalRequestReq) (models.ApprovalRequestResp, error) {
+	org, member, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionRequest)
+	if err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+	if err := requireKybVerified(org); err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+	if len(data.Note) > maxApprovalNote {
+		return models.ApprovalRequestResp{}, fmt.Errorf("note must be at most %d characters", maxApprovalNote)
+	}
+
+	req := &models.ApprovalRequest{
+		OrganizationID: org.ID,
+		RequesterID:    data.Userid,
+		Type:           data.Type,
+		Status:         "pending",
+	}
+	if note := strings.TrimSpace(data.Note); note != "" {
+		req.Note = &note
+	}
+	switch data.Type {
+	case ApprovalTypeCard:
+		if data.CardType != "single-use" && data.CardType != "multi-use" {
+			return models.ApprovalRequestResp{}, errors.New("card type must be single-use or multi-use")
+		}
+		limit := utils.RoundAmount(data.SpendingLimit)
+		if limit <= 0 {
+			return models.ApprovalRequestResp{}, errors.New("invalid spending limit")
+		}
+		currency := strings.ToUpper(strings.TrimSpace(data.Currency))
+		if currency == "" {
+			currency = org.Currency
+		}
+		if _, _, err := utils.ConvertAmount(1, org.Currency, currency); err != nil {
+			return models.ApprovalRequestResp{}, errors.New("unsupported card currency")
+		}
+		req.CardType, req.Amount, req.Currency = data.CardType, limit, currency
+	case ApprovalTypeTopUp:
+		cardID, err := uuid.Parse(data.Cardid)
+		if err != nil {
+			return models.ApprovalRequestResp{}, errors.New("card not found")
+		}
+		card, err := s.orgrepo.FindOrganizationCard(ctx, org.ID, cardID)
+		if err != nil {
+			return models.ApprovalRequestResp{}, errors.New("something went wrong, please try again later")
+		}
+		if card.ID == uuid.Nil || card.UserID != data.Userid {
+			return models.ApprovalRequestResp{}, errors.New("card not found")
+		}
+		if card.Status != "active" {
+			return models.ApprovalRequestResp{}, errors.New("card is not active")
+		}
+		amount, err := checkOrgAmount(data.Amount)
+		if err != nil {
+			return models.ApprovalRequestResp{}, err
+		}
+		req.CardID, req.Amount, req.Currency = &card.ID, amount, org.Currency
+	default:
+		return models.ApprovalRequestResp{}, errors.New("request type must be card or top_up")
+	}
+
+	policies, err := s.approvalrepo.FindPolicies(ctx, org.ID)
+	if err != nil {
+		return models.ApprovalRequestResp{}, errors.New("something went wrong, please try again later")
+	}
+	req.RequiredApprovals = RequiredApprovals(policies, req.Type, req.Amount)
+	approvers, err := s.approversFor(ctx, org.ID, data.Userid)
+	if err != nil {
+		return models.ApprovalRequestResp{}, errors.New("something went wrong, please try again later")
+	}
+	if len(approvers) < req.RequiredApprovals {
+		return models.ApprovalRequestResp{}, errors.New("the organization does not have enough approvers for this request")
+	}
+	if err := s.approvalrepo.CreateRequest(ctx, req); err != nil {
+		return models.ApprovalRequestResp{}, errors.New("something went wrong, please try again later")
+	}
+	meta := map[string]any{"organization_id": org.ID, "type": req.Type, "amount": req.Amount, "required_approvals": req.RequiredApprovals}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "organization.approval_requested", "approval_request", req.ID, data.Client, meta); err != nil {
+		log.Printf("failed to audit approval request %s: %v", req.ID, err)
+	}
+
+	summary := approvalSummary(*req)
+	for _, approver := range approvers {
+		notifyInApp(ctx, s.notificationrepo, *approver.UserID, "approval_requested", "Approval needed in "+org.Name,
+			fmt.Sprintf("%s has asked for %s.", member.Email, summary))
+		email := map[string]string{
+			"Email":             approver.Email,
+			"OrganizationName":  org.Name,
+			"RequesterEmail":    member.Email,
+			"Summary":           summary,
+			"RequiredApprovals": strconv.Itoa(req.RequiredApprovals),
+		}
+		go func() {
+			if err := utils.SendWithRetry(3, 2*time.Second, func() error {
+				return SendApprovalRequestEmail(email)
+			}); err != nil {
+				log.Printf("failed to email approver about request %s: %v", req.ID, err)
+			}
+		}()
+	}
+	req.Requester.Email = member.Email
+	return approvalResp(*req, nil), nil
+}
+
+func (s *organizationService) GetApprovalRequests(ctx context.Context, data models.ApprovalListReq) ([]models.ApprovalRequestResp, error) {
+	org, member, err := s.authorize(ctx, data.Orgid, data.Userid, "")
+	if err != nil {
+		return nil, err
+	}
+	if data.Status != "" {
+		valid := false
+		for _, status := range approvalStatuses {
+			valid = valid || status == data.Status
+		}
+		if !valid {
+			return nil, errors.New("invalid status")
+		}
+	}
+	// approvers see every request, everyone else only their own
+	var requester *uuid.UUID
+	if !OrgRoleAllows(member.Role, OrgActionApprove) {
+		requester = &data.Userid
+	}
+	reqs, err := s.approvalrepo.FindRequests(ctx, org.ID, requester, data.Status, orgListLimit)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.ApprovalRequestResp, 0, len(reqs))
+	for _, req := range reqs {
+		res = append(res, approvalResp(req, nil))
+	}
+	return res, nil
+}
+
+// findApproval loads a request the caller may see, with its decisions.
+func (s *organizationService) findApproval(ctx context.Context, org *models.Organization, member *models.OrganizationMember, requestID string) (*models.ApprovalRequest, []models.ApprovalDecision, error) {
+	id, err := uuid.Parse(requestID)
+	if err != nil {
+		return nil, nil, errors.New("request not found")
+	}
+	req, err := s.approvalrepo.FindRequest(ctx, org.ID, id)
+	if err != nil {
+		return nil, nil, errors.New("something went wrong, please try again later")
+	}
+	if req == nil || (req.RequesterID != *member.UserID && !OrgRoleAllows(member.Role, OrgActionApprove)) {
+		return nil, nil, errors.New("request not found")
+	}
+	decisions, err := s.approvalrepo.FindDecisions(ctx, req.ID)
+	if err != nil {
+		return nil, nil, errors.New("something went wrong, please try again later")
+	}
+	return req, decisions, nil
+}
+
+func (s *organizationService) GetApprovalRequest(ctx context.Context, data models.ApprovalDecisionReq) (models.ApprovalRequestResp, error) {
+	org, member, err := s.authorize(ctx, data.Orgid, data.Userid, "")
+	if err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+	req, decisions, err := s.findApproval(ctx, org, member, data.Requestid)
+	if err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+	return approvalResp(*req, decisions), nil
+}
+
+// ApproveRequest adds the caller's approval. Once the request has as many as
+// its policy needs, the card is issued or funded. Approving again after the
+// action ran returns the outcome without running it twice, and approving a
+// request whose action failed retries it.
+func (s *organizationService) ApproveRequest(ctx context.Context, data models.ApprovalDecisionReq) (models.ApprovalRequestResp, error) {
+	return s.decide(ctx, data, "approve")
+}
+
+// RejectRequest turns a pending request down. One rejection is final.
+func (s *organizationService) RejectRequest(ctx context.Context, data models.ApprovalDecisionReq) (models.ApprovalRequestResp, error) {
+	return s.decide(ctx, data, "reject")
+}
+
+func (s *organizationService) decide(ctx context.Context, data models.ApprovalDecisionReq, decision string) (models.ApprovalRequestResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionApprove)
+	if err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+	requestID, err := uuid.Parse(data.Requestid)
+	if err != nil {
+		return models.ApprovalRequestResp{}, errors.New("request not found")
+	}
+	if len(data.Comment) > maxApprovalNote {
+		return models.ApprovalRequestResp{}, fmt.Errorf("comment must be at most %d characters", maxApprovalNote)
+	}
+
+	var req *models.ApprovalRequest
+	recorded := false
+	err = s.approvalrepo.RunInTransaction(ctx, func(approvals repositories.ApprovalRepository) error {
+		req, err = approvals.FindRequestForUpdate(ctx, org.ID, requestID)
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if req == nil {
+			return errors.New("request not found")
+		}
+		if req.RequesterID == data.Userid {
+			return errors.New("you cannot decide on your own request")
+		}
+		previous, err := approvals.FindDecision(ctx, req.ID, data.Userid)
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if previous != nil {
+			if previous.Decision != decision {
+				return fmt.Errorf("you already decided to %s this request", previous.Decision)
+			}
+			return nil
+		}
+		if req.Status != "pending" {
+			if decision == "approve" && req.Status != "rejected" && req.Status != "cancelled" {
+				// already fully approved; a further approval adds nothing
+				return nil
+			}
+			return errors.New("request is no longer pending")
+		}
+
+		d := &models.ApprovalDecision{ApprovalRequestID: req.ID, ApproverID: data.Userid, Decision: decision}
+		if comment := strings.TrimSpace(data.Comment); comment != "" {
+			d.Comment = &comment
+		}
+		if err := approvals.CreateDecision(ctx, d); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		now := time.Now()
+		if decision == "reject" {
+			req.Status = "rejected"
+			req.DecidedAt = &now
+		} else {
+			req.Approvals++
+			if req.Approvals >= req.RequiredApprovals {
+				req.Status = "approved"
+				req.DecidedAt = &now
+			}
+		}
+		if err := approvals.UpdateRequest(ctx, req); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		recorded = true
+		return nil
+	})
+	if err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+
+	if recorded {
+		meta := map[string]any{"organization_id": org.ID, "approvals": req.Approvals, "required_approvals": req.RequiredApprovals, "status": req.Status}
+		if data.Comment != "" {
+			meta["comment"] = data.Comment
+		}
+		action := "organization.approval_approved"
+		if decision == "reject" {
+			action = "organization.approval_rejected"
+		}
+		if err := recordAudit(ctx, s.auditrepo, data.Userid, action, "approval_request", req.ID, data.Client, meta); err != nil {
+			log.Printf("failed to audit decision on approval request %s: %v", req.ID, err)
+		}
+		if req.Status == "rejected" {
+			notifyInApp(ctx, s.notificationrepo, req.RequesterID, "approval_rejected", "Request rejected",
+				fmt.Sprintf("Your request for %s in %s was rejected.", approvalSummary(*req), org.Name))
+		}
+	}
+	if decision == "approve" && (req.Status == "approved" || req.Status == "failed" || req.Status == "executing") {
+		s.executeApproval(ctx, org, req, data.Userid, data.Client)
+	}
+
+	req, decisions, err := s.findApproval(ctx, org, &models.OrganizationMember{UserID: &data.Userid, Role: OrgRoleOwner}, requestID.String())
+	if err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+	return approvalResp(*req, decisions), nil
+}
+
+// executeApproval runs the card action of an approved request. Only the
+// caller that moves the request to executing runs it; everyone else sees
+// the outcome when they reload the request. A request stuck in executing
+// is taken over once approvalExecutionTimeout has passed, and both actions
+// are keyed on the request so a second run never repeats the first.
+func (s *organizationService) executeApproval(ctx context.Context, org *models.Organization, req *models.ApprovalRequest, actorID uuid.UUID, client models.ClientInfo) {
+	claimed, err := s.approvalrepo.ClaimForExecution(ctx, req.ID, time.Now().Add(-approvalExecutionTimeout))
+	if err != nil {
+		log.Printf("failed to claim approval request %s: %v", req.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	// reload, since an earlier run may have issued the card already
+	req, err = s.approvalrepo.FindRequest(ctx, org.ID, req.ID)
+	if err != nil || req == nil {
+		log.Printf("failed to reload claimed approval request: %v", err)
+		return
+	}
+
+	var resultCard *uuid.UUID
+	var resultRef *string
+	switch req.Type {
+	case ApprovalTypeCard:
+		resultCard = req.ResultCardID
+		if resultCard == nil {
+			var holder *models.OrganizationMember
+			holder, err = s.orgrepo.FindMember(ctx, org.ID, req.RequesterID)
+			if err == nil && (holder == nil || holder.Status != "active") {
+				err = errors.New("the requester is no longer a member of the organization")
+			}
+			if err == nil {
+				var card models.OrgCardResp
+				card, err = s.issueCard(ctx, org, holder, req.CardType, req.Currency, req.Amount, actorID, client, &req.ID)
+				resultCard = &card.Cardid
+			}
+		}
+	case ApprovalTypeTopUp:
+		reference := approvalReference(req.ID)
+		resultCard, resultRef = req.CardID, &reference
+		var done *models.OrganizationLedger
+		done, err = s.orgrepo.FindLedgerByReference(ctx, reference)
+		if err == nil && done == nil {
+			_, err = s.transferCardFunds(ctx, org, *req.CardID, req.Amount, true, actorID, reference, client)
+		}
+	}
+
+	now := time.Now()
+	action := "organization.approval_executed"
+	if err != nil {
+		reason := err.Error()
+		req.Status, req.FailureReason = "failed", &reason
+		action = "organization.approval_failed"
+	} else {
+		req.Status, req.FailureReason = "executed", nil
+		req.ResultCardID, req.ResultReference, req.ExecutedAt = resultCard, resultRef, &now
+	}
+	if err := s.approvalrepo.UpdateRequest(ctx, req); err != nil {
+		log.Printf("failed to save outcome of approval request %s: %v", req.ID, err)
+		return
+	}
+	meta := map[string]any{"organization_id": org.ID, "type": req.Type}
+	if req.FailureReason != nil {
+		meta["reason"] = *req.FailureReason
+	}
+	if err := recordAudit(ctx, s.auditrepo, actorID, action, "approval_request", req.ID, client, meta); err != nil {
+		log.Printf("failed to audit approval request %s: %v", req.ID, err)
+	}
+	if req.Status == "executed" {
+		notifyInApp(ctx, s.notificationrepo, req.RequesterID, "approval_approved", "Request approved",
+			fmt.Sprintf("Your request for %s in %s was approved.", approvalSummary(*req), org.Name))
+	}
+}
+
+// CancelRequest lets the requester withdraw a request nobody has decided on
+// yet.
+func (s *organizationService) CancelRequest(ctx context.Context, data models.ApprovalDecisionReq) (models.ApprovalRequestResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, "")
+	if err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+	requestID, err := uuid.Parse(data.Requestid)
+	if err != nil {
+		return models.ApprovalRequestResp{}, errors.New("request not found")
+	}
+	var req *models.ApprovalRequest
+	err = s.approvalrepo.RunInTransaction(ctx, func(approvals repositories.ApprovalRepository) error {
+		req, err = approvals.FindRequestForUpdate(ctx, org.ID, requestID)
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if req == nil || req.RequesterID != data.Userid {
+			return errors.New("request not found")
+		}
+		if req.Status != "pending" {
+			return errors.New("request is no longer pending")
+		}
+		now := time.Now()
+		req.Status, req.DecidedAt = "cancelled", &now
+		if err := approvals.UpdateRequest(ctx, req); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		return nil
+	})
+	if err != nil {
+		return models.ApprovalRequestResp{}, err
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "organization.approval_cancelled", "approval_request", req.ID, data.Client, map[string]any{"organization_id": org.ID}); err != nil {
+		log.Printf("failed to audit approval request %s: %v", req.ID, err)
+	}
+	return approvalResp(*req, nil), nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateApprovalPolicies(t *testing.T) {
+	valid := []models.ApprovalPolicyTier{
+		{RequestType: ApprovalTypeCard, MinAmount: 0, RequiredApprovals: 1},
+		{RequestType: ApprovalTypeTopUp, MinAmount: 1000, RequiredApprovals: 2},
+		{RequestType: ApprovalTypeTopUp, MinAmount: 10000, RequiredApprovals: 3},
+	}
+	if _, err := ValidateApprovalPolicies(valid); err != nil {
+		t.Fatalf("valid tiers rejected: %v", err)
+	}
+
+	cases := map[string][]models.ApprovalPolicyTier{
+		"unknown type":    {{RequestType: "refund", RequiredApprovals: 1}},
+		"negative amount": {{RequestType: ApprovalTypeCard, MinAmount: -1, RequiredApprovals: 1}},
+		"no approvals":    {{RequestType: ApprovalTypeCard, RequiredApprovals: 0}},
+		"too many needed": {{RequestType: ApprovalTypeCard, RequiredApprovals: maxRequiredApprovals + 1}},
+		"duplicate tier":  {{RequestType: ApprovalTypeTopUp, MinAmount: 500, RequiredApprovals: 1}, {RequestType: ApprovalTypeTopUp, MinAmount: 500, RequiredApprovals: 2}},
+		"too many tiers":  make([]models.ApprovalPolicyTier, maxApprovalTiers+1),
+	}
+	for name, tiers := range cases {
+		if _, err := ValidateApprovalPolicies(tiers); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRequiredApprovals(t *testing.T) {
+	policies := []models.ApprovalPolicy{
+		{RequestType: ApprovalTypeTopUp, MinAmount: 10000, RequiredApprovals: 3},
+		{RequestType: ApprovalTypeTopUp, MinAmount: 1000, RequiredApprovals: 2},
+		{RequestType: ApprovalTypeCard, MinAmount: 0, RequiredApprovals: 2},
+	}
+	cases := []struct {
+		kind   string
+		amount float64
+		want   int
+	}{
+		{ApprovalTypeTopUp, 50, 1},
+		{ApprovalTypeTopUp, 1000, 2},
+		{ApprovalTypeTopUp, 9999.99, 2},
+		{ApprovalTypeTopUp, 25000, 3},
+		{ApprovalTypeCard, 10, 2},
+	}
+	for _, c := range cases {
+		if got := RequiredApprovals(policies, c.kind, c.amount); got != c.want {
+			t.Errorf("RequiredApprovals(%s, %.2f) = %d, want %d", c.kind, c.amount, got, c.want)
+		}
+	}
+	if got := RequiredApprovals(nil, ApprovalTypeCard, 100); got != 1 {
+		t.Errorf("without policies got %d, want 1", got)
+	}
+}
+
+// memoryApprovalRepo keeps approval requests in memory for the execution
+// path. Like memoryCardRepo, its transactions don't roll back.
+type memoryApprovalRepo struct {
+	repositories.ApprovalRepository
+	requests map[uuid.UUID]models.ApprovalRequest
+	cards    *memoryCardRepo
+}
+
+func (m *memoryApprovalRepo) FindRequest(ctx context.Context, orgID, requestID uuid.UUID) (*models.ApprovalRequest, error) {
+	req, ok := m.requests[requestID]
+	if !ok || req.OrganizationID != orgID {
+		return nil, nil
+	}
+	return &req, nil
+}
+
+func (m *memoryApprovalRepo) UpdateRequest(ctx context.Context, req *models.ApprovalRequest) error {
+	m.requests[req.ID] = *req
+	return nil
+}
+
+func (m *memoryApprovalRepo) ClaimForExecution(ctx context.Context, requestID uuid.UUID, staleBefore time.Time) (bool, error) {
+	req := m.requests[requestID]
+	if req.Status != "approved" && req.Status != "failed" && (req.Status != "executing" || !req.UpdatedAt.Before(staleBefore)) {
+		return false, nil
+	}
+	req.Status, req.UpdatedAt = "executing", time.Now()
+	m.requests[requestID] = req
+	return true, nil
+}
+
+func (m *memoryApprovalRepo) SetResultCard(ctx context.Context, requestID, cardID uuid.UUID) (bool, error) {
+	req := m.requests[requestID]
+	if req.ResultCardID != nil {
+		return false, nil
+	}
+	req.ResultCardID = &cardID
+	m.requests[requestID] = req
+	return true, nil
+}
+
+func (m *memoryApprovalRepo) RunWithCards(ctx context.Context, fn func(approvals repositories.ApprovalRepository, cards repositories.CardRepository) error) error {
+	return fn(m, m.cards)
+}
+
+type memoryOrgRepo struct {
+	repositories.OrganizationRepository
+	members []models.OrganizationMember
+}
+
+func (m *memoryOrgRepo) FindMember(ctx context.Context, orgID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	for _, member := range m.members {
+		if member.OrganizationID == orgID && member.UserID != nil && *member.UserID == userID {
+			return &member, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestExecuteCardApproval(t *testing.T) {
+	key := func(c string) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(c, 32))) }
+	saved := []string{config.IIN, config.EncryptionKey, config.PanFingerprintKey, config.CvvKey}
+	defer func() {
+		config.IIN, config.EncryptionKey, config.PanFingerprintKey, config.CvvKey = saved[0], saved[1], saved[2], saved[3]
+	}()
+	config.IIN, config.EncryptionKey, config.PanFingerprintKey, config.CvvKey = "12345", key("e"), key("f"), key("c")
+
+	ctx := context.Background()
+	org := &models.Organization{ID: uuid.New(), Name: "Acme", Currency: "USD"}
+	requesterID, approverID := uuid.New(), uuid.New()
+	cards := &memoryCardRepo{cards: map[uuid.UUID]models.Card{}}
+	approvals := &memoryApprovalRepo{requests: map[uuid.UUID]models.ApprovalRequest{}, cards: cards}
+	svc := &organizationService{
+		orgrepo:      &memoryOrgRepo{members: []models.OrganizationMember{{ID: uuid.New(), OrganizationID: org.ID, UserID: &requesterID, Role: OrgRoleCardholder, Status: "active"}}},
+		cardrepo:     cards,
+		auditrepo:    &memoryAuditRepo{},
+		approvalrepo: approvals,
+	}
+	request := func(status string, updatedAt time.Time) *models.ApprovalRequest {
+		req := models.ApprovalRequest{ID: uuid.New(), OrganizationID: org.ID, RequesterID: requesterID, Type: ApprovalTypeCard, CardType: "multi-use", Currency: "USD", Amount: 500, Status: status, UpdatedAt: updatedAt}
+		approvals.requests[req.ID] = req
+		return &req
+	}
+
+	approved := request("approved", time.Now())
+	svc.executeApproval(ctx, org, approved, approverID, models.ClientInfo{})
+	done := approvals.requests[approved.ID]
+	if done.Status != "executed" || done.ResultCardID == nil || len(cards.cards) != 1 {
+		t.Fatalf("expected one card issued and linked, got %+v", done)
+	}
+	if card := cards.cards[*done.ResultCardID]; card.UserID != requesterID || card.OrganizationID == nil || card.SpendingLimitAmount != 500 {
+		t.Fatalf("expected the requested card, got %+v", card)
+	}
+	svc.executeApproval(ctx, org, approved, approverID, models.ClientInfo{})
+	if len(cards.cards) != 1 {
+		t.Fatalf("expected an executed request not to issue again")
+	}
+
+	// a run that is still in progress is left alone
+	running := request("executing", time.Now())
+	svc.executeApproval(ctx, org, running, approverID, models.ClientInfo{})
+	if approvals.requests[running.ID].Status != "executing" || len(cards.cards) != 1 {
+		t.Fatalf("expected a fresh executing request not to be taken over")
+	}
+
+	// a run that issued the card and then died is finished without a second card
+	stuck := request("executing", time.Now().Add(-2*approvalExecutionTimeout))
+	issued := uuid.New()
+	stuckReq := approvals.requests[stuck.ID]
+	stuckReq.ResultCardID = &issued
+	approvals.requests[stuck.ID] = stuckReq
+	svc.executeApproval(ctx, org, stuck, approverID, models.ClientInfo{})
+	recovered := approvals.requests[stuck.ID]
+	if recovered.Status != "executed" || *recovered.ResultCardID != issued || len(cards.cards) != 1 {
+		t.Fatalf("expected the stuck request finished with its first card, got %+v", recovered)
+	}
+
+	// a run that died before issuing is retried
+	stale := request("executing", time.Now().Add(-2*approvalExecutionTimeout))
+	svc.executeApproval(ctx, org, stale, approverID, models.ClientInfo{})
+	if retried := approvals.requests[stale.ID]; retried.Status != "executed" || retried.ResultCardID == nil || len(cards.cards) != 2 {
+		t.Fatalf("expected the stale request to issue its card, got %+v", retried)
+	}
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// organizationFundingSource marks the card transactions that move money
+// between a business card and its organization's balance. They do not count
+// towards the cardholder's personal funding limits.
+const organizationFundingSource = "organization"
+
+// ErrBusinessCard is returned when a personal money movement touches a
+// business card. Money on business cards only moves to and from the
+// organization balance.
+var ErrBusinessCard = errors.New("business cards are funded from and returned to the organization balance")
+
+const orgListLimit = 200
+
+func requireKybVerified(org *models.Organization) error {
+	if org.Status != "active" {
+		return errors.New("organization is not active")
+	}
+	if org.KybStatus != "verified" {
+		return errors.New("the organization must complete business verification first")
+	}
+	return nil
+}
+
+func checkOrgAmount(amount float64) (float64, error) {
+	amount = utils.RoundAmount(amount)
+	if amount <= 0 {
+		return 0, errors.New("amount must be greater than zero")
+	}
+	return amount, nil
+}
+
+// Deposit credits the organization balance. Like card top-ups, the money is
+// collected before this is called.
+func (s *organizationService) Deposit(ctx context.Context, data models.OrgFundsReq) (models.OrgFundsResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageFunds)
+	if err != nil {
+		return models.OrgFundsResp{}, err
+	}
+	if err := requireKybVerified(org); err != nil {
+		return models.OrgFundsResp{}, err
+	}
+	amount, err := checkOrgAmount(data.Amount)
+	if err != nil {
+		return models.OrgFundsResp{}, err
+	}
+
+	var res models.OrgFundsResp
+	err = s.orgrepo.RunInTransaction(ctx, func(orgs repositories.OrganizationRepository, _ repositories.CardRepository, _ repositories.TransactionRepository) error {
+		locked, err := orgs.FindOrganizationForUpdate(ctx, org.ID)
+		if err != nil || locked == nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		locked.Balance = utils.RoundAmount(locked.Balance + amount)
+		if err := orgs.UpdateOrganization(ctx, locked); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		entry := &models.OrganizationLedger{
+			OrganizationID: locked.ID,
+			ActorID:        data.Userid,
+			EntryType:      "deposit",
+			Amount:         amount,
+			BalanceAfter:   locked.Balance,
+			Reference:      GenerateCardReference("ORGDEP"),
+		}
+		if err := orgs.CreateLedgerEntry(ctx, entry); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		res = models.OrgFundsResp{Reference: entry.Reference, Balance: locked.Balance}
+		return nil
+	})
+	if err != nil {
+		return models.OrgFundsResp{}, err
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "organization.deposit", "organization", org.ID, data.Client, map[string]any{"amount": amount, "reference": res.Reference}); err != nil {
+		log.Printf("failed to audit deposit %s: %v", res.Reference, err)
+	}
+	return res, nil
+}
+
+// FundCard moves money from the organization balance onto one of its cards,
+// converting it into the card's currency.
+func (s *organizationService) FundCard(ctx context.Context, data models.OrgFundsReq) (models.OrgFundsResp, error) {
+	return s.moveCardFunds(ctx, data, true)
+}
+
+// ReturnCardFunds moves money from a card back to the organization balance,
+// for example after its holder left the team.
+func (s *organizationService) ReturnCardFunds(ctx context.Context, data models.OrgFundsReq) (models.OrgFundsResp, error) {
+	return s.moveCardFunds(ctx, data, false)
+}
+
+func (s *organizationService) moveCardFunds(ctx context.Context, data models.OrgFundsReq, toCard bool) (models.OrgFundsResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageFunds)
+	if err != nil {
+		return models.OrgFundsResp{}, err
+	}
+	cardID, err := uuid.Parse(data.Cardid)
+	if err != nil {
+		return models.OrgFundsResp{}, errors.New("card not found")
+	}
+	prefix := "ORGFND"
+	if !toCard {
+		prefix = "ORGRTN"
+	}
+	return s.transferCardFunds(ctx, org, cardID, data.Amount, toCard, data.Userid, GenerateCardReference(prefix), data.Client)
+}
+
+// transferCardFunds moves money between the organization balance and one of
+// its cards under the given reference, which is unique, so a retried move
+// with the same reference cannot run twice.
+func (s *organizationService) transferCardFunds(ctx context.Context, org *models.Organization, cardID uuid.UUID, amount float64, toCard bool, actorID uuid.UUID, reference string, client models.ClientInfo) (models.OrgFundsResp, error) {
+	if toCard {
+		if err := requireKybVerified(org); err != nil {
+			return models.OrgFundsResp{}, err
+		}
+	}
+	amount, err := checkOrgAmount(amount)
+	if err != nil {
+		return models.OrgFundsResp{}, err
+	}
+
+	var res models.OrgFundsResp
+	err = s.orgrepo.RunInTransaction(ctx, func(orgs repositories.OrganizationRepository, cards repositories.CardRepository, txns repositories.TransactionRepository) error {
+		locked, err := orgs.FindOrganizationForUpdate(ctx, org.ID)
+		if err != nil || locked == nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		card, err := orgs.FindOrganizationCardForUpdate(ctx, org.ID, cardID)
+		if err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if card.ID == uuid.Nil {
+			return errors.New("card not found")
+		}
+
+		var cardAmount, orgAmount float64
+		if toCard {
+			if card.Status != "active" {
+				return errors.New("card is not active")
+			}
+			if locked.Balance < amount {
+				return errors.New("insufficient organization balance")
+			}
+			if cardAmount, _, err = utils.ConvertAmount(amount, locked.Currency, card.Currency); err != nil {
+				return errors.New("currency conversion is not available for this card")
+			}
+			orgAmount = amount
+			locked.Balance = utils.RoundAmount(locked.Balance - orgAmount)
+			card.CurrentBalance = utils.RoundAmount(card.CurrentBalance + cardAmount)
+		} else {
+			if card.CurrentBalance-card.HeldBalance < amount {
+				return errors.New("insufficient available balance")
+			}
+			if orgAmount, _, err = utils.ConvertAmount(amount, card.Currency, locked.Currency); err != nil {
+				return errors.New("currency conversion is not available for this card")
+			}
+			cardAmount = amount
+			card.CurrentBalance = utils.RoundAmount(card.CurrentBalance - cardAmount)
+			locked.Balance = utils.RoundAmount(locked.Balance + orgAmount)
+		}
+		if err := orgs.UpdateOrganization(ctx, locked); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if err := cards.Update(ctx, card); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+
+		txnType, direction, entryType, orgEntry := "funding", "credit", "Organization Funding", "card_funding"
+		if !toCard {
+			txnType, direction, entryType, orgEntry = "organization_return", "debit", "Organization Return", "card_return"
+		}
+		source := organizationFundingSource
+		txn := &models.Transaction{
+			UserID:               card.UserID,
+			CardID:               card.ID,
+			TransactionReference: reference,
+			Amount:               cardAmount,
+			Currency:             card.Currency,
+			Type:                 txnType,
+			Direction:            direction,
+			Status:               "completed",
+			Source:               &source,
+			TransactionTimestamp: time.Now(),
+		}
+		if err := txns.CreateTransaction(ctx, txn); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		ledger := models.BalanceLedger{
+			CardID:        card.ID,
+			TransactionID: txn.ID,
+			EntryType:     entryType,
+			Amount:        cardAmount,
+			FeeCharged:    0,
+			BalanceAfter:  card.CurrentBalance,
+		}
+		if err := txns.CreateLedger(ctx, ledger); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		entry := &models.OrganizationLedger{
+			OrganizationID: locked.ID,
+			CardID:         &card.ID,
+			ActorID:        actorID,
+			EntryType:      orgEntry,
+			Amount:         orgAmount,
+			BalanceAfter:   locked.Balance,
+			Reference:      reference,
+		}
+		if err := orgs.CreateLedgerEntry(ctx, entry); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		cardBalance := card.CurrentBalance
+		res = models.OrgFundsResp{Reference: reference, Balance: locked.Balance, CardBalance: &cardBalance}
+		return nil
+	})
+	if err != nil {
+		return models.OrgFundsResp{}, err
+	}
+	action := "organization.card_funded"
+	if !toCard {
+		action = "organization.card_funds_returned"
+	}
+	if err := recordAudit(ctx, s.auditrepo, actorID, action, "card", cardID, client, map[string]any{"organization_id": org.ID, "amount": amount, "reference": res.Reference}); err != nil {
+		log.Printf("failed to audit %s: %v", res.Reference, err)
+	}
+	return res, nil
+}
+
+func (s *organizationService) GetLedger(ctx context.Context, data models.OrganizationReq) ([]models.OrganizationLedgerResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionView)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.orgrepo.FindLedger(ctx, org.ID, orgListLimit)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.OrganizationLedgerResp, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, models.OrganizationLedgerResp{
+			Reference:    e.Reference,
+			EntryType:    e.EntryType,
+			Amount:       e.Amount,
+			BalanceAfter: e.BalanceAfter,
+			Cardid:       e.CardID,
+			Actorid:      e.ActorID,
+			CreatedAt:    e.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+// IssueCard issues a business card to a team member. The card starts empty
+// and is funded from the organization balance; its holder sees the full
+// card details through the ordinary reveal flow.
+func (s *organizationService) IssueCard(ctx context.Context, data models.CreateOrgCardReq) (models.OrgCardResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageCards)
+	if err != nil {
+		return models.OrgCardResp{}, err
+	}
+	if err := requireKybVerified(org); err != nil {
+		return models.OrgCardResp{}, err
+	}
+	memberID, err := uuid.Parse(data.Memberid)
+	if err != nil {
+		return models.OrgCardResp{}, errors.New("member not found")
+	}
+	holder, err := s.orgrepo.FindMemberByID(ctx, org.ID, memberID)
+	if err != nil {
+		return models.OrgCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	if holder == nil || holder.Status != "active" || holder.UserID == nil {
+		return models.OrgCardResp{}, errors.New("member not found")
+	}
+	return s.issueCard(ctx, org, holder, data.CardType, data.Currency, data.SpendingLimit, data.Userid, data.Client, nil)
+}
+
+// issueCard creates a business card for an active member on behalf of
+// actorID. A card issued for an approval request is linked to the request in
+// the same transaction, and issuance fails if the request already has one.
+func (s *organizationService) issueCard(ctx context.Context, org *models.Organization, holder *models.OrganizationMember, cardType, currency string, spendingLimit float64, actorID uuid.UUID, client models.ClientInfo, requestID *uuid.UUID) (models.OrgCardResp, error) {
+	if holder.Role == OrgRoleViewer {
+		return models.OrgCardResp{}, errors.New("viewers cannot hold cards")
+	}
+	if spendingLimit < 0 {
+		return models.OrgCardResp{}, errors.New("invalid spending limit")
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = org.Currency
+	}
+	if _, _, err := utils.ConvertAmount(1, org.Currency, currency); err != nil {
+		return models.OrgCardResp{}, errors.New("unsupported card currency")
+	}
+	if s.screening != nil {
+		if err := s.screening.CheckCardIssuance(ctx, *holder.UserID); err != nil {
+			return models.OrgCardResp{}, err
+		}
+	}
+
+	creds, err := generateCardCredentials(ctx, s.cardrepo, cardType)
+	if err != nil {
+		return models.OrgCardResp{}, err
+	}
+	card := &models.Card{
+		UserID:              *holder.UserID,
+		OrganizationID:      &org.ID,
+		CardType:            cardType,
+		Currency:            currency,
+		SpendingLimitAmount: spendingLimit,
+		Status:              "active",
+	}
+	creds.applyTo(card)
+	if requestID == nil {
+		err = s.cardrepo.CreateCard(ctx, card)
+	} else {
+		err = s.approvalrepo.RunWithCards(ctx, func(approvals repositories.ApprovalRepository, cards repositories.CardRepository) error {
+			if err := cards.CreateCard(ctx, card); err != nil {
+				return err
+			}
+			linked, err := approvals.SetResultCard(ctx, *requestID, card.ID)
+			if err == nil && !linked {
+				err = errApprovalCardIssued
+			}
+			return err
+		})
+	}
+	if errors.Is(err, errApprovalCardIssued) {
+		return models.OrgCardResp{}, err
+	}
+	if err != nil {
+		return models.OrgCardResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, actorID, "organization.card_issued", "card", card.ID, client, map[string]any{"organization_id": org.ID, "member_id": holder.ID}); err != nil {
+		log.Printf("failed to audit issuance of card %s: %v", card.ID, err)
+	}
+	return orgCardResp(*card, holder.Email), nil
+}
+
+func orgCardResp(card models.Card, holderEmail string) models.OrgCardResp {
+	return models.OrgCardResp{
+		Cardid:        card.ID,
+		Holderid:      card.UserID,
+		HolderEmail:   holderEmail,
+		CardType:      card.CardType,
+		MaskedPAN:     card.MaskedPAN,
+		Lastfour:      card.LastFour,
+		Currency:      card.Currency,
+		Status:        card.Status,
+		Balance:       card.CurrentBalance,
+		SpendingLimit: card.SpendingLimitAmount,
+	}
+}
+
+// GetCards lists every card of the organization, whoever holds it.
+func (s *organizationService) GetCards(ctx context.Context, data models.OrganizationReq) ([]models.OrgCardResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionView)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := s.orgrepo.FindOrganizationCards(ctx, org.ID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.OrgCardResp, 0, len(cards))
+	for _, card := range cards {
+		res = append(res, orgCardResp(card, card.User.Email))
+	}
+	return res, nil
+}
+
+// GetTransactions lists the latest transactions across the organization's
+// cards.
+func (s *organizationService) GetTransactions(ctx context.Context, data models.OrganizationReq) ([]models.GetCardTransactionsResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionView)
+	if err != nil {
+		return nil, err
+	}
+	txns, err := s.orgrepo.FindOrganizationTransactions(ctx, org.ID, orgListLimit)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.GetCardTransactionsResp, 0, len(txns))
+	for _, transaction := range txns {
+		res = append(res, models.GetCardTransactionsResp{
+			Cardid:                transaction.CardID,
+			Transaction_Reference: transaction.TransactionReference,
+			Amount:                transaction.Amount,
+			AuthorizedAmount:      transaction.AuthorizedAmount,
+			CapturedAmount:        transaction.CapturedAmount,
+			Currency:              transaction.Currency,
+			MerchantName:          transaction.MerchantName,
+			Direction:             transaction.Direction,
+			Type:                  transaction.Type,
+			Source:                transaction.Source,
+			DeclineReason:         transaction.DeclineReason,
+			CreatedAt:             transaction.CreatedAt,
+		})
+	}
+	return res, nil
+}
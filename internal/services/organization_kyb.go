@@ -0,0 +1,340 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Business verification (KYB) runs per organization, separately from its
+// members' personal KYC. Details and documents can be changed until the
+// organization submits them for review, and again after a rejection.
+const (
+	KybNotStarted  = "not_started"
+	KybUnderReview = "under_review"
+	KybVerified    = "verified"
+	KybRejected    = "rejected"
+)
+
+const (
+	KybDocIncorporation = "certificate_of_incorporation"
+	KybDocMemorandum    = "memorandum_of_association"
+	KybDocDirectors     = "register_of_directors"
+	KybDocProofOfAddr   = "proof_of_address"
+)
+
+// KybDocumentTypes are the documents an organization can upload.
+var KybDocumentTypes = []string{KybDocIncorporation, KybDocMemorandum, KybDocDirectors, KybDocProofOfAddr}
+
+// KybRequiredDocuments must all be present before KYB can be submitted.
+var KybRequiredDocuments = []string{KybDocIncorporation, KybDocProofOfAddr}
+
+const maxKybDirectors = 20
+
+var registrationNumberPattern = regexp.MustCompile(`^[A-Z0-9/-]{3,50}$`)
+
+func kybEditable(status string) bool {
+	return status == KybNotStarted || status == KybRejected
+}
+
+func validKybDocumentType(docType string) bool {
+	for _, t := range KybDocumentTypes {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateKybDirectors checks the declared directors and returns them as
+// rows. Directors are held to the same minimum age as personal KYC.
+func ValidateKybDirectors(directors []models.KybDirector, now time.Time, minAge int) ([]models.OrganizationDirector, error) {
+	if len(directors) == 0 {
+		return nil, errors.New("at least one director is required")
+	}
+	if len(directors) > maxKybDirectors {
+		return nil, fmt.Errorf("at most %d directors can be declared", maxKybDirectors)
+	}
+	rows := make([]models.OrganizationDirector, 0, len(directors))
+	var ownership float64
+	for i, d := range directors {
+		name := strings.TrimSpace(d.FullName)
+		if name == "" || len(name) > 255 {
+			return nil, fmt.Errorf("director %d: full name is required", i+1)
+		}
+		dob, err := checkDateOfBirth(d.DateOfBirth, now, minAge)
+		if err != nil {
+			return nil, fmt.Errorf("director %d: %v", i+1, err)
+		}
+		nationality := strings.ToUpper(strings.TrimSpace(d.Nationality))
+		if !countryCodePattern.MatchString(nationality) {
+			return nil, fmt.Errorf("director %d: nationality must be a two letter country code", i+1)
+		}
+		if d.OwnershipPercent < 0 || d.OwnershipPercent > 100 {
+			return nil, fmt.Errorf("director %d: ownership must be between 0 and 100 percent", i+1)
+		}
+		ownership += d.OwnershipPercent
+		rows = append(rows, models.OrganizationDirector{
+			FullName:         name,
+			DateOfBirth:      &dob,
+			Nationality:      nationality,
+			OwnershipPercent: d.OwnershipPercent,
+		})
+	}
+	if ownership > 100 {
+		return nil, errors.New("directors' ownership adds up to more than 100 percent")
+	}
+	return rows, nil
+}
+
+// outstandingKyb lists what is still missing before KYB can be submitted.
+func outstandingKyb(org *models.Organization, directors []models.OrganizationDirector, docs []models.KYBDocument) []string {
+	var missing []string
+	if org.RegistrationNumber == "" || org.Country == "" {
+		missing = append(missing, "registration_details")
+	}
+	if len(directors) == 0 {
+		missing = append(missing, "directors")
+	}
+	has := map[string]bool{}
+	for _, doc := range docs {
+		has[doc.DocumentType] = true
+	}
+	for _, docType := range KybRequiredDocuments {
+		if !has[docType] {
+			missing = append(missing, docType)
+		}
+	}
+	return missing
+}
+
+func (s *organizationService) kybStatus(ctx context.Context, org *models.Organization) (models.KybStatusResp, error) {
+	directors, err := s.orgrepo.FindDirectors(ctx, org.ID)
+	if err != nil {
+		return models.KybStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	docs, err := s.orgrepo.FindKybDocuments(ctx, org.ID)
+	if err != nil {
+		return models.KybStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	res := models.KybStatusResp{
+		Orgid:              org.ID,
+		Name:               org.Name,
+		Status:             org.KybStatus,
+		RejectionReason:    org.KybRejectionReason,
+		RegistrationNumber: org.RegistrationNumber,
+		Country:            org.Country,
+		Directors:          make([]models.KybDirector, 0, len(directors)),
+		Documents:          make([]models.KybDocumentResp, 0, len(docs)),
+		Outstanding:        outstandingKyb(org, directors, docs),
+	}
+	for _, d := range directors {
+		director := models.KybDirector{FullName: d.FullName, Nationality: d.Nationality, OwnershipPercent: d.OwnershipPercent}
+		if d.DateOfBirth != nil {
+			director.DateOfBirth = d.DateOfBirth.Format("2006-01-02")
+		}
+		res.Directors = append(res.Directors, director)
+	}
+	for _, doc := range docs {
+		res.Documents = append(res.Documents, models.KybDocumentResp{
+			Documentid:   doc.ID,
+			DocumentType: doc.DocumentType,
+			MimeType:     doc.MimeType,
+			SizeBytes:    doc.SizeBytes,
+			CreatedAt:    doc.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+// SaveKybDetails records the organization's registration details and
+// directors.
+func (s *organizationService) SaveKybDetails(ctx context.Context, data models.KybDetailsReq) (models.KybStatusResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageKyb)
+	if err != nil {
+		return models.KybStatusResp{}, err
+	}
+	if !kybEditable(org.KybStatus) {
+		return models.KybStatusResp{}, errors.New("business verification cannot be changed while it is " + strings.ReplaceAll(org.KybStatus, "_", " "))
+	}
+	number := strings.ToUpper(strings.TrimSpace(data.RegistrationNumber))
+	if !registrationNumberPattern.MatchString(number) {
+		return models.KybStatusResp{}, errors.New("invalid registration number")
+	}
+	country := strings.ToUpper(strings.TrimSpace(data.Country))
+	if !countryCodePattern.MatchString(country) {
+		return models.KybStatusResp{}, errors.New("country must be a two letter country code")
+	}
+	directors, err := ValidateKybDirectors(data.Directors, time.Now(), kycMinimumAge())
+	if err != nil {
+		return models.KybStatusResp{}, err
+	}
+	for i := range directors {
+		directors[i].OrganizationID = org.ID
+	}
+
+	org.RegistrationNumber = number
+	org.Country = country
+	err = s.orgrepo.RunInTransaction(ctx, func(orgs repositories.OrganizationRepository, _ repositories.CardRepository, _ repositories.TransactionRepository) error {
+		if err := orgs.UpdateOrganization(ctx, org); err != nil {
+			return err
+		}
+		return orgs.ReplaceDirectors(ctx, org.ID, directors)
+	})
+	if err != nil {
+		log.Printf("failed to save kyb details for organization %s: %v", org.ID, err)
+		return models.KybStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	return s.kybStatus(ctx, org)
+}
+
+// UploadKybDocument encrypts a company document and puts it in the document
+// store next to the KYC documents.
+func (s *organizationService) UploadKybDocument(ctx context.Context, data models.KybFile) error {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageKyb)
+	if err != nil {
+		return err
+	}
+	if !kybEditable(org.KybStatus) {
+		return errors.New("business verification cannot be changed while it is " + strings.ReplaceAll(org.KybStatus, "_", " "))
+	}
+	if !validKybDocumentType(data.DocumentType) {
+		return errors.New("document type must be one of " + strings.Join(KybDocumentTypes, ", "))
+	}
+	encrypted, mime, err := utils.EncryptDocument(data.Data)
+	if err != nil {
+		return err
+	}
+	key := kybObjectKey(org.ID, data.DocumentType)
+	if err := s.store.Put(ctx, key, []byte(encrypted)); err != nil {
+		log.Printf("failed to store kyb document for organization %s: %v", org.ID, err)
+		return errors.New("something went wrong, please try again later")
+	}
+	doc := &models.KYBDocument{
+		OrganizationID:    org.ID,
+		DocumentType:      data.DocumentType,
+		MimeType:          mime,
+		EncryptionVersion: utils.KeyIDOf(encrypted),
+		StorageKey:        key,
+		Checksum:          documentChecksum([]byte(encrypted)),
+		SizeBytes:         int64(len(encrypted)),
+	}
+	if err := s.orgrepo.CreateKybDocument(ctx, doc); err != nil {
+		discardDocument(ctx, s.store, &models.KYCDocument{StorageKey: &key})
+		return errors.New("something went wrong, please try again later")
+	}
+	return nil
+}
+
+func kybObjectKey(orgID uuid.UUID, docType string) string {
+	return fmt.Sprintf("kyb/%s/%s-%s", orgID, docType, uuid.NewString())
+}
+
+func (s *organizationService) GetKybStatus(ctx context.Context, data models.OrganizationReq) (models.KybStatusResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionView)
+	if err != nil {
+		return models.KybStatusResp{}, err
+	}
+	return s.kybStatus(ctx, org)
+}
+
+// SubmitKyb sends the organization's details and documents for review once
+// nothing is outstanding.
+func (s *organizationService) SubmitKyb(ctx context.Context, data models.OrganizationReq) (models.KybStatusResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageKyb)
+	if err != nil {
+		return models.KybStatusResp{}, err
+	}
+	if !kybEditable(org.KybStatus) {
+		return models.KybStatusResp{}, errors.New("business verification is already " + strings.ReplaceAll(org.KybStatus, "_", " "))
+	}
+	res, err := s.kybStatus(ctx, org)
+	if err != nil {
+		return models.KybStatusResp{}, err
+	}
+	if len(res.Outstanding) > 0 {
+		return res, errors.New("business verification is incomplete, missing: " + strings.Join(res.Outstanding, ", "))
+	}
+	org.KybStatus = KybUnderReview
+	org.KybRejectionReason = nil
+	if err := s.orgrepo.UpdateOrganization(ctx, org); err != nil {
+		return models.KybStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "organization.kyb_submitted", "organization", org.ID, data.Client, nil); err != nil {
+		log.Printf("failed to audit kyb submission of organization %s: %v", org.ID, err)
+	}
+	res.Status = org.KybStatus
+	res.RejectionReason = nil
+	return res, nil
+}
+
+// GetKybForReview shows a compliance officer an organization's KYB details.
+func (s *organizationService) GetKybForReview(ctx context.Context, data models.AdminOrganizationReq) (models.KybStatusResp, error) {
+	id, err := uuid.Parse(data.Orgid)
+	if err != nil {
+		return models.KybStatusResp{}, errors.New("organization not found")
+	}
+	org, err := s.orgrepo.FindOrganization(ctx, id)
+	if err != nil {
+		return models.KybStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	if org == nil {
+		return models.KybStatusResp{}, errors.New("organization not found")
+	}
+	return s.kybStatus(ctx, org)
+}
+
+// ReviewKyb approves or rejects an organization's business verification.
+func (s *organizationService) ReviewKyb(ctx context.Context, data models.AdminKybReviewReq) (models.KybStatusResp, error) {
+	id, err := uuid.Parse(data.Orgid)
+	if err != nil {
+		return models.KybStatusResp{}, errors.New("organization not found")
+	}
+	org, err := s.orgrepo.FindOrganization(ctx, id)
+	if err != nil {
+		return models.KybStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	if org == nil {
+		return models.KybStatusResp{}, errors.New("organization not found")
+	}
+	if org.KybStatus != KybUnderReview {
+		return models.KybStatusResp{}, errors.New("business verification is not under review")
+	}
+	meta := map[string]any{}
+	reason := strings.TrimSpace(data.Reason)
+	switch data.Decision {
+	case "approve":
+		org.KybStatus = KybVerified
+		org.KybRejectionReason = nil
+	case "reject":
+		if reason == "" {
+			return models.KybStatusResp{}, errors.New("a rejection reason is required")
+		}
+		org.KybStatus = KybRejected
+		org.KybRejectionReason = &reason
+		meta["reason"] = reason
+	default:
+		return models.KybStatusResp{}, errors.New("invalid review decision")
+	}
+	now := time.Now()
+	org.KybReviewedBy = &data.AdminId
+	org.KybReviewedAt = &now
+
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "organization.kyb_"+org.KybStatus, "organization", org.ID, data.Client, meta); err != nil {
+		return models.KybStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := s.orgrepo.UpdateOrganization(ctx, org); err != nil {
+		log.Printf("failed to save kyb review for organization %s: %v", org.ID, err)
+		return models.KybStatusResp{}, errors.New("something went wrong, please try again later")
+	}
+	return s.kybStatus(ctx, org)
+}
@@ -0,0 +1,479 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrganizationService interface {
+	CreateOrganization(context.Context, models.CreateOrganizationReq) (models.OrganizationResp, error)
+	GetOrganizations(context.Context, uuid.UUID) ([]models.OrganizationResp, error)
+	GetOrganization(context.Context, models.OrganizationReq) (models.OrganizationResp, error)
+	InviteMember(context.Context, models.InviteMemberReq) (models.OrganizationMemberResp, error)
+	AcceptInvite(context.Context, models.AcceptInviteReq) (models.OrganizationResp, error)
+	GetMembers(context.Context, models.OrganizationReq) ([]models.OrganizationMemberResp, error)
+	UpdateMemberRole(context.Context, models.UpdateMemberReq) (models.OrganizationMemberResp, error)
+	RemoveMember(context.Context, models.UpdateMemberReq) error
+	Deposit(context.Context, models.OrgFundsReq) (models.OrgFundsResp, error)
+	FundCard(context.Context, models.OrgFundsReq) (models.OrgFundsResp, error)
+	ReturnCardFunds(context.Context, models.OrgFundsReq) (models.OrgFundsResp, error)
+	GetLedger(context.Context, models.OrganizationReq) ([]models.OrganizationLedgerResp, error)
+	IssueCard(context.Context, models.CreateOrgCardReq) (models.OrgCardResp, error)
+	GetCards(context.Context, models.OrganizationReq) ([]models.OrgCardResp, error)
+	GetTransactions(context.Context, models.OrganizationReq) ([]models.GetCardTransactionsResp, error)
+	SaveKybDetails(context.Context, models.KybDetailsReq) (models.KybStatusResp, error)
+	UploadKybDocument(context.Context, models.KybFile) error
+	GetKybStatus(context.Context, models.OrganizationReq) (models.KybStatusResp, error)
+	SubmitKyb(context.Context, models.OrganizationReq) (models.KybStatusResp, error)
+	GetKybForReview(context.Context, models.AdminOrganizationReq) (models.KybStatusResp, error)
+	ReviewKyb(context.Context, models.AdminKybReviewReq) (models.KybStatusResp, error)
+	SaveApprovalPolicies(context.Context, models.ApprovalPoliciesReq) ([]models.ApprovalPolicyTier, error)
+	GetApprovalPolicies(context.Context, models.OrganizationReq) ([]models.ApprovalPolicyTier, error)
+	RequestApproval(context.Context, models.ApprovalRequestReq) (models.ApprovalRequestResp, error)
+	GetApprovalRequests(context.Context, models.ApprovalListReq) ([]models.ApprovalRequestResp, error)
+	GetApprovalRequest(context.Context, models.ApprovalDecisionReq) (models.ApprovalRequestResp, error)
+	ApproveRequest(context.Context, models.ApprovalDecisionReq) (models.ApprovalRequestResp, error)
+	RejectRequest(context.Context, models.ApprovalDecisionReq) (models.ApprovalRequestResp, error)
+	CancelRequest(context.Context, models.ApprovalDecisionReq) (models.ApprovalRequestResp, error)
+}
+
+type organizationService struct {
+	orgrepo          repositories.OrganizationRepository
+	userrepo         repositories.UserRepository
+	cardrepo         repositories.CardRepository
+	auditrepo        repositories.AuditRepository
+	store            integrations.DocumentStore
+	screening        ScreeningService
+	approvalrepo     repositories.ApprovalRepository
+	notificationrepo repositories.NotificationRepository
+	tokens           CardTokenSyncer
+}
+
+func NewOrganizationService(orgRepo repositories.OrganizationRepository, userRepo repositories.UserRepository, cardRepo repositories.CardRepository, auditRepo repositories.AuditRepository, store integrations.DocumentStore, screening ScreeningService, approvalRepo repositories.ApprovalRepository, notificationRepo repositories.NotificationRepository, tokens CardTokenSyncer) OrganizationService {
+	return &organizationService{orgrepo: orgRepo, userrepo: userRepo, cardrepo: cardRepo, auditrepo: auditRepo, store: store, screening: screening, approvalrepo: approvalRepo, notificationrepo: notificationRepo, tokens: tokens}
+}
+
+const (
+	OrgRoleOwner      = "owner"
+	OrgRoleAdmin      = "admin"
+	OrgRoleCardholder = "cardholder"
+	OrgRoleViewer     = "viewer"
+)
+
+// Actions checked against a member's role.
+const (
+	OrgActionView          = "view"
+	OrgActionManageMembers = "manage_members"
+	OrgActionManageOwners  = "manage_owners"
+	OrgActionManageCards   = "manage_cards"
+	OrgActionManageFunds   = "manage_funds"
+	OrgActionManageKyb     = "manage_kyb"
+	OrgActionManagePolicy  = "manage_policy"
+	OrgActionRequest       = "request"
+	OrgActionApprove       = "approve"
+)
+
+// orgPermissions lists what each role may do. Cardholders use the cards
+// issued to them through the ordinary card endpoints and ask for new cards
+// and top-ups through approval requests.
+var orgPermissions = map[string][]string{
+	OrgRoleOwner:      {OrgActionView, OrgActionManageMembers, OrgActionManageOwners, OrgActionManageCards, OrgActionManageFunds, OrgActionManageKyb, OrgActionManagePolicy, OrgActionRequest, OrgActionApprove},
+	OrgRoleAdmin:      {OrgActionView, OrgActionManageMembers, OrgActionManageCards, OrgActionManageFunds, OrgActionManageKyb, OrgActionRequest, OrgActionApprove},
+	OrgRoleCardholder: {OrgActionRequest},
+	OrgRoleViewer:     {OrgActionView},
+}
+
+var ErrOrgForbidden = errors.New("your role in this organization does not allow this")
+
+const orgInviteTTL = 7 * 24 * time.Hour
+
+func validOrgRole(role string) bool {
+	_, ok := orgPermissions[role]
+	return ok
+}
+
+// OrgRoleAllows reports whether a role may take an action. An empty action
+// only needs membership.
+func OrgRoleAllows(role, action string) bool {
+	if action == "" {
+		return validOrgRole(role)
+	}
+	for _, allowed := range orgPermissions[role] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize loads an organization for one of its active members, checking
+// the member's role allows the action. Non-members get the same answer as
+// for an organization that does not exist.
+func (s *organizationService) authorize(ctx context.Context, orgID string, userID uuid.UUID, action string) (*models.Organization, *models.OrganizationMember, error) {
+	id, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, nil, errors.New("organization not found")
+	}
+	member, err := s.orgrepo.FindMember(ctx, id, userID)
+	if err != nil {
+		return nil, nil, errors.New("something went wrong, please try again later")
+	}
+	if member == nil || member.Status != "active" {
+		return nil, nil, errors.New("organization not found")
+	}
+	if !OrgRoleAllows(member.Role, action) {
+		return nil, nil, ErrOrgForbidden
+	}
+	org, err := s.orgrepo.FindOrganization(ctx, id)
+	if err != nil {
+		return nil, nil, errors.New("something went wrong, please try again later")
+	}
+	if org == nil {
+		return nil, nil, errors.New("organization not found")
+	}
+	return org, member, nil
+}
+
+func organizationResp(org models.Organization, role string) models.OrganizationResp {
+	return models.OrganizationResp{
+		Orgid:     org.ID,
+		Name:      org.Name,
+		Status:    org.Status,
+		KybStatus: org.KybStatus,
+		Balance:   org.Balance,
+		Currency:  org.Currency,
+		Role:      role,
+		CreatedAt: org.CreatedAt,
+	}
+}
+
+func memberResp(m models.OrganizationMember) models.OrganizationMemberResp {
+	return models.OrganizationMemberResp{
+		Memberid: m.ID,
+		Userid:   m.UserID,
+		Email:    m.Email,
+		Role:     m.Role,
+		Status:   m.Status,
+		JoinedAt: m.JoinedAt,
+	}
+}
+
+// CreateOrganization sets up a business account with the caller as its
+// first owner.
+func (s *organizationService) CreateOrganization(ctx context.Context, data models.CreateOrganizationReq) (models.OrganizationResp, error) {
+	name := strings.TrimSpace(data.Name)
+	if len(name) < 2 || len(name) > 255 {
+		return models.OrganizationResp{}, errors.New("organization name must be between 2 and 255 characters")
+	}
+	user, err := s.userrepo.FindByID(ctx, data.Userid)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return models.OrganizationResp{}, ErrUserNotFound
+		}
+		return models.OrganizationResp{}, errors.New("something went wrong, please try again later")
+	}
+	if !user.EmailVerified {
+		return models.OrganizationResp{}, errors.New("verify your email before creating an organization")
+	}
+
+	org := &models.Organization{Name: name, Status: "active", KybStatus: "not_started", Currency: "USD", CreatedBy: user.ID}
+	now := time.Now()
+	err = s.orgrepo.RunInTransaction(ctx, func(orgs repositories.OrganizationRepository, _ repositories.CardRepository, _ repositories.TransactionRepository) error {
+		if err := orgs.CreateOrganization(ctx, org); err != nil {
+			return err
+		}
+		return orgs.CreateMember(ctx, &models.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         &user.ID,
+			Email:          strings.ToLower(user.Email),
+			Role:           OrgRoleOwner,
+			Status:         "active",
+			JoinedAt:       &now,
+		})
+	})
+	if err != nil {
+		log.Printf("failed to create organization for user %s: %v", user.ID, err)
+		return models.OrganizationResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, user.ID, "organization.created", "organization", org.ID, data.Client, map[string]any{"name": name}); err != nil {
+		log.Printf("failed to audit creation of organization %s: %v", org.ID, err)
+	}
+	return organizationResp(*org, OrgRoleOwner), nil
+}
+
+// GetOrganizations lists the organizations the user is an active member of.
+func (s *organizationService) GetOrganizations(ctx context.Context, userID uuid.UUID) ([]models.OrganizationResp, error) {
+	members, err := s.orgrepo.FindMembershipsByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.OrganizationResp, 0, len(members))
+	for _, m := range members {
+		res = append(res, organizationResp(m.Organization, m.Role))
+	}
+	return res, nil
+}
+
+func (s *organizationService) GetOrganization(ctx context.Context, data models.OrganizationReq) (models.OrganizationResp, error) {
+	org, member, err := s.authorize(ctx, data.Orgid, data.Userid, "")
+	if err != nil {
+		return models.OrganizationResp{}, err
+	}
+	return organizationResp(*org, member.Role), nil
+}
+
+// InviteMember emails an invitation to join the organization. Inviting an
+// address that was removed or never accepted sends a fresh invitation.
+func (s *organizationService) InviteMember(ctx context.Context, data models.InviteMemberReq) (models.OrganizationMemberResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageMembers)
+	if err != nil {
+		return models.OrganizationMemberResp{}, err
+	}
+	if !validOrgRole(data.Role) {
+		return models.OrganizationMemberResp{}, errors.New("role must be one of owner, admin, cardholder or viewer")
+	}
+	if data.Role == OrgRoleOwner {
+		if _, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageOwners); err != nil {
+			return models.OrganizationMemberResp{}, err
+		}
+	}
+	email := strings.ToLower(strings.TrimSpace(data.Email))
+	if !strings.Contains(email, "@") || len(email) > 255 {
+		return models.OrganizationMemberResp{}, errors.New("invalid email address")
+	}
+
+	token, hash, err := newInviteToken()
+	if err != nil {
+		return models.OrganizationMemberResp{}, errors.New("something went wrong, please try again later")
+	}
+	expires := time.Now().Add(orgInviteTTL)
+	member, err := s.orgrepo.FindMemberByEmail(ctx, org.ID, email)
+	if err != nil {
+		return models.OrganizationMemberResp{}, errors.New("something went wrong, please try again later")
+	}
+	if member != nil && member.Status == "active" {
+		return models.OrganizationMemberResp{}, errors.New("this person is already a member of the organization")
+	}
+	if member == nil {
+		member = &models.OrganizationMember{OrganizationID: org.ID, Email: email}
+	}
+	member.UserID = nil
+	member.Role = data.Role
+	member.Status = "invited"
+	member.InviteTokenHash = &hash
+	member.InviteExpiresAt = &expires
+	member.InvitedBy = &data.Userid
+	member.JoinedAt = nil
+	if member.ID == uuid.Nil {
+		err = s.orgrepo.CreateMember(ctx, member)
+	} else {
+		err = s.orgrepo.UpdateMember(ctx, member)
+	}
+	if err != nil {
+		return models.OrganizationMemberResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "organization.member_invited", "organization", org.ID, data.Client, map[string]any{"email": email, "role": data.Role}); err != nil {
+		log.Printf("failed to audit invitation to organization %s: %v", org.ID, err)
+	}
+
+	res := map[string]string{
+		"Email":            email,
+		"OrganizationName": org.Name,
+		"Role":             data.Role,
+		"Token":            token,
+	}
+	go func() {
+		if err := utils.SendWithRetry(3, 2*time.Second, func() error {
+			return SendOrganizationInviteEmail(res)
+		}); err != nil {
+			log.Printf("failed to send invitation to organization %s: %v", org.ID, err)
+		}
+	}()
+	return memberResp(*member), nil
+}
+
+// newInviteToken returns a random invitation token and the hash stored in
+// its place.
+func newInviteToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(raw)
+	return token, hashInviteToken(token), nil
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AcceptInvite adds the signed-in user to the organization that invited
+// them. The invitation has to have been sent to the user's email address.
+func (s *organizationService) AcceptInvite(ctx context.Context, data models.AcceptInviteReq) (models.OrganizationResp, error) {
+	token := strings.TrimSpace(data.Token)
+	if token == "" {
+		return models.OrganizationResp{}, errors.New("invalid or expired invitation")
+	}
+	member, err := s.orgrepo.FindMemberByInviteHash(ctx, hashInviteToken(token))
+	if err != nil {
+		return models.OrganizationResp{}, errors.New("something went wrong, please try again later")
+	}
+	if member == nil || member.Status != "invited" || member.InviteExpiresAt == nil || time.Now().After(*member.InviteExpiresAt) {
+		return models.OrganizationResp{}, errors.New("invalid or expired invitation")
+	}
+	user, err := s.userrepo.FindByID(ctx, data.Userid)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return models.OrganizationResp{}, ErrUserNotFound
+		}
+		return models.OrganizationResp{}, errors.New("something went wrong, please try again later")
+	}
+	if !strings.EqualFold(user.Email, member.Email) {
+		return models.OrganizationResp{}, errors.New("this invitation was sent to a different email address")
+	}
+	existing, err := s.orgrepo.FindMember(ctx, member.OrganizationID, user.ID)
+	if err != nil {
+		return models.OrganizationResp{}, errors.New("something went wrong, please try again later")
+	}
+	if existing != nil && existing.Status == "active" {
+		return models.OrganizationResp{}, errors.New("you are already a member of this organization")
+	}
+	org, err := s.orgrepo.FindOrganization(ctx, member.OrganizationID)
+	if err != nil || org == nil {
+		return models.OrganizationResp{}, errors.New("something went wrong, please try again later")
+	}
+
+	now := time.Now()
+	member.UserID = &user.ID
+	member.Status = "active"
+	member.JoinedAt = &now
+	member.InviteTokenHash = nil
+	member.InviteExpiresAt = nil
+	if err := s.orgrepo.UpdateMember(ctx, member); err != nil {
+		return models.OrganizationResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, user.ID, "organization.member_joined", "organization", org.ID, models.ClientInfo{}, map[string]any{"role": member.Role}); err != nil {
+		log.Printf("failed to audit member joining organization %s: %v", org.ID, err)
+	}
+	return organizationResp(*org, member.Role), nil
+}
+
+func (s *organizationService) GetMembers(ctx context.Context, data models.OrganizationReq) ([]models.OrganizationMemberResp, error) {
+	org, _, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionView)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.orgrepo.FindMembers(ctx, org.ID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.OrganizationMemberResp, 0, len(members))
+	for _, m := range members {
+		res = append(res, memberResp(m))
+	}
+	return res, nil
+}
+
+// findTargetMember loads the member an admin is acting on. Only owners can
+// act on owners, and an organization always keeps one active owner.
+func (s *organizationService) findTargetMember(ctx context.Context, org *models.Organization, actor *models.OrganizationMember, memberID string, newRole string) (*models.OrganizationMember, error) {
+	id, err := uuid.Parse(memberID)
+	if err != nil {
+		return nil, errors.New("member not found")
+	}
+	target, err := s.orgrepo.FindMemberByID(ctx, org.ID, id)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	if target == nil || target.Status == "removed" {
+		return nil, errors.New("member not found")
+	}
+	if (target.Role == OrgRoleOwner || newRole == OrgRoleOwner) && !OrgRoleAllows(actor.Role, OrgActionManageOwners) {
+		return nil, ErrOrgForbidden
+	}
+	if target.Role == OrgRoleOwner && newRole != OrgRoleOwner && target.Status == "active" {
+		owners, err := s.orgrepo.CountActiveOwners(ctx, org.ID)
+		if err != nil {
+			return nil, errors.New("something went wrong, please try again later")
+		}
+		if owners <= 1 {
+			return nil, errors.New("an organization needs at least one owner")
+		}
+	}
+	return target, nil
+}
+
+func (s *organizationService) UpdateMemberRole(ctx context.Context, data models.UpdateMemberReq) (models.OrganizationMemberResp, error) {
+	org, actor, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageMembers)
+	if err != nil {
+		return models.OrganizationMemberResp{}, err
+	}
+	if !validOrgRole(data.Role) {
+		return models.OrganizationMemberResp{}, errors.New("role must be one of owner, admin, cardholder or viewer")
+	}
+	target, err := s.findTargetMember(ctx, org, actor, data.Memberid, data.Role)
+	if err != nil {
+		return models.OrganizationMemberResp{}, err
+	}
+	previous := target.Role
+	target.Role = data.Role
+	if err := s.orgrepo.UpdateMember(ctx, target); err != nil {
+		return models.OrganizationMemberResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "organization.member_role_changed", "organization", org.ID, data.Client, map[string]any{"member_id": target.ID, "from": previous, "to": data.Role}); err != nil {
+		log.Printf("failed to audit role change in organization %s: %v", org.ID, err)
+	}
+	return memberResp(*target), nil
+}
+
+// RemoveMember takes someone out of the organization and suspends the
+// business cards they hold. Money on those cards stays there until an admin
+// returns it to the organization balance.
+func (s *organizationService) RemoveMember(ctx context.Context, data models.UpdateMemberReq) error {
+	org, actor, err := s.authorize(ctx, data.Orgid, data.Userid, OrgActionManageMembers)
+	if err != nil {
+		return err
+	}
+	target, err := s.findTargetMember(ctx, org, actor, data.Memberid, "")
+	if err != nil {
+		return err
+	}
+	var suspended []models.Card
+	err = s.orgrepo.RunInTransaction(ctx, func(orgs repositories.OrganizationRepository, _ repositories.CardRepository, _ repositories.TransactionRepository) error {
+		target.Status = "removed"
+		target.InviteTokenHash = nil
+		target.InviteExpiresAt = nil
+		if err := orgs.UpdateMember(ctx, target); err != nil {
+			return err
+		}
+		if target.UserID == nil {
+			return nil
+		}
+		suspended, err = orgs.SuspendMemberCards(ctx, org.ID, *target.UserID)
+		return err
+	})
+	if err != nil {
+		log.Printf("failed to remove member %s from organization %s: %v", target.ID, org.ID, err)
+		return errors.New("something went wrong, please try again later")
+	}
+	for _, card := range suspended {
+		s.tokens.SyncCardTokens(ctx, card.ID, card.Status)
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "organization.member_removed", "organization", org.ID, data.Client, map[string]any{"member_id": target.ID, "cards_suspended": len(suspended)}); err != nil {
+		log.Printf("failed to audit member removal in organization %s: %v", org.ID, err)
+	}
+	return nil
+}
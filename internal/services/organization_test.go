@@ -0,0 +1,73 @@
+package services
+
+import (
+	"CardFlow/internal/models"
+	"testing"
+	"time"
+)
+
+func TestOrgRoleAllows(t *testing.T) {
+	cases := []struct {
+		role, action string
+		want         bool
+	}{
+		{OrgRoleOwner, OrgActionManageOwners, true},
+		{OrgRoleAdmin, OrgActionManageOwners, false},
+		{OrgRoleAdmin, OrgActionManageFunds, true},
+		{OrgRoleCardholder, OrgActionView, false},
+		{OrgRoleViewer, OrgActionView, true},
+		{OrgRoleViewer, OrgActionManageCards, false},
+		{OrgRoleCardholder, OrgActionRequest, true},
+		{OrgRoleCardholder, OrgActionApprove, false},
+		{OrgRoleAdmin, OrgActionApprove, true},
+		{OrgRoleAdmin, OrgActionManagePolicy, false},
+		{"auditor", OrgActionView, false},
+		{OrgRoleViewer, "", true},
+		{"auditor", "", false},
+	}
+	for _, c := range cases {
+		if got := OrgRoleAllows(c.role, c.action); got != c.want {
+			t.Errorf("OrgRoleAllows(%q, %q) = %v, want %v", c.role, c.action, got, c.want)
+		}
+	}
+}
+
+func TestValidateKybDirectors(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	director := models.KybDirector{FullName: " Ada Obi ", DateOfBirth: "1980-01-15", Nationality: "ng", OwnershipPercent: 60}
+
+	rows, err := ValidateKybDirectors([]models.KybDirector{director}, now, 18)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rows[0].FullName != "Ada Obi" || rows[0].Nationality != "NG" {
+		t.Fatalf("director not normalized: %+v", rows[0])
+	}
+
+	if _, err := ValidateKybDirectors(nil, now, 18); err == nil {
+		t.Fatal("expected an error for no directors")
+	}
+	minor := director
+	minor.DateOfBirth = "2015-01-01"
+	if _, err := ValidateKybDirectors([]models.KybDirector{minor}, now, 18); err == nil {
+		t.Fatal("expected an error for an underage director")
+	}
+	if _, err := ValidateKybDirectors([]models.KybDirector{director, director}, now, 18); err == nil {
+		t.Fatal("expected an error for ownership over 100 percent")
+	}
+}
+
+func TestOutstandingKyb(t *testing.T) {
+	org := &models.Organization{}
+	missing := outstandingKyb(org, nil, nil)
+	if len(missing) != 4 {
+		t.Fatalf("expected everything outstanding, got %v", missing)
+	}
+
+	org.RegistrationNumber, org.Country = "RC123456", "NG"
+	directors := []models.OrganizationDirector{{FullName: "Ada Obi"}}
+	docs := []models.KYBDocument{{DocumentType: KybDocIncorporation}, {DocumentType: KybDocProofOfAddr}}
+	if missing := outstandingKyb(org, directors, docs); len(missing) != 0 {
+		t.Fatalf("expected nothing outstanding, got %v", missing)
+	}
+}
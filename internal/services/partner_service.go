@@ -0,0 +1,384 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PartnerService interface {
+	CreatePartner(context.Context, models.AdminCreatePartnerReq) (models.PartnerResp, error)
+	GetPartners(context.Context) ([]models.PartnerResp, error)
+	CreateKey(context.Context, models.AdminCreatePartnerKeyReq) (models.PartnerKeyCreatedResp, error)
+	GetKeys(context.Context, models.AdminPartnerKeyReq) ([]models.PartnerKeyResp, error)
+	RevokeKey(context.Context, models.AdminPartnerKeyReq) error
+	LinkPartner(context.Context, models.PartnerLinkReq) (models.PartnerLinkResp, error)
+	GetLinkedPartners(context.Context, uuid.UUID) ([]models.PartnerLinkResp, error)
+	UnlinkPartner(context.Context, models.PartnerLinkReq) error
+	AuthenticatePartnerKey(context.Context, models.PartnerAuthReq) (models.PartnerPrincipal, error)
+}
+
+type partnerService struct {
+	partnerrepo repositories.PartnerRepository
+	auditrepo   repositories.AuditRepository
+}
+
+func NewPartnerService(partnerRepo repositories.PartnerRepository, auditRepo repositories.AuditRepository) PartnerService {
+	return &partnerService{partnerrepo: partnerRepo, auditrepo: auditRepo}
+}
+
+// checkPartnerCardStatusChange limits what a partner key can do to a card's
+// status: freeze, unfreeze and terminate. A partner can't move money off the
+// card, so a card with a balance can only be terminated by its holder.
+func checkPartnerCardStatusChange(req models.ModifyCardStatusReq, status string) error {
+	if req.PartnerId == nil {
+		return nil
+	}
+	switch status {
+	case "freeze", "unfreeze", "terminate":
+	default:
+		return utils.ErrAPIKeyScope
+	}
+	if req.Payout != nil || req.TransferToCardId != "" {
+		return errors.New("partners can't move a card's balance, the cardholder has to terminate a card with money on it")
+	}
+	return nil
+}
+
+const maxPartnerRateLimit = 6000
+
+func defaultPartnerRateLimit() int {
+	if limit, err := strconv.Atoi(config.PartnerRateLimit); err == nil && limit > 0 {
+		return limit
+	}
+	return 120
+}
+
+func partnerResp(p models.Partner) models.PartnerResp {
+	return models.PartnerResp{Partnerid: p.ID, Name: p.Name, ContactEmail: p.ContactEmail, Status: p.Status, CreatedAt: p.CreatedAt}
+}
+
+func (s *partnerService) CreatePartner(ctx context.Context, data models.AdminCreatePartnerReq) (models.PartnerResp, error) {
+	name := strings.TrimSpace(data.Name)
+	if name == "" || len(name) > 255 {
+		return models.PartnerResp{}, errors.New("partner name is required")
+	}
+	partner := &models.Partner{
+		Name:         name,
+		ContactEmail: strings.ToLower(strings.TrimSpace(data.ContactEmail)),
+		Status:       "active",
+		CreatedBy:    data.AdminId,
+	}
+	if err := s.partnerrepo.CreatePartner(ctx, partner); err != nil {
+		return models.PartnerResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "partner.created", "partner", partner.ID, data.Client, map[string]any{"name": name}); err != nil {
+		log.Printf("failed to write audit log for partner %s: %v", partner.ID, err)
+	}
+	return partnerResp(*partner), nil
+}
+
+func (s *partnerService) GetPartners(ctx context.Context) ([]models.PartnerResp, error) {
+	partners, err := s.partnerrepo.FindPartners(ctx)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.PartnerResp, 0, len(partners))
+	for _, p := range partners {
+		res = append(res, partnerResp(p))
+	}
+	return res, nil
+}
+
+func (s *partnerService) findPartner(ctx context.Context, partnerID string) (*models.Partner, error) {
+	id, err := uuid.Parse(partnerID)
+	if err != nil {
+		return nil, errors.New("partner not found")
+	}
+	partner, err := s.partnerrepo.FindPartner(ctx, id)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	if partner == nil {
+		return nil, errors.New("partner not found")
+	}
+	return partner, nil
+}
+
+func partnerKeyResp(key models.PartnerAPIKey) models.PartnerKeyResp {
+	res := models.PartnerKeyResp{
+		Keyid:              key.ID,
+		Name:               key.Name,
+		Prefix:             key.Prefix,
+		Scopes:             []string{},
+		AllowedIPs:         []string{},
+		RateLimitPerMinute: key.RateLimitPerMinute,
+		ExpiresAt:          key.ExpiresAt,
+		LastUsedAt:         key.LastUsedAt,
+		RevokedAt:          key.RevokedAt,
+		CreatedAt:          key.CreatedAt,
+	}
+	json.Unmarshal(key.Scopes, &res.Scopes)
+	if len(key.AllowedIPs) > 0 {
+		json.Unmarshal(key.AllowedIPs, &res.AllowedIPs)
+	}
+	return res
+}
+
+// CreateKey issues an API key for a partner. The key is returned once and
+// only its hash is stored.
+func (s *partnerService) CreateKey(ctx context.Context, data models.AdminCreatePartnerKeyReq) (models.PartnerKeyCreatedResp, error) {
+	partner, err := s.findPartner(ctx, data.Partnerid)
+	if err != nil {
+		return models.PartnerKeyCreatedResp{}, err
+	}
+	if partner.Status != "active" {
+		return models.PartnerKeyCreatedResp{}, errors.New("partner is not active")
+	}
+	name := strings.TrimSpace(data.Name)
+	if name == "" || len(name) > 100 {
+		return models.PartnerKeyCreatedResp{}, errors.New("key name is required")
+	}
+	if len(data.Scopes) == 0 {
+		return models.PartnerKeyCreatedResp{}, errors.New("at least one scope is required")
+	}
+	seen := map[string]bool{}
+	scopes := make([]string, 0, len(data.Scopes))
+	for _, scope := range data.Scopes {
+		if !utils.ValidPartnerScope(scope) {
+			return models.PartnerKeyCreatedResp{}, errors.New("unknown scope " + scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	allowedIPs, err := utils.NormalizeAllowedIPs(data.AllowedIPs)
+	if err != nil {
+		return models.PartnerKeyCreatedResp{}, err
+	}
+	limit := data.RateLimitPerMinute
+	if limit == 0 {
+		limit = defaultPartnerRateLimit()
+	}
+	if limit < 0 || limit > maxPartnerRateLimit {
+		return models.PartnerKeyCreatedResp{}, errors.New("rate limit must be between 1 and 6000 requests per minute")
+	}
+	if data.ExpiresAt != nil && !data.ExpiresAt.After(time.Now()) {
+		return models.PartnerKeyCreatedResp{}, errors.New("expiry must be in the future")
+	}
+
+	raw, prefix, hash, err := utils.GeneratePartnerKey()
+	if err != nil {
+		return models.PartnerKeyCreatedResp{}, errors.New("something went wrong, please try again later")
+	}
+	scopesJSON, _ := json.Marshal(scopes)
+	ipsJSON, _ := json.Marshal(allowedIPs)
+	key := &models.PartnerAPIKey{
+		PartnerID:          partner.ID,
+		Name:               name,
+		Prefix:             prefix,
+		KeyHash:            hash,
+		Scopes:             scopesJSON,
+		AllowedIPs:         ipsJSON,
+		RateLimitPerMinute: limit,
+		ExpiresAt:          data.ExpiresAt,
+		CreatedBy:          data.AdminId,
+	}
+	if err := s.partnerrepo.CreateKey(ctx, key); err != nil {
+		return models.PartnerKeyCreatedResp{}, errors.New("something went wrong, please try again later")
+	}
+	meta := map[string]any{"partner_id": partner.ID, "prefix": prefix, "scopes": scopes, "allowed_ips": allowedIPs, "rate_limit_per_minute": limit}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "partner.key_created", "partner_api_key", key.ID, data.Client, meta); err != nil {
+		log.Printf("failed to write audit log for partner key %s: %v", key.ID, err)
+	}
+	return models.PartnerKeyCreatedResp{PartnerKeyResp: partnerKeyResp(*key), Key: raw}, nil
+}
+
+func (s *partnerService) GetKeys(ctx context.Context, data models.AdminPartnerKeyReq) ([]models.PartnerKeyResp, error) {
+	partner, err := s.findPartner(ctx, data.Partnerid)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.partnerrepo.FindKeys(ctx, partner.ID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.PartnerKeyResp, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, partnerKeyResp(key))
+	}
+	return res, nil
+}
+
+// RevokeKey stops a key from authenticating. Revoked keys stay listed.
+func (s *partnerService) RevokeKey(ctx context.Context, data models.AdminPartnerKeyReq) error {
+	partner, err := s.findPartner(ctx, data.Partnerid)
+	if err != nil {
+		return err
+	}
+	keyID, err := uuid.Parse(data.Keyid)
+	if err != nil {
+		return errors.New("API key not found")
+	}
+	key, err := s.partnerrepo.FindKey(ctx, partner.ID, keyID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if key == nil {
+		return errors.New("API key not found")
+	}
+	if key.RevokedAt != nil {
+		return errors.New("API key is already revoked")
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	key.RevokedBy = &data.AdminId
+	if err := s.partnerrepo.UpdateKey(ctx, key); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "partner.key_revoked", "partner_api_key", key.ID, data.Client, map[string]any{"partner_id": partner.ID, "prefix": key.Prefix}); err != nil {
+		log.Printf("failed to write audit log for partner key %s: %v", key.ID, err)
+	}
+	return nil
+}
+
+// LinkPartner records the user's consent for a partner to act on their
+// behalf.
+func (s *partnerService) LinkPartner(ctx context.Context, data models.PartnerLinkReq) (models.PartnerLinkResp, error) {
+	partner, err := s.findPartner(ctx, data.Partnerid)
+	if err != nil {
+		return models.PartnerLinkResp{}, err
+	}
+	if partner.Status != "active" {
+		return models.PartnerLinkResp{}, errors.New("partner is not active")
+	}
+	link, err := s.partnerrepo.FindLink(ctx, partner.ID, data.Userid)
+	if err != nil {
+		return models.PartnerLinkResp{}, errors.New("something went wrong, please try again later")
+	}
+	if link == nil {
+		link = &models.PartnerLink{PartnerID: partner.ID, UserID: data.Userid}
+	} else if link.Status == "active" {
+		return models.PartnerLinkResp{}, errors.New("partner already has access")
+	}
+	link.Status = "active"
+	link.RevokedAt = nil
+	if err := s.partnerrepo.SaveLink(ctx, link); err != nil {
+		return models.PartnerLinkResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "partner.linked", "partner", partner.ID, data.Client, nil); err != nil {
+		log.Printf("failed to write audit log for partner link %s: %v", link.ID, err)
+	}
+	return models.PartnerLinkResp{Partnerid: partner.ID, Name: partner.Name, Status: link.Status, CreatedAt: link.CreatedAt}, nil
+}
+
+func (s *partnerService) GetLinkedPartners(ctx context.Context, userID uuid.UUID) ([]models.PartnerLinkResp, error) {
+	links, err := s.partnerrepo.FindUserLinks(ctx, userID)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.PartnerLinkResp, 0, len(links))
+	for _, link := range links {
+		res = append(res, models.PartnerLinkResp{Partnerid: link.PartnerID, Name: link.Partner.Name, Status: link.Status, CreatedAt: link.CreatedAt})
+	}
+	return res, nil
+}
+
+// UnlinkPartner withdraws a partner's access to the user's account.
+func (s *partnerService) UnlinkPartner(ctx context.Context, data models.PartnerLinkReq) error {
+	partnerID, err := uuid.Parse(data.Partnerid)
+	if err != nil {
+		return errors.New("partner not found")
+	}
+	link, err := s.partnerrepo.FindLink(ctx, partnerID, data.Userid)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if link == nil || link.Status != "active" {
+		return errors.New("partner not found")
+	}
+	now := time.Now()
+	link.Status = "revoked"
+	link.RevokedAt = &now
+	if err := s.partnerrepo.SaveLink(ctx, link); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, data.Userid, "partner.unlinked", "partner", partnerID, data.Client, nil); err != nil {
+		log.Printf("failed to write audit log for partner link %s: %v", link.ID, err)
+	}
+	return nil
+}
+
+// AuthenticatePartnerKey checks a partner API call: the key must be live,
+// called from an allowed IP, carry the route's scope and be for a user who
+// has linked the partner. Calls that change something are written to the
+// user's audit trail.
+func (s *partnerService) AuthenticatePartnerKey(ctx context.Context, data models.PartnerAuthReq) (models.PartnerPrincipal, error) {
+	prefix, ok := utils.ParsePartnerKey(data.Key)
+	if !ok {
+		return models.PartnerPrincipal{}, utils.ErrInvalidAPIKey
+	}
+	key, err := s.partnerrepo.FindKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return models.PartnerPrincipal{}, errors.New("something went wrong, please try again later")
+	}
+	now := time.Now()
+	if key == nil || !utils.PartnerKeyMatches(data.Key, key.KeyHash) || key.RevokedAt != nil ||
+		(key.ExpiresAt != nil && !key.ExpiresAt.After(now)) || key.Partner.Status != "active" {
+		return models.PartnerPrincipal{}, utils.ErrInvalidAPIKey
+	}
+
+	var allowedIPs []string
+	if len(key.AllowedIPs) > 0 {
+		json.Unmarshal(key.AllowedIPs, &allowedIPs)
+	}
+	if !utils.IPAllowed(allowedIPs, data.Client.IPAddress) {
+		return models.PartnerPrincipal{}, utils.ErrAPIKeyIPNotAllowed
+	}
+	var scopes []string
+	json.Unmarshal(key.Scopes, &scopes)
+	if !utils.HasScope(scopes, data.Scope) {
+		return models.PartnerPrincipal{}, utils.ErrAPIKeyScope
+	}
+
+	userID, err := uuid.Parse(data.OnBehalfOf)
+	if err != nil {
+		return models.PartnerPrincipal{}, utils.ErrPartnerNotLinked
+	}
+	link, err := s.partnerrepo.FindLink(ctx, key.PartnerID, userID)
+	if err != nil {
+		return models.PartnerPrincipal{}, errors.New("something went wrong, please try again later")
+	}
+	if link == nil || link.Status != "active" {
+		return models.PartnerPrincipal{}, utils.ErrPartnerNotLinked
+	}
+
+	if err := s.partnerrepo.TouchKey(ctx, key.ID, data.Client.IPAddress, now); err != nil {
+		log.Printf("failed to record use of partner key %s: %v", key.ID, err)
+	}
+	if data.Method != http.MethodGet {
+		meta := map[string]any{"partner_id": key.PartnerID, "key_id": key.ID, "method": data.Method, "path": data.Path}
+		if err := recordAudit(ctx, s.auditrepo, userID, "partner.request", "partner", key.PartnerID, data.Client, meta); err != nil {
+			log.Printf("failed to write audit log for partner key %s: %v", key.ID, err)
+			return models.PartnerPrincipal{}, errors.New("something went wrong, please try again later")
+		}
+	}
+	return models.PartnerPrincipal{
+		Partnerid:          key.PartnerID,
+		Keyid:              key.ID,
+		Userid:             userID,
+		RateLimitPerMinute: key.RateLimitPerMinute,
+	}, nil
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// passwordReuseDepth is how many earlier passwords, on top of the current
+// one, a new password may not match.
+const passwordReuseDepth = 4
+
+var errInvalidResetLink = errors.New("this reset link is invalid or has expired, please request a new one")
+
+type PasswordService interface {
+	ForgotPassword(ctx context.Context, data models.ForgotPasswordReq) error
+	ResetPassword(ctx context.Context, data models.ResetPasswordReq) error
+	ChangePassword(ctx context.Context, data models.ChangePasswordReq) error
+}
+
+type passwordService struct {
+	passwordrepo repositories.PasswordRepository
+	userrepo     repositories.UserRepository
+	auditrepo    repositories.AuditRepository
+}
+
+func NewPasswordService(passwordRepo repositories.PasswordRepository, userRepo repositories.UserRepository, auditRepo repositories.AuditRepository) PasswordService {
+	return &passwordService{passwordrepo: passwordRepo, userrepo: userRepo, auditrepo: auditRepo}
+}
+
+func passwordResetTTL() time.Duration {
+	if v, err := strconv.Atoi(config.PasswordResetTTL); err == nil && v >= 5 && v <= 24*60 {
+		return time.Duration(v) * time.Minute
+	}
+	return 30 * time.Minute
+}
+
+// passwordReused reports whether password matches any of the hashes.
+func passwordReused(password string, hashes []string) bool {
+	for _, hash := range hashes {
+		if hash != "" && utils.CompareHashAndPassword(hash, password) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ForgotPassword emails a reset link. It succeeds whether or not the email
+// belongs to an account, so it can't be used to find out which do. Earlier
+// links stop working when a new one is sent.
+func (s *passwordService) ForgotPassword(ctx context.Context, data models.ForgotPasswordReq) error {
+	user, err := s.userrepo.FindByEmail(ctx, strings.TrimSpace(data.Email))
+	if err != nil {
+		log.Printf("failed to look up user for password reset: %v", err)
+		return nil
+	}
+	if user == nil || user.Status != "active" {
+		return nil
+	}
+
+	token, hash, err := newLinkToken()
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	now := time.Now()
+	ttl := passwordResetTTL()
+	if err := s.passwordrepo.InvalidateResetTokens(ctx, user.ID, now); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	reset := &models.PasswordResetToken{UserID: user.ID, TokenHash: hash, ExpiresAt: now.Add(ttl)}
+	if err := s.passwordrepo.CreateResetToken(ctx, reset); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, user.ID, "user.password_reset_requested", "user", user.ID, data.Client, nil); err != nil {
+		log.Printf("failed to audit password reset request of user %s: %v", user.ID, err)
+	}
+
+	email := map[string]string{
+		"Email":     user.Email,
+		"FirstName": user.FirstName,
+		"Link":      linkWithToken(config.PasswordResetUrl, token),
+		"Minutes":   strconv.Itoa(int(ttl.Minutes())),
+	}
+	go func() {
+		if err := utils.SendWithRetry(3, 2*time.Second, func() error {
+			return SendPasswordResetEmail(email)
+		}); err != nil {
+			log.Printf("failed to send password reset email to user %s: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword sets a new password with the token from a reset link. The
+// token works once.
+func (s *passwordService) ResetPassword(ctx context.Context, data models.ResetPasswordReq) error {
+	if strings.TrimSpace(data.Token) == "" {
+		return errInvalidResetLink
+	}
+	if err := utils.ValidatePassword(data.NewPassword); err != nil {
+		return err
+	}
+	reset, err := s.passwordrepo.FindResetToken(ctx, hashLinkToken(strings.TrimSpace(data.Token)))
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if reset == nil || reset.UsedAt != nil || !reset.ExpiresAt.After(time.Now()) {
+		return errInvalidResetLink
+	}
+	user, err := s.userrepo.FindByID(ctx, reset.UserID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	return s.setPassword(ctx, user, data.NewPassword, reset.ID, "user.password_reset", data.Client)
+}
+
+// ChangePassword replaces the password of a logged-in user, who has to
+// give the current one.
+func (s *passwordService) ChangePassword(ctx context.Context, data models.ChangePasswordReq) error {
+	user, err := s.userrepo.FindByID(ctx, data.Userid)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if utils.CompareHashAndPassword(user.PasswordHash, data.CurrentPassword) != nil {
+		return errors.New("current password is incorrect")
+	}
+	if err := utils.ValidatePassword(data.NewPassword); err != nil {
+		return err
+	}
+	return s.setPassword(ctx, user, data.NewPassword, uuid.Nil, "user.password_changed", data.Client)
+}
+
+// setPassword stores a new password and, in the same database transaction,
+// uses up the reset token if there is one, keeps the old hash in the
+// history and signs the user out of every session and refresh token.
+func (s *passwordService) setPassword(ctx context.Context, user *models.User, password string, resetID uuid.UUID, action string, client models.ClientInfo) error {
+	recent, err := s.passwordrepo.FindRecentHashes(ctx, user.ID, passwordReuseDepth)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if passwordReused(password, append([]string{user.PasswordHash}, recent...)) {
+		return errors.New("please choose a password you haven't used recently")
+	}
+	hash, err := utils.Hash(password)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+
+	now := time.Now()
+	var revoked int64
+	err = s.passwordrepo.RunInTransaction(ctx, func(users repositories.UserRepository, passwords repositories.PasswordRepository, sessions repositories.SessionRepository) error {
+		if resetID != uuid.Nil {
+			ok, err := passwords.UseResetToken(ctx, resetID, now)
+			if err != nil {
+				return errors.New("something went wrong, please try again later")
+			}
+			if !ok {
+				return errInvalidResetLink
+			}
+		}
+		if err := passwords.AddHistory(ctx, &models.PasswordHistory{UserID: user.ID, PasswordHash: user.PasswordHash}); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		user.PasswordHash = hash
+		if err := users.Update(ctx, user); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if err := passwords.InvalidateResetTokens(ctx, user.ID, now); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if revoked, err = sessions.RevokeAllForUser(ctx, user.ID, now); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		if err := passwords.RevokeRefreshTokens(ctx, user.ID, now); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := recordAudit(ctx, s.auditrepo, user.ID, action, "user", user.ID, client, map[string]any{"sessions_revoked": revoked}); err != nil {
+		log.Printf("failed to audit password change of user %s: %v", user.ID, err)
+	}
+	email := map[string]string{
+		"Email":     user.Email,
+		"FirstName": user.FirstName,
+		"Time":      now.UTC().Format("2 Jan 2006 15:04 MST"),
+	}
+	go func() {
+		if err := utils.SendWithRetry(3, 2*time.Second, func() error {
+			return SendPasswordChangedEmail(email)
+		}); err != nil {
+			log.Printf("failed to send password changed email to user %s: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordReused(t *testing.T) {
+	hash := func(password string) string {
+		h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(h)
+	}
+	hashes := []string{hash("Current#2024"), hash("Older#2023"), ""}
+	if !passwordReused("Current#2024", hashes) {
+		t.Errorf("the current password should count as reused")
+	}
+	if !passwordReused("Older#2023", hashes) {
+		t.Errorf("a password from the history should count as reused")
+	}
+	if passwordReused("Brand#New2025", hashes) {
+		t.Errorf("a new password should not count as reused")
+	}
+	if passwordReused("anything", nil) {
+		t.Errorf("nothing is reused without a history")
+	}
+}
+
+func TestPasswordResetTTL(t *testing.T) {
+	saved := config.PasswordResetTTL
+	defer func() { config.PasswordResetTTL = saved }()
+
+	cases := map[string]time.Duration{
+		"":     30 * time.Minute,
+		"15":   15 * time.Minute,
+		"2":    30 * time.Minute,
+		"5000": 30 * time.Minute,
+		"abc":  30 * time.Minute,
+	}
+	for value, want := range cases {
+		config.PasswordResetTTL = value
+		if got := passwordResetTTL(); got != want {
+			t.Errorf("PASSWORD_RESET_TTL_MINUTES=%q: got %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+type ReencryptionStats struct {
+	Cards     int
+	Documents int
+	Profiles  int
+	Kyb       int
+	Failed    int
+}
+
+// ReencryptionJob moves card, KYC document, KYC profile ID number and KYB
+// document ciphertexts onto the primary
+// key after a rotation. It only selects rows that are not on the primary key
+// yet, so an interrupted run picks up where it stopped. A row is only
+// rewritten if its ciphertext is still the one that was read, so a
+// concurrent change is never overwritten; such rows are left for the next
+// run. Once a run reports no failures and finds nothing left, the old keys
+// can be dropped from ENCRYPTION_KEYS.
+type ReencryptionJob struct {
+	cardrepo  repositories.CardRepository
+	kycrepo   repositories.KycRepository
+	orgrepo   repositories.OrganizationRepository
+	store     integrations.DocumentStore
+	BatchSize int
+}
+
+func NewReencryptionJob(cardRepo repositories.CardRepository, kycRepo repositories.KycRepository, orgRepo repositories.OrganizationRepository, store integrations.DocumentStore) *ReencryptionJob {
+	return &ReencryptionJob{cardrepo: cardRepo, kycrepo: kycRepo, orgrepo: orgRepo, store: store, BatchSize: 200}
+}
+
+func (j *ReencryptionJob) Run(ctx context.Context) (ReencryptionStats, error) {
+	var stats ReencryptionStats
+	keyring, err := utils.LoadKeyring()
+	if err != nil {
+		return stats, err
+	}
+	primary := keyring.PrimaryKeyID()
+
+	after := uuid.Nil
+	for ctx.Err() == nil {
+		cards, err := j.cardrepo.FindCardsNotOnKey(ctx, primary, after, j.BatchSize)
+		if err != nil {
+			return stats, err
+		}
+		if len(cards) == 0 {
+			break
+		}
+		for _, card := range cards {
+			after = card.ID
+			pan, err := rewrap(keyring, card.PANencrypted)
+			if err != nil {
+				log.Printf("re-encryption: card %s PAN: %v", card.ID, err)
+				stats.Failed++
+				continue
+			}
+			updated, err := j.cardrepo.UpdateCardCiphertext(ctx, card.ID, card.PANencrypted, pan)
+			if err != nil {
+				return stats, err
+			}
+			if updated {
+				stats.Cards++
+			}
+		}
+	}
+
+	after = uuid.Nil
+	for ctx.Err() == nil {
+		docs, err := j.kycrepo.FindDocumentsNotOnKey(ctx, primary, after, j.BatchSize)
+		if err != nil {
+			return stats, err
+		}
+		if len(docs) == 0 {
+			break
+		}
+		for _, doc := range docs {
+			after = doc.ID
+			if doc.StorageKey != nil {
+				if err := j.rewrapStoredDocument(ctx, keyring, doc); err != nil {
+					log.Printf("re-encryption: kyc document %s: %v", doc.ID, err)
+					stats.Failed++
+					continue
+				}
+				stats.Documents++
+				continue
+			}
+			sealed, err := rewrap(keyring, string(doc.EncryptedData))
+			if err != nil {
+				log.Printf("re-encryption: kyc document %s: %v", doc.ID, err)
+				stats.Failed++
+				continue
+			}
+			updated, err := j.kycrepo.UpdateDocumentCiphertext(ctx, doc.ID, doc.EncryptedData, []byte(sealed), primary)
+			if err != nil {
+				return stats, err
+			}
+			if updated {
+				stats.Documents++
+			}
+		}
+	}
+
+	after = uuid.Nil
+	for ctx.Err() == nil {
+		profiles, err := j.kycrepo.FindProfilesNotOnKey(ctx, primary, after, j.BatchSize)
+		if err != nil {
+			return stats, err
+		}
+		if len(profiles) == 0 {
+			break
+		}
+		for _, profile := range profiles {
+			after = profile.ID
+			sealed, err := rewrap(keyring, profile.IDNumberEncrypted)
+			if err != nil {
+				log.Printf("re-encryption: kyc profile %s: %v", profile.ID, err)
+				stats.Failed++
+				continue
+			}
+			updated, err := j.kycrepo.UpdateProfileIDNumber(ctx, profile.ID, profile.IDNumberEncrypted, sealed)
+			if err != nil {
+				return stats, err
+			}
+			if updated {
+				stats.Profiles++
+			}
+		}
+	}
+
+	after = uuid.Nil
+	for ctx.Err() == nil {
+		docs, err := j.orgrepo.FindKybDocumentsNotOnKey(ctx, primary, after, j.BatchSize)
+		if err != nil {
+			return stats, err
+		}
+		if len(docs) == 0 {
+			break
+		}
+		for _, doc := range docs {
+			after = doc.ID
+			if err := j.rewrapKybDocument(ctx, keyring, doc); err != nil {
+				log.Printf("re-encryption: kyb document %s: %v", doc.ID, err)
+				stats.Failed++
+				continue
+			}
+			stats.Kyb++
+		}
+	}
+	return stats, ctx.Err()
+}
+
+// rewrapStoredDocument writes the re-encrypted document under a new key and
+// only deletes the old object once the row points at the new one.
+func (j *ReencryptionJob) rewrapStoredDocument(ctx context.Context, keyring *utils.Keyring, doc models.KYCDocument) error {
+	data, err := documentCiphertext(ctx, j.store, doc)
+	if err != nil {
+		return err
+	}
+	sealed, err := rewrap(keyring, string(data))
+	if err != nil {
+		return err
+	}
+	key := documentObjectKey(doc.KYCSubmissionID, doc.DocumentType)
+	if err := j.store.Put(ctx, key, []byte(sealed)); err != nil {
+		return err
+	}
+	if err := j.kycrepo.SetDocumentObject(ctx, doc.ID, key, documentChecksum([]byte(sealed)), int64(len(sealed)), utils.KeyIDOf(sealed)); err != nil {
+		discardDocument(ctx, j.store, &models.KYCDocument{StorageKey: &key})
+		return err
+	}
+	discardDocument(ctx, j.store, &doc)
+	return nil
+}
+
+// rewrapKybDocument does the same for an organization's KYB document.
+func (j *ReencryptionJob) rewrapKybDocument(ctx context.Context, keyring *utils.Keyring, doc models.KYBDocument) error {
+	data, err := j.store.Get(ctx, doc.StorageKey)
+	if err != nil {
+		return err
+	}
+	if documentChecksum(data) != doc.Checksum {
+		return errors.New("failed its checksum")
+	}
+	sealed, err := rewrap(keyring, string(data))
+	if err != nil {
+		return err
+	}
+	key := kybObjectKey(doc.OrganizationID, doc.DocumentType)
+	if err := j.store.Put(ctx, key, []byte(sealed)); err != nil {
+		return err
+	}
+	if err := j.orgrepo.SetKybDocumentObject(ctx, doc.ID, key, documentChecksum([]byte(sealed)), int64(len(sealed)), utils.KeyIDOf(sealed)); err != nil {
+		discardDocument(ctx, j.store, &models.KYCDocument{StorageKey: &key})
+		return err
+	}
+	discardDocument(ctx, j.store, &models.KYCDocument{StorageKey: &doc.StorageKey})
+	return nil
+}
+
+func rewrap(keyring *utils.Keyring, ciphertext string) (string, error) {
+	if utils.KeyIDOf(ciphertext) == keyring.PrimaryKeyID() {
+		return ciphertext, nil
+	}
+	plain, err := keyring.Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return keyring.Encrypt(plain)
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ScreeningThresholds tunes how close a name has to be to count as a
+// potential hit.
+type ScreeningThresholds struct {
+	// Name is the lowest name similarity, from 0 to 1, reported as a hit.
+	Name float64
+	// DobYearTolerance is how many years a listed date of birth may be off
+	// from the user's before the entry is ruled out.
+	DobYearTolerance int
+}
+
+var DefaultScreeningThresholds = ScreeningThresholds{Name: 0.88, DobYearTolerance: 1}
+
+type preparedName struct {
+	value  string
+	tokens []string
+}
+
+type preparedEntry struct {
+	entry integrations.WatchlistEntry
+	names []preparedName
+}
+
+// Screener matches people against loaded watchlists. It is read-only once
+// built, so a reload swaps in a new Screener.
+type Screener struct {
+	entries    []preparedEntry
+	thresholds ScreeningThresholds
+}
+
+func NewScreener(entries []integrations.WatchlistEntry, thresholds ScreeningThresholds) *Screener {
+	s := &Screener{thresholds: thresholds, entries: make([]preparedEntry, 0, len(entries))}
+	for _, e := range entries {
+		p := preparedEntry{entry: e}
+		for _, name := range append([]string{e.Name}, e.Aliases...) {
+			if tokens := nameTokens(name); len(tokens) > 0 {
+				p.names = append(p.names, preparedName{value: name, tokens: tokens})
+			}
+		}
+		if len(p.names) > 0 {
+			s.entries = append(s.entries, p)
+		}
+	}
+	return s
+}
+
+func (s *Screener) Size() int {
+	return len(s.entries)
+}
+
+// Screen returns the entries of the given type whose name or an alias is a
+// close enough match, best first. Entries with dates of birth that are all
+// too far from dob are ruled out; a missing date on either side is not.
+func (s *Screener) Screen(fullName string, dob *time.Time, entryType string) []models.ScreeningMatch {
+	tokens := nameTokens(fullName)
+	if len(tokens) == 0 {
+		return nil
+	}
+	var matches []models.ScreeningMatch
+	for _, p := range s.entries {
+		if p.entry.Type != entryType {
+			continue
+		}
+		best, matched := 0.0, ""
+		for _, name := range p.names {
+			if score := tokenSimilarity(tokens, name.tokens); score > best {
+				best, matched = score, name.value
+			}
+		}
+		if best < s.thresholds.Name || !dobCompatible(dob, p.entry.DatesOfBirth, s.thresholds.DobYearTolerance) {
+			continue
+		}
+		matches = append(matches, models.ScreeningMatch{
+			Source:       p.entry.Source,
+			ListID:       p.entry.ListID,
+			ListedName:   p.entry.Name,
+			MatchedName:  matched,
+			Score:        float64(int(best*1000)) / 1000,
+			DatesOfBirth: p.entry.DatesOfBirth,
+			Programs:     p.entry.Programs,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+func dobCompatible(dob *time.Time, listed []string, tolerance int) bool {
+	if dob == nil || len(listed) == 0 {
+		return true
+	}
+	for _, d := range listed {
+		if len(d) < 4 {
+			return true
+		}
+		year, err := strconv.Atoi(d[:4])
+		if err != nil {
+			return true
+		}
+		diff := dob.Year() - year
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= tolerance {
+			return true
+		}
+	}
+	return false
+}
+
+var nameFolder = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// nameTokens lowercases a name, strips accents and punctuation and sorts the
+// parts, so "SMITH, John" and "John Smith" compare equal.
+func nameTokens(name string) []string {
+	folded, _, err := transform.String(nameFolder, name)
+	if err != nil {
+		folded = name
+	}
+	tokens := strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(tokens)
+	return tokens
+}
+
+// tokenSimilarity scores two tokenized names from 0 to 1. It takes the
+// better of comparing the whole sorted names and matching each part of the
+// shorter name to its closest part in the longer one, which tolerates
+// middle names and reordering. A single name part only matches a single
+// name part, so a lone surname does not hit every listed person sharing it.
+func tokenSimilarity(a, b []string) float64 {
+	whole := jaroWinkler(strings.Join(a, " "), strings.Join(b, " "))
+	short, long := a, b
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) < 2 && len(long) > 1 {
+		return whole
+	}
+	total := 0.0
+	for _, t := range short {
+		best := 0.0
+		for _, u := range long {
+			if score := jaroWinkler(t, u); score > best {
+				best = score
+			}
+		}
+		total += best
+	}
+	parts := total / float64(len(short)) * (0.9 + 0.1*float64(len(short))/float64(len(long)))
+	if parts > whole {
+		return parts
+	}
+	return whole
+}
+
+// jaroWinkler is the Jaro-Winkler similarity of two strings, from 0 to 1.
+func jaroWinkler(a, b string) float64 {
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 || len(t) == 0 {
+		if len(s) == len(t) {
+			return 1
+		}
+		return 0
+	}
+	window := max(len(s), len(t))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	sMatched := make([]bool, len(s))
+	tMatched := make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		lo, hi := max(0, i-window), min(len(t), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !tMatched[j] && s[i] == t[j] {
+				sMatched[i], tMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions, k := 0, 0
+	for i := range s {
+		if !sMatched[i] {
+			continue
+		}
+		for !tMatched[k] {
+			k++
+		}
+		if s[i] != t[k] {
+			transpositions++
+		}
+		k++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
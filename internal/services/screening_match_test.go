@@ -0,0 +1,65 @@
+package services
+
+import (
+	"CardFlow/internal/integrations"
+	"testing"
+	"time"
+)
+
+func testScreener() *Screener {
+	return NewScreener([]integrations.WatchlistEntry{
+		{Source: "ofac", ListID: "1001", Type: "individual", Name: "OKAFOR, Chukwuemeka", Aliases: []string{"Emeka Okafor"}, DatesOfBirth: []string{"1970-03-14"}},
+		{Source: "un", ListID: "QDi.1", Type: "individual", Name: "José Álvarez Muñoz"},
+		{Source: "eu", ListID: "EU.9", Type: "entity", Name: "Okafor Trading Ltd"},
+	}, DefaultScreeningThresholds)
+}
+
+func TestScreenMatchesReorderedAndAccentedNames(t *testing.T) {
+	s := testScreener()
+
+	matches := s.Screen("Chukwuemeka Okafor", nil, "individual")
+	if len(matches) != 1 || matches[0].ListID != "1001" {
+		t.Fatalf("expected the OFAC entry, got %+v", matches)
+	}
+	if matches[0].Score != 1 {
+		t.Fatalf("expected an exact match after reordering, got %v", matches[0].Score)
+	}
+
+	matches = s.Screen("Jose Alvarez Munoz", nil, "individual")
+	if len(matches) != 1 || matches[0].Source != "un" {
+		t.Fatalf("expected the UN entry, got %+v", matches)
+	}
+
+	if matches := s.Screen("Emeka Okafr", nil, "individual"); len(matches) != 1 || matches[0].MatchedName != "Emeka Okafor" {
+		t.Fatalf("expected a fuzzy alias match, got %+v", matches)
+	}
+}
+
+func TestScreenIgnoresUnrelatedNamesAndOtherTypes(t *testing.T) {
+	s := testScreener()
+	if matches := s.Screen("Adaeze Nwosu", nil, "individual"); len(matches) != 0 {
+		t.Fatalf("expected no matches, got %+v", matches)
+	}
+	for _, m := range s.Screen("Okafor Trading Ltd", nil, "individual") {
+		if m.ListID == "EU.9" {
+			t.Fatal("entity entries must not match individuals")
+		}
+	}
+}
+
+func TestScreenRulesOutDistantDatesOfBirth(t *testing.T) {
+	s := testScreener()
+	near := time.Date(1971, 6, 1, 0, 0, 0, 0, time.UTC)
+	far := time.Date(1995, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	if matches := s.Screen("Chukwuemeka Okafor", &near, "individual"); len(matches) != 1 {
+		t.Fatalf("a date of birth within tolerance should still match, got %+v", matches)
+	}
+	if matches := s.Screen("Chukwuemeka Okafor", &far, "individual"); len(matches) != 0 {
+		t.Fatalf("a distant date of birth should rule the entry out, got %+v", matches)
+	}
+	// entries without a date of birth are never ruled out by one
+	if matches := s.Screen("José Álvarez Muñoz", &far, "individual"); len(matches) != 1 {
+		t.Fatalf("expected the UN entry, got %+v", matches)
+	}
+}
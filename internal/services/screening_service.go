@@ -0,0 +1,347 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrScreeningHold blocks card issuance while a screening case is open or
+// after a hit has been confirmed.
+var ErrScreeningHold = errors.New("card issuance is on hold pending a compliance review")
+
+type ScreeningService interface {
+	ScreenUser(ctx context.Context, userID uuid.UUID, trigger string) (*models.ScreeningCase, error)
+	ScreenUserInBackground(userID uuid.UUID, trigger string)
+	CheckCardIssuance(ctx context.Context, userID uuid.UUID) error
+	RescreenAll(ctx context.Context) (ScreeningStats, error)
+	ReloadWatchlists() error
+	GetCases(ctx context.Context, status string) ([]models.ScreeningCaseResp, error)
+	ResolveCase(ctx context.Context, data models.ResolveScreeningCaseReq) (models.ScreeningCaseResp, error)
+}
+
+type ScreeningStats struct {
+	Screened int
+	Hits     int
+	Failed   int
+}
+
+type screeningService struct {
+	screeningrepo repositories.ScreeningRepository
+	userrepo      repositories.UserRepository
+	kycrepo       repositories.KycRepository
+	auditrepo     repositories.AuditRepository
+
+	mu       sync.RWMutex
+	screener *Screener // nil when no watchlists are configured
+}
+
+// NewScreeningService loads the lists in WATCHLIST_FILES. Without any lists
+// screening is off, which is only meant for development.
+func NewScreeningService(screeningRepo repositories.ScreeningRepository, userRepo repositories.UserRepository, kycRepo repositories.KycRepository, auditRepo repositories.AuditRepository) (ScreeningService, error) {
+	s := &screeningService{screeningrepo: screeningRepo, userrepo: userRepo, kycrepo: kycRepo, auditrepo: auditRepo}
+	if err := s.ReloadWatchlists(); err != nil {
+		return nil, err
+	}
+	if s.screener == nil {
+		log.Println("WATCHLIST_FILES is not set, sanctions screening is disabled")
+	}
+	return s, nil
+}
+
+func screeningThresholds() ScreeningThresholds {
+	t := DefaultScreeningThresholds
+	if v, err := strconv.ParseFloat(config.ScreeningNameThreshold, 64); err == nil && v > 0 && v <= 1 {
+		t.Name = v
+	}
+	if v, err := strconv.Atoi(config.ScreeningDobYearTolerance); err == nil && v >= 0 {
+		t.DobYearTolerance = v
+	}
+	return t
+}
+
+// ReloadWatchlists rereads the list files. On failure the lists already
+// loaded stay in use.
+func (s *screeningService) ReloadWatchlists() error {
+	if strings.TrimSpace(config.WatchlistFiles) == "" {
+		return nil
+	}
+	entries, err := integrations.LoadWatchlistFiles(config.WatchlistFiles)
+	if err != nil {
+		return err
+	}
+	screener := NewScreener(entries, screeningThresholds())
+	s.mu.Lock()
+	s.screener = screener
+	s.mu.Unlock()
+	log.Printf("loaded %d watchlist entries", screener.Size())
+	return nil
+}
+
+func (s *screeningService) currentScreener() *Screener {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.screener
+}
+
+// ScreenUser matches a user's name and date of birth against the lists.
+// Hits a compliance officer already cleared for this user are not raised
+// again; new hits go on the user's open case, or a new one. It returns the
+// case that received new hits, if any.
+func (s *screeningService) ScreenUser(ctx context.Context, userID uuid.UUID, trigger string) (*models.ScreeningCase, error) {
+	screener := s.currentScreener()
+	if screener == nil {
+		return nil, nil
+	}
+	user, err := s.userrepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	var dob *time.Time
+	kyc, err := s.kycrepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if kyc != nil {
+		profile, err := s.kycrepo.FindProfile(ctx, kyc.ID)
+		if err != nil {
+			return nil, err
+		}
+		if profile != nil {
+			dob = profile.DateOfBirth
+		}
+	}
+	matches := screener.Screen(user.FirstName+" "+user.LastName, dob, "individual")
+
+	cases, err := s.screeningrepo.FindCasesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	var open *models.ScreeningCase
+	for i := range cases {
+		c := &cases[i]
+		if c.Status == "open" && open == nil {
+			open = c
+		}
+		var caseMatches []models.ScreeningMatch
+		if err := json.Unmarshal(c.Matches, &caseMatches); err != nil {
+			return nil, err
+		}
+		for _, m := range caseMatches {
+			known[screeningMatchKey(m)] = true
+		}
+	}
+	var fresh []models.ScreeningMatch
+	for _, m := range matches {
+		if !known[screeningMatchKey(m)] {
+			fresh = append(fresh, m)
+		}
+	}
+
+	var updated *models.ScreeningCase
+	if len(fresh) > 0 {
+		if open != nil {
+			var existing []models.ScreeningMatch
+			if err := json.Unmarshal(open.Matches, &existing); err != nil {
+				return nil, err
+			}
+			if open.Matches, err = json.Marshal(append(existing, fresh...)); err != nil {
+				return nil, err
+			}
+			if err := s.screeningrepo.UpdateCase(ctx, open); err != nil {
+				return nil, err
+			}
+			updated = open
+		} else {
+			raw, err := json.Marshal(fresh)
+			if err != nil {
+				return nil, err
+			}
+			updated = &models.ScreeningCase{UserID: userID, Status: "open", Trigger: trigger, Matches: raw}
+			if err := s.screeningrepo.CreateCase(ctx, updated); err != nil {
+				return nil, err
+			}
+		}
+		log.Printf("screening: %d potential hit(s) for user %s, case %s", len(fresh), userID, updated.ID)
+	}
+	if err := s.screeningrepo.SetScreenedAt(ctx, userID); err != nil {
+		return updated, err
+	}
+	return updated, nil
+}
+
+func (s *screeningService) ScreenUserInBackground(userID uuid.UUID, trigger string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if _, err := s.ScreenUser(ctx, userID, trigger); err != nil {
+			log.Printf("screening failed for user %s: %v", userID, err)
+		}
+	}()
+}
+
+// CheckCardIssuance screens users who have never been screened and refuses
+// issuance while any case is open or confirmed.
+func (s *screeningService) CheckCardIssuance(ctx context.Context, userID uuid.UUID) error {
+	if s.currentScreener() == nil {
+		return nil
+	}
+	user, err := s.userrepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if user.ScreenedAt == nil {
+		if _, err := s.ScreenUser(ctx, userID, "card_issuance"); err != nil {
+			log.Printf("screening failed for user %s: %v", userID, err)
+			return errors.New("something went wrong, please try again later")
+		}
+	}
+	cases, err := s.screeningrepo.FindCasesByUser(ctx, userID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	for _, c := range cases {
+		if c.Status == "open" || c.Status == "confirmed" {
+			return ErrScreeningHold
+		}
+	}
+	return nil
+}
+
+// RescreenAll reloads the lists and screens every user again, so people
+// added to a list after onboarding are caught.
+func (s *screeningService) RescreenAll(ctx context.Context) (ScreeningStats, error) {
+	var stats ScreeningStats
+	if err := s.ReloadWatchlists(); err != nil {
+		log.Printf("failed to reload watchlists, screening against the lists already loaded: %v", err)
+	}
+	if s.currentScreener() == nil {
+		return stats, nil
+	}
+	after := uuid.Nil
+	for ctx.Err() == nil {
+		users, err := s.screeningrepo.FindUsersToScreen(ctx, after, 200)
+		if err != nil {
+			return stats, err
+		}
+		if len(users) == 0 {
+			break
+		}
+		for _, user := range users {
+			after = user.ID
+			updated, err := s.ScreenUser(ctx, user.ID, "rescreen")
+			if err != nil {
+				log.Printf("screening failed for user %s: %v", user.ID, err)
+				stats.Failed++
+				continue
+			}
+			stats.Screened++
+			if updated != nil {
+				stats.Hits++
+			}
+		}
+	}
+	return stats, ctx.Err()
+}
+
+func (s *screeningService) GetCases(ctx context.Context, status string) ([]models.ScreeningCaseResp, error) {
+	if status == "" {
+		status = "open"
+	}
+	switch status {
+	case "open", "cleared", "confirmed":
+	default:
+		return nil, errors.New("invalid case status")
+	}
+	cases, err := s.screeningrepo.FindCasesByStatus(ctx, status, 100)
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.ScreeningCaseResp, 0, len(cases))
+	for _, c := range cases {
+		res = append(res, screeningCaseResp(c))
+	}
+	return res, nil
+}
+
+// ResolveCase records a compliance officer's decision. Clearing a case lets
+// the user be issued cards again unless another case is still open;
+// confirming it keeps issuance blocked for good.
+func (s *screeningService) ResolveCase(ctx context.Context, data models.ResolveScreeningCaseReq) (models.ScreeningCaseResp, error) {
+	var status string
+	switch data.Action {
+	case "clear":
+		status = "cleared"
+	case "confirm":
+		status = "confirmed"
+	default:
+		return models.ScreeningCaseResp{}, errors.New("invalid case action")
+	}
+	if strings.TrimSpace(data.Note) == "" {
+		return models.ScreeningCaseResp{}, errors.New("a resolution note is required")
+	}
+	caseID, err := uuid.Parse(data.Caseid)
+	if err != nil {
+		return models.ScreeningCaseResp{}, errors.New("case not found")
+	}
+	c, err := s.screeningrepo.FindCaseByID(ctx, caseID)
+	if err != nil {
+		return models.ScreeningCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	if c == nil {
+		return models.ScreeningCaseResp{}, errors.New("case not found")
+	}
+	if c.Status != "open" {
+		return models.ScreeningCaseResp{}, errors.New("case is already resolved")
+	}
+
+	now := time.Now()
+	c.Status = status
+	c.ResolvedBy = &data.AdminId
+	c.ResolutionNote = strings.TrimSpace(data.Note)
+	c.ResolvedAt = &now
+	// the audit entry is the record of who made the call, so it must be written
+	meta := map[string]any{"user_id": c.UserID, "note": c.ResolutionNote}
+	if err := recordAdminAudit(ctx, s.auditrepo, data.AdminId, "screening.case_"+status, "screening_case", c.ID, data.Client, meta); err != nil {
+		return models.ScreeningCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	if err := s.screeningrepo.UpdateCase(ctx, c); err != nil {
+		return models.ScreeningCaseResp{}, errors.New("something went wrong, please try again later")
+	}
+	return screeningCaseResp(*c), nil
+}
+
+func screeningMatchKey(m models.ScreeningMatch) string {
+	return m.Source + ":" + m.ListID
+}
+
+func screeningCaseResp(c models.ScreeningCase) models.ScreeningCaseResp {
+	return models.ScreeningCaseResp{
+		Caseid:         c.ID,
+		Userid:         c.UserID,
+		Status:         c.Status,
+		Trigger:        c.Trigger,
+		Matches:        json.RawMessage(c.Matches),
+		ResolutionNote: c.ResolutionNote,
+		ResolvedAt:     c.ResolvedAt,
+		CreatedAt:      c.CreatedAt,
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/integrations"
+	"CardFlow/internal/models"
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sessionTouchInterval limits how often a session's last-seen time is
+// written while it is in use.
+const sessionTouchInterval = 5 * time.Minute
+
+type SessionService interface {
+	KnownDevice(ctx context.Context, userID uuid.UUID, fingerprint string) (bool, error)
+	StartSession(ctx context.Context, user *models.User, fingerprint string, client models.ClientInfo, newDevice bool) (models.Session, error)
+	ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	GetSessions(ctx context.Context, userID, currentID uuid.UUID) ([]models.SessionResp, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string, client models.ClientInfo) error
+}
+
+type sessionService struct {
+	sessionrepo repositories.SessionRepository
+	auditrepo   repositories.AuditRepository
+	geo         *integrations.GeoIPDatabase
+}
+
+// NewSessionService loads the GeoIP database named by GEOIP_DATABASE_FILE.
+// Without one, sessions are recorded with no location.
+func NewSessionService(sessionRepo repositories.SessionRepository, auditRepo repositories.AuditRepository) (SessionService, error) {
+	s := &sessionService{sessionrepo: sessionRepo, auditrepo: auditRepo}
+	if strings.TrimSpace(config.GeoIPDatabaseFile) == "" {
+		log.Println("GEOIP_DATABASE_FILE is not set, sessions will have no location")
+		return s, nil
+	}
+	geo, err := integrations.LoadGeoIPFile(config.GeoIPDatabaseFile)
+	if err != nil {
+		return nil, err
+	}
+	s.geo = geo
+	log.Printf("loaded %d GeoIP ranges", geo.Size())
+	return s, nil
+}
+
+// DeviceFingerprint identifies the device a login comes from: the app's own
+// device ID when it sends one, otherwise the user agent.
+func DeviceFingerprint(deviceID, userAgent string) string {
+	source := "id:" + strings.TrimSpace(deviceID)
+	if strings.TrimSpace(deviceID) == "" {
+		source = "ua:" + strings.TrimSpace(userAgent)
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionLocation formats a location for people, e.g. "Lagos, Lagos, NG".
+func sessionLocation(city, region, country string) string {
+	var parts []string
+	for _, p := range []string{city, region, country} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return "unknown location"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// KnownDevice reports whether the user has logged in from the device
+// before. A user with no sessions at all has nothing to compare against, so
+// their first login is treated as coming from a known device.
+func (s *sessionService) KnownDevice(ctx context.Context, userID uuid.UUID, fingerprint string) (bool, error) {
+	loggedIn, err := s.sessionrepo.HasAny(ctx, userID)
+	if err != nil || !loggedIn {
+		return true, err
+	}
+	return s.sessionrepo.HasDevice(ctx, userID, fingerprint)
+}
+
+// StartSession records a login. For a login from a new device the user is
+// emailed, so they can revoke the session if it wasn't them.
+func (s *sessionService) StartSession(ctx context.Context, user *models.User, fingerprint string, client models.ClientInfo, newDevice bool) (models.Session, error) {
+	now := time.Now()
+	loc := s.geo.Lookup(client.IPAddress)
+	session := models.Session{
+		UserID:            user.ID,
+		DeviceFingerprint: fingerprint,
+		IPAddress:         client.IPAddress,
+		UserAgent:         client.UserAgent,
+		Country:           loc.Country,
+		Region:            loc.Region,
+		City:              loc.City,
+		LastSeenAt:        now,
+		ExpiresAt:         now.Add(utils.UserTokenTTL),
+	}
+	if err := s.sessionrepo.Create(ctx, &session); err != nil {
+		return models.Session{}, err
+	}
+	if !newDevice {
+		return session, nil
+	}
+
+	meta := map[string]any{"session_id": session.ID, "country": loc.Country, "city": loc.City}
+	if err := recordAudit(ctx, s.auditrepo, user.ID, "user.login_new_device", "session", session.ID, client, meta); err != nil {
+		log.Printf("failed to audit new device login for user %s: %v", user.ID, err)
+	}
+	device := client.UserAgent
+	if device == "" {
+		device = "an unknown device"
+	}
+	email := map[string]string{
+		"Email":     user.Email,
+		"FirstName": user.FirstName,
+		"Device":    device,
+		"IPAddress": client.IPAddress,
+		"Location":  sessionLocation(loc.City, loc.Region, loc.Country),
+		"Time":      now.UTC().Format("2 Jan 2006 15:04 MST"),
+	}
+	go func() {
+		if err := utils.SendWithRetry(3, 2*time.Second, func() error {
+			return SendNewDeviceLoginEmail(email)
+		}); err != nil {
+			log.Printf("failed to send new device alert to user %s: %v", user.ID, err)
+		}
+	}()
+	return session, nil
+}
+
+// ValidateSession checks that a token's session is still live, and notes
+// that it was used.
+func (s *sessionService) ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := s.sessionrepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if session == nil || session.UserID != userID || session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return utils.ErrSessionEnded
+	}
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		if err := s.sessionrepo.Touch(ctx, session.ID, now); err != nil {
+			log.Printf("failed to update last seen of session %s: %v", session.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *sessionService) GetSessions(ctx context.Context, userID, currentID uuid.UUID) ([]models.SessionResp, error) {
+	sessions, err := s.sessionrepo.FindActiveByUserID(ctx, userID, time.Now())
+	if err != nil {
+		return nil, errors.New("something went wrong, please try again later")
+	}
+	res := make([]models.SessionResp, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, models.SessionResp{
+			Sessionid:  session.ID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			Country:    session.Country,
+			Region:     session.Region,
+			City:       session.City,
+			Current:    session.ID == currentID,
+			LastSeenAt: session.LastSeenAt,
+			CreatedAt:  session.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+// RevokeSession signs one of the user's sessions out. Revoking the current
+// session logs the caller out.
+func (s *sessionService) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string, client models.ClientInfo) error {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return errors.New("session not found")
+	}
+	session, err := s.sessionrepo.FindByID(ctx, id)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if session == nil || session.UserID != userID {
+		return errors.New("session not found")
+	}
+	if session.RevokedAt != nil || !session.ExpiresAt.After(time.Now()) {
+		return errors.New("session has already ended")
+	}
+	if err := s.sessionrepo.Revoke(ctx, session.ID, time.Now()); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if err := recordAudit(ctx, s.auditrepo, userID, "session.revoked", "session", session.ID, client, nil); err != nil {
+		log.Printf("failed to audit revoking session %s: %v", session.ID, err)
+	}
+	return nil
+}
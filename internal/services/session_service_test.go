@@ -0,0 +1,36 @@
+package services
+
+import "testing"
+
+func TestDeviceFingerprint(t *testing.T) {
+	app := DeviceFingerprint("install-123", "CardFlow/2.1 (iOS 17)")
+	if len(app) != 64 {
+		t.Fatalf("expected a SHA-256 hex digest, got %q", app)
+	}
+	if app != DeviceFingerprint(" install-123 ", "CardFlow/2.2 (iOS 17)") {
+		t.Errorf("the app's device ID should identify the device across app updates")
+	}
+	browser := DeviceFingerprint("", "Mozilla/5.0")
+	if browser != DeviceFingerprint("", "Mozilla/5.0") || browser == DeviceFingerprint("", "curl/8.0") {
+		t.Errorf("without a device ID the user agent should identify the device")
+	}
+	if DeviceFingerprint("Mozilla/5.0", "") == browser {
+		t.Errorf("a device ID must not collide with a user agent of the same text")
+	}
+}
+
+func TestSessionLocation(t *testing.T) {
+	cases := []struct {
+		city, region, country string
+		want                  string
+	}{
+		{"Lagos", "Lagos", "NG", "Lagos, Lagos, NG"},
+		{"", "", "AU", "AU"},
+		{"", "", "", "unknown location"},
+	}
+	for _, c := range cases {
+		if got := sessionLocation(c.city, c.region, c.country); got != c.want {
+			t.Errorf("sessionLocation(%q, %q, %q) = %q, want %q", c.city, c.region, c.country, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"CardFlow/internal/repositories"
+	"CardFlow/internal/utils"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrStepUpRequired = errors.New("step-up authentication required")
+
+// verifyStepUp re-authenticates a logged-in user before a sensitive action.
+// Users with MFA enabled must present a current TOTP code; everyone else uses
+// the email OTP from POST /users/step-up/otp, which is consumed on success.
+func verifyStepUp(ctx context.Context, repo repositories.UserRepository, userID uuid.UUID, totpCode, otp string) error {
+	user, err := repo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+
+	if user.MFAEnabled {
+		if totpCode == "" {
+			return ErrStepUpRequired
+		}
+		return useTotpCode(ctx, repo, user.ID, totpCode, user.MFASecret)
+	}
+
+	if otp == "" {
+		return ErrStepUpRequired
+	}
+	if user.OTP == "" || subtle.ConstantTimeCompare([]byte(user.OTP), []byte(otp)) != 1 {
+		return errors.New("invalid OTP")
+	}
+	if user.OTPExpiresAt.IsZero() || user.OTPExpiresAt.Before(time.Now()) {
+		return errors.New("OTP has expired")
+	}
+	user.OTP = ""
+	user.OTPExpiresAt = time.Time{}
+	if err := repo.Update(ctx, user); err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	return nil
+}
+
+// useTotpCode validates a TOTP code and records its time step, so a code
+// that was seen once, on screen or in transit, can't be used again.
+func useTotpCode(ctx context.Context, repo repositories.UserRepository, userID uuid.UUID, code, secret string) error {
+	step, err := utils.ValidateTotpStep(code, secret)
+	if err != nil {
+		return err
+	}
+	fresh, err := repo.UseTotpStep(ctx, userID, step)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if !fresh {
+		return errors.New("authentication code already used")
+	}
+	return nil
+}
@@ -5,27 +5,35 @@ import (
 	"CardFlow/internal/repositories"
 	"CardFlow/internal/utils"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
 
 type TransactionService interface{
 	WebhookTransaction(ctx context.Context, data models.WebhookReq)(any, error)
 	GetCardTransactions(ctx context.Context, data models.GetCardTransactionsReq)([]models.GetCardTransactionsResp, error)
+	PayoutWebhook(ctx context.Context, data models.PayoutWebhookReq) error
 }
 
 type transactionService struct {
 	userrepo repositories.UserRepository
 	cardrepo repositories.CardRepository
 	Txnrepo repositories.TransactionRepository
+	pinrepo repositories.CardPinRepository
+	tokenrepo repositories.NetworkTokenRepository
+	kycrepo repositories.KycRepository
+	fraud FraudService
+	tokens CardTokenSyncer
 }
-func NewTransactionService(Txnrepo repositories.TransactionRepository, cardRepo repositories.CardRepository, userRepo repositories.UserRepository) TransactionService {
-    return &transactionService{Txnrepo:Txnrepo, cardrepo: cardRepo, userrepo: userRepo}
+func NewTransactionService(Txnrepo repositories.TransactionRepository, cardRepo repositories.CardRepository, userRepo repositories.UserRepository, pinRepo repositories.CardPinRepository, tokenRepo repositories.NetworkTokenRepository, kycRepo repositories.KycRepository, fraud FraudService, tokens CardTokenSyncer) TransactionService {
+    return &transactionService{Txnrepo:Txnrepo, cardrepo: cardRepo, userrepo: userRepo, pinrepo: pinRepo, tokenrepo: tokenRepo, kycrepo: kycRepo, fraud: fraud, tokens: tokens}
 }
 
 
@@ -37,6 +45,10 @@ func (s *transactionService) WebhookTransaction(ctx context.Context,data models.
 	if data.IdempotencyKey != "" {
 		existing, _ := s.Txnrepo.FindByIdempotencyKey(ctx, data.IdempotencyKey)
 		if existing.ID != uuid.Nil {
+			// A declined authorization stays declined when the network retries it
+			if existing.Status == "declined" {
+				return nil, errors.New("transaction declined")
+			}
 			// Webhook already processed — acknowledge safely
 			return map[string]string{"status": "duplicate_ignored"}, nil
 		}
@@ -45,12 +57,12 @@ func (s *transactionService) WebhookTransaction(ctx context.Context,data models.
 	// --------------------------------------------------
 	// 2. Load Card
 	// --------------------------------------------------
-	card, err := s.cardrepo.FindCardsByReference(ctx, data)
-	if err != nil || card.ID == uuid.Nil {
-		return nil, errors.New("card not found")
+	card, err := s.resolveCard(ctx, data)
+	if err != nil {
+		return nil, err
 	}
 	switch card.Status {
-	case "frozen", "expired", "terminated":
+	case "frozen", "expired", "terminated", "locked", "suspended", "blocked":
 		return nil, errors.New("card is not active")
 	}
 
@@ -59,6 +71,12 @@ func (s *transactionService) WebhookTransaction(ctx context.Context,data models.
 	switch data.Type {
 	case "authorization":
 
+		// The network verifies the PIN and reports the outcome; repeated
+		// failures lock the card
+		if err := s.applyPinVerification(ctx, &card, data.PinVerification); err != nil {
+			return nil, err
+		}
+
 		if availableBalance < data.Amount {
 			return nil, errors.New("insufficient available balance")
 		}
@@ -67,6 +85,33 @@ func (s *transactionService) WebhookTransaction(ctx context.Context,data models.
 			return nil, errors.New("exceeds card spending limit")
 		}
 
+		// The cardholder's KYC tier has to still allow this kind of card;
+		// business cards rest on their organization's verification instead
+		if card.OrganizationID == nil {
+			tier, _, err := resolveKycTier(ctx, s.userrepo, s.kycrepo, card.UserID)
+			if err != nil {
+				log.Printf("failed to resolve kyc tier for user %s: %v", card.UserID, err)
+				return nil, errors.New("something went wrong")
+			}
+			if !tier.AllowsCardType(card.CardType) {
+				return nil, errors.New("card type not allowed at the cardholder's verification level")
+			}
+		}
+
+		// Merchant-locked cards bind on first use; the binding is saved
+		// together with the held balance below
+		if _, err := CheckMerchantLock(&card, data.Merchant.Name, data.Amount); err != nil {
+			return nil, err
+		}
+
+		// Fraud rules decline, flag or approve; every hit, shadow rules
+		// included, is kept on the transaction
+		fraud := s.fraud.Evaluate(ctx, card, data)
+		var fraudHits datatypes.JSON
+		if len(fraud.Hits) > 0 {
+			fraudHits, _ = json.Marshal(fraud.Hits)
+		}
+
 		// Create transaction
 		txn := &models.Transaction{
 			UserID:               card.UserID,
@@ -85,6 +130,21 @@ func (s *transactionService) WebhookTransaction(ctx context.Context,data models.
 			MerchantCountry:      &data.Merchant.Country,
 			Source:               &data.Network,
 			TransactionTimestamp: data.Timestamp,
+			FraudDecision:        &fraud.Decision,
+			FraudRuleHits:        fraudHits,
+		}
+
+		// A fraud decline is recorded without holding any balance, and the
+		// card is frozen under a fraud case
+		if fraud.Decision == FraudDecline {
+			reason := "declined by fraud rules"
+			txn.Status = "declined"
+			txn.DeclineReason = &reason
+			if err := s.Txnrepo.CreateTransaction(ctx, txn); err != nil {
+				return nil, err
+			}
+			s.fraud.RecordAuthorization(ctx, card, *txn, fraud)
+			return nil, errors.New("transaction declined")
 		}
 
 		if err := s.Txnrepo.CreateTransaction(ctx, txn); err != nil {
@@ -108,6 +168,10 @@ func (s *transactionService) WebhookTransaction(ctx context.Context,data models.
 		}
 		_ = s.Txnrepo.CreateLedger(ctx, *ledger)
 
+		if fraud.Decision == FraudFlag {
+			s.fraud.RecordAuthorization(ctx, card, *txn, fraud)
+			return map[string]string{"status": "authorized", "fraud": "flagged"}, nil
+		}
 		return map[string]string{"status": "authorized"}, nil
 
 	case "capture":
@@ -284,7 +348,15 @@ func (s *transactionService) WebhookTransaction(ctx context.Context,data models.
 
 func (s *transactionService) GetCardTransactions(ctx context.Context, data models.GetCardTransactionsReq)([]models.GetCardTransactionsResp, error){
 	var res []models.GetCardTransactionsResp
-	transactions, err := s.Txnrepo.FindCardTransactions(ctx, data)
+	card, err := s.cardrepo.FindCardByID(ctx, models.GetCardReq{UserId: data.Userid, CardId: data.Cardid})
+	if err != nil {
+		return nil,  errors.New("something went wrong, please try again later")
+	}
+	if card.ID == uuid.Nil {
+		return nil, errors.New("card not found")
+	}
+	// include transactions from the cards this one replaced
+	transactions, err := cardHistory(ctx, s.cardrepo, s.Txnrepo, card)
 	if err != nil {
 		return nil,  errors.New("something went wrong, please try again later")
 	}
@@ -307,4 +379,67 @@ func (s *transactionService) GetCardTransactions(ctx context.Context, data model
 	}
 	
 	return res, nil
-}
\ No newline at end of file
+}
+
+// PayoutWebhook settles withdrawals once the payout provider reports the final
+// status of a bank transfer. Failed payouts are credited back to the card.
+func (s *transactionService) PayoutWebhook(ctx context.Context, data models.PayoutWebhookReq) error {
+	var payload models.PayoutWebhookData
+	if err := json.Unmarshal(data.Data, &payload); err != nil || payload.Reference == "" {
+		return errors.New("invalid payout payload")
+	}
+	txn, err := s.Txnrepo.FindTxnByReference(ctx, payload.Reference)
+	if err != nil {
+		return errors.New("something went wrong, please try again later")
+	}
+	if txn.ID == uuid.Nil || txn.Type != "withdrawal" {
+		return errors.New("transaction not found")
+	}
+	if txn.Status != "pending" {
+		// already settled, acknowledge the retry
+		return nil
+	}
+
+	switch data.Event {
+	case "transfer.success":
+		txn.Status = "completed"
+		if err := s.Txnrepo.Update(ctx, txn); err != nil {
+			return errors.New("something went wrong, please try again later")
+		}
+	case "transfer.failed":
+		if err := reverseWithdrawal(ctx, s.cardrepo, txn, "payout failed"); err != nil {
+			log.Printf("failed to reverse withdrawal %s: %v", txn.TransactionReference, err)
+			return errors.New("something went wrong, please try again later")
+		}
+	default:
+		return errors.New("unsupported payout event")
+	}
+	return nil
+}
+
+// resolveCard finds the card a network event is for. Wallet payments carry a
+// network token instead of the card reference; only active tokens resolve.
+func (s *transactionService) resolveCard(ctx context.Context, data models.WebhookReq) (models.Card, error) {
+	if data.TokenReference == "" {
+		card, err := s.cardrepo.FindCardsByReference(ctx, data)
+		if err != nil || card.ID == uuid.Nil {
+			return models.Card{}, errors.New("card not found")
+		}
+		return card, nil
+	}
+	token, err := s.tokenrepo.FindByReference(ctx, data.TokenReference)
+	if err != nil {
+		return models.Card{}, errors.New("something went wrong")
+	}
+	if token == nil {
+		return models.Card{}, errors.New("token not found")
+	}
+	if token.Status != "active" && data.Type == "authorization" {
+		return models.Card{}, errors.New("token is not active")
+	}
+	card, err := s.cardrepo.FindCardByUUID(ctx, token.CardID)
+	if err != nil || card.ID == uuid.Nil {
+		return models.Card{}, errors.New("card not found")
+	}
+	return card, nil
+}
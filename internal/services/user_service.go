@@ -1,6 +1,7 @@
 package services
 
 import (
+	"CardFlow/internal/config"
 	"CardFlow/internal/models"
 	"CardFlow/internal/repositories"
 	"CardFlow/internal/utils"
@@ -20,16 +21,22 @@ type UserService interface {
 	VerifyOtp(ctx context.Context, userID uuid.UUID, otp string) error
 	EnableMFA(ctx context.Context, userID uuid.UUID)(string, error)
 	VerifyMFA(ctx context.Context, userID uuid.UUID, data string) error
+	SendStepUpOtp(ctx context.Context, userID uuid.UUID) error
 }
 
 type userService struct {
     repo repositories.UserRepository
+    sessions SessionService
 }
 
-func NewUserService(repo repositories.UserRepository) UserService {
-    return &userService{repo: repo}
+func NewUserService(repo repositories.UserRepository, sessions SessionService) UserService {
+    return &userService{repo: repo, sessions: sessions}
 }
 
+// ErrDeviceVerificationRequired asks the client to repeat the login with the
+// OTP just emailed, see LOGIN_NEW_DEVICE_STEP_UP.
+var ErrDeviceVerificationRequired = errors.New("device verification required")
+
 
 func (s *userService) RegisterUser(ctx context.Context, req models.CreateUserRequest) error {
     existingUser, err := s.repo.FindByEmail(ctx, req.Email)
@@ -75,7 +82,39 @@ func (s *userService)Login(ctx context.Context, req models.LoginReq) (string, er
 		return "", errors.New("invalid email or password")
 	}
 
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	fingerprint := DeviceFingerprint(req.DeviceID, req.Client.UserAgent)
+	known, err := s.sessions.KnownDevice(ctx, user.ID, fingerprint)
+	if err != nil {
+		return "", errors.New("something went wrong, please try again later")
+	}
+	// users with MFA never get here, they always present a TOTP code
+	if !known && config.LoginNewDeviceStepUp == "true" {
+		if req.Otp == "" {
+			if err := s.sendOtp(ctx, user.ID); err != nil {
+				return "", err
+			}
+			return "", ErrDeviceVerificationRequired
+		}
+		if err := verifyStepUp(ctx, s.repo, user.ID, "", req.Otp); err != nil {
+			return "", err
+		}
+	}
+	return s.startSession(ctx, user, fingerprint, req.Client, !known)
+}
+
+// startSession records the login and issues its token.
+func (s *userService) startSession(ctx context.Context, user *models.User, fingerprint string, client models.ClientInfo, newDevice bool) (string, error) {
+	session, err := s.sessions.StartSession(ctx, user, fingerprint, client, newDevice)
+	if err != nil {
+		return "", errors.New("something went wrong, please try again later")
+	}
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := s.repo.UpdateLastLogin(ctx, user.ID, now); err != nil {
+		log.Printf("failed to set last login of user %s: %v", user.ID, err)
+	}
+
+	token, err := utils.GenerateJWT(user.ID, user.Email, session.ID, session.ExpiresAt)
 	if err != nil {
 		return "", errors.New("something went wrong, please try again later")
 	}
@@ -95,21 +134,31 @@ func (s *userService) MFALogin(ctx context.Context,req models.MFALoginReq) (stri
         return "", errors.New("MFA is not enabled for this user")
     }
 
-    err = utils.ValidateTotp(req.TOTPCode, user.MFASecret)
+    err = useTotpCode(ctx, s.repo, user.ID, req.TOTPCode, user.MFASecret)
     if err != nil {
         return "", err
     }
 
-    token, err := utils.GenerateJWT(user.ID, user.Email)
+    fingerprint := DeviceFingerprint(req.DeviceID, req.Client.UserAgent)
+    known, err := s.sessions.KnownDevice(ctx, user.ID, fingerprint)
     if err != nil {
         return "", errors.New("something went wrong, please try again later")
     }
-
-    return token, nil
+    return s.startSession(ctx, user, fingerprint, req.Client, !known)
 }
 
 
 func (s *userService) VerifyEmail(ctx context.Context,userID uuid.UUID) error {
+	return s.sendOtp(ctx, userID)
+}
+
+// SendStepUpOtp emails a one-time code that users without MFA present to
+// confirm sensitive actions such as revealing card details.
+func (s *userService) SendStepUpOtp(ctx context.Context, userID uuid.UUID) error {
+	return s.sendOtp(ctx, userID)
+}
+
+func (s *userService) sendOtp(ctx context.Context, userID uuid.UUID) error {
 	user,  err := s.repo.FindByID(ctx, userID)
 	if err != nil {
 		return errors.New("something went wrong, please try again later")
@@ -196,7 +245,7 @@ func (s *userService) VerifyMFA(ctx context.Context, userID uuid.UUID, data stri
 		return errors.New("user not found")
 	}
 	secret := user.MFASecret
-	err = utils.ValidateTotp(data, secret)
+	err = useTotpCode(ctx, s.repo, user.ID, data, secret)
 	if err != nil {
 		return err
 	}	
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestAdminTokensUseTheirOwnSecret(t *testing.T) {
+	defer func(user, admin string) { config.JwtSecret, config.AdminJwtSecret = user, admin }(config.JwtSecret, config.AdminJwtSecret)
+	config.JwtSecret = "user-secret"
+
+	config.AdminJwtSecret = "user-secret"
+	if _, err := GenerateAdminJWT(uuid.New(), "superadmin"); err == nil {
+		t.Fatalf("expected an admin secret shared with customer tokens to be refused")
+	}
+
+	config.AdminJwtSecret = "admin-secret"
+	signed, err := GenerateAdminJWT(uuid.New(), "superadmin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFor := func(secret string) jwt.Keyfunc {
+		return func(*jwt.Token) (interface{}, error) { return []byte(secret), nil }
+	}
+	if _, err := jwt.Parse(signed, keyFor("user-secret")); err == nil {
+		t.Fatalf("expected an admin token not to verify with the customer secret")
+	}
+	if _, err := jwt.Parse(signed, keyFor("admin-secret"), jwt.WithAudience(AdminTokenAudience)); err != nil {
+		t.Fatalf("expected an admin token for the admin audience, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// PanFingerprint returns a keyed HMAC-SHA256 of a PAN. Unlike a bcrypt hash
+// it is deterministic, so it can be indexed and searched, and without
+// PAN_FINGERPRINT_KEY_BASE64 it cannot be brute forced from the PAN space.
+func PanFingerprint(pan string) (string, error) {
+	key, err := decodeKey(config.PanFingerprintKey, "PAN_FINGERPRINT_KEY_BASE64")
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(pan))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// DeriveCVV computes a card's CVV from its PAN and expiry with CVV_KEY_BASE64,
+// the way issuers use a card verification key, so the CVV never has to be
+// stored. The MAC is decimalized: decimal nibbles first, then A-F less ten.
+func DeriveCVV(pan, expiryMonth, expiryYear string) (string, error) {
+	key, err := decodeKey(config.CvvKey, "CVV_KEY_BASE64")
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(pan + "|" + expiryMonth + "|" + expiryYear))
+	nibbles := unpackNibbles(mac.Sum(nil))
+
+	cvv := make([]byte, 0, 3)
+	for _, n := range nibbles {
+		if len(cvv) == 3 {
+			break
+		}
+		if n < 10 {
+			cvv = append(cvv, '0'+n)
+		}
+	}
+	for _, n := range nibbles {
+		if len(cvv) == 3 {
+			break
+		}
+		if n >= 10 {
+			cvv = append(cvv, '0'+n-10)
+		}
+	}
+	return string(cvv), nil
+}
+
+func decodeKey(encoded, name string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New(name + " must be a base64 encoded 32 byte key")
+	}
+	return key, nil
+}
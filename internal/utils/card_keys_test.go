@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestPanFingerprintIsKeyedAndDeterministic(t *testing.T) {
+	config.PanFingerprintKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	first, err := PanFingerprint("4111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := PanFingerprint("4111111111111111")
+	if first != second || len(first) != 64 {
+		t.Fatalf("expected a stable 64 character fingerprint, got %q and %q", first, second)
+	}
+	config.PanFingerprintKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", 32)))
+	other, _ := PanFingerprint("4111111111111111")
+	if other == first {
+		t.Fatalf("expected a different key to give a different fingerprint")
+	}
+}
+
+func TestDeriveCVV(t *testing.T) {
+	config.CvvKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("c", 32)))
+	cvv, err := DeriveCVV("4111111111111111", "09", "2029")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cvv) != 3 || !isDigits(cvv) {
+		t.Fatalf("expected three digits, got %q", cvv)
+	}
+	again, _ := DeriveCVV("4111111111111111", "09", "2029")
+	if again != cvv {
+		t.Fatalf("expected the same CVV for the same card, got %q and %q", cvv, again)
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectDocumentType(t *testing.T) {
+	png := append([]byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}, make([]byte, 32)...)
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF")
+	encryptedPDF := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<< /Root 1 0 R /Encrypt 2 0 R >>\n%%EOF")
+
+	cases := []struct {
+		name string
+		data []byte
+		mime string
+	}{
+		{"png", png, "image/png"},
+		{"pdf", pdf, "application/pdf"},
+		{"encrypted pdf", encryptedPDF, ""},
+		{"text declared as an image", []byte("not really a png"), ""},
+		{"empty", nil, ""},
+		{"too large", append(png, bytes.Repeat([]byte{0}, MaxKycDocumentBytes)...), ""},
+	}
+	for _, c := range cases {
+		mime, err := DetectDocumentType(c.data)
+		if c.mime == "" && err == nil {
+			t.Errorf("%s: expected rejection, got %q", c.name, mime)
+		}
+		if c.mime != "" && (err != nil || mime != c.mime) {
+			t.Errorf("%s: expected %q, got %q %v", c.name, c.mime, mime, err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/integrations"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Envelope ciphertexts are "<kek alias>:<base64 wrapped data key>:<base64
+// nonce||ciphertext>". Every record gets its own data key unless the key
+// cache lets a recent one be reused; the data key is wrapped by the KMS and
+// only the wrapped form is stored.
+
+const (
+	kmsTimeout = 10 * time.Second
+	// maxDataKeyUses caps how many records share one cached data key.
+	maxDataKeyUses = 1000
+	// maxCachedDataKeys bounds the decryption cache.
+	maxCachedDataKeys = 1024
+)
+
+var (
+	kmsMu      sync.Mutex
+	kmsManager integrations.KeyManager
+	dataKeys   = &dataKeyCache{entries: map[string]*cachedDataKey{}}
+)
+
+// SetKeyManager replaces the KeyManager built from KMS_PROVIDER, for tests
+// and tools, and drops every cached data key.
+func SetKeyManager(km integrations.KeyManager) {
+	kmsMu.Lock()
+	defer kmsMu.Unlock()
+	kmsManager = km
+	dataKeys.clear()
+}
+
+func keyManager() (integrations.KeyManager, error) {
+	kmsMu.Lock()
+	defer kmsMu.Unlock()
+	if kmsManager == nil {
+		km, err := integrations.NewKeyManager()
+		if err != nil {
+			return nil, err
+		}
+		kmsManager = km
+	}
+	return kmsManager, nil
+}
+
+func envelopeEnabled() bool {
+	return config.KmsKeyID != ""
+}
+
+// kmsKeyName maps a KEK alias to the name the KMS knows the key by, such as
+// an AWS key ARN or a Cloud KMS resource name, which may contain ':'.
+func kmsKeyName(alias string) string {
+	for _, entry := range strings.Split(config.KmsKeys, ",") {
+		if name, value, ok := strings.Cut(strings.TrimSpace(entry), "="); ok && name == alias {
+			return value
+		}
+	}
+	return alias
+}
+
+func envelopeEncrypt(plain []byte) (string, error) {
+	alias := config.KmsKeyID
+	if !validKeyID(alias) {
+		return "", fmt.Errorf("invalid KMS_KEY_ID %q", alias)
+	}
+	dataKey, wrapped, err := dataKeys.forEncryption(alias)
+	if err != nil {
+		return "", err
+	}
+	defer zero(dataKey)
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	wrappedB64 := base64.StdEncoding.EncodeToString(wrapped)
+	// binding the wrapped key stops a record being paired with another data key
+	sealed := append(nonce, gcm.Seal(nil, nonce, plain, []byte(wrappedB64))...)
+	return alias + ":" + wrappedB64 + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func envelopeDecrypt(alias, wrappedB64, sealedB64 string) ([]byte, error) {
+	dataKey, err := dataKeys.forDecryption(alias, wrappedB64)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(dataKey)
+	data, err := base64.StdEncoding.DecodeString(sealedB64)
+	if err != nil {
+		return nil, errors.New("invalid encrypted base64")
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(wrappedB64))
+	if err != nil {
+		return nil, errors.New("decryption failed")
+	}
+	return plain, nil
+}
+
+type cachedDataKey struct {
+	plain   []byte
+	wrapped []byte
+	expires time.Time
+	uses    int
+}
+
+// dataKeyCache keeps plaintext data keys in memory for KMS_KEY_CACHE_TTL at
+// most, so the KMS is not called for every record. A TTL of 0 turns caching
+// off and gives every record a fresh data key. Callers always get their own
+// copy of a key, since the cache zeroes its keys when they are evicted.
+type dataKeyCache struct {
+	mu      sync.Mutex
+	current map[string]*cachedDataKey // alias -> key used for new records
+	entries map[string]*cachedDataKey // alias|wrapped -> unwrapped key
+}
+
+func dataKeyTTL() time.Duration {
+	if config.KmsKeyCacheTTL == "" {
+		return 5 * time.Minute
+	}
+	seconds, err := strconv.Atoi(config.KmsKeyCacheTTL)
+	if err != nil || seconds < 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (c *dataKeyCache) forEncryption(alias string) ([]byte, []byte, error) {
+	ttl := dataKeyTTL()
+	now := time.Now()
+	c.mu.Lock()
+	if k := c.current[alias]; k != nil && now.Before(k.expires) && k.uses < maxDataKeyUses {
+		k.uses++
+		plain, wrapped := append([]byte(nil), k.plain...), k.wrapped
+		c.mu.Unlock()
+		return plain, wrapped, nil
+	}
+	c.mu.Unlock()
+
+	km, err := keyManager()
+	if err != nil {
+		return nil, nil, err
+	}
+	plain := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, plain); err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+	wrapped, err := km.WrapKey(ctx, kmsKeyName(alias), plain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	if ttl > 0 {
+		c.mu.Lock()
+		if c.current == nil {
+			c.current = map[string]*cachedDataKey{}
+		}
+		if old := c.current[alias]; old != nil {
+			zero(old.plain)
+		}
+		c.current[alias] = &cachedDataKey{plain: append([]byte(nil), plain...), wrapped: wrapped, expires: now.Add(ttl), uses: 1}
+		c.mu.Unlock()
+	}
+	return plain, wrapped, nil
+}
+
+func (c *dataKeyCache) forDecryption(alias, wrappedB64 string) ([]byte, error) {
+	ttl := dataKeyTTL()
+	cacheKey := alias + "|" + wrappedB64
+	now := time.Now()
+	c.mu.Lock()
+	if k := c.entries[cacheKey]; k != nil {
+		if now.Before(k.expires) {
+			plain := append([]byte(nil), k.plain...)
+			c.mu.Unlock()
+			return plain, nil
+		}
+		zero(k.plain)
+		delete(c.entries, cacheKey)
+	}
+	c.mu.Unlock()
+
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedB64)
+	if err != nil {
+		return nil, errors.New("invalid wrapped data key")
+	}
+	km, err := keyManager()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+	plain, err := km.UnwrapKey(ctx, kmsKeyName(alias), wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	if ttl > 0 {
+		c.mu.Lock()
+		c.evictLocked(now)
+		c.entries[cacheKey] = &cachedDataKey{plain: append([]byte(nil), plain...), expires: now.Add(ttl)}
+		c.mu.Unlock()
+	}
+	return plain, nil
+}
+
+// evictLocked drops expired keys and, if the cache is still full, the one
+// closest to expiry.
+func (c *dataKeyCache) evictLocked(now time.Time) {
+	for key, k := range c.entries {
+		if !now.Before(k.expires) {
+			zero(k.plain)
+			delete(c.entries, key)
+		}
+	}
+	for len(c.entries) >= maxCachedDataKeys {
+		var oldest string
+		for key, k := range c.entries {
+			if oldest == "" || k.expires.Before(c.entries[oldest].expires) {
+				oldest = key
+			}
+		}
+		zero(c.entries[oldest].plain)
+		delete(c.entries, oldest)
+	}
+}
+
+func (c *dataKeyCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range c.current {
+		zero(k.plain)
+	}
+	for _, k := range c.entries {
+		zero(k.plain)
+	}
+	c.current = map[string]*cachedDataKey{}
+	c.entries = map[string]*cachedDataKey{}
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"CardFlow/internal/integrations"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+type countingKMS struct {
+	*integrations.LocalKMS
+	wraps, unwraps int
+}
+
+func (c *countingKMS) WrapKey(ctx context.Context, kekID string, dataKey []byte) ([]byte, error) {
+	c.wraps++
+	return c.LocalKMS.WrapKey(ctx, kekID, dataKey)
+}
+
+func (c *countingKMS) UnwrapKey(ctx context.Context, kekID string, wrapped []byte) ([]byte, error) {
+	c.unwraps++
+	return c.LocalKMS.UnwrapKey(ctx, kekID, wrapped)
+}
+
+func TestEnvelopeEncryption(t *testing.T) {
+	kms := &countingKMS{LocalKMS: integrations.NewLocalKMSFromKeys(map[string][]byte{
+		"kek-1": []byte(strings.Repeat("k", 32)),
+		"kek-2": []byte(strings.Repeat("q", 32)),
+	})}
+	SetKeyManager(kms)
+	config.KmsKeyID = "kek-1"
+	config.KmsKeyCacheTTL = "0"
+	defer func() {
+		config.KmsKeyID, config.KmsKeyCacheTTL = "", ""
+		SetKeyManager(nil)
+	}()
+
+	first, err := EncryptWithKeyring("4111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := EncryptWithKeyring("4111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyIDOf(first) != "kek-1" || len(strings.Split(first, ":")) != 3 {
+		t.Fatalf("expected an envelope ciphertext under kek-1, got %q", first)
+	}
+	if strings.Split(first, ":")[1] == strings.Split(second, ":")[1] {
+		t.Fatalf("expected a fresh data key per record with caching off")
+	}
+	for _, ct := range []string{first, second} {
+		if plain, err := DecryptWithKeyring(ct); err != nil || plain != "4111111111111111" {
+			t.Fatalf("expected %q to decrypt, got %q %v", ct, plain, err)
+		}
+	}
+
+	// with caching on, data keys are reused and unwrapped once
+	config.KmsKeyCacheTTL = "300"
+	SetKeyManager(kms)
+	kms.wraps, kms.unwraps = 0, 0
+	a, _ := EncryptWithKeyring("a")
+	b, _ := EncryptWithKeyring("b")
+	if kms.wraps != 1 {
+		t.Fatalf("expected one wrap for two records, got %d", kms.wraps)
+	}
+	SetKeyManager(kms)
+	for _, ct := range []string{a, b, a} {
+		if _, err := DecryptWithKeyring(ct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if kms.unwraps != 1 {
+		t.Fatalf("expected the data key to be unwrapped once, got %d", kms.unwraps)
+	}
+
+	// a tampered wrapped key must not open the record
+	parts := strings.Split(first, ":")
+	if _, err := DecryptWithKeyring(parts[0] + ":" + parts[1] + ":" + strings.Split(second, ":")[2]); err == nil {
+		t.Fatalf("expected a record paired with another data key to fail")
+	}
+
+	// rotating the KEK moves new records while old ones still decrypt
+	config.KmsKeyID = "kek-2"
+	rotated, err := EncryptWithKeyring("4111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, _ := LoadKeyring()
+	if KeyIDOf(rotated) != "kek-2" || k.PrimaryKeyID() != "kek-2" || KeyIDOf(first) == k.PrimaryKeyID() {
+		t.Fatalf("expected new records under kek-2 and old ones flagged for re-wrapping")
+	}
+	if plain, err := DecryptWithKeyring(first); err != nil || plain != "4111111111111111" {
+		t.Fatalf("expected kek-1 records to still decrypt, got %v", err)
+	}
+}
+
+func TestDataKeyCacheReturnsCopies(t *testing.T) {
+	SetKeyManager(integrations.NewLocalKMSFromKeys(map[string][]byte{"kek-1": []byte(strings.Repeat("k", 32))}))
+	config.KmsKeyCacheTTL = "300"
+	defer func() {
+		config.KmsKeyCacheTTL = ""
+		SetKeyManager(nil)
+	}()
+
+	plain, wrapped, err := dataKeys.forEncryption("kek-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, _, err := dataKeys.forEncryption("kek-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unwrapped, err := dataKeys.forDecryption("kek-1", base64.StdEncoding.EncodeToString(wrapped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cachedUnwrapped, err := dataKeys.forDecryption("kek-1", base64.StdEncoding.EncodeToString(wrapped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]byte(nil), plain...)
+
+	// clearing the cache zeroes its own keys, not the ones handed out
+	dataKeys.clear()
+	for _, key := range [][]byte{cached, unwrapped, cachedUnwrapped} {
+		if !bytes.Equal(key, want) {
+			t.Fatalf("expected a key handed out by the cache to survive clearing it")
+		}
+	}
+}
@@ -14,10 +14,12 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/smtp"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,28 +31,6 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-var KYCEncryptionKey []byte
-
-func LoadEncryptionKey() error {
-	keyB64 := config.EncryptionKey
-	if keyB64 == "" {
-		return errors.New("KYC_ENCRYPTION_KEY_BASE64 not set")
-	}
-
-	key, err := base64.StdEncoding.DecodeString(keyB64)
-	if err != nil {
-		return errors.New("invalid base64 encryption key")
-	}
-
-	if len(key) != 32 {
-		return errors.New("encryption key must be 32 bytes (AES-256)")
-	}
-
-	KYCEncryptionKey = key
-	return nil
-}
-
-
 func Hash(password string) (string, error) {
 	//hash password with bcrypt
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
@@ -61,15 +41,57 @@ func CompareHashAndPassword(hash string, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func GenerateJWT(userID uuid.UUID, email string) (string, error) {
+// UserTokenTTL is how long a customer JWT, and the session behind it, lasts.
+const UserTokenTTL = 1 * time.Hour
+
+// ErrSessionEnded is returned for a token whose session was revoked or has
+// expired.
+var ErrSessionEnded = errors.New("session has ended, please log in again")
+
+// GenerateJWT issues a customer token for the session it was logged in with;
+// JWTProtected rejects it once that session is revoked.
+func GenerateJWT(userID uuid.UUID, email string, sessionID uuid.UUID, expiresAt time.Time) (string, error) {
 	secret := config.JwtSecret
 	if secret == "" {
 		return "", errors.New("no secret key found")
 	}
 
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(1 * time.Hour).Unix(),// Token expires in 1 hour
+		"user_id":    userID,
+		"session_id": sessionID,
+		"exp":        expiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// AdminTokenAudience is the aud claim of back-office tokens.
+const AdminTokenAudience = "cardflow-admin"
+
+// AdminJwtSecret returns the key back-office tokens are signed with. It has
+// to be set and differ from JWT_SECRET, so a customer token can never be
+// passed off as an admin one or the other way round.
+func AdminJwtSecret() (string, error) {
+	if config.AdminJwtSecret == "" || config.AdminJwtSecret == config.JwtSecret {
+		return "", errors.New("ADMIN_JWT_SECRET must be set and differ from JWT_SECRET")
+	}
+	return config.AdminJwtSecret, nil
+}
+
+// GenerateAdminJWT issues a back-office token, signed with ADMIN_JWT_SECRET
+// and scoped to AdminTokenAudience.
+func GenerateAdminJWT(adminID uuid.UUID, role string) (string, error) {
+	secret, err := AdminJwtSecret()
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"admin_id": adminID,
+		"role":     role,
+		"aud":      AdminTokenAudience,
+		"exp":      time.Now().Add(30 * time.Minute).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -156,22 +178,32 @@ func GenerateMFASecret(userID uuid.UUID) (string, string, error) {
 }
 
 func ValidateTotp(data, secret string) error{
-	valid, err := totp.ValidateCustom(data, secret, time.Now().UTC(), totp.ValidateOpts{
-		Period:    30,
-		Skew:      1,
-		Digits:    otp.DigitsSix,
-		Algorithm: otp.AlgorithmSHA1,
-	})
-
-	if err != nil {
-        log.Printf("TOTP validation failed: %v", err) // log for devs
-        return errors.New("something went wrong, please try again later")
-    }
+	_, err := ValidateTotpStep(data, secret)
+	return err
+}
 
-	if !valid{
-		return errors.New("invalid authentication code")
+// ValidateTotpStep checks a TOTP code against the current 30-second step
+// and one either side, and returns the step it matched so callers can refuse
+// to accept the same code twice.
+func ValidateTotpStep(data, secret string) (int64, error){
+	now := time.Now().UTC()
+	for _, skew := range []int64{0, -1, 1} {
+		at := now.Add(time.Duration(skew) * 30 * time.Second)
+		valid, err := totp.ValidateCustom(data, secret, at, totp.ValidateOpts{
+			Period:    30,
+			Skew:      0,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			log.Printf("TOTP validation failed: %v", err) // log for devs
+			return 0, errors.New("something went wrong, please try again later")
+		}
+		if valid {
+			return at.Unix() / 30, nil
+		}
 	}
-	return nil
+	return 0, errors.New("invalid authentication code")
 }
 
 func GenerateTotp(secret string) (string, error){
@@ -184,10 +216,19 @@ func GenerateTotp(secret string) (string, error){
 
 
 func EncryptBase64Document(base64File string,) (encryptedBase64 string, mimeType string, err error) {
-	// Expected: data:<mime>;base64,<data>
+	plainBytes, err := DecodeDataURI(base64File)
+	if err != nil {
+		return "", "", err
+	}
+	return EncryptDocument(plainBytes)
+}
+
+// DecodeDataURI decodes a data:<mime>;base64,<data> URI. The declared MIME
+// type must be one we accept, but only the content decides the real type.
+func DecodeDataURI(base64File string) ([]byte, error) {
 	parts := strings.SplitN(base64File, ",", 2)
 	if len(parts) != 2 {
-		return "", "", errors.New("invalid base64 format")
+		return nil, errors.New("invalid base64 format")
 	}
 
 	// Extract MIME type
@@ -195,45 +236,39 @@ func EncryptBase64Document(base64File string,) (encryptedBase64 string, mimeType
 	start := strings.Index(meta, ":")
 	end := strings.Index(meta, ";")
 	if start == -1 || end == -1 {
-		return "", "", errors.New("invalid base64 metadata")
+		return nil, errors.New("invalid base64 metadata")
 	}
 
-	mimeType = meta[start+1 : end]
-
 	// Validate MIME
-	if _, err := getFileExtensionFromMIME(mimeType); err != nil {
-		return "", "", err
+	if _, err := getFileExtensionFromMIME(meta[start+1 : end]); err != nil {
+		return nil, err
 	}
 
 	// Decode base64 payload
 	plainBytes, err := base64.StdEncoding.DecodeString(parts[1])
 	if err != nil {
-		return "", "", errors.New("invalid base64 payload")
+		return nil, errors.New("invalid base64 payload")
 	}
+	return plainBytes, nil
+}
 
-	// AES-256-GCM
-	block, err := aes.NewCipher(KYCEncryptionKey)
+// EncryptDocument validates a document by its content and encrypts it with
+// the keyring. The returned MIME type is the detected one, whatever the
+// client declared.
+func EncryptDocument(plainBytes []byte) (encryptedBase64 string, mimeType string, err error) {
+	mimeType, err = DetectDocumentType(plainBytes)
 	if err != nil {
 		return "", "", err
 	}
-
-	gcm, err := cipher.NewGCM(block)
+	keyring, err := currentKeyring()
 	if err != nil {
 		return "", "", err
 	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+	encryptedBase64, err = keyring.Encrypt(plainBytes)
+	if err != nil {
 		return "", "", err
 	}
 
-	ciphertext := gcm.Seal(nil, nonce, plainBytes, nil)
-
-	// nonce || ciphertext
-	final := append(nonce, ciphertext...)
-
-	encryptedBase64 = base64.StdEncoding.EncodeToString(final)
-
 	return encryptedBase64, mimeType, nil
 }
 
@@ -250,31 +285,27 @@ func getFileExtensionFromMIME(mimeType string) (string, error) {
 	}
 }
 
-func DecryptBase64Document(encryptedBase64 string) (plainBase64 string, err error) {
-	data, err := base64.StdEncoding.DecodeString(encryptedBase64)
+// DecryptDocument opens a document sealed by EncryptDocument.
+func DecryptDocument(ciphertext []byte) ([]byte, error) {
+	keyring, err := currentKeyring()
 	if err != nil {
-		return "", errors.New("invalid encrypted base64")
+		return nil, err
 	}
-	block, err := aes.NewCipher(KYCEncryptionKey)
+	return keyring.Decrypt(string(ciphertext))
+}
+
+// DecryptBase64Document opens a document sealed by EncryptBase64Document
+// with whichever keyring key it names.
+func DecryptBase64Document(encryptedBase64 string) (plainBase64 string, err error) {
+	keyring, err := currentKeyring()
 	if err != nil {
 		return "", err
 	}
-	gcm, err := cipher.NewGCM(block)
+	plainBytes, err := keyring.Decrypt(encryptedBase64)
 	if err != nil {
 		return "", err
 	}
-	if len(data) < gcm.NonceSize() {
-		return "", errors.New("ciphertext too short")
-	}
-	nonce := data[:gcm.NonceSize()]
-	ciphertext := data[gcm.NonceSize():]
-
-	plainBytes, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", errors.New("decryption failed")
-	}
-	plainBase64 = base64.StdEncoding.EncodeToString(plainBytes)
-	return plainBase64, nil
+	return base64.StdEncoding.EncodeToString(plainBytes), nil
 }
 
 
@@ -327,14 +358,22 @@ func isPDFEncrypted(raw string) bool {
 }
 
 
-var ValidateFileType =func(base64Str string) bool {
-	fileBytes, err := base64.StdEncoding.DecodeString(base64Str)
-	if err != nil {
-		return false
+// MaxKycDocumentBytes caps the size of an uploaded KYC document.
+const MaxKycDocumentBytes = 10 * 1024 * 1024
+
+// DetectDocumentType checks a KYC document by its magic bytes rather than
+// its declared type. Only JPEG, PNG and unencrypted PDFs within
+// MaxKycDocumentBytes are accepted.
+func DetectDocumentType(fileBytes []byte) (string, error) {
+	if len(fileBytes) == 0 {
+		return "", errors.New("document is empty")
+	}
+	if len(fileBytes) > MaxKycDocumentBytes {
+		return "", errors.New("document too large")
 	}
 	kind, _ := filetype.Match(fileBytes)
 	if kind == filetype.Unknown {
-		return false
+		return "", errors.New("unsupported file type")
 	}
 	allowedTypes := map[string]bool{
 		"image/jpg":       true,
@@ -342,7 +381,22 @@ var ValidateFileType =func(base64Str string) bool {
 		"image/png":       true,
 		"application/pdf": true,
 	}
-	return allowedTypes[kind.MIME.Value]
+	if !allowedTypes[kind.MIME.Value] {
+		return "", errors.New("unsupported file type")
+	}
+	if kind.MIME.Value == "application/pdf" && isPDFEncrypted(string(fileBytes)) {
+		return "", errors.New("password protected PDFs are not accepted")
+	}
+	return kind.MIME.Value, nil
+}
+
+var ValidateFileType =func(base64Str string) bool {
+	fileBytes, err := base64.StdEncoding.DecodeString(base64Str)
+	if err != nil {
+		return false
+	}
+	_, err = DetectDocumentType(fileBytes)
+	return err == nil
 }
 
 func ConvertS3URLToBase64(s3URL string) (string, error) {
@@ -568,6 +622,41 @@ func ValidateHMAC(rawBody []byte, receivedSignature string) error {
 	return nil
 }
 
+// ValidateKorapaySignature checks the x-korapay-signature header, which is an
+// HMAC-SHA256 of the webhook's data object keyed with the Korapay secret.
+func ValidateKorapaySignature(data []byte, receivedSignature string) error {
+	if config.KorapaySecret == "" {
+		return errors.New("korapay secret is not configured")
+	}
+	mac := hmac.New(sha256.New, []byte(config.KorapaySecret))
+	mac.Write(data)
+	receivedMAC, err := hex.DecodeString(receivedSignature)
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+	if !hmac.Equal(mac.Sum(nil), receivedMAC) {
+		return errors.New("hmac signature mismatch")
+	}
+	return nil
+}
+
+// ValidateTspSignature checks the X-TSP-Signature header on token lifecycle
+// webhooks, a hex HMAC-SHA256 of the raw body keyed with TSP_WEBHOOK_SECRET.
+func ValidateTspSignature(rawBody []byte, receivedSignature string) error {
+	if config.TspWebhookSecret == "" {
+		return errors.New("token service webhook secret is not configured")
+	}
+	mac := hmac.New(sha256.New, []byte(config.TspWebhookSecret))
+	mac.Write(rawBody)
+	receivedMAC, err := hex.DecodeString(receivedSignature)
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+	if !hmac.Equal(mac.Sum(nil), receivedMAC) {
+		return errors.New("hmac signature mismatch")
+	}
+	return nil
+}
 
 func SendWithRetry(maxRetries int, delay time.Duration, sendFn func() error,) error {
     var err error
@@ -589,3 +678,34 @@ func SendWithRetry(maxRetries int, delay time.Duration, sendFn func() error,) er
 
     return err
 }
+
+
+// ConvertAmount converts amount between currencies using the FX_RATES table.
+// A pair may be configured in either direction; the inverse rate is derived.
+func ConvertAmount(amount float64, from, to string) (converted float64, rate float64, err error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return amount, 1, nil
+	}
+	for _, pair := range strings.Split(config.FxRates, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, parseErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if parseErr != nil || value <= 0 {
+			continue
+		}
+		switch strings.ToUpper(strings.TrimSpace(parts[0])) {
+		case from + "_" + to:
+			return RoundAmount(amount * value), value, nil
+		case to + "_" + from:
+			return RoundAmount(amount / value), 1 / value, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("no exchange rate for %s to %s", from, to)
+}
+
+func RoundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// LegacyKeyID names ENCRYPTION_KEY_BASE64, the single key used before the
+// keyring. Ciphertexts without a key ID prefix were written with it.
+const LegacyKeyID = "v1"
+
+// Keyring holds every data encryption key that may still be needed to
+// decrypt, and the primary key all new ciphertexts are written with.
+// Ciphertexts are "<key id>:<base64 nonce||ciphertext>", so each one says
+// which key opens it.
+type Keyring struct {
+	keys    map[string][]byte
+	primary string
+}
+
+// LoadKeyring builds the keyring from ENCRYPTION_KEYS ("id:base64key,..."),
+// ENCRYPTION_PRIMARY_KEY_ID and the legacy ENCRYPTION_KEY_BASE64. Without any
+// new keys the legacy key stays primary. When KMS_KEY_ID is set new
+// ciphertexts use envelope encryption under that KEK instead, and the keys
+// here are only kept to decrypt older records.
+func LoadKeyring() (*Keyring, error) {
+	k := &Keyring{keys: map[string][]byte{}, primary: config.EncryptionPrimaryKeyID}
+	if config.EncryptionKey != "" {
+		key, err := decodeKey(config.EncryptionKey, "ENCRYPTION_KEY_BASE64")
+		if err != nil {
+			return nil, err
+		}
+		k.keys[LegacyKeyID] = key
+	}
+	for _, entry := range strings.Split(config.EncryptionKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || !validKeyID(id) {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS entry %q", id)
+		}
+		key, err := decodeKey(encoded, "ENCRYPTION_KEYS key "+id)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = key
+	}
+	if envelopeEnabled() {
+		k.primary = config.KmsKeyID
+		return k, nil
+	}
+	if k.primary == "" {
+		k.primary = LegacyKeyID
+	}
+	if _, ok := k.keys[k.primary]; !ok {
+		return nil, fmt.Errorf("primary encryption key %q is not in the keyring", k.primary)
+	}
+	return k, nil
+}
+
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Encrypt seals plain with the primary key, or with a fresh data key wrapped
+// by the KMS in envelope mode.
+func (k *Keyring) Encrypt(plain []byte) (string, error) {
+	if envelopeEnabled() {
+		return envelopeEncrypt(plain)
+	}
+	gcm, err := newGCM(k.keys[k.primary])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := append(nonce, gcm.Seal(nil, nonce, plain, nil)...)
+	return k.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a ciphertext with the key named in it.
+func (k *Keyring) Decrypt(ciphertext string) ([]byte, error) {
+	if parts := strings.Split(ciphertext, ":"); len(parts) == 3 {
+		return envelopeDecrypt(parts[0], parts[1], parts[2])
+	}
+	id := KeyIDOf(ciphertext)
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("encryption key %q is not in the keyring", id)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, id+":"))
+	if err != nil {
+		return nil, errors.New("invalid encrypted base64")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("decryption failed")
+	}
+	return plain, nil
+}
+
+// KeyIDOf reports which key a ciphertext was written with, the KEK alias for
+// envelope ciphertexts. Base64 never contains ':', so ciphertexts without a
+// prefix are legacy ones.
+func KeyIDOf(ciphertext string) string {
+	if id, _, ok := strings.Cut(ciphertext, ":"); ok {
+		return id
+	}
+	return LegacyKeyID
+}
+
+// keyringCache keeps the parsed keyring, so card reads and writes don't
+// decode the keys from the environment every time. It is rebuilt only when
+// the key settings change, which in practice means in tests and tools.
+var keyringCache struct {
+	sync.Mutex
+	source  string
+	keyring *Keyring
+}
+
+func currentKeyring() (*Keyring, error) {
+	source := strings.Join([]string{config.EncryptionKey, config.EncryptionKeys, config.EncryptionPrimaryKeyID, config.KmsKeyID}, "\x00")
+	keyringCache.Lock()
+	defer keyringCache.Unlock()
+	if keyringCache.keyring != nil && keyringCache.source == source {
+		return keyringCache.keyring, nil
+	}
+	k, err := LoadKeyring()
+	if err != nil {
+		return nil, err
+	}
+	keyringCache.source, keyringCache.keyring = source, k
+	return k, nil
+}
+
+// EncryptWithKeyring encrypts a field such as a PAN with the primary key.
+func EncryptWithKeyring(plain string) (string, error) {
+	k, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	return k.Encrypt([]byte(plain))
+}
+
+// DecryptWithKeyring decrypts a field written by EncryptWithKeyring or by
+// EncryptString with the legacy key.
+func DecryptWithKeyring(ciphertext string) (string, error) {
+	k, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	plain, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func validKeyID(id string) bool {
+	if id == "" || len(id) > 20 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
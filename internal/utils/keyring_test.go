@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	legacy := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	config.EncryptionKey = legacy
+	config.EncryptionKeys = ""
+	config.EncryptionPrimaryKeyID = ""
+	defer func() { config.EncryptionKeys, config.EncryptionPrimaryKeyID = "", "" }()
+
+	// ciphertext written before the keyring has no key ID
+	old, err := EncryptString("4111111111111111", legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyIDOf(old) != LegacyKeyID {
+		t.Fatalf("expected an unprefixed ciphertext to belong to the legacy key")
+	}
+
+	config.EncryptionKeys = "v2:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", 32)))
+	config.EncryptionPrimaryKeyID = "v2"
+	fresh, err := EncryptWithKeyring("4111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyIDOf(fresh) != "v2" {
+		t.Fatalf("expected new ciphertexts on the primary key, got %q", KeyIDOf(fresh))
+	}
+	for _, ct := range []string{old, fresh} {
+		plain, err := DecryptWithKeyring(ct)
+		if err != nil || plain != "4111111111111111" {
+			t.Fatalf("expected %q to decrypt, got %q %v", ct, plain, err)
+		}
+	}
+
+	config.EncryptionKey = ""
+	if _, err := DecryptWithKeyring(old); err == nil {
+		t.Fatalf("expected decryption to fail once the legacy key is removed")
+	}
+}
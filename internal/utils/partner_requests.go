@@ -1 +1,127 @@
-package utils
\ No newline at end of file
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+)
+
+// Partner API keys look like cfk_<prefix>_<secret>. The prefix is stored in
+// the clear to find the key; only a SHA-256 of the whole key is kept.
+const partnerKeyTag = "cfk"
+
+const (
+	ScopeCardsRead        = "cards:read"
+	ScopeCardsWrite       = "cards:write"
+	ScopeTransactionsRead = "transactions:read"
+)
+
+// PartnerScopes are the permissions a partner API key can be given.
+var PartnerScopes = []string{ScopeCardsRead, ScopeCardsWrite, ScopeTransactionsRead}
+
+var (
+	ErrInvalidAPIKey      = errors.New("invalid or revoked API key")
+	ErrAPIKeyIPNotAllowed = errors.New("request IP is not allowed for this API key")
+	ErrAPIKeyScope        = errors.New("API key is not allowed to do this")
+	ErrPartnerNotLinked   = errors.New("user has not granted this partner access")
+)
+
+// GeneratePartnerKey returns a new key, the prefix to look it up by and the
+// hash to store. The key itself is only shown once.
+func GeneratePartnerKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 28)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(b[:4])
+	key = partnerKeyTag + "_" + prefix + "_" + hex.EncodeToString(b[4:])
+	return key, prefix, HashPartnerKey(key), nil
+}
+
+// ParsePartnerKey returns the lookup prefix of a key, or false when the key
+// is not in the partner key format.
+func ParsePartnerKey(key string) (string, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != partnerKeyTag || len(parts[1]) != 8 || len(parts[2]) != 48 {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func HashPartnerKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// PartnerKeyMatches compares a presented key with a stored hash in constant
+// time.
+func PartnerKeyMatches(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashPartnerKey(key)), []byte(hash)) == 1
+}
+
+func ValidPartnerScope(scope string) bool {
+	for _, s := range PartnerScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAllowedIPs checks an allowlist of addresses and CIDR ranges and
+// writes single addresses as ranges.
+func NormalizeAllowedIPs(entries []string) ([]string, error) {
+	out := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, errors.New("invalid IP address " + entry)
+			}
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, errors.New("invalid IP range " + entry)
+		}
+		out = append(out, network.String())
+	}
+	return out, nil
+}
+
+// IPAllowed reports whether ip is in one of the allowlist's ranges. An empty
+// allowlist allows every address.
+func IPAllowed(allowlist []string, ip string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		_, network, err := net.ParseCIDR(entry)
+		if err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
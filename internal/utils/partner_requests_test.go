@@ -0,0 +1,53 @@
+package utils
+
+import "testing"
+
+func TestPartnerKeyRoundTrip(t *testing.T) {
+	key, prefix, hash, err := GeneratePartnerKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, ok := ParsePartnerKey(key)
+	if !ok || parsed != prefix {
+		t.Fatalf("ParsePartnerKey(%q) = %q, %v, want %q", key, parsed, ok, prefix)
+	}
+	if !PartnerKeyMatches(key, hash) {
+		t.Fatal("key does not match its own hash")
+	}
+	if PartnerKeyMatches(key+"0", hash) {
+		t.Fatal("altered key matched")
+	}
+	for _, bad := range []string{"", "cfk_abc_def", "sk_" + key[4:], key + "_x"} {
+		if _, ok := ParsePartnerKey(bad); ok {
+			t.Errorf("ParsePartnerKey(%q) accepted a malformed key", bad)
+		}
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	allowlist, err := NormalizeAllowedIPs([]string{"203.0.113.7", "198.51.100.0/24", "2001:db8::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowlist[0] != "203.0.113.7/32" || allowlist[2] != "2001:db8::1/128" {
+		t.Fatalf("unexpected allowlist %v", allowlist)
+	}
+	cases := map[string]bool{
+		"203.0.113.7":   true,
+		"203.0.113.8":   false,
+		"198.51.100.42": true,
+		"2001:db8::1":   true,
+		"not-an-ip":     false,
+	}
+	for ip, want := range cases {
+		if got := IPAllowed(allowlist, ip); got != want {
+			t.Errorf("IPAllowed(%q) = %v, want %v", ip, got, want)
+		}
+	}
+	if !IPAllowed(nil, "192.0.2.1") {
+		t.Fatal("an empty allowlist should allow every address")
+	}
+	if _, err := NormalizeAllowedIPs([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("expected an error for an invalid range")
+	}
+}
@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ISO 9564 PIN block formats supported for stored card PINs.
+const (
+	PinBlockFormat0 = "iso0"
+	PinBlockFormat4 = "iso4"
+)
+
+// PinEncryptionKey decodes PIN_ENCRYPTION_KEY_BASE64. PIN blocks use their own
+// AES-256 key so they never share a key with card numbers or KYC documents.
+func PinEncryptionKey() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(config.PinEncryptionKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("PIN_ENCRYPTION_KEY_BASE64 must be a base64 encoded 32 byte key")
+	}
+	return key, nil
+}
+
+// EncodePinBlock builds an ISO 9564 PIN block for pin and pan and encrypts it
+// with key. Format 4 is AES-native; a format 0 block is sealed with AES-GCM.
+// The result is base64 encoded for storage.
+func EncodePinBlock(pin, pan, format string, key []byte) (string, error) {
+	if err := validatePinDigits(pin); err != nil {
+		return "", err
+	}
+	switch format {
+	case PinBlockFormat0:
+		panField, err := format0PanField(pan)
+		if err != nil {
+			return "", err
+		}
+		pinField := make([]byte, 16)
+		pinField[0], pinField[1] = 0x0, byte(len(pin))
+		for i := 2; i < 16; i++ {
+			pinField[i] = 0xF
+			if i-2 < len(pin) {
+				pinField[i] = pin[i-2] - '0'
+			}
+		}
+		block := xorBytes(packNibbles(pinField), packNibbles(panField))
+		return sealGCM(block, key)
+	case PinBlockFormat4:
+		panField, err := format4PanField(pan)
+		if err != nil {
+			return "", err
+		}
+		random := make([]byte, 8)
+		if _, err := io.ReadFull(rand.Reader, random); err != nil {
+			return "", err
+		}
+		pinField := make([]byte, 32)
+		pinField[0], pinField[1] = 0x4, byte(len(pin))
+		for i := 2; i < 16; i++ {
+			pinField[i] = 0xA
+			if i-2 < len(pin) {
+				pinField[i] = pin[i-2] - '0'
+			}
+		}
+		copy(pinField[16:], unpackNibbles(random))
+		c, err := aes.NewCipher(key)
+		if err != nil {
+			return "", err
+		}
+		intermediate := make([]byte, 16)
+		c.Encrypt(intermediate, packNibbles(pinField))
+		block := make([]byte, 16)
+		c.Encrypt(block, xorBytes(intermediate, packNibbles(panField)))
+		return base64.StdEncoding.EncodeToString(block), nil
+	default:
+		return "", errors.New("unsupported PIN block format")
+	}
+}
+
+// DecodePinBlock reverses EncodePinBlock and returns the clear PIN.
+func DecodePinBlock(stored, pan, format string, key []byte) (string, error) {
+	var pinField []byte
+	switch format {
+	case PinBlockFormat0:
+		block, err := openGCM(stored, key)
+		if err != nil {
+			return "", err
+		}
+		panField, err := format0PanField(pan)
+		if err != nil {
+			return "", err
+		}
+		if len(block) != 8 {
+			return "", errors.New("invalid PIN block")
+		}
+		pinField = unpackNibbles(xorBytes(block, packNibbles(panField)))
+		if pinField[0] != 0x0 {
+			return "", errors.New("invalid PIN block")
+		}
+	case PinBlockFormat4:
+		block, err := base64.StdEncoding.DecodeString(stored)
+		if err != nil || len(block) != 16 {
+			return "", errors.New("invalid PIN block")
+		}
+		panField, err := format4PanField(pan)
+		if err != nil {
+			return "", err
+		}
+		c, err := aes.NewCipher(key)
+		if err != nil {
+			return "", err
+		}
+		intermediate := make([]byte, 16)
+		c.Decrypt(intermediate, block)
+		clear := make([]byte, 16)
+		c.Decrypt(clear, xorBytes(intermediate, packNibbles(panField)))
+		pinField = unpackNibbles(clear)
+		if pinField[0] != 0x4 {
+			return "", errors.New("invalid PIN block")
+		}
+	default:
+		return "", errors.New("unsupported PIN block format")
+	}
+
+	length := int(pinField[1])
+	if length < 4 || length > 12 {
+		return "", errors.New("invalid PIN block")
+	}
+	var pin strings.Builder
+	for _, d := range pinField[2 : 2+length] {
+		if d > 9 {
+			return "", errors.New("invalid PIN block")
+		}
+		pin.WriteByte('0' + d)
+	}
+	return pin.String(), nil
+}
+
+func validatePinDigits(pin string) error {
+	if len(pin) < 4 || len(pin) > 12 {
+		return errors.New("PIN must be between 4 and 12 digits")
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return errors.New("PIN must contain digits only")
+		}
+	}
+	return nil
+}
+
+// format0PanField is 0000 followed by the 12 rightmost PAN digits, check
+// digit excluded.
+func format0PanField(pan string) ([]byte, error) {
+	if len(pan) < 13 || !isDigits(pan) {
+		return nil, errors.New("invalid PAN")
+	}
+	digits := pan[len(pan)-13 : len(pan)-1]
+	field := make([]byte, 16)
+	for i, r := range digits {
+		field[4+i] = byte(r - '0')
+	}
+	return field, nil
+}
+
+// format4PanField is the PAN length minus 12 followed by the PAN, right
+// padded with zeros to 32 nibbles.
+func format4PanField(pan string) ([]byte, error) {
+	if len(pan) < 12 || len(pan) > 19 || !isDigits(pan) {
+		return nil, errors.New("invalid PAN")
+	}
+	field := make([]byte, 32)
+	field[0] = byte(len(pan) - 12)
+	for i, r := range pan {
+		field[1+i] = byte(r - '0')
+	}
+	return field, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func packNibbles(nibbles []byte) []byte {
+	out := make([]byte, len(nibbles)/2)
+	for i := range out {
+		out[i] = nibbles[2*i]<<4 | nibbles[2*i+1]&0x0F
+	}
+	return out
+}
+
+func unpackNibbles(data []byte) []byte {
+	out := make([]byte, len(data)*2)
+	for i, b := range data {
+		out[2*i], out[2*i+1] = b>>4, b&0x0F
+	}
+	return out
+}
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+func sealGCM(plain, key []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(append(nonce, gcm.Seal(nil, nonce, plain, nil)...)), nil
+}
+
+func openGCM(encoded string, key []byte) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid PIN block")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid PIN block")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("invalid PIN block")
+	}
+	return plain, nil
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestPinBlockRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	pan := "4111111111111111"
+
+	for _, format := range []string{PinBlockFormat0, PinBlockFormat4} {
+		block, err := EncodePinBlock("4821", pan, format, key)
+		if err != nil {
+			t.Fatalf("%s: encode failed: %v", format, err)
+		}
+		pin, err := DecodePinBlock(block, pan, format, key)
+		if err != nil {
+			t.Fatalf("%s: decode failed: %v", format, err)
+		}
+		if pin != "4821" {
+			t.Fatalf("%s: expected 4821, got %s", format, pin)
+		}
+		if other, err := DecodePinBlock(block, "4000000000000002", format, key); err == nil && other == "4821" {
+			t.Fatalf("%s: expected the PIN block to be bound to its PAN", format)
+		}
+	}
+}
+
+func TestPinBlockRejectsInvalidPin(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := EncodePinBlock("12a4", "4111111111111111", PinBlockFormat4, key); err == nil {
+		t.Fatalf("expected non-digit PIN to be rejected")
+	}
+	if _, err := EncodePinBlock("123", "4111111111111111", PinBlockFormat0, key); err == nil {
+		t.Fatalf("expected short PIN to be rejected")
+	}
+}
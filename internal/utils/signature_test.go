@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"CardFlow/internal/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestValidateKorapaySignature(t *testing.T) {
+	saved := config.KorapaySecret
+	defer func() { config.KorapaySecret = saved }()
+	data := []byte(`{"reference":"WDR123","status":"success"}`)
+	sign := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(data)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	config.KorapaySecret = ""
+	if err := ValidateKorapaySignature(data, sign("")); err == nil {
+		t.Fatalf("expected webhooks to be refused without a secret")
+	}
+
+	config.KorapaySecret = "sk_test_secret"
+	if err := ValidateKorapaySignature(data, sign("sk_test_secret")); err != nil {
+		t.Fatalf("expected a valid signature to pass, got %v", err)
+	}
+	if err := ValidateKorapaySignature(data, sign("another")); err == nil {
+		t.Fatalf("expected a signature with another key to fail")
+	}
+	if err := ValidateKorapaySignature(data, "not-hex"); err == nil {
+		t.Fatalf("expected a malformed signature to fail")
+	}
+}
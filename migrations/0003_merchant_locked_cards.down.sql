@@ -0,0 +1,8 @@
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS recurring_tolerance,
+    DROP COLUMN IF EXISTS recurring_amount,
+    DROP COLUMN IF EXISTS locked_merchant_name;
+
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_card_type_check;
+ALTER TABLE cards ADD CONSTRAINT cards_card_type_check
+    CHECK (card_type IN ('single-use', 'multi-use'));
@@ -0,0 +1,12 @@
+-- ============================================================
+-- Merchant-locked cards
+-- ============================================================
+
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_card_type_check;
+ALTER TABLE cards ADD CONSTRAINT cards_card_type_check
+    CHECK (card_type IN ('single-use', 'multi-use', 'merchant-locked'));
+
+ALTER TABLE cards
+    ADD COLUMN locked_merchant_name VARCHAR(255),
+    ADD COLUMN recurring_amount     DECIMAL(15,2),
+    ADD COLUMN recurring_tolerance  DECIMAL(5,2) CHECK (recurring_tolerance >= 0 AND recurring_tolerance <= 100);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_cards_replaces_card_id;
+
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS replaced_by_card_id,
+    DROP COLUMN IF EXISTS replaces_card_id;
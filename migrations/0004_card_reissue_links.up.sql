@@ -0,0 +1,9 @@
+-- ============================================================
+-- Card reissue links
+-- ============================================================
+
+ALTER TABLE cards
+    ADD COLUMN replaces_card_id     UUID REFERENCES cards(id),
+    ADD COLUMN replaced_by_card_id  UUID REFERENCES cards(id);
+
+CREATE INDEX idx_cards_replaces_card_id ON cards(replaces_card_id);
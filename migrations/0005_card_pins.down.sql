@@ -0,0 +1,5 @@
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_status_check;
+ALTER TABLE cards ADD CONSTRAINT cards_status_check
+    CHECK (status IN ('active', 'frozen', 'expired', 'terminated'));
+
+DROP TABLE IF EXISTS card_pins;
@@ -0,0 +1,18 @@
+-- ============================================================
+-- Card PINs
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS card_pins (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    card_id          UUID NOT NULL UNIQUE REFERENCES cards(id),
+    pin_block        VARCHAR(255) NOT NULL,
+    format           VARCHAR(10) NOT NULL CHECK (format IN ('iso0', 'iso4')),
+    failed_attempts  INTEGER NOT NULL DEFAULT 0,
+    locked_at        TIMESTAMP,
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_status_check;
+ALTER TABLE cards ADD CONSTRAINT cards_status_check
+    CHECK (status IN ('active', 'frozen', 'expired', 'terminated', 'locked'));
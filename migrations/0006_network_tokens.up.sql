@@ -0,0 +1,19 @@
+-- ============================================================
+-- Network tokens (wallet provisioning)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS network_tokens (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    card_id          UUID NOT NULL REFERENCES cards(id),
+    token_reference  VARCHAR(100) NOT NULL UNIQUE,
+    token_last_four  VARCHAR(4) NOT NULL,
+    wallet           VARCHAR(50) NOT NULL,
+    device_id        VARCHAR(255) NOT NULL,
+    device_name      VARCHAR(255),
+    status           VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended', 'deleted')),
+    status_reason    VARCHAR(50),
+    created_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_network_tokens_card_id ON network_tokens(card_id);
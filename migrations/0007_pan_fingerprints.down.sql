@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_audit_admin_id;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS admin_id;
+
+-- the bcrypt hashes cannot be rebuilt; the columns come back empty
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS pan_fingerprint,
+    ADD COLUMN pan_hash VARCHAR(255),
+    ADD COLUMN cvv_hash VARCHAR(255);
@@ -0,0 +1,19 @@
+-- ============================================================
+-- Searchable PAN fingerprints, CVVs no longer stored
+-- ============================================================
+
+-- bcrypt hashes cannot be searched; cards get a keyed HMAC fingerprint
+-- instead, backfilled for existing cards by cmd/pan-fingerprints
+DROP INDEX IF EXISTS uniq_cards_pan;
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS pan_hash,
+    DROP COLUMN IF EXISTS cvv_hash,
+    ADD COLUMN pan_fingerprint VARCHAR(64) UNIQUE;
+
+-- new cards derive their CVV from the card verification key
+ALTER TABLE cards ALTER COLUMN cvv_encrypted DROP NOT NULL;
+
+ALTER TABLE audit_logs
+    ADD COLUMN admin_id UUID REFERENCES admins(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_admin_id ON audit_logs(admin_id);
@@ -0,0 +1,6 @@
+-- documents must be copied back into encrypted_data before rolling back
+ALTER TABLE kyc_documents
+    ALTER COLUMN encrypted_data SET NOT NULL,
+    DROP COLUMN IF EXISTS size_bytes,
+    DROP COLUMN IF EXISTS checksum,
+    DROP COLUMN IF EXISTS storage_key;
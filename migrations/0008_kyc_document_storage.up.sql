@@ -0,0 +1,11 @@
+-- ============================================================
+-- KYC documents move to object storage
+-- ============================================================
+
+-- rows keep the object key, checksum and size; encrypted_data is only set
+-- on documents cmd/kyc-documents has not moved out yet
+ALTER TABLE kyc_documents
+    ADD COLUMN storage_key VARCHAR(255) UNIQUE,
+    ADD COLUMN checksum VARCHAR(64),
+    ADD COLUMN size_bytes BIGINT,
+    ALTER COLUMN encrypted_data DROP NOT NULL;
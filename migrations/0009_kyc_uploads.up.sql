@@ -0,0 +1,17 @@
+-- ============================================================
+-- Direct-to-storage KYC uploads
+-- ============================================================
+
+-- a presigned upload waits here until it is confirmed and attached
+CREATE TABLE kyc_uploads (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    document_type   VARCHAR(50) NOT NULL CHECK (document_type IN ('id_document', 'proof_of_address', 'selfie')),
+    staging_key     VARCHAR(255) NOT NULL UNIQUE,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'attached', 'rejected')),
+    expires_at      TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_kyc_uploads_user_id ON kyc_uploads(user_id);
@@ -0,0 +1,24 @@
+-- ============================================================
+-- Identity verification provider results
+-- ============================================================
+
+-- one row per run of a submission through the provider; the scores and
+-- decision are advice for the compliance officer reviewing it
+CREATE TABLE kyc_provider_checks (
+    id                          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kyc_submission_id           UUID NOT NULL REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+    provider                    VARCHAR(50) NOT NULL,
+    provider_reference          VARCHAR(255),
+    status                      VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'failed')),
+    document_authenticity_score DOUBLE PRECISION,
+    face_match_score            DOUBLE PRECISION,
+    liveness_score              DOUBLE PRECISION,
+    decision                    VARCHAR(20), -- approve, review or decline once completed
+    reasons                     JSONB,
+    error                       TEXT,
+    completed_at                TIMESTAMP,
+    created_at                  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at                  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_kyc_provider_checks_submission_id ON kyc_provider_checks(kyc_submission_id);
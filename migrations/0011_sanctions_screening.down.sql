@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS screening_cases;
+ALTER TABLE kyc_submissions DROP COLUMN IF EXISTS date_of_birth;
+ALTER TABLE users DROP COLUMN IF EXISTS screened_at;
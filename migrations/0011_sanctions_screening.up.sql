@@ -0,0 +1,25 @@
+-- ============================================================
+-- Sanctions and PEP screening
+-- ============================================================
+
+ALTER TABLE users ADD COLUMN screened_at TIMESTAMP;
+
+-- captured with the selfie and screened with the name
+ALTER TABLE kyc_submissions ADD COLUMN date_of_birth DATE;
+
+-- potential watchlist hits; an open or confirmed case blocks card issuance
+CREATE TABLE screening_cases (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status          VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'cleared', 'confirmed')),
+    trigger         VARCHAR(30) NOT NULL,
+    matches         JSONB NOT NULL,
+    resolved_by     UUID REFERENCES admins(id),
+    resolution_note TEXT,
+    resolved_at     TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_screening_cases_user_id ON screening_cases(user_id);
+CREATE INDEX idx_screening_cases_status ON screening_cases(status);
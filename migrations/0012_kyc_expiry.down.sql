@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_kyc_submissions_status_expires;
+DROP INDEX IF EXISTS idx_kyc_submissions_user_created;
+ALTER TABLE kyc_submissions DROP COLUMN IF EXISTS expiry_reminder_sent_at;
+ALTER TABLE kyc_submissions DROP COLUMN IF EXISTS id_document_expires_on;
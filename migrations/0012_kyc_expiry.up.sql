@@ -0,0 +1,11 @@
+-- ============================================================
+-- KYC expiry and re-verification
+-- ============================================================
+
+-- captured by the reviewer; caps how long an approval lasts
+ALTER TABLE kyc_submissions ADD COLUMN id_document_expires_on DATE;
+ALTER TABLE kyc_submissions ADD COLUMN expiry_reminder_sent_at TIMESTAMP;
+
+-- users now keep one submission per verification round
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_user_created ON kyc_submissions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_status_expires ON kyc_submissions(status, expires_at);
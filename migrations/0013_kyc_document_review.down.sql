@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_kyc_documents_current_type;
+-- rejected documents that were replaced can't coexist with the old index
+DELETE FROM kyc_documents d WHERE d.status = 'rejected' AND EXISTS (
+    SELECT 1 FROM kyc_documents o
+    WHERE o.kyc_submission_id = d.kyc_submission_id AND o.document_type = d.document_type
+      AND (o.status <> 'rejected' OR o.created_at > d.created_at)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_kyc_document_per_type ON kyc_documents (kyc_submission_id, document_type);
+ALTER TABLE kyc_documents DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE kyc_documents DROP COLUMN IF EXISTS rejection_reason;
+ALTER TABLE kyc_documents DROP COLUMN IF EXISTS status;
@@ -0,0 +1,25 @@
+-- ============================================================
+-- KYC document review and resubmission
+-- ============================================================
+
+ALTER TABLE kyc_documents ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'accepted', 'rejected'));
+ALTER TABLE kyc_documents ADD COLUMN rejection_reason TEXT;
+ALTER TABLE kyc_documents ADD COLUMN reviewed_at TIMESTAMP;
+
+-- documents on submissions that were already approved count as accepted
+UPDATE kyc_documents d SET status = 'accepted'
+FROM kyc_submissions s
+WHERE d.kyc_submission_id = s.id AND s.status IN ('verified', 'expired');
+
+-- the old unique constraints allowed one document per submission, one per
+-- type across all submissions and one per type within a submission;
+-- rejected documents now stay next to their replacements
+ALTER TABLE kyc_documents DROP CONSTRAINT IF EXISTS uni_kyc_documents_kyc_submission_id;
+ALTER TABLE kyc_documents DROP CONSTRAINT IF EXISTS uni_kyc_documents_document_type;
+ALTER TABLE kyc_documents DROP CONSTRAINT IF EXISTS kyc_documents_kyc_submission_id_key;
+ALTER TABLE kyc_documents DROP CONSTRAINT IF EXISTS kyc_documents_document_type_key;
+DROP INDEX IF EXISTS uniq_kyc_document_per_type;
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_kyc_submission_id ON kyc_documents(kyc_submission_id);
+CREATE UNIQUE INDEX idx_kyc_documents_current_type ON kyc_documents(kyc_submission_id, document_type)
+    WHERE status <> 'rejected';
@@ -0,0 +1,4 @@
+ALTER TABLE kyc_submissions ADD COLUMN date_of_birth DATE;
+UPDATE kyc_submissions s SET date_of_birth = p.date_of_birth
+FROM kyc_profiles p WHERE p.kyc_submission_id = s.id;
+DROP TABLE IF EXISTS kyc_profiles;
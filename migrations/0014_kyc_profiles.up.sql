@@ -0,0 +1,29 @@
+-- ============================================================
+-- Structured KYC profile
+-- ============================================================
+
+CREATE TABLE kyc_profiles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kyc_submission_id UUID NOT NULL UNIQUE REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+    date_of_birth DATE,
+    nationality VARCHAR(2),
+    address_line1 VARCHAR(100),
+    address_line2 VARCHAR(100),
+    city VARCHAR(100),
+    state VARCHAR(100),
+    postal_code VARCHAR(20),
+    country VARCHAR(2),
+    id_type VARCHAR(30),
+    id_number_encrypted TEXT, -- keyring ciphertext, rotated by the re-encryption job
+    id_number_last4 VARCHAR(4),
+    id_issuing_country VARCHAR(2),
+    id_expires_on DATE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- the date of birth given with the selfie moves onto the profile
+INSERT INTO kyc_profiles (kyc_submission_id, date_of_birth)
+SELECT id, date_of_birth FROM kyc_submissions WHERE date_of_birth IS NOT NULL;
+
+ALTER TABLE kyc_submissions DROP COLUMN date_of_birth;
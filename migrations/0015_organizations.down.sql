@@ -0,0 +1,13 @@
+UPDATE cards SET status = 'frozen' WHERE status = 'suspended';
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_status_check;
+ALTER TABLE cards ADD CONSTRAINT cards_status_check
+    CHECK (status IN ('active', 'frozen', 'expired', 'terminated', 'locked'));
+
+DROP INDEX IF EXISTS idx_cards_organization_id;
+ALTER TABLE cards DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS kyb_documents;
+DROP TABLE IF EXISTS organization_directors;
+DROP TABLE IF EXISTS organization_ledgers;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,88 @@
+-- ============================================================
+-- Organizations, team members, shared balance and KYB
+-- ============================================================
+
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    registration_number VARCHAR(50),
+    country VARCHAR(2),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    kyb_status VARCHAR(20) NOT NULL DEFAULT 'not_started'
+        CHECK (kyb_status IN ('not_started', 'under_review', 'verified', 'rejected')),
+    kyb_rejection_reason TEXT,
+    kyb_reviewed_by UUID,
+    kyb_reviewed_at TIMESTAMP,
+    balance DECIMAL(15,2) NOT NULL DEFAULT 0.00 CHECK (balance >= 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE organization_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id),
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'cardholder', 'viewer')),
+    status VARCHAR(20) NOT NULL DEFAULT 'invited' CHECK (status IN ('invited', 'active', 'removed')),
+    invite_token_hash VARCHAR(64) UNIQUE, -- SHA-256 of the emailed token
+    invite_expires_at TIMESTAMP,
+    invited_by UUID,
+    joined_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_org_member_email UNIQUE (organization_id, email)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+
+CREATE TABLE organization_ledgers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    card_id UUID REFERENCES cards(id),
+    actor_id UUID NOT NULL,
+    entry_type VARCHAR(30) NOT NULL CHECK (entry_type IN ('deposit', 'card_funding', 'card_return')),
+    amount DECIMAL(15,2) NOT NULL,
+    balance_after DECIMAL(15,2) NOT NULL,
+    reference VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_organization_ledgers_organization_id ON organization_ledgers(organization_id);
+
+CREATE TABLE organization_directors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    full_name VARCHAR(255) NOT NULL,
+    date_of_birth DATE,
+    nationality VARCHAR(2),
+    ownership_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_organization_directors_organization_id ON organization_directors(organization_id);
+
+CREATE TABLE kyb_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    document_type VARCHAR(50) NOT NULL,
+    mime_type VARCHAR(100),
+    encryption_version VARCHAR(20), -- rotated by the re-encryption job
+    storage_key VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_kyb_documents_organization_id ON kyb_documents(organization_id);
+
+-- business cards are held by a member and funded from the organization
+ALTER TABLE cards ADD COLUMN organization_id UUID REFERENCES organizations(id);
+CREATE INDEX idx_cards_organization_id ON cards(organization_id);
+
+-- a removed member's cards are suspended and only the organization can act on them
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_status_check;
+ALTER TABLE cards ADD CONSTRAINT cards_status_check
+    CHECK (status IN ('active', 'frozen', 'expired', 'terminated', 'locked', 'suspended'));
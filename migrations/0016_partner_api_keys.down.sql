@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS partner_links;
+DROP TABLE IF EXISTS partner_api_keys;
+DROP TABLE IF EXISTS partners;
@@ -0,0 +1,45 @@
+-- ============================================================
+-- Partner API keys
+-- ============================================================
+
+CREATE TABLE partners (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    contact_email VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended')),
+    created_by UUID NOT NULL REFERENCES admins(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE partner_api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    partner_id UUID NOT NULL REFERENCES partners(id),
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(8) NOT NULL UNIQUE, -- shown in the dashboard, used to find the key
+    key_hash VARCHAR(64) NOT NULL,     -- SHA-256 of the whole key
+    scopes JSONB NOT NULL,
+    allowed_ips JSONB,                 -- CIDR ranges, empty allows any address
+    rate_limit_per_minute INTEGER NOT NULL CHECK (rate_limit_per_minute > 0),
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(45),
+    revoked_at TIMESTAMP,
+    revoked_by UUID,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_partner_api_keys_partner_id ON partner_api_keys(partner_id);
+
+-- a user's consent for a partner to act on their behalf
+CREATE TABLE partner_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    partner_id UUID NOT NULL REFERENCES partners(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'revoked')),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_partner_link_user UNIQUE (partner_id, user_id)
+);
@@ -0,0 +1,12 @@
+DELETE FROM notifications WHERE channel = 'in_app';
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('pending', 'sent', 'failed'));
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('email', 'sms', 'push'));
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('transaction', 'kyc', 'card', 'security', 'system'));
+DROP TABLE IF EXISTS approval_decisions;
+DROP TABLE IF EXISTS approval_requests;
+DROP TABLE IF EXISTS approval_policies;
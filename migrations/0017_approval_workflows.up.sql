@@ -0,0 +1,61 @@
+-- ============================================================
+-- Approval workflows for business cards and top-ups
+-- ============================================================
+
+-- the number of approvals a request needs from min_amount upwards
+CREATE TABLE approval_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    request_type VARCHAR(20) NOT NULL CHECK (request_type IN ('card', 'top_up')),
+    min_amount DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+    required_approvals INTEGER NOT NULL CHECK (required_approvals BETWEEN 1 AND 5),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_approval_policy_tier UNIQUE (organization_id, request_type, min_amount)
+);
+
+CREATE TABLE approval_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    requester_id UUID NOT NULL REFERENCES users(id),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('card', 'top_up')),
+    card_id UUID REFERENCES cards(id),        -- top_up only
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    card_type VARCHAR(50),                    -- card only
+    note TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'executing', 'executed', 'failed', 'rejected', 'cancelled')),
+    required_approvals INTEGER NOT NULL,
+    approvals INTEGER NOT NULL DEFAULT 0,
+    result_card_id UUID,
+    result_reference VARCHAR(100),
+    failure_reason TEXT,
+    decided_at TIMESTAMP,
+    executed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_approval_requests_organization_id ON approval_requests(organization_id);
+CREATE INDEX idx_approval_requests_requester_id ON approval_requests(requester_id);
+
+CREATE TABLE approval_decisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    approval_request_id UUID NOT NULL REFERENCES approval_requests(id),
+    approver_id UUID NOT NULL REFERENCES users(id),
+    decision VARCHAR(10) NOT NULL CHECK (decision IN ('approve', 'reject')),
+    comment TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_approval_decision_approver UNIQUE (approval_request_id, approver_id)
+);
+
+-- in-app notifications share the notifications table with email, sms and
+-- push; its original checks rejected the in_app channel, the unread/read
+-- statuses and every notification type added since
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check
+    CHECK (channel IN ('email', 'sms', 'push', 'in_app'));
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('pending', 'sent', 'failed', 'unread', 'read'));
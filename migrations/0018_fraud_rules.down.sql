@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transactions_card_id_created_at;
+DROP INDEX IF EXISTS idx_transactions_fraud_rule_hits;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS fraud_rule_hits,
+    DROP COLUMN IF EXISTS fraud_decision;
+
+DROP TABLE IF EXISTS fraud_rules;
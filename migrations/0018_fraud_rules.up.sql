@@ -0,0 +1,27 @@
+-- ============================================================
+-- Fraud rules on the authorization path
+-- ============================================================
+
+CREATE TABLE fraud_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(50) NOT NULL
+        CHECK (type IN ('velocity', 'high_risk_mcc', 'country_mismatch', 'near_limit', 'card_testing')),
+    params JSONB,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('decline', 'flag')),
+    mode VARCHAR(20) NOT NULL DEFAULT 'shadow' CHECK (mode IN ('active', 'shadow')), -- shadow rules only record hits
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID NOT NULL REFERENCES admins(id),
+    updated_by UUID REFERENCES admins(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE transactions
+    ADD COLUMN fraud_decision  VARCHAR(20) CHECK (fraud_decision IN ('approve', 'decline', 'flag')),
+    ADD COLUMN fraud_rule_hits JSONB;
+
+-- finds the authorizations a rule matched
+CREATE INDEX idx_transactions_fraud_rule_hits ON transactions USING GIN (fraud_rule_hits jsonb_path_ops);
+-- recent authorizations of a card, for velocity and card testing rules
+CREATE INDEX idx_transactions_card_id_created_at ON transactions(card_id, created_at);
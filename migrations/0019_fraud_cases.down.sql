@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS fraud_case_cards;
+DROP TABLE IF EXISTS fraud_case_transactions;
+DROP TABLE IF EXISTS fraud_cases;
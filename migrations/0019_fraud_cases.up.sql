@@ -0,0 +1,41 @@
+-- ============================================================
+-- Fraud case management
+-- ============================================================
+
+CREATE TABLE fraud_cases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    source VARCHAR(20) NOT NULL CHECK (source IN ('fraud_rule', 'user_report', 'dispute')),
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    summary TEXT NOT NULL,
+    outcome VARCHAR(30) CHECK (outcome IN ('confirmed_fraud', 'false_positive', 'customer_confirmed')),
+    resolution_note TEXT,
+    resolved_by UUID REFERENCES admins(id), -- empty when the customer's answers closed the case
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_fraud_cases_user_id ON fraud_cases(user_id);
+CREATE INDEX idx_fraud_cases_status ON fraud_cases(status);
+
+CREATE TABLE fraud_case_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    fraud_case_id UUID NOT NULL REFERENCES fraud_cases(id),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    response_token_hash VARCHAR(64) UNIQUE, -- SHA-256 of the token in the confirm or deny link
+    response_expires_at TIMESTAMP,
+    customer_response VARCHAR(20) CHECK (customer_response IN ('confirmed', 'denied')),
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_fraud_case_transaction UNIQUE (fraud_case_id, transaction_id)
+);
+
+CREATE TABLE fraud_case_cards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    fraud_case_id UUID NOT NULL REFERENCES fraud_cases(id),
+    card_id UUID NOT NULL REFERENCES cards(id),
+    action VARCHAR(20) CHECK (action IN ('frozen', 'terminated')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_fraud_case_card UNIQUE (fraud_case_id, card_id)
+);
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS disputes;
+
+UPDATE fraud_case_cards SET action = 'frozen' WHERE action = 'blocked';
+ALTER TABLE fraud_case_cards DROP CONSTRAINT IF EXISTS fraud_case_cards_action_check;
+ALTER TABLE fraud_case_cards ADD CONSTRAINT fraud_case_cards_action_check
+    CHECK (action IN ('frozen', 'terminated'));
+
+UPDATE cards SET status = 'frozen' WHERE status = 'blocked';
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_status_check;
+ALTER TABLE cards ADD CONSTRAINT cards_status_check
+    CHECK (status IN ('active', 'frozen', 'expired', 'terminated', 'locked', 'suspended'));
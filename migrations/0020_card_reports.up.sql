@@ -0,0 +1,32 @@
+-- ============================================================
+-- Lost, stolen and compromised card reports
+-- ============================================================
+
+-- a reported card is blocked: it declines everything and can only be
+-- reissued or terminated
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_status_check;
+ALTER TABLE cards ADD CONSTRAINT cards_status_check
+    CHECK (status IN ('active', 'frozen', 'expired', 'terminated', 'locked', 'suspended', 'blocked'));
+
+ALTER TABLE fraud_case_cards DROP CONSTRAINT IF EXISTS fraud_case_cards_action_check;
+ALTER TABLE fraud_case_cards ADD CONSTRAINT fraud_case_cards_action_check
+    CHECK (action IN ('frozen', 'blocked', 'terminated'));
+
+CREATE TABLE disputes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    card_id UUID NOT NULL REFERENCES cards(id),
+    transaction_id UUID NOT NULL UNIQUE REFERENCES transactions(id),
+    fraud_case_id UUID REFERENCES fraud_cases(id),
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('lost', 'stolen', 'compromised')),
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'won', 'lost')),
+    amount DECIMAL(15,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_disputes_user_id ON disputes(user_id);
+CREATE INDEX idx_disputes_card_id ON disputes(card_id);
+CREATE INDEX idx_disputes_fraud_case_id ON disputes(fraud_case_id);
@@ -0,0 +1,21 @@
+-- ============================================================
+-- Login sessions
+-- ============================================================
+
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    device_fingerprint VARCHAR(64) NOT NULL, -- SHA-256 of the app's device ID, or of the user agent
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    country VARCHAR(2),
+    region VARCHAR(100),
+    city VARCHAR(100),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX idx_sessions_user_device ON sessions(user_id, device_fingerprint);
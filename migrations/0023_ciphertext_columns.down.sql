@@ -0,0 +1,3 @@
+-- fails while any card still holds a ciphertext longer than 255 characters
+ALTER TABLE cards ALTER COLUMN cvv_encrypted TYPE VARCHAR(255);
+ALTER TABLE cards ALTER COLUMN pan_encrypted TYPE VARCHAR(255);
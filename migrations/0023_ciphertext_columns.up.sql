@@ -0,0 +1,9 @@
+-- ============================================================
+-- Room for envelope ciphertexts
+-- ============================================================
+
+-- "<alias>:<base64 wrapped data key>:<base64 sealed>" runs past 255
+-- characters once the data key is wrapped by a cloud KMS. KYC ID numbers are
+-- already TEXT and document ciphertexts live in the document store.
+ALTER TABLE cards ALTER COLUMN pan_encrypted TYPE TEXT;
+ALTER TABLE cards ALTER COLUMN cvv_encrypted TYPE TEXT;
@@ -0,0 +1,2 @@
+-- the stored CVVs cannot be rebuilt; the column comes back empty
+ALTER TABLE cards ADD COLUMN cvv_encrypted TEXT;
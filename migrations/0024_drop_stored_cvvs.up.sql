@@ -0,0 +1,14 @@
+-- ============================================================
+-- Stored CVVs removed
+-- ============================================================
+
+-- cards issued before CVVs were derived kept an encrypted copy; run
+-- cmd/legacy-cvvs first so their holders are told about the new CVV
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM cards WHERE COALESCE(cvv_encrypted, '') <> '') THEN
+        RAISE EXCEPTION 'cards still hold stored CVVs, run cmd/legacy-cvvs first';
+    END IF;
+END $$;
+
+ALTER TABLE cards DROP COLUMN cvv_encrypted;
@@ -0,0 +1,3 @@
+ALTER TABLE admins DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+DROP TABLE IF EXISTS card_reveal_tokens;
@@ -0,0 +1,22 @@
+-- ============================================================
+-- Shared reveal tokens and TOTP replay protection
+-- ============================================================
+
+-- One-time card reveal tokens, stored so any API instance can redeem them.
+-- Only the SHA-256 of the token handed to the display service is kept.
+CREATE TABLE card_reveal_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    card_id UUID NOT NULL REFERENCES cards(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_card_reveal_tokens_card_id ON card_reveal_tokens(card_id);
+
+-- The last 30-second TOTP step a user or admin authenticated with. A code
+-- is only accepted for a later step, so a captured code can't be replayed.
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT;
+ALTER TABLE admins ADD COLUMN totp_last_step BIGINT;